@@ -9,12 +9,16 @@ import (
 	"syscall"
 	"time"
 
+	"annotation-service/internal/calibration"
 	"annotation-service/internal/config"
 	"annotation-service/internal/gemini"
 	"annotation-service/internal/handler"
+	"annotation-service/internal/httpx"
 	"annotation-service/internal/llm"
+	"annotation-service/internal/promptlog"
 	"annotation-service/internal/repository"
 	"annotation-service/internal/service"
+	"annotation-service/internal/telemetry"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -36,14 +40,44 @@ func main() {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// Tune the shared HTTP transport before any provider client is created
+	if err := httpx.Configure(httpx.ClientConfig{
+		MaxIdleConnsPerHost: cfg.HTTPClient.MaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(cfg.HTTPClient.IdleConnTimeoutSeconds) * time.Second,
+		ProxyURL:            cfg.HTTPClient.ProxyURL,
+	}); err != nil {
+		logger.Fatal("Invalid HTTP client configuration", zap.Error(err))
+	}
+
+	// Require the configured justification language in every prompt
+	gemini.SetJustificationLanguage(cfg.JustificationLanguage)
+	gemini.SetLanguageRouting(cfg.LanguageRouting)
+	gemini.SetGroomingStageExtraction(cfg.ExtractGroomingStage)
+
+	// Sampled logging of raw LLM exchanges for offline analysis (off by default)
+	promptlog.Configure(cfg.PromptLogSampleRate, *cfg.PromptLogRedactText, cfg.PromptLogPath, logger)
+
 	// Initialize LLM client (multi-provider with rate limiting)
 	var llmClient service.LLMClient
 
 	// Try to use multi-provider if providers are configured
 	if len(cfg.Providers) > 0 {
+		var keywordFallback *llm.KeywordFallback
+		if cfg.KeywordFallback.Enabled {
+			keywordFallback = llm.NewKeywordFallback(cfg.KeywordFallback.Keywords, logger)
+			logger.Info("Keyword fallback classifier enabled")
+		}
+
 		multiClient, err := llm.NewMultiProviderClient(llm.MultiProviderConfig{
-			Providers:   cfg.Providers,
-			MaxFailures: cfg.MaxFailuresBeforeSwitch,
+			Providers:          cfg.Providers,
+			MaxFailures:        cfg.MaxFailuresBeforeSwitch,
+			LatencyThreshold:   time.Duration(cfg.LatencyThresholdMs) * time.Millisecond,
+			LatencySwitchAfter: cfg.LatencySwitchAfter,
+			KeywordFallback:    keywordFallback,
+			BudgetStatePath:    cfg.BudgetStatePath,
+			OrderByAccuracy:    cfg.OrderProvidersByAccuracy,
+			AccuracyMinSamples: cfg.AccuracyMinSamples,
+			FailureCooldown:    time.Duration(cfg.FailureCooldownSeconds) * time.Second,
 		}, logger)
 		if err != nil {
 			logger.Warn("Failed to initialize multi-provider client, falling back to single provider",
@@ -79,20 +113,46 @@ func main() {
 	}
 
 	// Initialize repository
-	// Create data directory if not exists
-	os.MkdirAll("./data", 0755)
+	if cfg.Database.Type == "sqlite" {
+		// Create data directory if not exists
+		os.MkdirAll("./data", 0755)
+	}
 
-	repo, err := repository.NewAnnotationRepository(cfg.Database.Path, logger)
+	repo, err := repository.NewAnnotationRepository(cfg.Database.Type, cfg.Database.Path, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize repository", zap.Error(err))
 	}
 	defer repo.Close()
 
 	// Initialize service
-	annotator := service.NewAnnotator(llmClient, repo, logger)
+	var calibrator *calibration.Calibrator
+	if cfg.ConfidenceCalibration.Enabled {
+		calibrator = calibration.New(cfg.ConfidenceCalibration.Temperature, cfg.ConfidenceCalibration.Providers)
+	}
+	annotator := service.NewAnnotator(llmClient, repo, cfg.JustificationLanguage, cfg.EnforceJustificationLanguage, cfg.LanguageRouting, cfg.BatchWorkers, calibrator, logger)
+
+	// Opt-in anonymized telemetry export (default off)
+	if cfg.Telemetry.Enabled {
+		exporter, err := telemetry.NewExporter(telemetry.Config{
+			Endpoint: cfg.Telemetry.Endpoint,
+			Interval: time.Duration(cfg.Telemetry.IntervalSeconds) * time.Second,
+			DryRun:   cfg.Telemetry.DryRun,
+		}, annotator, logger)
+		if err != nil {
+			logger.Fatal("Invalid telemetry configuration", zap.Error(err))
+		}
+		go exporter.Run()
+		defer exporter.Stop()
+		logger.Info("Anonymized telemetry export enabled",
+			zap.String("endpoint", cfg.Telemetry.Endpoint),
+			zap.Bool("dry_run", cfg.Telemetry.DryRun))
+	}
 
 	// Initialize HTTP handler
-	apiHandler := handler.NewHandler(annotator, logger)
+	apiHandler := handler.NewHandler(annotator, cfg.DebugEndpoints, cfg.MaxConcurrentAnnotations, logger)
+	if cfg.DebugEndpoints {
+		logger.Warn("Debug endpoints are enabled; do not run with this in production")
+	}
 
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
@@ -149,6 +209,10 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Stop batch jobs first so they persist a "cancelled" status instead of
+	// staying "processing" across the restart.
+	annotator.Shutdown()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
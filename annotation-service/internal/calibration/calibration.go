@@ -0,0 +1,64 @@
+// Package calibration rescales provider-reported confidence values so they
+// are comparable across a heterogeneous provider set. LLMs tend to emit
+// overconfident or arbitrary confidences; temperature scaling on the logit
+// flattens (T > 1) or sharpens (T < 1) the distribution without moving 0,
+// 0.5, or 1.
+package calibration
+
+import "math"
+
+// Calibrator applies per-provider temperature scaling to raw confidences.
+type Calibrator struct {
+	temperature float64
+	providers   map[string]float64
+}
+
+// New builds a calibrator with a default temperature and optional
+// per-provider overrides. Non-positive temperatures fall back to 1
+// (identity).
+func New(temperature float64, providers map[string]float64) *Calibrator {
+	if temperature <= 0 {
+		temperature = 1
+	}
+	valid := make(map[string]float64, len(providers))
+	for provider, t := range providers {
+		if t > 0 {
+			valid[provider] = t
+		}
+	}
+	return &Calibrator{temperature: temperature, providers: valid}
+}
+
+// Temperature returns the scaling temperature applied to the provider.
+func (c *Calibrator) Temperature(provider string) float64 {
+	if t, ok := c.providers[provider]; ok {
+		return t
+	}
+	return c.temperature
+}
+
+// Calibrate rescales a raw confidence via temperature scaling on its logit:
+// calibrated = sigmoid(logit(raw) / T). Values at or outside (0, 1) pass
+// through unchanged — the logit is undefined there and 0/1 should survive
+// round trips exactly.
+func (c *Calibrator) Calibrate(provider string, raw float64) float64 {
+	t := c.Temperature(provider)
+	if t == 1 || raw <= 0 || raw >= 1 {
+		return raw
+	}
+	logit := math.Log(raw / (1 - raw))
+	return 1 / (1 + math.Exp(-logit/t))
+}
+
+// Mapping describes the active calibration for the inspection endpoint.
+func (c *Calibrator) Mapping() map[string]interface{} {
+	providers := make(map[string]float64, len(c.providers))
+	for provider, t := range c.providers {
+		providers[provider] = t
+	}
+	return map[string]interface{}{
+		"method":      "temperature_scaling",
+		"temperature": c.temperature,
+		"providers":   providers,
+	}
+}
@@ -0,0 +1,74 @@
+package calibration
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalibrateTemperatureScaling(t *testing.T) {
+	c := New(2, nil)
+
+	// T > 1 flattens: values above 0.5 move down, values below move up.
+	if got := c.Calibrate("gemini", 0.9); got >= 0.9 || got <= 0.5 {
+		t.Errorf("Calibrate(0.9) with T=2 = %v, want in (0.5, 0.9)", got)
+	}
+	if got := c.Calibrate("gemini", 0.1); got <= 0.1 || got >= 0.5 {
+		t.Errorf("Calibrate(0.1) with T=2 = %v, want in (0.1, 0.5)", got)
+	}
+
+	// sigmoid(logit(0.9)/2) computed directly.
+	logit := math.Log(0.9 / 0.1)
+	want := 1 / (1 + math.Exp(-logit/2))
+	if got := c.Calibrate("gemini", 0.9); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Calibrate(0.9) = %v, want %v", got, want)
+	}
+}
+
+func TestCalibrateSharpening(t *testing.T) {
+	c := New(0.5, nil)
+	if got := c.Calibrate("groq", 0.9); got <= 0.9 || got >= 1 {
+		t.Errorf("Calibrate(0.9) with T=0.5 = %v, want in (0.9, 1)", got)
+	}
+}
+
+func TestCalibrateFixedPoints(t *testing.T) {
+	c := New(3, nil)
+	for _, raw := range []float64{0, 0.5, 1} {
+		if got := c.Calibrate("gemini", raw); math.Abs(got-raw) > 1e-12 {
+			t.Errorf("Calibrate(%v) = %v, want the fixed point preserved", raw, got)
+		}
+	}
+	// Out-of-range values pass through: the logit is undefined there.
+	for _, raw := range []float64{-0.2, 1.5} {
+		if got := c.Calibrate("gemini", raw); got != raw {
+			t.Errorf("Calibrate(%v) = %v, want pass-through", raw, got)
+		}
+	}
+}
+
+func TestPerProviderOverride(t *testing.T) {
+	c := New(2, map[string]float64{"groq": 1, "bad": -3})
+
+	if got := c.Temperature("groq"); got != 1 {
+		t.Errorf("Temperature(groq) = %v, want override 1", got)
+	}
+	if got := c.Temperature("gemini"); got != 2 {
+		t.Errorf("Temperature(gemini) = %v, want default 2", got)
+	}
+	// Non-positive overrides are dropped at construction.
+	if got := c.Temperature("bad"); got != 2 {
+		t.Errorf("Temperature(bad) = %v, want default 2", got)
+	}
+
+	// T=1 is the identity even for mid-range values.
+	if got := c.Calibrate("groq", 0.73); got != 0.73 {
+		t.Errorf("Calibrate with T=1 changed the value: %v", got)
+	}
+}
+
+func TestNewRejectsNonPositiveDefault(t *testing.T) {
+	c := New(-1, nil)
+	if got := c.Temperature("gemini"); got != 1 {
+		t.Errorf("default temperature = %v, want identity fallback 1", got)
+	}
+}
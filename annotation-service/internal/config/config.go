@@ -31,6 +31,108 @@ type Config struct {
 	} `yaml:"database"`
 
 	MaxFailuresBeforeSwitch int `yaml:"max_failures_before_switch"`
+
+	// BudgetStatePath is where per-provider request budget counters persist
+	// across restarts (default ./data/provider_budget.json).
+	BudgetStatePath string `yaml:"budget_state_path"`
+
+	// Latency-based failover: switch away from a provider whose responses exceed
+	// the threshold for several consecutive calls.
+	LatencyThresholdMs int `yaml:"latency_threshold_ms"`
+	LatencySwitchAfter int `yaml:"latency_switch_after"`
+
+	// OrderProvidersByAccuracy lets reported historical accuracy (recomputed
+	// from validated dataset entries and pushed via the providers/accuracy
+	// endpoint) pick the preferred provider instead of the configured order.
+	// Off by default.
+	OrderProvidersByAccuracy bool `yaml:"order_providers_by_accuracy"`
+	// AccuracyMinSamples is how many validated samples a provider needs
+	// before its accuracy can influence the rotation (default 20).
+	AccuracyMinSamples int `yaml:"accuracy_min_samples"`
+
+	// FailureCooldownSeconds is how long a provider that hit
+	// max_failures_before_switch is skipped by the rotation before getting
+	// fresh attempts (default 60).
+	FailureCooldownSeconds int64 `yaml:"failure_cooldown_seconds"`
+
+	// BatchWorkers is the number of concurrent LLM calls per batch
+	// annotation job (default 4). Saves are serialized regardless.
+	BatchWorkers int `yaml:"batch_workers"`
+
+	// MaxConcurrentAnnotations caps in-flight synchronous annotation requests.
+	// Requests beyond the cap get 429 instead of queueing unboundedly.
+	MaxConcurrentAnnotations int `yaml:"max_concurrent_annotations"`
+
+	// JustificationLanguage is the language annotation justifications must be
+	// written in ("ru" or "en", default "ru").
+	JustificationLanguage string `yaml:"justification_language"`
+	// EnforceJustificationLanguage re-requests an annotation once when the
+	// returned justification is detectably in the wrong language.
+	EnforceJustificationLanguage bool `yaml:"enforce_justification_language"`
+	// ExtractGroomingStage extends the annotation prompt to also tag the
+	// grooming stage (friendship_forming, isolation, secrecy,
+	// desensitization, request) so escalation can be tracked over time.
+	ExtractGroomingStage bool `yaml:"extract_grooming_stage"`
+	// LanguageRouting routes detectably English messages to the
+	// English-tuned prompt variant instead of the Russian default.
+	LanguageRouting bool `yaml:"language_routing"`
+
+	// ConfidenceCalibration rescales provider-reported confidence via
+	// temperature scaling before storage, so confidence-based filtering is
+	// meaningful across providers. Raw values are kept alongside.
+	ConfidenceCalibration struct {
+		Enabled bool `yaml:"enabled"`
+		// Temperature flattens (>1) or sharpens (<1) confidences
+		// (default 1, identity).
+		Temperature float64 `yaml:"temperature"`
+		// Providers overrides the temperature per provider name.
+		Providers map[string]float64 `yaml:"providers"`
+	} `yaml:"confidence_calibration"`
+
+	// PromptLogSampleRate is the fraction of LLM exchanges (prompt, raw
+	// response, parsed result) written to the prompt log sink for offline
+	// analysis. 0 (the default) disables sampling entirely.
+	PromptLogSampleRate float64 `yaml:"prompt_log_sample_rate"`
+	// PromptLogPath is the JSONL file sampled exchanges append to
+	// (default ./data/prompt_log.jsonl).
+	PromptLogPath string `yaml:"prompt_log_path"`
+	// PromptLogRedactText replaces the message text inside sampled prompts
+	// and responses, so the log carries structure without content. On by
+	// default.
+	PromptLogRedactText *bool `yaml:"prompt_log_redact_text"`
+
+	// HTTPClient tunes the shared outbound HTTP transport.
+	HTTPClient struct {
+		MaxIdleConnsPerHost    int    `yaml:"max_idle_conns_per_host"`
+		IdleConnTimeoutSeconds int64  `yaml:"idle_conn_timeout_seconds"`
+		ProxyURL               string `yaml:"proxy_url"`
+	} `yaml:"http_client"`
+
+	// KeywordFallback enables a deterministic keyword classifier of last
+	// resort that runs when every LLM provider has failed. Extra keyword ->
+	// category-ID rules can be added on top of the built-in lexicon.
+	KeywordFallback struct {
+		Enabled  bool           `yaml:"enabled"`
+		Keywords map[string]int `yaml:"keywords"`
+	} `yaml:"keyword_fallback"`
+
+	// Telemetry controls the opt-in export of anonymized aggregate signals
+	// (category distribution, provider accuracy, confidence histograms —
+	// never message content or identities). Strictly default-off given the
+	// sensitive domain.
+	Telemetry struct {
+		Enabled  bool   `yaml:"enabled"`
+		Endpoint string `yaml:"endpoint"`
+		// IntervalSeconds is the time between exports (default 86400).
+		IntervalSeconds int64 `yaml:"interval_seconds"`
+		// DryRun logs the payload that would be sent instead of sending
+		// it, so operators can review it before opting in for real.
+		DryRun bool `yaml:"dry_run"`
+	} `yaml:"telemetry"`
+
+	// DebugEndpoints enables the /api/v1/debug/* routes (prompt preview etc).
+	// Must stay off in production.
+	DebugEndpoints bool `yaml:"debug_endpoints"`
 }
 
 // LoadConfig loads configuration from YAML file
@@ -69,10 +171,55 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.Database.Path = "./data/annotations.db"
 	}
 
+	if config.BudgetStatePath == "" {
+		config.BudgetStatePath = "./data/provider_budget.json"
+	}
+
+	if config.ConfidenceCalibration.Temperature == 0 {
+		config.ConfidenceCalibration.Temperature = 1
+	}
+
+	if config.PromptLogPath == "" {
+		config.PromptLogPath = "./data/prompt_log.jsonl"
+	}
+
+	if config.PromptLogRedactText == nil {
+		redact := true
+		config.PromptLogRedactText = &redact
+	}
+
 	if config.MaxFailuresBeforeSwitch == 0 {
 		config.MaxFailuresBeforeSwitch = 3
 	}
 
+	if config.LatencySwitchAfter == 0 {
+		config.LatencySwitchAfter = 3
+	}
+
+	if config.AccuracyMinSamples == 0 {
+		config.AccuracyMinSamples = 20
+	}
+
+	if config.FailureCooldownSeconds == 0 {
+		config.FailureCooldownSeconds = 60
+	}
+
+	if config.BatchWorkers == 0 {
+		config.BatchWorkers = 4
+	}
+
+	if config.MaxConcurrentAnnotations == 0 {
+		config.MaxConcurrentAnnotations = 8
+	}
+
+	if config.Telemetry.IntervalSeconds == 0 {
+		config.Telemetry.IntervalSeconds = 86400
+	}
+
+	if config.JustificationLanguage == "" {
+		config.JustificationLanguage = "ru"
+	}
+
 	// Expand environment variables in provider API keys
 	for i := range config.Providers {
 		config.Providers[i].APIKey = os.ExpandEnv(config.Providers[i].APIKey)
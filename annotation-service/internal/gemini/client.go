@@ -2,12 +2,12 @@ package gemini
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"annotation-service/internal/models"
+	"annotation-service/internal/promptlog"
 
 	"github.com/google/generative-ai-go/genai"
 	"go.uber.org/zap"
@@ -16,12 +16,12 @@ import (
 
 // Client wraps the Gemini API client
 type Client struct {
-	client       *genai.Client
-	model        *genai.GenerativeModel
-	logger       *zap.Logger
-	modelName    string
-	maxRetries   int
-	retryDelay   time.Duration
+	client     *genai.Client
+	model      *genai.GenerativeModel
+	logger     *zap.Logger
+	modelName  string
+	maxRetries int
+	retryDelay time.Duration
 }
 
 // Config for Gemini client
@@ -93,6 +93,15 @@ func (c *Client) Close() error {
 	return c.client.Close()
 }
 
+// HealthCheck probes the API with a token-count call: cheap, bills no
+// generation, and fails fast on a dead key or unknown model.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	if _, err := c.model.CountTokens(ctx, genai.Text("ping")); err != nil {
+		return fmt.Errorf("gemini health check failed: %w", err)
+	}
+	return nil
+}
+
 // Annotate classifies a single message
 func (c *Client) Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error) {
 	prompt := BuildPrompt(text)
@@ -106,6 +115,7 @@ func (c *Client) Annotate(ctx context.Context, text string) (*models.AnnotationR
 			time.Sleep(c.retryDelay)
 		}
 
+		start := time.Now()
 		resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
 		if err != nil {
 			lastErr = fmt.Errorf("gemini API error: %w", err)
@@ -135,10 +145,10 @@ func (c *Client) Annotate(ctx context.Context, text string) (*models.AnnotationR
 		cleanJSON = strings.TrimSuffix(cleanJSON, "```")
 		cleanJSON = strings.TrimSpace(cleanJSON)
 
-		var result models.AnnotationResponse
-		if err := json.Unmarshal([]byte(cleanJSON), &result); err != nil {
+		result, err := models.ParseAnnotationResponse([]byte(cleanJSON))
+		if err != nil {
 			lastErr = fmt.Errorf("failed to parse gemini response: %w", err)
-			c.logger.Error("Failed to parse JSON response",
+			c.logger.Error("Invalid annotation response",
 				zap.Error(err),
 				zap.String("original_response", string(textPart)),
 				zap.String("cleaned_response", cleanJSON),
@@ -146,21 +156,14 @@ func (c *Client) Annotate(ctx context.Context, text string) (*models.AnnotationR
 			continue
 		}
 
-		// Validate category ID
-		if result.CategoryID < 1 || result.CategoryID > 9 {
-			lastErr = fmt.Errorf("invalid category ID: %d", result.CategoryID)
-			c.logger.Error("Invalid category ID",
-				zap.Int("category_id", result.CategoryID),
-				zap.Int("attempt", attempt+1))
-			continue
-		}
+		promptlog.Sample("gemini", c.modelName, prompt, text, string(textPart), result, time.Since(start))
 
 		c.logger.Debug("Successfully annotated message",
 			zap.String("category", result.CategoryName),
 			zap.Int("category_id", result.CategoryID),
 			zap.Int("attempt", attempt+1))
 
-		return &result, nil
+		return result, nil
 	}
 
 	return nil, fmt.Errorf("failed after %d attempts: %w", c.maxRetries, lastErr)
@@ -214,17 +217,17 @@ func (c *Client) AnnotateBatch(ctx context.Context, messages []string) ([]*model
 
 // GetStats returns usage statistics (for rate limiting)
 type Stats struct {
-	TotalRequests   int64
-	FailedRequests  int64
-	AverageLatency  time.Duration
+	TotalRequests  int64
+	FailedRequests int64
+	AverageLatency time.Duration
 }
 
 // GetModelInfo returns model information
 func (c *Client) GetModelInfo() map[string]interface{} {
 	return map[string]interface{}{
-		"provider":     "gemini",
-		"model":        c.modelName,
-		"max_retries":  c.maxRetries,
-		"retry_delay":  c.retryDelay.String(),
+		"provider":    "gemini",
+		"model":       c.modelName,
+		"max_retries": c.maxRetries,
+		"retry_delay": c.retryDelay.String(),
 	}
 }
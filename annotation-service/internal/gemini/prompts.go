@@ -1,6 +1,74 @@
 package gemini
 
-import "fmt"
+import (
+	"fmt"
+
+	"annotation-service/internal/langdetect"
+)
+
+// languageRouting enables per-message prompt selection: messages detected as
+// English get the English prompt variant instead of the Russian-tuned one.
+var languageRouting bool
+
+// SetLanguageRouting enables or disables language-based prompt routing.
+// Configured once at startup, like SetJustificationLanguage.
+func SetLanguageRouting(enabled bool) {
+	languageRouting = enabled
+}
+
+// justificationLanguage is the language the justification field must be
+// written in. Configured once at startup via SetJustificationLanguage.
+var justificationLanguage = "ru"
+
+// languageDirectives maps a language code to the prompt line enforcing it.
+var languageDirectives = map[string]string{
+	"ru": `ВАЖНО: поле "justification" должно быть написано строго на русском языке.`,
+	"en": `IMPORTANT: the "justification" field must be written strictly in English.`,
+}
+
+// SetJustificationLanguage sets the required justification language ("ru" or
+// "en"). Unknown codes are ignored and the default is kept.
+func SetJustificationLanguage(lang string) {
+	if _, ok := languageDirectives[lang]; ok {
+		justificationLanguage = lang
+	}
+}
+
+// JustificationLanguage returns the currently required justification language.
+func JustificationLanguage() string {
+	return justificationLanguage
+}
+
+// extractGroomingStage appends the grooming-stage extraction block to the
+// prompts when enabled. Configured once at startup, like SetLanguageRouting.
+var extractGroomingStage bool
+
+// SetGroomingStageExtraction enables or disables the optional grooming-stage
+// prompt extension.
+func SetGroomingStageExtraction(enabled bool) {
+	extractGroomingStage = enabled
+}
+
+// groomingStageDirective is the Russian prompt block asking the model to tag
+// the grooming stage. The stage names are kept in English so they form a
+// fixed machine-readable vocabulary regardless of the prompt language.
+const groomingStageDirective = `ДОПОЛНИТЕЛЬНО: добавь в JSON поле "grooming_stage". Если сообщение относится к грумингу, укажи стадию строго одним из значений: "friendship_forming" (установление дружбы и доверия), "isolation" (изоляция от близких), "secrecy" (требование секретности), "desensitization" (нормализация недопустимых тем), "request" (прямая просьба о действиях или материалах). Если стадия не выражена или сообщение не про груминг, укажи пустую строку "".`
+
+// groomingStageDirectiveEN mirrors groomingStageDirective for the English
+// prompt variant.
+const groomingStageDirectiveEN = `ADDITIONALLY: add a "grooming_stage" field to the JSON. If the message relates to grooming, set it to exactly one of: "friendship_forming" (building friendship and trust), "isolation" (isolating from family and friends), "secrecy" (demanding secrecy), "desensitization" (normalizing inappropriate topics), "request" (a direct request for actions or material). If no stage is evident or the message is not about grooming, use an empty string "".`
+
+// groomingStageBlock returns the stage-extraction instruction for the given
+// prompt language, or "" when extraction is disabled.
+func groomingStageBlock(lang string) string {
+	if !extractGroomingStage {
+		return ""
+	}
+	if lang == "en" {
+		return "\n" + groomingStageDirectiveEN
+	}
+	return "\n" + groomingStageDirective
+}
 
 // SystemInstruction is the base instruction for Gemini (from your llm.py)
 const SystemInstruction = `Ты — высокоточный классификатор рисков для детей в сети. Твоя задача — проанализировать сообщение и выбрать ОДНУ наиболее подходящую категорию.
@@ -26,8 +94,54 @@ const FewShotExamples = `ПРИМЕРЫ (Few-Shot Context):
 - Сообщение: "Ты выиграл! Чтобы получить приз, дай пароль и имя своей любимой собаки." -> Категория: Сбор личных данных (Фишинг)
 - Сообщение: "Привет, как дела? Какую игру сейчас проходишь?" -> Категория: Нейтральное общение`
 
-// BuildPrompt creates the full prompt for a message
+// CategoriesTextEN mirrors the category list for the English prompt variant.
+// The canonical Russian names are kept so category_name stays uniform in the
+// dataset regardless of the message language.
+const CategoriesTextEN = `1: Склонение к сексуальным действиям (Груминг) — grooming / sexual coercion
+2: Угрозы, шантаж, вымогательство — threats, blackmail, extortion
+3: Физическое насилие/Буллинг — physical violence / bullying
+4: Склонение к суициду/Самоповреждению — suicide / self-harm incitement
+5: Склонение к опасным играм/действиям — dangerous games or dares
+6: Пропаганда запрещенных веществ — promotion of illegal substances
+7: Финансовое мошенничество — financial fraud
+8: Сбор личных данных (Фишинг) — personal data harvesting (phishing)
+9: Нейтральное общение — neutral conversation`
+
+// FewShotExamplesEN provides English examples for the English prompt variant.
+const FewShotExamplesEN = `EXAMPLES (Few-Shot Context):
+- Message: "Come over to my place, nobody will know. Send me your photo, it's our secret." -> Category: Склонение к сексуальным действиям (Груминг)
+- Message: "If you don't do what I said, I'll post all your secrets." -> Category: Угрозы, шантаж, вымогательство
+- Message: "You're a loser, everyone would be better off without you." -> Category: Склонение к суициду/Самоповреждению
+- Message: "You won a prize! To claim it, tell me your password and your pet's name." -> Category: Сбор личных данных (Фишинг)
+- Message: "Hey, how's it going? What game are you playing right now?" -> Category: Нейтральное общение`
+
+// buildPromptEN is the English-tuned prompt used when language routing
+// detects an English message.
+func buildPromptEN(text string) string {
+	return fmt.Sprintf(`MESSAGE TO CLASSIFY: "%s"
+
+RISK CATEGORIES (use the exact Russian names listed, one per message):
+%s
+
+%s
+
+ANSWER ONLY IN STRICT JSON (plain JSON, no markdown formatting):
+{
+  "category_name": "The exact Russian category name from the list",
+  "category_id": CATEGORY_NUMBER_AS_INTEGER,
+  "justification": "A short explanation of why this category was chosen."
+}
+
+IMPORTANT: category_id must be an integer from 1 to 9, NOT an array!
+%s%s`, text, CategoriesTextEN, FewShotExamplesEN, languageDirectives[justificationLanguage], groomingStageBlock("en"))
+}
+
+// BuildPrompt creates the full prompt for a message. With language routing
+// enabled, English messages get the English prompt variant.
 func BuildPrompt(text string) string {
+	if languageRouting && langdetect.Detect(text) == "en" {
+		return buildPromptEN(text)
+	}
 	return fmt.Sprintf(`СООБЩЕНИЕ ДЛЯ КЛАССИФИКАЦИИ: "%s"
 
 КАТЕГОРИИ РИСКОВ (используй только эти названия):
@@ -42,5 +156,6 @@ func BuildPrompt(text string) string {
   "justification": "Краткое объяснение, почему выбрана эта категория."
 }
 
-ВАЖНО: category_id должен быть целым числом от 1 до 9, НЕ массивом!`, text, CategoriesText, FewShotExamples)
+ВАЖНО: category_id должен быть целым числом от 1 до 9, НЕ массивом!
+%s%s`, text, CategoriesText, FewShotExamples, languageDirectives[justificationLanguage], groomingStageBlock("ru"))
 }
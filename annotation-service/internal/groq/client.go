@@ -5,13 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	"annotation-service/internal/gemini"
+	"annotation-service/internal/httpx"
 	"annotation-service/internal/models"
+	"annotation-service/internal/promptlog"
 
 	"go.uber.org/zap"
 )
@@ -25,6 +26,7 @@ type Client struct {
 	logger     *zap.Logger
 	maxRetries int
 	retryDelay time.Duration
+	jsonMode   bool
 }
 
 // Config for Groq client
@@ -33,14 +35,23 @@ type Config struct {
 	ModelName  string // Default: "llama-3.3-70b-versatile"
 	MaxRetries int
 	RetryDelay time.Duration
+	// JSONMode sets response_format json_object so the model returns plain
+	// JSON. Leave off for models that do not support it; the markdown fence
+	// stripping below still handles those.
+	JSONMode bool
 }
 
 // groqRequest represents the request to Groq API
 type groqRequest struct {
-	Model    string          `json:"model"`
-	Messages []groqMessage   `json:"messages"`
-	Stream   bool            `json:"stream"`
-	Temperature float32      `json:"temperature,omitempty"`
+	Model          string              `json:"model"`
+	Messages       []groqMessage       `json:"messages"`
+	Stream         bool                `json:"stream"`
+	Temperature    float32             `json:"temperature,omitempty"`
+	ResponseFormat *groqResponseFormat `json:"response_format,omitempty"`
+}
+
+type groqResponseFormat struct {
+	Type string `json:"type"` // "json_object"
 }
 
 type groqMessage struct {
@@ -92,10 +103,11 @@ func NewClient(cfg Config, logger *zap.Logger) (*Client, error) {
 		apiKey:     cfg.APIKey,
 		baseURL:    "https://api.groq.com/openai/v1",
 		modelName:  cfg.ModelName,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: httpx.NewClient(30 * time.Second),
 		logger:     logger,
 		maxRetries: cfg.MaxRetries,
 		retryDelay: cfg.RetryDelay,
+		jsonMode:   cfg.JSONMode,
 	}, nil
 }
 
@@ -108,123 +120,109 @@ func (c *Client) Close() error {
 func (c *Client) Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error) {
 	prompt := gemini.BuildPrompt(text)
 
-	var lastErr error
-	for attempt := 0; attempt < c.maxRetries; attempt++ {
-		if attempt > 0 {
-			c.logger.Warn("Retrying Groq request",
-				zap.Int("attempt", attempt+1),
-				zap.Int("max_retries", c.maxRetries))
-			time.Sleep(c.retryDelay)
-		}
-
-		reqBody := groqRequest{
-			Model: c.modelName,
-			Messages: []groqMessage{
-				{
-					Role:    "system",
-					Content: gemini.SystemInstruction,
-				},
-				{
-					Role:    "user",
-					Content: prompt,
-				},
+	reqBody := groqRequest{
+		Model: c.modelName,
+		Messages: []groqMessage{
+			{
+				Role:    "system",
+				Content: gemini.SystemInstruction,
 			},
-			Stream:      false,
-			Temperature: 0.3,
-		}
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		Stream:      false,
+		Temperature: 0.3,
+	}
+	if c.jsonMode {
+		reqBody.ResponseFormat = &groqResponseFormat{Type: "json_object"}
+	}
 
-		jsonData, err := json.Marshal(reqBody)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to marshal request: %w", err)
-			continue
-		}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
 
+	start := time.Now()
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
 		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 		if err != nil {
-			lastErr = fmt.Errorf("failed to create request: %w", err)
-			continue
+			return nil, err
 		}
-
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		return req, nil
+	}, httpx.Options{
+		MaxAttempts: c.maxRetries,
+		BaseDelay:   c.retryDelay,
+		Name:        "groq",
+		Logger:      c.logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("groq API error: %w", err)
+	}
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("groq API error: %w", err)
-			c.logger.Error("Groq API error", zap.Error(err), zap.Int("attempt", attempt+1))
-			continue
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read response: %w", err)
-			continue
-		}
+	var groqResp groqResponse
+	if err := json.Unmarshal(body, &groqResp); err != nil {
+		c.logger.Error("Failed to parse JSON response",
+			zap.Error(err),
+			zap.String("body", string(body)))
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			lastErr = fmt.Errorf("groq API returned status %d: %s", resp.StatusCode, string(body))
-			c.logger.Error("Groq API error",
-				zap.Int("status", resp.StatusCode),
-				zap.String("body", string(body)),
-				zap.Int("attempt", attempt+1))
-			continue
-		}
+	if len(groqResp.Choices) == 0 {
+		c.logger.Error("Empty response from Groq")
+		return nil, fmt.Errorf("empty response from groq")
+	}
 
-		var groqResp groqResponse
-		if err := json.Unmarshal(body, &groqResp); err != nil {
-			lastErr = fmt.Errorf("failed to parse response: %w", err)
-			c.logger.Error("Failed to parse JSON response",
-				zap.Error(err),
-				zap.String("body", string(body)),
-				zap.Int("attempt", attempt+1))
-			continue
-		}
+	content := groqResp.Choices[0].Message.Content
+
+	// Parse JSON - strip markdown code blocks if present (fallback for
+	// models running without JSON mode)
+	cleanJSON := strings.TrimSpace(content)
+	cleanJSON = strings.TrimPrefix(cleanJSON, "```json")
+	cleanJSON = strings.TrimPrefix(cleanJSON, "```")
+	cleanJSON = strings.TrimSuffix(cleanJSON, "```")
+	cleanJSON = strings.TrimSpace(cleanJSON)
+
+	result, err := models.ParseAnnotationResponse([]byte(cleanJSON))
+	if err != nil {
+		c.logger.Error("Invalid annotation response",
+			zap.Error(err),
+			zap.String("original_response", content),
+			zap.String("cleaned_response", cleanJSON))
+		return nil, fmt.Errorf("failed to parse groq response: %w", err)
+	}
 
-		if len(groqResp.Choices) == 0 {
-			lastErr = fmt.Errorf("empty response from groq")
-			c.logger.Error("Empty response from Groq", zap.Int("attempt", attempt+1))
-			continue
-		}
+	promptlog.Sample("groq", c.modelName, prompt, text, content, result, time.Since(start))
 
-		content := groqResp.Choices[0].Message.Content
-
-		// Parse JSON - strip markdown code blocks if present
-		cleanJSON := strings.TrimSpace(content)
-		cleanJSON = strings.TrimPrefix(cleanJSON, "```json")
-		cleanJSON = strings.TrimPrefix(cleanJSON, "```")
-		cleanJSON = strings.TrimSuffix(cleanJSON, "```")
-		cleanJSON = strings.TrimSpace(cleanJSON)
-
-		var result models.AnnotationResponse
-		if err := json.Unmarshal([]byte(cleanJSON), &result); err != nil {
-			lastErr = fmt.Errorf("failed to parse groq response: %w", err)
-			c.logger.Error("Failed to parse JSON response",
-				zap.Error(err),
-				zap.String("original_response", content),
-				zap.String("cleaned_response", cleanJSON),
-				zap.Int("attempt", attempt+1))
-			continue
-		}
+	c.logger.Debug("Successfully annotated message with Groq",
+		zap.String("category", result.CategoryName),
+		zap.Int("category_id", result.CategoryID))
 
-		// Validate category ID
-		if result.CategoryID < 1 || result.CategoryID > 9 {
-			lastErr = fmt.Errorf("invalid category ID: %d", result.CategoryID)
-			c.logger.Error("Invalid category ID",
-				zap.Int("category_id", result.CategoryID),
-				zap.Int("attempt", attempt+1))
-			continue
-		}
+	return result, nil
+}
 
-		c.logger.Debug("Successfully annotated message with Groq",
-			zap.String("category", result.CategoryName),
-			zap.Int("category_id", result.CategoryID),
-			zap.Int("attempt", attempt+1))
+// HealthCheck probes the models endpoint, which requires a valid API key but
+// consumes no tokens.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-		return &result, nil
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("groq health check failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	return nil, fmt.Errorf("failed after %d attempts: %w", c.maxRetries, lastErr)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("groq health check returned status %d", resp.StatusCode)
+	}
+	return nil
 }
 
 // GetModelInfo returns model information
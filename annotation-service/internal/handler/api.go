@@ -3,10 +3,14 @@ package handler
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"annotation-service/internal/gemini"
+	"annotation-service/internal/llm"
 	"annotation-service/internal/models"
 	"annotation-service/internal/service"
 
@@ -16,15 +20,24 @@ import (
 
 // Handler handles HTTP requests
 type Handler struct {
-	annotator *service.Annotator
-	logger    *zap.Logger
+	annotator      *service.Annotator
+	debugEndpoints bool
+	// sem caps concurrent synchronous annotations so bursts get 429
+	// instead of piling up goroutines blocked on the rate limiter.
+	sem    chan struct{}
+	logger *zap.Logger
 }
 
 // NewHandler creates a new API handler
-func NewHandler(annotator *service.Annotator, logger *zap.Logger) *Handler {
+func NewHandler(annotator *service.Annotator, debugEndpoints bool, maxConcurrent int, logger *zap.Logger) *Handler {
+	if maxConcurrent < 1 {
+		maxConcurrent = 8
+	}
 	return &Handler{
-		annotator: annotator,
-		logger:    logger,
+		annotator:      annotator,
+		debugEndpoints: debugEndpoints,
+		sem:            make(chan struct{}, maxConcurrent),
+		logger:         logger,
 	}
 }
 
@@ -38,13 +51,30 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 		api.GET("/annotate/jobs/:id", h.GetJobStatus)
 
 		// Data retrieval
+		api.GET("/categories", h.GetCategories)
 		api.GET("/annotations", h.GetAllAnnotations)
 		api.GET("/annotations/category/:id", h.GetAnnotationsByCategory)
 		api.GET("/annotations/stats", h.GetStats)
+		api.GET("/annotations/calibration", h.GetCalibration)
+		api.GET("/annotations/confidence-histogram", h.GetConfidenceHistogram)
+
+		// Consolidated operational overview
+		api.GET("/stats", h.GetCombinedStats)
+
+		// Runtime provider control
+		api.POST("/providers/:index/disable", h.DisableProvider)
+		api.POST("/providers/:index/enable", h.EnableProvider)
+		api.POST("/providers/accuracy", h.ReportProviderAccuracy)
 
 		// Export
 		api.GET("/export/csv", h.ExportCSV)
 		api.GET("/export/json", h.ExportJSON)
+		api.GET("/export/jsonl", h.ExportJSONL)
+
+		// Debug endpoints (disabled in production via config)
+		if h.debugEndpoints {
+			api.POST("/debug/prompt", h.DebugPrompt)
+		}
 	}
 
 	// Health check
@@ -59,7 +89,48 @@ func (h *Handler) AnnotateSingle(c *gin.Context) {
 		return
 	}
 
-	annotation, err := h.annotator.AnnotateSingle(c.Request.Context(), req.Text)
+	select {
+	case h.sem <- struct{}{}:
+		defer func() { <-h.sem }()
+	default:
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many concurrent annotation requests, retry later"})
+		return
+	}
+
+	// Provider pinning for A/B testing: ?provider=groq or the
+	// X-Annotation-Provider header routes this one call to a specific
+	// provider, bypassing the rotation.
+	pinnedProvider := c.Query("provider")
+	if pinnedProvider == "" {
+		pinnedProvider = c.GetHeader("X-Annotation-Provider")
+	}
+
+	// ?dry_run=true (or the body flag) runs the LLM call without saving, so
+	// prompt tuning does not pollute the annotations table.
+	dryRun := req.DryRun || c.Query("dry_run") == "true"
+
+	var annotation *models.Annotation
+	var err error
+	if pinnedProvider != "" {
+		// Dry run composes with pinning: tuning a prompt against one
+		// specific provider is the main use of both flags.
+		if dryRun {
+			annotation, err = h.annotator.AnnotateWithProviderDryRun(c.Request.Context(), pinnedProvider, req.Text)
+		} else {
+			annotation, err = h.annotator.AnnotateWithProvider(c.Request.Context(), pinnedProvider, req.Text)
+		}
+		if errors.Is(err, llm.ErrUnknownProvider) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if req.SecondOpinion {
+		annotation, err = h.annotator.AnnotateSecondOpinion(c.Request.Context(), req.Text)
+	} else if dryRun {
+		annotation, err = h.annotator.AnnotateSingleDryRun(c.Request.Context(), req.Text)
+	} else {
+		annotation, err = h.annotator.AnnotateSingle(c.Request.Context(), req.Text)
+	}
 	if err != nil {
 		h.logger.Error("Failed to annotate", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "annotation failed"})
@@ -85,8 +156,8 @@ func (h *Handler) AnnotateBatch(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusAccepted, gin.H{
-		"job_id": jobID,
-		"status": "pending",
+		"job_id":  jobID,
+		"status":  "pending",
 		"message": "Batch annotation started. Check /api/v1/annotate/jobs/" + jobID + " for status",
 	})
 }
@@ -104,21 +175,112 @@ func (h *Handler) GetJobStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, job)
 }
 
-// GetAllAnnotations returns all annotations
+// GetCalibration returns the active confidence-calibration mapping.
+func (h *Handler) GetCalibration(c *gin.Context) {
+	mapping := h.annotator.CalibrationMapping()
+	if mapping == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "calibration": mapping})
+}
+
+// defaultAnnotationPageSize caps paged listings when the caller filters by
+// date without specifying a limit.
+const defaultAnnotationPageSize = 100
+
+// GetAllAnnotations returns annotations. Without query parameters it keeps
+// the original return-everything behavior; limit, offset, from, and to
+// switch to a paged listing with the total match count alongside the page.
 func (h *Handler) GetAllAnnotations(c *gin.Context) {
-	annotations, err := h.annotator.GetAllAnnotations()
+	filter, paged, err := parseAnnotationFilter(c)
 	if err != nil {
-		h.logger.Error("Failed to get annotations", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !paged {
+		annotations, err := h.annotator.GetAllAnnotations()
+		if err != nil {
+			h.logger.Error("Failed to get annotations", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get annotations"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"annotations": annotations,
+			"total":       len(annotations),
+		})
+		return
+	}
+
+	annotations, total, err := h.annotator.GetAnnotationsPaged(filter)
+	if err != nil {
+		h.logger.Error("Failed to get annotations page", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get annotations"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"annotations": annotations,
-		"total":       len(annotations),
+		"total":       total,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
 	})
 }
 
+// parseAnnotationFilter reads limit, offset, from, and to from the query
+// string. paged is false when none of them are present. Timestamps accept
+// RFC 3339 or a bare date; a bare date in "to" covers the whole day since
+// the upper bound is exclusive.
+func parseAnnotationFilter(c *gin.Context) (filter models.AnnotationFilter, paged bool, err error) {
+	if v := c.Query("limit"); v != "" {
+		paged = true
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil || filter.Limit <= 0 {
+			return filter, paged, fmt.Errorf("invalid limit: %s", v)
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		paged = true
+		filter.Offset, err = strconv.Atoi(v)
+		if err != nil || filter.Offset < 0 {
+			return filter, paged, fmt.Errorf("invalid offset: %s", v)
+		}
+	}
+	if v := c.Query("from"); v != "" {
+		paged = true
+		t, terr := parseTimeParam(v)
+		if terr != nil {
+			return filter, paged, fmt.Errorf("invalid from: %s", v)
+		}
+		filter.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		paged = true
+		t, terr := parseTimeParam(v)
+		if terr != nil {
+			return filter, paged, fmt.Errorf("invalid to: %s", v)
+		}
+		if len(v) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = &t
+	}
+	if paged && filter.Limit == 0 {
+		filter.Limit = defaultAnnotationPageSize
+	}
+	return filter, paged, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or bare dates.
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
 // GetAnnotationsByCategory returns annotations by category
 func (h *Handler) GetAnnotationsByCategory(c *gin.Context) {
 	categoryID, err := strconv.Atoi(c.Param("id"))
@@ -141,6 +303,11 @@ func (h *Handler) GetAnnotationsByCategory(c *gin.Context) {
 	})
 }
 
+// GetCategories returns the threat taxonomy so clients don't hardcode it
+func (h *Handler) GetCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"categories": models.Categories()})
+}
+
 // GetStats returns annotation statistics
 func (h *Handler) GetStats(c *gin.Context) {
 	stats, err := h.annotator.GetStats()
@@ -153,7 +320,123 @@ func (h *Handler) GetStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetConfidenceHistogram returns stored annotations bucketed by 0.1-wide
+// confidence ranges per provider and category, for checking how
+// well-calibrated each provider's reported confidence is.
+func (h *Handler) GetConfidenceHistogram(c *gin.Context) {
+	buckets, err := h.annotator.GetConfidenceHistogram()
+	if err != nil {
+		h.logger.Error("Failed to get confidence histogram", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get confidence histogram"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"histogram": buckets,
+		"total":     len(buckets),
+	})
+}
+
+// DisableProvider handles POST /api/v1/providers/:index/disable: soft-pauses
+// one provider so the rotation skips it, without a restart or config change.
+// The state is in-memory and resets on restart.
+func (h *Handler) DisableProvider(c *gin.Context) {
+	h.setProviderEnabled(c, false)
+}
+
+// EnableProvider handles POST /api/v1/providers/:index/enable: resumes a
+// soft-paused provider.
+func (h *Handler) EnableProvider(c *gin.Context) {
+	h.setProviderEnabled(c, true)
+}
+
+// ReportProviderAccuracy handles POST /api/v1/providers/accuracy: records
+// per-provider historical accuracy measured against validated dataset
+// entries. With accuracy-based ordering enabled the rotation re-prefers the
+// most accurate provider; otherwise the numbers only show up in provider
+// info.
+func (h *Handler) ReportProviderAccuracy(c *gin.Context) {
+	var req struct {
+		Providers []struct {
+			Provider string  `json:"provider" binding:"required"`
+			Samples  int     `json:"samples"`
+			Accuracy float64 `json:"accuracy"`
+		} `json:"providers" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, entry := range req.Providers {
+		if err := h.annotator.ReportProviderAccuracy(entry.Provider, entry.Samples, entry.Accuracy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": len(req.Providers)})
+}
+
+func (h *Handler) setProviderEnabled(c *gin.Context, enabled bool) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid provider index"})
+		return
+	}
+
+	if err := h.annotator.SetProviderEnabled(index, enabled); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Provider enabled state changed via API",
+		zap.Int("provider_index", index),
+		zap.Bool("enabled", enabled))
+	c.JSON(http.StatusOK, gin.H{
+		"provider_index": index,
+		"enabled":        enabled,
+	})
+}
+
+// GetCombinedStats returns a one-shot operational overview: annotation
+// counts, per-provider model/usage info, active batch jobs and the current
+// provider, so the dashboard needs one request instead of several.
+func (h *Handler) GetCombinedStats(c *gin.Context) {
+	annotations, err := h.annotator.GetStats()
+	if err != nil {
+		h.logger.Error("Failed to get stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stats"})
+		return
+	}
+
+	jobs, err := h.annotator.GetActiveJobs()
+	if err != nil {
+		// Jobs are the least critical section; degrade to empty instead of
+		// failing the whole overview.
+		h.logger.Warn("Failed to get active jobs for stats overview", zap.Error(err))
+		jobs = nil
+	}
+	if jobs == nil {
+		jobs = []*models.Job{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"annotations":      annotations,
+		"providers":        h.annotator.GetProvidersInfo(),
+		"current_provider": h.annotator.GetModelInfo(),
+		"active_jobs":      jobs,
+	})
+}
+
+// ExportSchemaVersion identifies the shape of exported annotation records.
+// Bump it whenever exported fields change so downstream ingestion pipelines
+// detect the schema drift instead of breaking silently.
+const ExportSchemaVersion = 1
+
 // ExportCSV exports annotations to CSV
+// The schema version and export time travel in the X-Export-Schema-Version
+// and X-Exported-At response headers.
 func (h *Handler) ExportCSV(c *gin.Context) {
 	annotations, err := h.annotator.GetAllAnnotations()
 	if err != nil {
@@ -164,6 +447,11 @@ func (h *Handler) ExportCSV(c *gin.Context) {
 
 	c.Header("Content-Type", "text/csv")
 	c.Header("Content-Disposition", "attachment; filename=annotations.csv")
+	// RFC 4180 has no comment syntax, so the schema envelope travels in
+	// response headers instead of a pseudo-comment row that would break
+	// strict CSV parsers.
+	c.Header("X-Export-Schema-Version", strconv.Itoa(ExportSchemaVersion))
+	c.Header("X-Exported-At", time.Now().UTC().Format(time.RFC3339))
 
 	writer := csv.NewWriter(c.Writer)
 	defer writer.Flush()
@@ -196,7 +484,57 @@ func (h *Handler) ExportJSON(c *gin.Context) {
 
 	encoder := json.NewEncoder(c.Writer)
 	encoder.SetIndent("", "  ")
-	encoder.Encode(annotations)
+	encoder.Encode(gin.H{
+		"schema_version": ExportSchemaVersion,
+		"exported_at":    time.Now().UTC(),
+		"annotations":    annotations,
+	})
+}
+
+// ExportJSONL exports annotations as one JSON object per line, the format
+// ML training pipelines (e.g. HuggingFace datasets) ingest directly. Rows
+// stream from the database cursor straight to the response writer, so the
+// dataset is never held in memory at once. ?validated=true restricts the
+// export to validated entries.
+func (h *Handler) ExportJSONL(c *gin.Context) {
+	validatedOnly := c.Query("validated") == "true"
+
+	c.Header("Content-Type", "application/jsonl")
+	c.Header("Content-Disposition", "attachment; filename=annotations.jsonl")
+	// Like CSV, the schema envelope travels in headers: a version line inside
+	// the stream would look like a training record to ingestion pipelines.
+	c.Header("X-Export-Schema-Version", strconv.Itoa(ExportSchemaVersion))
+	c.Header("X-Exported-At", time.Now().UTC().Format(time.RFC3339))
+
+	encoder := json.NewEncoder(c.Writer)
+	err := h.annotator.StreamAnnotations(validatedOnly, func(ann *models.Annotation) error {
+		return encoder.Encode(gin.H{
+			"text":          ann.Text,
+			"label":         ann.Category,
+			"category_name": ann.CategoryName,
+		})
+	})
+	if err != nil {
+		// Headers (and possibly rows) are already out, so a JSON error body
+		// is not an option; log and cut the stream short.
+		h.logger.Error("Failed to export JSONL", zap.Error(err))
+	}
+}
+
+// DebugPrompt returns the exact prompt that would be sent to the LLM for the
+// given text, without calling the model. Useful for verifying few-shot examples
+// and prompt assembly when debugging misclassifications.
+func (h *Handler) DebugPrompt(c *gin.Context) {
+	var req models.AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"system_instruction": gemini.SystemInstruction,
+		"prompt":             gemini.BuildPrompt(req.Text),
+	})
 }
 
 // HealthCheck returns service health
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"annotation-service/internal/models"
+	"annotation-service/internal/repository"
+	"annotation-service/internal/service"
+)
+
+// exportLLM satisfies service.LLMClient; export endpoints never call it.
+type exportLLM struct{}
+
+func (exportLLM) Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error) {
+	panic("export tests must not call the LLM")
+}
+func (exportLLM) Close() error                         { return nil }
+func (exportLLM) GetModelInfo() map[string]interface{} { return nil }
+
+func newExportRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo, err := repository.NewAnnotationRepository("sqlite", t.TempDir()+"/annotations.db", zap.NewNop())
+	if err != nil {
+		t.Fatalf("creating repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	seed := []*models.Annotation{
+		{Text: "это наш секрет, никому не говори", Category: 9, CategoryName: "Груминг", Justification: "Призыв к секретности", Provider: "fake", AnnotatedAt: time.Now(), IsValidated: true},
+		{Text: "привет, как дела", Category: 0, CategoryName: "Нейтральное", Justification: "Обычное приветствие", Provider: "fake", AnnotatedAt: time.Now()},
+	}
+	for _, ann := range seed {
+		if err := repo.SaveAnnotation(ann); err != nil {
+			t.Fatalf("seeding annotation: %v", err)
+		}
+	}
+
+	annotator := service.NewAnnotator(exportLLM{}, repo, "ru", false, false, 1, nil, zap.NewNop())
+	router := gin.New()
+	NewHandler(annotator, false, 8, zap.NewNop()).RegisterRoutes(router)
+	return router
+}
+
+func doExport(t *testing.T, router *gin.Engine, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d, body %s", path, w.Code, w.Body.String())
+	}
+	return w
+}
+
+func assertSchemaHeaders(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if got := w.Header().Get("X-Export-Schema-Version"); got != "1" {
+		t.Errorf("X-Export-Schema-Version = %q, want \"1\"", got)
+	}
+	exportedAt := w.Header().Get("X-Exported-At")
+	if _, err := time.Parse(time.RFC3339, exportedAt); err != nil {
+		t.Errorf("X-Exported-At %q is not RFC 3339: %v", exportedAt, err)
+	}
+}
+
+func TestExportCSVSchemaVersionInHeaders(t *testing.T) {
+	w := doExport(t, newExportRouter(t), "/api/v1/export/csv")
+	assertSchemaHeaders(t, w)
+
+	// A strict RFC 4180 parser must accept the body: no pseudo-comment rows.
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d CSV rows, want header + 2 data rows", len(records))
+	}
+	if records[0][0] != "text" || strings.HasPrefix(records[0][0], "#") {
+		t.Errorf("unexpected header row %v", records[0])
+	}
+}
+
+func TestExportJSONSchemaVersionInEnvelope(t *testing.T) {
+	w := doExport(t, newExportRouter(t), "/api/v1/export/json")
+
+	var envelope struct {
+		SchemaVersion int                  `json:"schema_version"`
+		ExportedAt    time.Time            `json:"exported_at"`
+		Annotations   []*models.Annotation `json:"annotations"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("parsing JSON export: %v", err)
+	}
+	if envelope.SchemaVersion != ExportSchemaVersion {
+		t.Errorf("schema_version = %d, want %d", envelope.SchemaVersion, ExportSchemaVersion)
+	}
+	if envelope.ExportedAt.IsZero() {
+		t.Error("exported_at missing from the envelope")
+	}
+	if len(envelope.Annotations) != 2 {
+		t.Errorf("exported %d annotations, want 2", len(envelope.Annotations))
+	}
+}
+
+func TestExportJSONLStreamsRecords(t *testing.T) {
+	router := newExportRouter(t)
+
+	w := doExport(t, router, "/api/v1/export/jsonl")
+	assertSchemaHeaders(t, w)
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d JSONL lines, want 2", len(lines))
+	}
+	for _, line := range lines {
+		var record struct {
+			Text         string `json:"text"`
+			Label        int    `json:"label"`
+			CategoryName string `json:"category_name"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", line, err)
+		}
+		if record.Text == "" || record.CategoryName == "" {
+			t.Errorf("record missing fields: %q", line)
+		}
+	}
+
+	// ?validated=true keeps only the validated entry.
+	w = doExport(t, router, "/api/v1/export/jsonl?validated=true")
+	lines = strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "секрет") {
+		t.Fatalf("validated export returned %v, want only the validated record", lines)
+	}
+}
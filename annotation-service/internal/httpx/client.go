@@ -0,0 +1,75 @@
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ClientConfig tunes the transport shared by outbound HTTP clients
+// (LLM providers and anything else this service calls).
+type ClientConfig struct {
+	// MaxIdleConnsPerHost bounds pooled keep-alive connections per host
+	// (default 10). Reusing connections matters for rate-limited LLM APIs.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes pooled connections idle for this long
+	// (default 90s).
+	IdleConnTimeout time.Duration
+	// ProxyURL routes requests through an HTTP proxy. Empty means the
+	// standard proxy environment variables apply.
+	ProxyURL string
+}
+
+var (
+	transportMu sync.Mutex
+	transport   *http.Transport
+)
+
+// Configure sets the shared transport used by clients created afterwards.
+// Call it once at startup, before constructing service clients; without a
+// call, defaults apply.
+func Configure(cfg ClientConfig) error {
+	if cfg.MaxIdleConnsPerHost == 0 {
+		cfg.MaxIdleConnsPerHost = 10
+	}
+	if cfg.IdleConnTimeout == 0 {
+		cfg.IdleConnTimeout = 90 * time.Second
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	transport = &http.Transport{
+		Proxy:               proxy,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+	return nil
+}
+
+// NewClient returns an *http.Client with the shared tuned transport and the
+// given per-request timeout. Clients created before Configure use defaults.
+func NewClient(timeout time.Duration) *http.Client {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if transport == nil {
+		transport = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
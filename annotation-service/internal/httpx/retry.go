@@ -0,0 +1,188 @@
+// Package httpx provides a shared HTTP retry helper so the provider clients
+// do not each reimplement call/retry logic with slightly different behavior.
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RateLimitError wraps the final error when every attempt ended in HTTP 429
+// and the server suggested a wait via Retry-After. Callers can pull the wait
+// out with RetryAfterFromError and keep traffic off the endpoint for that
+// long instead of retrying it immediately.
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitError) Error() string { return e.Err.Error() }
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// RetryAfterFromError extracts the server-suggested wait from an error chain
+// containing a RateLimitError.
+func RetryAfterFromError(err error) (time.Duration, bool) {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > 0 {
+		return rateLimitErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// Options configures DoWithRetry.
+type Options struct {
+	MaxAttempts int           // total attempts including the first (default 3)
+	BaseDelay   time.Duration // initial backoff delay (default 1s)
+	MaxDelay    time.Duration // upper bound for the backoff delay (default 30s)
+	Name        string        // label used in log and error messages
+	Logger      *zap.Logger   // optional; retries are logged at Warn level
+}
+
+// DoWithRetry executes the request produced by newRequest until it succeeds,
+// a non-retryable status is returned, the attempts are exhausted, or the
+// context is cancelled. Transport errors and retryable statuses (408, 429,
+// 5xx) are retried with exponential backoff plus jitter; a Retry-After header
+// on the response takes precedence over the computed backoff.
+//
+// newRequest is called once per attempt so request bodies are re-created for
+// each try. On success the response body and status code are returned; on
+// failure the body and status of the last attempt (if any) accompany the error.
+func DoWithRetry(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error), opts Options) ([]byte, int, error) {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 30 * time.Second
+	}
+	name := opts.Name
+	if name == "" {
+		name = "http"
+	}
+
+	var (
+		lastErr        error
+		lastBody       []byte
+		lastStatus     int
+		delay          time.Duration
+		retryAfterHint time.Duration
+	)
+
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if opts.Logger != nil {
+				opts.Logger.Warn("Retrying HTTP request",
+					zap.String("client", name),
+					zap.Int("attempt", attempt),
+					zap.Int("max_attempts", opts.MaxAttempts),
+					zap.Duration("delay", delay))
+			}
+			select {
+			case <-ctx.Done():
+				return lastBody, lastStatus, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create %s request: %w", name, err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return lastBody, lastStatus, ctx.Err()
+			}
+			lastErr = fmt.Errorf("%s request failed: %w", name, err)
+			delay = backoff(opts, attempt)
+			continue
+		}
+
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			lastErr = fmt.Errorf("failed to read %s response: %w", name, readErr)
+			delay = backoff(opts, attempt)
+			continue
+		}
+
+		lastBody = body
+		lastStatus = resp.StatusCode
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return body, resp.StatusCode, nil
+		}
+
+		lastErr = fmt.Errorf("%s returned status %d: %s", name, resp.StatusCode, string(body))
+		if !retryableStatus(resp.StatusCode) {
+			return body, resp.StatusCode, lastErr
+		}
+
+		delay = backoff(opts, attempt)
+		retryAfterHint = 0
+		if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			// The hint keeps the server's full figure for the caller; only
+			// the sleep between attempts is capped.
+			retryAfterHint = ra
+			delay = ra
+			if delay > opts.MaxDelay {
+				delay = opts.MaxDelay
+			}
+		}
+	}
+
+	err := fmt.Errorf("%s: giving up after %d attempts: %w", name, opts.MaxAttempts, lastErr)
+	if lastStatus == http.StatusTooManyRequests && retryAfterHint > 0 {
+		return lastBody, lastStatus, &RateLimitError{RetryAfter: retryAfterHint, Err: err}
+	}
+	return lastBody, lastStatus, err
+}
+
+// retryableStatus reports whether a status code is worth retrying.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 500
+}
+
+// backoff computes the exponential delay for the given attempt with up to 50%
+// added jitter, capped at MaxDelay.
+func backoff(opts Options, attempt int) time.Duration {
+	delay := opts.BaseDelay << (attempt - 1)
+	if delay > opts.MaxDelay || delay <= 0 {
+		delay = opts.MaxDelay
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// parseRetryAfter parses a Retry-After header as either a delay in seconds or
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,40 @@
+// Package langdetect provides a lightweight script-based language guess for
+// annotation justifications. It only needs to tell Russian from English, so a
+// simple Cyrillic/Latin letter ratio is sufficient — no external models.
+package langdetect
+
+import "unicode"
+
+// minLetters is the minimum number of letters required before a guess is made;
+// shorter strings are reported as unknown.
+const minLetters = 8
+
+// Detect returns "ru" when the text is predominantly Cyrillic, "en" when it is
+// predominantly Latin, and "" when there are too few letters or no clear
+// majority (mixed text, numbers, emoji).
+func Detect(text string) string {
+	var cyrillic, latin, total int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	if total < minLetters {
+		return ""
+	}
+	if cyrillic*3 > total*2 {
+		return "ru"
+	}
+	if latin*3 > total*2 {
+		return "en"
+	}
+	return ""
+}
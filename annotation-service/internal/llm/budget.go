@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrQuotaExhausted is returned when every configured provider has used up
+// its request budget for the current window.
+var ErrQuotaExhausted = errors.New("all provider request budgets exhausted")
+
+// budgetLimits holds the per-window request budgets for one provider; zero
+// means unlimited.
+type budgetLimits struct {
+	daily   int
+	monthly int
+}
+
+// budgetUsage is the persisted per-provider counter state. Counters reset
+// when the stored window label no longer matches the current day/month.
+type budgetUsage struct {
+	Day        string `json:"day"`
+	DayCount   int    `json:"day_count"`
+	Month      string `json:"month"`
+	MonthCount int    `json:"month_count"`
+}
+
+// BudgetTracker enforces daily/monthly request budgets per provider and
+// persists usage counters to disk so restarts don't reset free-tier spend
+// tracking. Providers are identified by a type/model key, so reordering the
+// provider list in config keeps counters attached to the right provider.
+type BudgetTracker struct {
+	mu        sync.Mutex
+	keys      []string
+	limits    []budgetLimits
+	usage     map[string]*budgetUsage
+	statePath string
+	logger    *zap.Logger
+}
+
+func newBudgetTracker(keys []string, limits []budgetLimits, statePath string, logger *zap.Logger) *BudgetTracker {
+	t := &BudgetTracker{
+		keys:      keys,
+		limits:    limits,
+		usage:     make(map[string]*budgetUsage),
+		statePath: statePath,
+		logger:    logger,
+	}
+	t.load()
+	return t
+}
+
+// load restores persisted counters; a missing or unreadable state file just
+// starts the counters from zero.
+func (t *BudgetTracker) load() {
+	data, err := os.ReadFile(t.statePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.logger.Warn("Failed to read provider budget state, starting fresh",
+				zap.String("path", t.statePath), zap.Error(err))
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &t.usage); err != nil {
+		t.logger.Warn("Failed to parse provider budget state, starting fresh",
+			zap.String("path", t.statePath), zap.Error(err))
+		t.usage = make(map[string]*budgetUsage)
+	}
+}
+
+// save persists the counters; failures are logged but never block annotation.
+func (t *BudgetTracker) save() {
+	data, err := json.Marshal(t.usage)
+	if err != nil {
+		t.logger.Error("Failed to marshal provider budget state", zap.Error(err))
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(t.statePath), 0755); err != nil {
+		t.logger.Error("Failed to create budget state directory", zap.Error(err))
+		return
+	}
+	if err := os.WriteFile(t.statePath, data, 0644); err != nil {
+		t.logger.Error("Failed to write provider budget state",
+			zap.String("path", t.statePath), zap.Error(err))
+	}
+}
+
+// usageFor returns the counters for a provider with expired windows reset.
+func (t *BudgetTracker) usageFor(index int, now time.Time) *budgetUsage {
+	u, ok := t.usage[t.keys[index]]
+	if !ok {
+		u = &budgetUsage{}
+		t.usage[t.keys[index]] = u
+	}
+	if day := now.Format("2006-01-02"); u.Day != day {
+		u.Day = day
+		u.DayCount = 0
+	}
+	if month := now.Format("2006-01"); u.Month != month {
+		u.Month = month
+		u.MonthCount = 0
+	}
+	return u
+}
+
+// allow reports whether the provider still has budget in both windows.
+func (t *BudgetTracker) allow(index int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limits := t.limits[index]
+	u := t.usageFor(index, time.Now())
+	if limits.daily > 0 && u.DayCount >= limits.daily {
+		return false
+	}
+	if limits.monthly > 0 && u.MonthCount >= limits.monthly {
+		return false
+	}
+	return true
+}
+
+// record counts one request against the provider's budgets and persists the
+// counters. Requests count regardless of whether the call later succeeds,
+// matching how provider quotas are consumed.
+func (t *BudgetTracker) record(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.usageFor(index, time.Now())
+	u.DayCount++
+	u.MonthCount++
+	t.save()
+}
+
+// remaining returns the requests left in each window (-1 means unlimited)
+// and whether the provider has any budget configured at all.
+func (t *BudgetTracker) remaining(index int) (daily, monthly int, limited bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limits := t.limits[index]
+	if limits.daily == 0 && limits.monthly == 0 {
+		return -1, -1, false
+	}
+
+	u := t.usageFor(index, time.Now())
+	daily, monthly = -1, -1
+	if limits.daily > 0 {
+		if daily = limits.daily - u.DayCount; daily < 0 {
+			daily = 0
+		}
+	}
+	if limits.monthly > 0 {
+		if monthly = limits.monthly - u.MonthCount; monthly < 0 {
+			monthly = 0
+		}
+	}
+	return daily, monthly, true
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"annotation-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// KeywordFallbackProvider is the provider tag on annotations produced by the
+// keyword classifier; consumers can select on it to re-annotate once LLM
+// providers recover.
+const KeywordFallbackProvider = "keyword-fallback"
+
+// defaultKeywordRules maps lowercase threat keywords to category IDs. The
+// rules are intentionally narrow: the fallback only has to keep the pipeline
+// producing results during a total LLM outage, not to be accurate.
+var defaultKeywordRules = map[string]int{
+	// Grooming (1)
+	"пришли фото":    1,
+	"это наш секрет": 1,
+	"разденься":      1,
+	// Blackmail (2)
+	"шантаж":          2,
+	"я расскажу всем": 2,
+	"опубликую твои":  2,
+	"плати или":       2,
+	// Bullying (3)
+	"изобью":  3,
+	"изобьем": 3,
+	"убью":    3,
+	// Suicide encouragement (4)
+	"покончи с собой": 4,
+	"суицид":          4,
+	"вскройся":        4,
+	// Drug propaganda (6)
+	"закладк":  6,
+	"мефедрон": 6,
+	"наркотик": 6,
+	// Financial fraud (7)
+	"ты выиграл":      7,
+	"получить приз":   7,
+	"переведи деньги": 7,
+	// Phishing (8)
+	"код из смс":  8,
+	"номер карты": 8,
+	"дай пароль":  8,
+}
+
+// KeywordFallback is a deterministic keyword classifier of last resort. It
+// runs only after every LLM provider has failed, so a total outage still
+// produces low-confidence annotations instead of silently dropping messages.
+type KeywordFallback struct {
+	rules    map[string]int
+	keywords []string // sorted, so matching order is deterministic
+	logger   *zap.Logger
+}
+
+// NewKeywordFallback creates the fallback classifier using the built-in rules
+// plus any configured extras. Extra rules with category IDs outside 1-9 are
+// dropped with a warning.
+func NewKeywordFallback(extraRules map[string]int, logger *zap.Logger) *KeywordFallback {
+	rules := make(map[string]int, len(defaultKeywordRules)+len(extraRules))
+	for keyword, categoryID := range defaultKeywordRules {
+		rules[keyword] = categoryID
+	}
+	for keyword, categoryID := range extraRules {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" {
+			continue
+		}
+		if categoryID < 1 || categoryID > 9 {
+			logger.Warn("Ignoring keyword fallback rule with invalid category",
+				zap.String("keyword", keyword),
+				zap.Int("category_id", categoryID))
+			continue
+		}
+		rules[keyword] = categoryID
+	}
+	keywords := make([]string, 0, len(rules))
+	for keyword := range rules {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	return &KeywordFallback{rules: rules, keywords: keywords, logger: logger}
+}
+
+// Annotate classifies text by keyword match. The first matching rule wins;
+// without a match the text is labeled neutral. Results carry low confidence
+// and the keyword-fallback provider tag so they can be re-annotated later.
+func (f *KeywordFallback) Annotate(text string) *models.AnnotationResponse {
+	lowered := strings.ToLower(text)
+
+	categoryID := int(models.Neutral)
+	justification := "Ключевых слов угроз не обнаружено (LLM-провайдеры недоступны)"
+	for _, keyword := range f.keywords {
+		if strings.Contains(lowered, keyword) {
+			categoryID = f.rules[keyword]
+			justification = fmt.Sprintf("Совпадение по ключевому слову %q (LLM-провайдеры недоступны)", keyword)
+			break
+		}
+	}
+
+	return &models.AnnotationResponse{
+		CategoryID:    categoryID,
+		CategoryName:  models.ThreatCategory(categoryID).Name(),
+		Justification: justification,
+		Confidence:    0.2,
+		Provider:      KeywordFallbackProvider,
+		ModelVersion:  "lexicon-v1",
+		AnnotatedAt:   time.Now(),
+		IsValidated:   false,
+	}
+}
@@ -8,8 +8,10 @@ import (
 
 	"annotation-service/internal/gemini"
 	"annotation-service/internal/groq"
-	"annotation-service/internal/openrouter"
+	"annotation-service/internal/httpx"
 	"annotation-service/internal/models"
+	"annotation-service/internal/ollama"
+	"annotation-service/internal/openrouter"
 
 	"go.uber.org/zap"
 )
@@ -21,22 +23,47 @@ const (
 	ProviderGemini     ProviderType = "gemini"
 	ProviderGroq       ProviderType = "groq"
 	ProviderOpenRouter ProviderType = "openrouter"
+	// ProviderOllama runs against a self-hosted Ollama server, keeping
+	// message content off external APIs for privacy-sensitive deployments.
+	ProviderOllama ProviderType = "ollama"
 )
 
 // ProviderConfig holds configuration for a single provider instance
 type ProviderConfig struct {
-	Type              ProviderType  `yaml:"type"`
-	APIKey            string        `yaml:"api_key"`
-	ModelName         string        `yaml:"model_name"`
-	MaxRetries        int           `yaml:"max_retries"`
-	RetryDelay        time.Duration `yaml:"retry_delay"`
+	Type   ProviderType `yaml:"type"`
+	APIKey string       `yaml:"api_key"`
+	// BaseURL points an "ollama" provider at a self-hosted instance
+	// (default http://localhost:11434). Ignored by the hosted providers,
+	// which have fixed endpoints.
+	BaseURL    string        `yaml:"base_url"`
+	ModelName  string        `yaml:"model_name"`
+	MaxRetries int           `yaml:"max_retries"`
+	RetryDelay time.Duration `yaml:"retry_delay"`
 	// Rate limiting per provider
-	RequestsPerMinute int           `yaml:"requests_per_minute"`
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	// Request budgets per window; 0 means unlimited. When a window's budget
+	// is spent the provider is skipped until the window resets.
+	DailyRequestBudget   int `yaml:"daily_request_budget"`
+	MonthlyRequestBudget int `yaml:"monthly_request_budget"`
+	// Weight biases the rotation toward this provider. With any provider
+	// weighted, selection becomes weighted round-robin; providers without an
+	// explicit weight count as 1. All-zero weights keep the original
+	// sequential behavior.
+	Weight int `yaml:"weight"`
+	// JSONMode asks the provider for structured JSON output
+	// (response_format json_object) instead of relying on markdown fence
+	// stripping alone. Off by default since not every model supports it;
+	// only groq and openrouter honor it (gemini always uses its native
+	// JSON response type).
+	JSONMode bool `yaml:"json_mode"`
 }
 
 // Provider interface for any LLM provider
 type Provider interface {
 	Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error)
+	// HealthCheck is a cheap reachability probe (no token spend) that fails
+	// on a dead API key or unreachable endpoint.
+	HealthCheck(ctx context.Context) error
 	Close() error
 	GetModelInfo() map[string]interface{}
 }
@@ -50,13 +77,13 @@ type RateLimitedProvider struct {
 
 // RateLimiter implements token bucket rate limiting
 type RateLimiter struct {
-	mu               sync.Mutex
-	tokens           int
-	maxTokens        int
-	refillRate       time.Duration
-	lastRefill       time.Time
-	requestsThisMin  int
-	minuteResetTime  time.Time
+	mu              sync.Mutex
+	tokens          int
+	maxTokens       int
+	refillRate      time.Duration
+	lastRefill      time.Time
+	requestsThisMin int
+	minuteResetTime time.Time
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -135,6 +162,12 @@ func (p *RateLimitedProvider) Annotate(ctx context.Context, text string) (*model
 	return p.provider.Annotate(ctx, text)
 }
 
+// HealthCheck probes the wrapped provider directly; probes are cheap and do
+// not consume a rate-limit token.
+func (p *RateLimitedProvider) HealthCheck(ctx context.Context) error {
+	return p.provider.HealthCheck(ctx)
+}
+
 func (p *RateLimitedProvider) Close() error {
 	return p.provider.Close()
 }
@@ -143,20 +176,98 @@ func (p *RateLimitedProvider) GetModelInfo() map[string]interface{} {
 	return p.provider.GetModelInfo()
 }
 
+// providerAccuracy holds externally reported historical accuracy for one
+// provider, measured against validated dataset entries.
+type providerAccuracy struct {
+	accuracy float64
+	samples  int
+}
+
+// latencyStats tracks a rolling average response time for one provider
+type latencyStats struct {
+	avg             time.Duration // Exponentially-weighted rolling average
+	samples         int
+	consecutiveSlow int
+}
+
+// ErrUnknownProvider is returned when a pinned annotation request names a
+// provider type that is not configured.
+var ErrUnknownProvider = fmt.Errorf("unknown provider")
+
+// ErrAllProvidersDisabled is returned when every provider has been
+// soft-paused by an operator, so no annotation can be attempted.
+var ErrAllProvidersDisabled = fmt.Errorf("all providers are disabled")
+
 // MultiProviderClient manages multiple LLM providers with fallback
 type MultiProviderClient struct {
-	providers      []*RateLimitedProvider
-	currentIndex   int
-	mu             sync.RWMutex
-	logger         *zap.Logger
-	failureCount   map[int]int
-	maxFailures    int
+	providers          []*RateLimitedProvider
+	providerTypes      []ProviderType
+	currentIndex       int
+	mu                 sync.RWMutex
+	logger             *zap.Logger
+	failureCount       map[int]int
+	maxFailures        int
+	latency            map[int]*latencyStats
+	latencyThreshold   time.Duration    // 0 disables latency-based switching
+	latencySwitchAfter int              // Consecutive slow calls before switching
+	keywordFallback    *KeywordFallback // Optional classifier of last resort
+	budget             *BudgetTracker   // nil when no provider has a budget
+	// disabled marks providers soft-paused by an operator at runtime. The
+	// state is in-memory only and resets on restart.
+	disabled map[int]bool
+	// accuracy holds reported per-provider accuracy; with orderByAccuracy on,
+	// the rotation prefers the most accurate provider that has enough samples.
+	accuracy           map[int]providerAccuracy
+	orderByAccuracy    bool
+	accuracyMinSamples int
+	// Weighted round-robin state: with weighted on, getCurrentProvider picks
+	// providers proportionally to their weight (smooth weighted round-robin)
+	// instead of sticking to currentIndex.
+	weighted       bool
+	weights        []int
+	currentWeights []int
+	// cooldownUntil parks a provider that hit maxFailures; the rotation skips
+	// it until the timestamp passes.
+	cooldownUntil   map[int]time.Time
+	failureCooldown time.Duration
+	// unhealthy marks providers that failed their last health probe. The
+	// rotation avoids them like cooling-down ones until a background re-probe
+	// succeeds.
+	unhealthy           map[int]bool
+	healthCheckInterval time.Duration
+	stopHealth          chan struct{}
+	stopHealthOnce      sync.Once
 }
 
 // MultiProviderConfig holds configuration for multiple providers
 type MultiProviderConfig struct {
 	Providers   []ProviderConfig
 	MaxFailures int // Max consecutive failures before switching provider
+	// LatencyThreshold marks a call as slow; after LatencySwitchAfter consecutive
+	// slow calls the client switches to the provider with the lowest average latency.
+	LatencyThreshold   time.Duration
+	LatencySwitchAfter int
+	// KeywordFallback, when set, classifies deterministically after every
+	// provider has failed, so a total LLM outage still yields annotations.
+	KeywordFallback *KeywordFallback
+	// BudgetStatePath is where per-provider usage counters persist across
+	// restarts. Only used when at least one provider has a request budget.
+	BudgetStatePath string
+	// OrderByAccuracy, when set, lets reported historical accuracy (from
+	// validated dataset entries) pick the preferred provider instead of the
+	// configured order. Failure failover still walks the configured order.
+	OrderByAccuracy bool
+	// AccuracyMinSamples is how many validated samples a provider needs
+	// before its accuracy can influence the rotation (default 20), so a
+	// provider with a handful of lucky answers cannot jump the order.
+	AccuracyMinSamples int
+	// FailureCooldown is how long a provider that hit MaxFailures is skipped
+	// by the rotation before it gets fresh attempts (default 1 minute).
+	FailureCooldown time.Duration
+	// HealthCheckInterval is how often providers that failed their startup
+	// probe are re-probed in the background (default 1 minute). Negative
+	// disables health probing entirely.
+	HealthCheckInterval time.Duration
 }
 
 // NewMultiProviderClient creates a new multi-provider client
@@ -169,7 +280,29 @@ func NewMultiProviderClient(cfg MultiProviderConfig, logger *zap.Logger) (*Multi
 		cfg.MaxFailures = 3
 	}
 
+	if cfg.LatencySwitchAfter == 0 {
+		cfg.LatencySwitchAfter = 3
+	}
+
+	if cfg.AccuracyMinSamples == 0 {
+		cfg.AccuracyMinSamples = 20
+	}
+
+	if cfg.FailureCooldown == 0 {
+		cfg.FailureCooldown = time.Minute
+	}
+
+	if cfg.HealthCheckInterval == 0 {
+		cfg.HealthCheckInterval = time.Minute
+	}
+
 	providers := make([]*RateLimitedProvider, 0, len(cfg.Providers))
+	providerTypes := make([]ProviderType, 0, len(cfg.Providers))
+	weights := make([]int, 0, len(cfg.Providers))
+	weighted := false
+	budgetKeys := make([]string, 0, len(cfg.Providers))
+	limits := make([]budgetLimits, 0, len(cfg.Providers))
+	hasBudget := false
 
 	for i, providerCfg := range cfg.Providers {
 		var provider Provider
@@ -189,6 +322,7 @@ func NewMultiProviderClient(cfg MultiProviderConfig, logger *zap.Logger) (*Multi
 				ModelName:  providerCfg.ModelName,
 				MaxRetries: providerCfg.MaxRetries,
 				RetryDelay: providerCfg.RetryDelay,
+				JSONMode:   providerCfg.JSONMode,
 			}, logger)
 		case ProviderOpenRouter:
 			provider, err = openrouter.NewClient(openrouter.Config{
@@ -196,6 +330,14 @@ func NewMultiProviderClient(cfg MultiProviderConfig, logger *zap.Logger) (*Multi
 				ModelName:  providerCfg.ModelName,
 				MaxRetries: providerCfg.MaxRetries,
 				RetryDelay: providerCfg.RetryDelay,
+				JSONMode:   providerCfg.JSONMode,
+			}, logger)
+		case ProviderOllama:
+			provider, err = ollama.NewClient(ollama.Config{
+				BaseURL:    providerCfg.BaseURL,
+				ModelName:  providerCfg.ModelName,
+				MaxRetries: providerCfg.MaxRetries,
+				RetryDelay: providerCfg.RetryDelay,
 			}, logger)
 		default:
 			logger.Warn("Unknown provider type, skipping",
@@ -220,6 +362,16 @@ func NewMultiProviderClient(cfg MultiProviderConfig, logger *zap.Logger) (*Multi
 
 		rateLimitedProvider := NewRateLimitedProvider(provider, rateLimit, logger)
 		providers = append(providers, rateLimitedProvider)
+		providerTypes = append(providerTypes, providerCfg.Type)
+		weights = append(weights, providerCfg.Weight)
+		if providerCfg.Weight > 0 {
+			weighted = true
+		}
+		budgetKeys = append(budgetKeys, fmt.Sprintf("%s/%s", providerCfg.Type, providerCfg.ModelName))
+		limits = append(limits, budgetLimits{daily: providerCfg.DailyRequestBudget, monthly: providerCfg.MonthlyRequestBudget})
+		if providerCfg.DailyRequestBudget > 0 || providerCfg.MonthlyRequestBudget > 0 {
+			hasBudget = true
+		}
 
 		logger.Info("Provider initialized",
 			zap.String("type", string(providerCfg.Type)),
@@ -232,29 +384,199 @@ func NewMultiProviderClient(cfg MultiProviderConfig, logger *zap.Logger) (*Multi
 		return nil, fmt.Errorf("no providers could be initialized")
 	}
 
-	return &MultiProviderClient{
-		providers:    providers,
-		currentIndex: 0,
-		logger:       logger,
-		failureCount: make(map[int]int),
-		maxFailures:  cfg.MaxFailures,
-	}, nil
+	var budget *BudgetTracker
+	if hasBudget {
+		budget = newBudgetTracker(budgetKeys, limits, cfg.BudgetStatePath, logger)
+	}
+
+	// In weighted mode a provider without an explicit weight counts as 1.
+	if weighted {
+		for i, w := range weights {
+			if w <= 0 {
+				weights[i] = 1
+			}
+		}
+	}
+
+	client := &MultiProviderClient{
+		providers:           providers,
+		providerTypes:       providerTypes,
+		currentIndex:        0,
+		logger:              logger,
+		failureCount:        make(map[int]int),
+		maxFailures:         cfg.MaxFailures,
+		latency:             make(map[int]*latencyStats),
+		latencyThreshold:    cfg.LatencyThreshold,
+		latencySwitchAfter:  cfg.LatencySwitchAfter,
+		keywordFallback:     cfg.KeywordFallback,
+		budget:              budget,
+		disabled:            make(map[int]bool),
+		accuracy:            make(map[int]providerAccuracy),
+		orderByAccuracy:     cfg.OrderByAccuracy,
+		accuracyMinSamples:  cfg.AccuracyMinSamples,
+		weighted:            weighted,
+		weights:             weights,
+		currentWeights:      make([]int, len(providers)),
+		cooldownUntil:       make(map[int]time.Time),
+		failureCooldown:     cfg.FailureCooldown,
+		unhealthy:           make(map[int]bool),
+		healthCheckInterval: cfg.HealthCheckInterval,
+		stopHealth:          make(chan struct{}),
+	}
+
+	// Probe providers off the startup path so a dead key degrades that
+	// provider instead of stalling initialization; failed ones are re-probed
+	// on a ticker until they recover.
+	if cfg.HealthCheckInterval > 0 {
+		go client.runHealthChecks()
+	}
+
+	return client, nil
 }
 
-// getCurrentProvider returns the current provider and its index
-func (c *MultiProviderClient) getCurrentProvider() (*RateLimitedProvider, int) {
+// runHealthChecks probes every provider once at startup, then re-probes only
+// the degraded ones on the configured interval.
+func (c *MultiProviderClient) runHealthChecks() {
+	c.probeProviders(false)
+
+	ticker := time.NewTicker(c.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopHealth:
+			return
+		case <-ticker.C:
+			c.probeProviders(true)
+		}
+	}
+}
+
+// probeProviders health-checks providers and updates their degraded state.
+// With degradedOnly set, healthy providers are left alone so routine
+// re-probing costs nothing for a healthy fleet.
+func (c *MultiProviderClient) probeProviders(degradedOnly bool) {
+	for i, provider := range c.providers {
+		if degradedOnly && !c.isProviderUnhealthy(i) {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := provider.HealthCheck(ctx)
+		cancel()
+
+		c.mu.Lock()
+		wasUnhealthy := c.unhealthy[i]
+		c.unhealthy[i] = err != nil
+		c.mu.Unlock()
+
+		if err != nil {
+			c.logger.Warn("Provider failed health check, degrading",
+				zap.Int("provider_index", i),
+				zap.String("type", string(c.providerTypes[i])),
+				zap.Error(err))
+		} else if wasUnhealthy {
+			c.logger.Info("Provider recovered, back in rotation",
+				zap.Int("provider_index", i),
+				zap.String("type", string(c.providerTypes[i])))
+		} else if !degradedOnly {
+			c.logger.Info("Provider passed health check",
+				zap.Int("provider_index", i),
+				zap.String("type", string(c.providerTypes[i])))
+		}
+	}
+}
+
+// isProviderUnhealthy reports whether a provider failed its last health probe.
+func (c *MultiProviderClient) isProviderUnhealthy(index int) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	return c.unhealthy[index]
+}
+
+// coolingDown reports whether a provider is parked after hitting maxFailures.
+// Callers must hold at least a read lock.
+func (c *MultiProviderClient) coolingDown(index int) bool {
+	if c.unhealthy[index] {
+		return true
+	}
+	until, ok := c.cooldownUntil[index]
+	return ok && time.Now().Before(until)
+}
+
+// getCurrentProvider returns the provider to try next and its index. In
+// weighted mode it runs one step of smooth weighted round-robin over the
+// eligible (enabled, not cooling-down) providers; otherwise it sticks to
+// currentIndex, moving off it only when it is cooling down.
+func (c *MultiProviderClient) getCurrentProvider() (*RateLimitedProvider, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.weighted {
+		if next, ok := c.pickWeighted(); ok {
+			c.currentIndex = next
+		}
+		return c.providers[c.currentIndex], c.currentIndex
+	}
+
+	if c.coolingDown(c.currentIndex) {
+		for step := 1; step <= len(c.providers); step++ {
+			next := (c.currentIndex + step) % len(c.providers)
+			if !c.disabled[next] && !c.coolingDown(next) {
+				c.currentIndex = next
+				break
+			}
+		}
+	}
 	return c.providers[c.currentIndex], c.currentIndex
 }
 
-// switchToNextProvider switches to the next available provider
+// pickWeighted runs one round of the smooth weighted round-robin algorithm:
+// every eligible provider gains its weight, the heaviest one is picked and
+// pays back the total. Over time each provider is picked proportionally to
+// its weight. Returns false when no provider is eligible.
+func (c *MultiProviderClient) pickWeighted() (int, bool) {
+	best := -1
+	total := 0
+	for i := range c.providers {
+		if c.disabled[i] || c.coolingDown(i) {
+			continue
+		}
+		c.currentWeights[i] += c.weights[i]
+		total += c.weights[i]
+		if best == -1 || c.currentWeights[i] > c.currentWeights[best] {
+			best = i
+		}
+	}
+	if best == -1 {
+		return 0, false
+	}
+	c.currentWeights[best] -= total
+	return best, true
+}
+
+// switchToNextProvider switches to the next available provider, skipping
+// soft-paused and cooling-down ones. With every provider unavailable it
+// falls back to plain sequential advance; Annotate reports the all-disabled
+// case to the caller.
 func (c *MultiProviderClient) switchToNextProvider() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	oldIndex := c.currentIndex
-	c.currentIndex = (c.currentIndex + 1) % len(c.providers)
+	moved := false
+	for step := 1; step <= len(c.providers); step++ {
+		next := (oldIndex + step) % len(c.providers)
+		if !c.disabled[next] && !c.coolingDown(next) {
+			c.currentIndex = next
+			moved = true
+			break
+		}
+	}
+	if !moved {
+		// Everything is paused or cooling down; advance anyway so repeated
+		// switches do not spin on one index.
+		c.currentIndex = (oldIndex + 1) % len(c.providers)
+	}
 
 	c.logger.Info("Switching provider",
 		zap.Int("from_index", oldIndex),
@@ -262,6 +584,100 @@ func (c *MultiProviderClient) switchToNextProvider() {
 		zap.Int("total_providers", len(c.providers)))
 }
 
+// isProviderDisabled reports whether an operator has soft-paused a provider.
+func (c *MultiProviderClient) isProviderDisabled(index int) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.disabled[index]
+}
+
+// SetProviderEnabled soft-pauses (enabled=false) or resumes one provider by
+// its index in the rotation, without a restart or config change. Disabling
+// the current provider moves the rotation to the next enabled one.
+func (c *MultiProviderClient) SetProviderEnabled(index int, enabled bool) error {
+	c.mu.Lock()
+	if index < 0 || index >= len(c.providers) {
+		c.mu.Unlock()
+		return fmt.Errorf("provider index %d out of range (have %d providers)", index, len(c.providers))
+	}
+	c.disabled[index] = !enabled
+	moveOff := !enabled && index == c.currentIndex
+	c.logger.Info("Provider enabled state changed",
+		zap.Int("provider_index", index),
+		zap.Bool("enabled", enabled))
+	c.mu.Unlock()
+
+	if moveOff {
+		c.switchToNextProvider()
+	}
+	return nil
+}
+
+// ReportProviderAccuracy records the measured historical accuracy for the
+// named provider type, typically recomputed from validated dataset entries
+// where reviewers confirmed or corrected the category. With OrderByAccuracy
+// enabled the rotation moves to the most accurate provider that has at least
+// AccuracyMinSamples samples; below that threshold the numbers are stored but
+// do not influence ordering.
+func (c *MultiProviderClient) ReportProviderAccuracy(providerType string, samples int, accuracy float64) error {
+	if accuracy < 0 || accuracy > 1 {
+		return fmt.Errorf("accuracy %.3f out of range (must be 0-1)", accuracy)
+	}
+	if samples < 0 {
+		return fmt.Errorf("samples must not be negative")
+	}
+	index, ok := c.providerIndexByType(providerType)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownProvider, providerType)
+	}
+
+	c.mu.Lock()
+	c.accuracy[index] = providerAccuracy{accuracy: accuracy, samples: samples}
+	c.logger.Info("Provider accuracy reported",
+		zap.String("provider", providerType),
+		zap.Int("provider_index", index),
+		zap.Float64("accuracy", accuracy),
+		zap.Int("samples", samples))
+	c.mu.Unlock()
+
+	if c.orderByAccuracy {
+		c.switchToMostAccurateProvider()
+	}
+	return nil
+}
+
+// switchToMostAccurateProvider moves the rotation to the enabled provider
+// with the highest reported accuracy, considering only providers with enough
+// samples. With no eligible provider the rotation stays where it is.
+func (c *MultiProviderClient) switchToMostAccurateProvider() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	best := -1
+	var bestAccuracy float64
+	for i := range c.providers {
+		if c.disabled[i] {
+			continue
+		}
+		stats, ok := c.accuracy[i]
+		if !ok || stats.samples < c.accuracyMinSamples {
+			continue
+		}
+		if best == -1 || stats.accuracy > bestAccuracy {
+			best = i
+			bestAccuracy = stats.accuracy
+		}
+	}
+
+	if best >= 0 && best != c.currentIndex {
+		c.logger.Info("Switching to most accurate provider",
+			zap.Int("from_index", c.currentIndex),
+			zap.Int("to_index", best),
+			zap.Float64("accuracy", bestAccuracy))
+		c.currentIndex = best
+	}
+}
+
 // recordFailure records a failure for a provider
 func (c *MultiProviderClient) recordFailure(providerIndex int) bool {
 	c.mu.Lock()
@@ -270,37 +686,165 @@ func (c *MultiProviderClient) recordFailure(providerIndex int) bool {
 	c.failureCount[providerIndex]++
 
 	if c.failureCount[providerIndex] >= c.maxFailures {
-		c.logger.Warn("Provider reached max failures",
+		// Park the provider for the cooldown window so the rotation stops
+		// hammering it, then let it earn its place back with fresh attempts.
+		c.cooldownUntil[providerIndex] = time.Now().Add(c.failureCooldown)
+		c.failureCount[providerIndex] = 0
+		c.logger.Warn("Provider reached max failures, cooling down",
 			zap.Int("provider_index", providerIndex),
-			zap.Int("failures", c.failureCount[providerIndex]))
+			zap.Duration("cooldown", c.failureCooldown))
 		return true // Should switch
 	}
 
 	return false
 }
 
+// maxRetryAfterCooldown caps how long a server-suggested Retry-After can park
+// a provider, so a bogus header cannot bench it indefinitely.
+const maxRetryAfterCooldown = 5 * time.Minute
+
+// cooldownProviderFor parks a provider for the given duration, as when the
+// provider's API says via Retry-After how long to stay away. An existing
+// longer cooldown is kept.
+func (c *MultiProviderClient) cooldownProviderFor(providerIndex int, d time.Duration) {
+	if d > maxRetryAfterCooldown {
+		d = maxRetryAfterCooldown
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(c.cooldownUntil[providerIndex]) {
+		c.cooldownUntil[providerIndex] = until
+	}
+	c.failureCount[providerIndex] = 0
+}
+
+// recordLatency updates the rolling average for a provider and returns true
+// when the provider has been slow for enough consecutive calls to warrant switching.
+func (c *MultiProviderClient) recordLatency(providerIndex int, elapsed time.Duration) bool {
+	if c.latencyThreshold <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats, ok := c.latency[providerIndex]
+	if !ok {
+		stats = &latencyStats{}
+		c.latency[providerIndex] = stats
+	}
+
+	// Rolling average over roughly the last 10 samples
+	stats.samples++
+	window := stats.samples
+	if window > 10 {
+		window = 10
+	}
+	stats.avg += (elapsed - stats.avg) / time.Duration(window)
+
+	if elapsed > c.latencyThreshold {
+		stats.consecutiveSlow++
+	} else {
+		stats.consecutiveSlow = 0
+	}
+
+	if stats.consecutiveSlow >= c.latencySwitchAfter {
+		stats.consecutiveSlow = 0
+		return true
+	}
+	return false
+}
+
+// switchToFastestProvider switches to the provider with the lowest average latency.
+func (c *MultiProviderClient) switchToFastestProvider() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fastest := c.currentIndex
+	for i := range c.providers {
+		if i == c.currentIndex || c.disabled[i] {
+			continue
+		}
+		stats, ok := c.latency[i]
+		if !ok || stats.samples == 0 {
+			// Untested provider: give it a chance over a known-slow one
+			fastest = i
+			break
+		}
+		if current, hasCurrent := c.latency[fastest]; !hasCurrent || stats.avg < current.avg {
+			fastest = i
+		}
+	}
+
+	if fastest != c.currentIndex {
+		c.logger.Info("Switching to faster provider",
+			zap.Int("from_index", c.currentIndex),
+			zap.Int("to_index", fastest))
+		c.currentIndex = fastest
+	}
+}
+
 // resetFailureCount resets failure count for a provider
 func (c *MultiProviderClient) resetFailureCount(providerIndex int) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.failureCount[providerIndex] = 0
+	delete(c.cooldownUntil, providerIndex)
+	delete(c.unhealthy, providerIndex)
 }
 
 // Annotate tries to annotate using current provider, falls back to next on failure
 func (c *MultiProviderClient) Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error) {
 	// Try all providers
+	budgetSkips := 0
+	disabledSkips := 0
 	for attempts := 0; attempts < len(c.providers); attempts++ {
 		provider, providerIndex := c.getCurrentProvider()
 
+		// Skip providers an operator has soft-paused
+		if c.isProviderDisabled(providerIndex) {
+			disabledSkips++
+			c.switchToNextProvider()
+			continue
+		}
+
+		// Skip providers whose request budget is spent for the window
+		if c.budget != nil && !c.budget.allow(providerIndex) {
+			c.logger.Warn("Provider request budget exhausted, skipping",
+				zap.Int("provider_index", providerIndex))
+			budgetSkips++
+			c.switchToNextProvider()
+			continue
+		}
+
 		c.logger.Debug("Attempting annotation",
 			zap.Int("provider_index", providerIndex),
 			zap.Int("attempt", attempts+1))
 
+		if c.budget != nil {
+			c.budget.record(providerIndex)
+		}
+
+		start := time.Now()
 		result, err := provider.Annotate(ctx, text)
+		elapsed := time.Since(start)
 
 		if err == nil {
 			// Success! Reset failure count
 			c.resetFailureCount(providerIndex)
+
+			// Latency-aware failover: move off a consistently slow provider
+			if c.recordLatency(providerIndex, elapsed) {
+				c.logger.Warn("Provider consistently slow, switching",
+					zap.Int("provider_index", providerIndex),
+					zap.Duration("elapsed", elapsed),
+					zap.Duration("threshold", c.latencyThreshold))
+				c.switchToFastestProvider()
+			}
+
 			return result, nil
 		}
 
@@ -311,15 +855,97 @@ func (c *MultiProviderClient) Annotate(ctx context.Context, text string) (*model
 
 		shouldSwitch := c.recordFailure(providerIndex)
 
+		// A rate-limited provider that told us how long to wait is parked
+		// for that long, so the rotation does not come straight back to it.
+		if retryAfter, ok := httpx.RetryAfterFromError(err); ok {
+			c.logger.Warn("Provider rate limited, honoring Retry-After",
+				zap.Int("provider_index", providerIndex),
+				zap.Duration("retry_after", retryAfter))
+			c.cooldownProviderFor(providerIndex, retryAfter)
+			c.switchToNextProvider()
+			continue
+		}
+
 		// If reached max failures or rate limit error, switch immediately
 		if shouldSwitch || isRateLimitError(err) {
 			c.switchToNextProvider()
 		}
 	}
 
+	if disabledSkips == len(c.providers) {
+		return nil, ErrAllProvidersDisabled
+	}
+
+	if c.keywordFallback != nil {
+		c.logger.Warn("All providers failed or out of budget; using keyword fallback classifier")
+		return c.keywordFallback.Annotate(text), nil
+	}
+
+	if budgetSkips == len(c.providers) {
+		return nil, ErrQuotaExhausted
+	}
+
 	return nil, fmt.Errorf("all providers failed")
 }
 
+// providerIndexByType finds the first configured provider of the given type.
+func (c *MultiProviderClient) providerIndexByType(providerType string) (int, bool) {
+	for i, t := range c.providerTypes {
+		if string(t) == providerType {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// AnnotateWith pins one request to the named provider type, bypassing the
+// rotation. Used for A/B comparisons and debugging; failures are returned to
+// the caller instead of triggering failover.
+func (c *MultiProviderClient) AnnotateWith(ctx context.Context, providerType, text string) (*models.AnnotationResponse, error) {
+	index, ok := c.providerIndexByType(providerType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, providerType)
+	}
+
+	if c.budget != nil && !c.budget.allow(index) {
+		return nil, fmt.Errorf("provider %q request budget exhausted", providerType)
+	}
+	if c.budget != nil {
+		c.budget.record(index)
+	}
+
+	c.logger.Debug("Annotation pinned to provider",
+		zap.String("provider", providerType),
+		zap.Int("provider_index", index))
+
+	result, err := c.providers[index].Annotate(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("pinned provider %q failed: %w", providerType, err)
+	}
+	c.resetFailureCount(index)
+	return result, nil
+}
+
+// ProviderModelInfo returns model info for the named provider type.
+func (c *MultiProviderClient) ProviderModelInfo(providerType string) (map[string]interface{}, bool) {
+	index, ok := c.providerIndexByType(providerType)
+	if !ok {
+		return nil, false
+	}
+	return c.providers[index].GetModelInfo(), true
+}
+
+// AnnotateSecondOpinion produces an independent verdict for a message that
+// the primary provider already answered: when more than one provider is
+// configured it rotates to the next one first, so the second opinion comes
+// from a different model. With a single provider it simply re-asks.
+func (c *MultiProviderClient) AnnotateSecondOpinion(ctx context.Context, text string) (*models.AnnotationResponse, error) {
+	if len(c.providers) > 1 {
+		c.switchToNextProvider()
+	}
+	return c.Annotate(ctx, text)
+}
+
 // isRateLimitError checks if error is a rate limit error
 func isRateLimitError(err error) bool {
 	if err == nil {
@@ -327,15 +953,15 @@ func isRateLimitError(err error) bool {
 	}
 	errStr := err.Error()
 	return contains(errStr, "429") ||
-	       contains(errStr, "quota") ||
-	       contains(errStr, "rate limit")
+		contains(errStr, "quota") ||
+		contains(errStr, "rate limit")
 }
 
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) &&
-	       (s == substr || len(s) > len(substr) &&
-	        (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-	         findInString(s, substr)))
+		(s == substr || len(s) > len(substr) &&
+			(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
+				findInString(s, substr)))
 }
 
 func findInString(s, substr string) bool {
@@ -349,6 +975,7 @@ func findInString(s, substr string) bool {
 
 // Close closes all providers
 func (c *MultiProviderClient) Close() error {
+	c.stopHealthOnce.Do(func() { close(c.stopHealth) })
 	var lastErr error
 	for i, provider := range c.providers {
 		if err := provider.Close(); err != nil {
@@ -366,6 +993,7 @@ func (c *MultiProviderClient) GetModelInfo() map[string]interface{} {
 	provider, index := c.getCurrentProvider()
 	info := provider.GetModelInfo()
 	info["is_current"] = true
+	info["healthy"] = !c.isProviderUnhealthy(index)
 	info["provider_index"] = index
 	info["total_providers"] = len(c.providers)
 	info["failure_count"] = c.failureCount[index]
@@ -381,7 +1009,33 @@ func (c *MultiProviderClient) GetProvidersInfo() []map[string]interface{} {
 	for i, provider := range c.providers {
 		providerInfo := provider.GetModelInfo()
 		providerInfo["is_current"] = (i == c.currentIndex)
+		providerInfo["enabled"] = !c.disabled[i]
+		providerInfo["healthy"] = !c.unhealthy[i]
 		providerInfo["failure_count"] = c.failureCount[i]
+		if stats, ok := c.latency[i]; ok && stats.samples > 0 {
+			providerInfo["avg_latency_ms"] = stats.avg.Milliseconds()
+		}
+		if stats, ok := c.accuracy[i]; ok {
+			providerInfo["reported_accuracy"] = stats.accuracy
+			providerInfo["accuracy_samples"] = stats.samples
+		}
+		effectiveWeight := c.weights[i]
+		if !c.weighted {
+			effectiveWeight = 0
+		}
+		if c.disabled[i] || c.coolingDown(i) {
+			effectiveWeight = 0
+		}
+		providerInfo["effective_weight"] = effectiveWeight
+		if until, ok := c.cooldownUntil[i]; ok && time.Now().Before(until) {
+			providerInfo["cooldown_until"] = until.Format(time.RFC3339)
+		}
+		if c.budget != nil {
+			if daily, monthly, limited := c.budget.remaining(i); limited {
+				providerInfo["daily_budget_remaining"] = daily
+				providerInfo["monthly_budget_remaining"] = monthly
+			}
+		}
 		info[i] = providerInfo
 	}
 	return info
@@ -1,53 +1,166 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // ThreatCategory represents the 9 categories from your llm.py
 type ThreatCategory int
 
 const (
-	Grooming            ThreatCategory = 1 // Склонение к сексуальным действиям (Груминг)
-	Blackmail           ThreatCategory = 2 // Угрозы, шантаж, вымогательство
-	Bullying            ThreatCategory = 3 // Физическое насилие/Буллинг
+	Grooming             ThreatCategory = 1 // Склонение к сексуальным действиям (Груминг)
+	Blackmail            ThreatCategory = 2 // Угрозы, шантаж, вымогательство
+	Bullying             ThreatCategory = 3 // Физическое насилие/Буллинг
 	SuicideEncouragement ThreatCategory = 4 // Склонение к суициду/Самоповреждению
-	DangerousActivities ThreatCategory = 5 // Склонение к опасным играм/действиям
-	DrugPropaganda      ThreatCategory = 6 // Пропаганда запрещенных веществ
-	FinancialFraud      ThreatCategory = 7 // Финансовое мошенничество
-	Phishing            ThreatCategory = 8 // Сбор личных данных (Фишинг)
-	Neutral             ThreatCategory = 9 // Нейтральное общение
+	DangerousActivities  ThreatCategory = 5 // Склонение к опасным играм/действиям
+	DrugPropaganda       ThreatCategory = 6 // Пропаганда запрещенных веществ
+	FinancialFraud       ThreatCategory = 7 // Финансовое мошенничество
+	Phishing             ThreatCategory = 8 // Сбор личных данных (Фишинг)
+	Neutral              ThreatCategory = 9 // Нейтральное общение
 )
 
 // CategoryNames maps category IDs to their Russian names
 var CategoryNames = map[ThreatCategory]string{
-	Grooming:            "Склонение к сексуальным действиям (Груминг)",
-	Blackmail:           "Угрозы, шантаж, вымогательство",
-	Bullying:            "Физическое насилие/Буллинг",
+	Grooming:             "Склонение к сексуальным действиям (Груминг)",
+	Blackmail:            "Угрозы, шантаж, вымогательство",
+	Bullying:             "Физическое насилие/Буллинг",
 	SuicideEncouragement: "Склонение к суициду/Самоповреждению",
-	DangerousActivities: "Склонение к опасным играм/действиям",
-	DrugPropaganda:      "Пропаганда запрещенных веществ",
-	FinancialFraud:      "Финансовое мошенничество",
-	Phishing:            "Сбор личных данных (Фишинг)",
-	Neutral:             "Нейтральное общение",
+	DangerousActivities:  "Склонение к опасным играм/действиям",
+	DrugPropaganda:       "Пропаганда запрещенных веществ",
+	FinancialFraud:       "Финансовое мошенничество",
+	Phishing:             "Сбор личных данных (Фишинг)",
+	Neutral:              "Нейтральное общение",
+}
+
+// Name returns the canonical Russian name for the category, or "" for an ID
+// outside the taxonomy.
+func (c ThreatCategory) Name() string {
+	return CategoryNames[c]
+}
+
+// IsThreat reports whether the category is a threat. Only Neutral (and
+// unknown IDs) are not.
+func (c ThreatCategory) IsThreat() bool {
+	_, known := CategoryNames[c]
+	return known && c != Neutral
+}
+
+// CategoryFromName resolves a canonical category name back to its ID; the
+// inverse of Name.
+func CategoryFromName(name string) (ThreatCategory, bool) {
+	for id, categoryName := range CategoryNames {
+		if categoryName == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Category describes one entry of the threat taxonomy as exposed to API
+// consumers, so clients can render filters and labels without hardcoding the
+// nine categories.
+type Category struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	IsThreat    bool   `json:"is_threat"`
+	Description string `json:"description"`
+}
+
+// categoryDescriptions holds a short human-readable description per category.
+var categoryDescriptions = map[ThreatCategory]string{
+	Grooming:             "Попытки склонить ребенка к сексуальным действиям или выманить интимные материалы",
+	Blackmail:            "Угрозы, шантаж и вымогательство денег или действий",
+	Bullying:             "Травля, оскорбления и угрозы физического насилия",
+	SuicideEncouragement: "Склонение к суициду или самоповреждению",
+	DangerousActivities:  "Вовлечение в опасные игры, челленджи и действия",
+	DrugPropaganda:       "Пропаганда и предложение запрещенных веществ",
+	FinancialFraud:       "Финансовое мошенничество и выманивание денег",
+	Phishing:             "Сбор личных данных, паролей и другой чувствительной информации",
+	Neutral:              "Обычное общение без признаков угрозы",
+}
+
+// Categories returns the full taxonomy in ID order. Every category except
+// Neutral is a threat.
+func Categories() []Category {
+	categories := make([]Category, 0, len(CategoryNames))
+	for id := Grooming; id <= Neutral; id++ {
+		categories = append(categories, Category{
+			ID:          int(id),
+			Name:        id.Name(),
+			IsThreat:    id.IsThreat(),
+			Description: categoryDescriptions[id],
+		})
+	}
+	return categories
+}
+
+// GroomingStageNames is the fixed vocabulary of grooming stages an LLM may
+// tag when stage extraction is enabled, in the order the stages typically
+// escalate. Reported values outside this vocabulary are discarded.
+var GroomingStageNames = []string{
+	"friendship_forming",
+	"isolation",
+	"secrecy",
+	"desensitization",
+	"request",
+}
+
+// NormalizeGroomingStage lowercases and validates a reported grooming stage,
+// returning "" for anything outside GroomingStageNames.
+func NormalizeGroomingStage(stage string) string {
+	stage = strings.ToLower(strings.TrimSpace(stage))
+	for _, name := range GroomingStageNames {
+		if stage == name {
+			return stage
+		}
+	}
+	return ""
 }
 
 // Annotation represents a labeled message
 type Annotation struct {
-	ID             int64          `json:"id" db:"id"`
-	MessageID      *int64         `json:"message_id,omitempty" db:"message_id"` // Optional link to original message
-	Text           string         `json:"text" db:"text"`
-	Category       ThreatCategory `json:"category_id" db:"category_id"`
-	CategoryName   string         `json:"category_name" db:"category_name"`
-	Justification  string         `json:"justification" db:"justification"`
-	Confidence     float64        `json:"confidence,omitempty" db:"confidence"` // If LLM provides confidence
-	AnnotatedAt    time.Time      `json:"annotated_at" db:"annotated_at"`
-	Provider       string         `json:"provider" db:"provider"` // "gemini", "manual", etc.
-	ModelVersion   string         `json:"model_version,omitempty" db:"model_version"`
-	IsValidated    bool           `json:"is_validated" db:"is_validated"` // Manual validation flag
+	ID              int64          `json:"id" db:"id"`
+	MessageID       *int64         `json:"message_id,omitempty" db:"message_id"` // Optional link to original message
+	Text            string         `json:"text" db:"text"`
+	Category        ThreatCategory `json:"category_id" db:"category_id"`
+	CategoryName    string         `json:"category_name" db:"category_name"`
+	Justification   string         `json:"justification" db:"justification"`
+	Language        string         `json:"justification_language,omitempty" db:"justification_language"` // Detected language of the justification
+	MessageLanguage string         `json:"message_language,omitempty" db:"message_language"`             // Detected language of the annotated message
+	Confidence      float64        `json:"confidence,omitempty" db:"confidence"`                         // Calibrated confidence (raw when calibration is off)
+	RawConfidence   float64        `json:"raw_confidence,omitempty" db:"raw_confidence"`                 // Provider-reported confidence before calibration
+	GroomingStage   string         `json:"grooming_stage,omitempty" db:"grooming_stage"`                 // Optional stage tag from GroomingStageNames; "" when absent
+	AnnotatedAt     time.Time      `json:"annotated_at" db:"annotated_at"`
+	Provider        string         `json:"provider" db:"provider"` // "gemini", "manual", etc.
+	ModelVersion    string         `json:"model_version,omitempty" db:"model_version"`
+	IsValidated     bool           `json:"is_validated" db:"is_validated"` // Manual validation flag
+}
+
+// ConfidenceBucket is one cell of the confidence histogram: how many
+// annotations a provider placed in a category within one 0.1-wide
+// confidence range. Comparing bucket shapes across providers shows how
+// well-calibrated each one is.
+type ConfidenceBucket struct {
+	Provider      string  `json:"provider"`
+	CategoryID    int     `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	Bucket        string  `json:"bucket"` // e.g. "0.3-0.4"; confidence 1.0 lands in "0.9-1.0"
+	Count         int     `json:"count"`
+	AvgConfidence float64 `json:"avg_confidence"`
 }
 
 // AnnotationRequest for single message annotation
 type AnnotationRequest struct {
 	Text string `json:"text" binding:"required"`
+	// SecondOpinion requests an independent re-annotation (a different
+	// provider where one is configured) instead of the primary verdict.
+	SecondOpinion bool `json:"second_opinion,omitempty"`
+	// DryRun runs the LLM call and returns the result without persisting
+	// it, so prompt tuning does not pollute the annotations table.
+	DryRun bool `json:"dry_run,omitempty"`
 }
 
 // BatchAnnotationRequest for multiple messages
@@ -66,21 +179,83 @@ type AnnotationResponse struct {
 	CategoryName  string    `json:"category_name"`
 	CategoryID    int       `json:"category_id"`
 	Justification string    `json:"justification"`
-	Confidence    float64   `json:"confidence,omitempty"` // Optional
-	Provider      string    `json:"provider"`              // groq, gemini, openrouter
-	ModelVersion  string    `json:"model_version"`         // Model name/version
-	AnnotatedAt   time.Time `json:"annotated_at"`          // Timestamp
-	IsValidated   bool      `json:"is_validated"`          // Manual validation flag
+	Confidence    float64   `json:"confidence,omitempty"`     // Optional
+	GroomingStage string    `json:"grooming_stage,omitempty"` // Optional stage tag from GroomingStageNames
+	Provider      string    `json:"provider"`                 // groq, gemini, openrouter
+	ModelVersion  string    `json:"model_version"`            // Model name/version
+	AnnotatedAt   time.Time `json:"annotated_at"`             // Timestamp
+	IsValidated   bool      `json:"is_validated"`             // Manual validation flag
+}
+
+// AnnotationFilter narrows and pages an annotation listing. Zero-value
+// fields are ignored; From is inclusive and To is exclusive so adjacent
+// ranges tile without double-counting.
+type AnnotationFilter struct {
+	Limit  int
+	Offset int
+	From   *time.Time
+	To     *time.Time
 }
 
 // Job represents an async annotation job
 type Job struct {
-	ID          string    `json:"id" db:"id"`
-	Status      string    `json:"status" db:"status"` // "pending", "processing", "completed", "failed"
-	TotalCount  int       `json:"total_count" db:"total_count"`
-	ProcessedCount int    `json:"processed_count" db:"processed_count"`
-	FailedCount int       `json:"failed_count" db:"failed_count"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
-	ErrorMessage string   `json:"error_message,omitempty" db:"error_message"`
+	ID             string     `json:"id" db:"id"`
+	Status         string     `json:"status" db:"status"` // "pending", "processing", "completed", "failed"
+	TotalCount     int        `json:"total_count" db:"total_count"`
+	ProcessedCount int        `json:"processed_count" db:"processed_count"`
+	FailedCount    int        `json:"failed_count" db:"failed_count"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ErrorMessage   string     `json:"error_message,omitempty" db:"error_message"`
+}
+
+// ParseAnnotationResponse strictly parses and validates an LLM reply into an
+// AnnotationResponse. All providers share this step so a model returning
+// category_id as a string, or omitting a required field, fails with one
+// descriptive error instead of provider-specific surprises. The category ID is
+// authoritative: a missing or mismatched category_name is auto-filled from
+// CategoryNames.
+func ParseAnnotationResponse(data []byte) (*AnnotationResponse, error) {
+	var raw struct {
+		CategoryName  *string         `json:"category_name"`
+		CategoryID    json.RawMessage `json:"category_id"`
+		Justification *string         `json:"justification"`
+		Confidence    float64         `json:"confidence"`
+		GroomingStage string          `json:"grooming_stage"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("annotation response is not valid JSON: %w", err)
+	}
+
+	if len(raw.CategoryID) == 0 {
+		return nil, fmt.Errorf("annotation response is missing category_id")
+	}
+	var categoryID int
+	if err := json.Unmarshal(raw.CategoryID, &categoryID); err != nil {
+		return nil, fmt.Errorf("category_id must be an integer, got %s", string(raw.CategoryID))
+	}
+	if categoryID < 1 || categoryID > 9 {
+		return nil, fmt.Errorf("category_id %d outside valid range 1-9", categoryID)
+	}
+	if raw.Justification == nil {
+		return nil, fmt.Errorf("annotation response is missing justification")
+	}
+
+	resp := &AnnotationResponse{
+		CategoryID:    categoryID,
+		Justification: *raw.Justification,
+		Confidence:    raw.Confidence,
+		// Stage extraction is optional and models hallucinate labels, so an
+		// out-of-vocabulary stage is dropped rather than failing the parse.
+		GroomingStage: NormalizeGroomingStage(raw.GroomingStage),
+	}
+
+	canonical := ThreatCategory(categoryID).Name()
+	if raw.CategoryName == nil || *raw.CategoryName != canonical {
+		resp.CategoryName = canonical
+	} else {
+		resp.CategoryName = *raw.CategoryName
+	}
+
+	return resp, nil
 }
@@ -0,0 +1,221 @@
+// Package ollama implements the Provider interface against a self-hosted
+// Ollama server, for deployments that must not send message content to an
+// external LLM API.
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"annotation-service/internal/gemini"
+	"annotation-service/internal/httpx"
+	"annotation-service/internal/models"
+	"annotation-service/internal/promptlog"
+
+	"go.uber.org/zap"
+)
+
+// Client wraps a local Ollama server.
+type Client struct {
+	baseURL    string
+	modelName  string
+	httpClient *http.Client
+	logger     *zap.Logger
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// Config for the Ollama client. No API key: the server is assumed to be
+// local or on a trusted network.
+type Config struct {
+	BaseURL    string // Default: "http://localhost:11434"
+	ModelName  string // Default: "llama3.2"
+	MaxRetries int
+	RetryDelay time.Duration
+}
+
+// ollamaRequest represents the request to Ollama's /api/chat endpoint.
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"` // "json" forces plain JSON output
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ollamaResponse represents the non-streaming response from /api/chat.
+type ollamaResponse struct {
+	Model   string        `json:"model"`
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// NewClient creates a new Ollama client.
+func NewClient(cfg Config, logger *zap.Logger) (*Client, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "http://localhost:11434"
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	if cfg.ModelName == "" {
+		cfg.ModelName = "llama3.2"
+	}
+
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = 2 * time.Second
+	}
+
+	logger.Info("Ollama client initialized",
+		zap.String("base_url", cfg.BaseURL),
+		zap.String("model", cfg.ModelName),
+		zap.Int("max_retries", cfg.MaxRetries))
+
+	return &Client{
+		baseURL: cfg.BaseURL,
+		// Local models can be slow on modest hardware, so the timeout is
+		// generous compared to the hosted providers.
+		httpClient: httpx.NewClient(120 * time.Second),
+		modelName:  cfg.ModelName,
+		logger:     logger,
+		maxRetries: cfg.MaxRetries,
+		retryDelay: cfg.RetryDelay,
+	}, nil
+}
+
+// Annotate classifies a single message using the local model.
+func (c *Client) Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error) {
+	prompt := gemini.BuildPrompt(text)
+
+	reqBody := ollamaRequest{
+		Model: c.modelName,
+		Messages: []ollamaMessage{
+			{
+				Role:    "system",
+				Content: gemini.SystemInstruction,
+			},
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		Stream:  false,
+		Format:  "json",
+		Options: ollamaOptions{Temperature: 0.3},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	start := time.Now()
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, httpx.Options{
+		MaxAttempts: c.maxRetries,
+		BaseDelay:   c.retryDelay,
+		Name:        "ollama",
+		Logger:      c.logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ollama API error: %w", err)
+	}
+
+	var apiResp ollamaResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		c.logger.Error("Failed to parse JSON response",
+			zap.Error(err),
+			zap.String("body", string(body)))
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if apiResp.Error != "" {
+		return nil, fmt.Errorf("ollama API error: %s", apiResp.Error)
+	}
+
+	responseText := apiResp.Message.Content
+
+	// Parse JSON - strip markdown code blocks if present (fallback for
+	// models that ignore the json format hint)
+	cleanJSON := strings.TrimSpace(responseText)
+	cleanJSON = strings.TrimPrefix(cleanJSON, "```json")
+	cleanJSON = strings.TrimPrefix(cleanJSON, "```")
+	cleanJSON = strings.TrimSuffix(cleanJSON, "```")
+	cleanJSON = strings.TrimSpace(cleanJSON)
+
+	result, err := models.ParseAnnotationResponse([]byte(cleanJSON))
+	if err != nil {
+		c.logger.Error("Invalid annotation response",
+			zap.Error(err),
+			zap.String("original_response", responseText),
+			zap.String("cleaned_response", cleanJSON))
+		return nil, fmt.Errorf("failed to parse ollama response: %w", err)
+	}
+
+	promptlog.Sample("ollama", c.modelName, prompt, text, responseText, result, time.Since(start))
+
+	c.logger.Debug("Successfully annotated message with Ollama",
+		zap.String("category", result.CategoryName),
+		zap.Int("category_id", result.CategoryID))
+
+	return result, nil
+}
+
+// HealthCheck probes the server's version endpoint, which answers without
+// loading a model.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/version", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close closes the client and releases resources.
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// GetModelInfo returns information about the model being used.
+func (c *Client) GetModelInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"provider": "ollama",
+		"model":    c.modelName,
+		"base_url": c.baseURL,
+	}
+}
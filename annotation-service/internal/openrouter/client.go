@@ -5,13 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	"annotation-service/internal/gemini"
+	"annotation-service/internal/httpx"
 	"annotation-service/internal/models"
+	"annotation-service/internal/promptlog"
 
 	"go.uber.org/zap"
 )
@@ -25,6 +26,7 @@ type Client struct {
 	logger     *zap.Logger
 	maxRetries int
 	retryDelay time.Duration
+	jsonMode   bool
 }
 
 // Config holds configuration for OpenRouter client.
@@ -33,14 +35,23 @@ type Config struct {
 	ModelName  string // e.g., "meta-llama/llama-3.2-3b-instruct:free"
 	MaxRetries int
 	RetryDelay time.Duration
+	// JSONMode sets response_format json_object so the model returns plain
+	// JSON. Leave off for models that do not support it; the markdown fence
+	// stripping below still handles those.
+	JSONMode bool
 }
 
 // openRouterRequest represents the request structure for OpenRouter API.
 type openRouterRequest struct {
-	Model       string                   `json:"model"`
-	Messages    []openRouterMessage      `json:"messages"`
-	Temperature float64                  `json:"temperature,omitempty"`
-	MaxTokens   int                      `json:"max_tokens,omitempty"`
+	Model          string                    `json:"model"`
+	Messages       []openRouterMessage       `json:"messages"`
+	Temperature    float64                   `json:"temperature,omitempty"`
+	MaxTokens      int                       `json:"max_tokens,omitempty"`
+	ResponseFormat *openRouterResponseFormat `json:"response_format,omitempty"`
+}
+
+type openRouterResponseFormat struct {
+	Type string `json:"type"` // "json_object"
 }
 
 type openRouterMessage struct {
@@ -92,10 +103,11 @@ func NewClient(cfg Config, logger *zap.Logger) (*Client, error) {
 		apiKey:     cfg.APIKey,
 		baseURL:    "https://openrouter.ai/api/v1",
 		modelName:  cfg.ModelName,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: httpx.NewClient(30 * time.Second),
 		logger:     logger,
 		maxRetries: cfg.MaxRetries,
 		retryDelay: cfg.RetryDelay,
+		jsonMode:   cfg.JSONMode,
 	}
 
 	logger.Info("OpenRouter client initialized",
@@ -107,39 +119,6 @@ func NewClient(cfg Config, logger *zap.Logger) (*Client, error) {
 
 // Annotate sends a text to OpenRouter for annotation.
 func (c *Client) Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error) {
-	var lastErr error
-
-	for attempt := 1; attempt <= c.maxRetries; attempt++ {
-		result, err := c.annotateOnce(ctx, text, attempt)
-		if err == nil {
-			return result, nil
-		}
-
-		lastErr = err
-		c.logger.Warn("OpenRouter API attempt failed",
-			zap.Int("attempt", attempt),
-			zap.Int("max_retries", c.maxRetries),
-			zap.Error(err))
-
-		// Don't retry if context is cancelled
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-
-		// Wait before retry (except on last attempt)
-		if attempt < c.maxRetries {
-			select {
-			case <-time.After(c.retryDelay):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
-		}
-	}
-
-	return nil, fmt.Errorf("failed after %d attempts: %w", c.maxRetries, lastErr)
-}
-
-func (c *Client) annotateOnce(ctx context.Context, text string, attempt int) (*models.AnnotationResponse, error) {
 	// Build the prompt using the same system instruction as Gemini
 	prompt := gemini.BuildPrompt(text)
 
@@ -158,42 +137,35 @@ func (c *Client) annotateOnce(ctx context.Context, text string, attempt int) (*m
 		Temperature: 0.3,
 		MaxTokens:   500,
 	}
+	if c.jsonMode {
+		reqBody.ResponseFormat = &openRouterResponseFormat{Type: "json_object"}
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("HTTP-Referer", "https://github.com/social-engineering-detector")
-	req.Header.Set("X-Title", "Social Engineering Detector")
-
-	resp, err := c.httpClient.Do(req)
+	start := time.Now()
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("HTTP-Referer", "https://github.com/social-engineering-detector")
+		req.Header.Set("X-Title", "Social Engineering Detector")
+		return req, nil
+	}, httpx.Options{
+		MaxAttempts: c.maxRetries,
+		BaseDelay:   c.retryDelay,
+		Name:        "openrouter",
+		Logger:      c.logger,
+	})
 	if err != nil {
-		c.logger.Error("OpenRouter API error", zap.Error(err), zap.Int("attempt", attempt))
 		return nil, fmt.Errorf("openrouter API request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Check for HTTP errors
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("OpenRouter API error",
-			zap.Int("status", resp.StatusCode),
-			zap.String("body", string(body)),
-			zap.Int("attempt", attempt))
-		return nil, fmt.Errorf("openrouter API returned status %d: %s", resp.StatusCode, string(body))
-	}
 
 	var apiResp openRouterResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
@@ -218,35 +190,27 @@ func (c *Client) annotateOnce(ctx context.Context, text string, attempt int) (*m
 	cleanJSON = strings.TrimSuffix(cleanJSON, "```")
 	cleanJSON = strings.TrimSpace(cleanJSON)
 
-	var result models.AnnotationResponse
-	if err := json.Unmarshal([]byte(cleanJSON), &result); err != nil {
-		c.logger.Error("Failed to parse JSON response",
+	result, err := models.ParseAnnotationResponse([]byte(cleanJSON))
+	if err != nil {
+		c.logger.Error("Invalid annotation response",
 			zap.Error(err),
 			zap.String("original_response", responseText),
-			zap.String("cleaned_response", cleanJSON),
-			zap.Int("attempt", attempt))
+			zap.String("cleaned_response", cleanJSON))
 		return nil, fmt.Errorf("failed to parse openrouter response: %w", err)
 	}
 
-	// Validate category ID
-	if result.CategoryID < 1 || result.CategoryID > 9 {
-		c.logger.Error("Invalid category ID",
-			zap.Int("category_id", result.CategoryID),
-			zap.Int("attempt", attempt))
-		return nil, fmt.Errorf("invalid category ID: %d", result.CategoryID)
-	}
-
 	// Add provider metadata
 	result.Provider = "openrouter"
 	result.ModelVersion = c.modelName
 	result.AnnotatedAt = time.Now()
 
+	promptlog.Sample("openrouter", c.modelName, prompt, text, responseText, result, time.Since(start))
+
 	c.logger.Debug("Successfully annotated message with OpenRouter",
 		zap.String("category", result.CategoryName),
-		zap.Int("category_id", result.CategoryID),
-		zap.Int("attempt", attempt))
+		zap.Int("category_id", result.CategoryID))
 
-	return &result, nil
+	return result, nil
 }
 
 // Close closes the client and releases resources.
@@ -255,6 +219,27 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// HealthCheck probes the key endpoint, which requires a valid API key but
+// consumes no tokens.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/key", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("openrouter health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("openrouter health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // GetModelInfo returns information about the model being used.
 func (c *Client) GetModelInfo() map[string]interface{} {
 	return map[string]interface{}{
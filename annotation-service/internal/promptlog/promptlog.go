@@ -0,0 +1,111 @@
+// Package promptlog samples raw LLM exchanges to a JSONL file for offline
+// analysis — prompt drift, provider response quality, parser failures. It is
+// off by default (sample rate 0) and never fails an annotation: sink errors
+// are logged and dropped.
+package promptlog
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Record is one sampled exchange with a provider.
+type Record struct {
+	Timestamp   time.Time   `json:"timestamp"`
+	Provider    string      `json:"provider"`
+	Model       string      `json:"model"`
+	Prompt      string      `json:"prompt"`
+	RawResponse string      `json:"raw_response"`
+	Parsed      interface{} `json:"parsed,omitempty"`
+	LatencyMs   int64       `json:"latency_ms"`
+	Redacted    bool        `json:"redacted,omitempty"`
+}
+
+var (
+	mu     sync.Mutex
+	rate   float64
+	redact bool
+	path   string
+	sink   *os.File
+	logger = zap.NewNop()
+)
+
+// Configure sets the sampling rate (0 disables, 1 logs everything), whether
+// message text is redacted from sampled prompts and responses, and the JSONL
+// sink path. Mirrors the other package-level toggles: call once at startup.
+func Configure(sampleRate float64, redactText bool, sinkPath string, log *zap.Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+	rate = sampleRate
+	redact = redactText
+	path = sinkPath
+	if log != nil {
+		logger = log
+	}
+	if sink != nil {
+		sink.Close()
+		sink = nil
+	}
+}
+
+// Sample writes the exchange to the sink for a sampled fraction of requests.
+// messageText is the raw user message; when redaction is on, its occurrences
+// in the prompt and response are replaced before writing so the sample file
+// never carries message content.
+func Sample(provider, model, prompt, messageText, rawResponse string, parsed interface{}, latency time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if rate <= 0 || rand.Float64() >= rate {
+		return
+	}
+	if sink == nil && !openSink() {
+		return
+	}
+
+	rec := Record{
+		Timestamp:   time.Now(),
+		Provider:    provider,
+		Model:       model,
+		Prompt:      prompt,
+		RawResponse: rawResponse,
+		Parsed:      parsed,
+		LatencyMs:   latency.Milliseconds(),
+	}
+	if redact && messageText != "" {
+		rec.Prompt = strings.ReplaceAll(rec.Prompt, messageText, "[REDACTED]")
+		rec.RawResponse = strings.ReplaceAll(rec.RawResponse, messageText, "[REDACTED]")
+		rec.Redacted = true
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		logger.Error("Failed to marshal prompt log record", zap.Error(err))
+		return
+	}
+	if _, err := sink.Write(append(line, '\n')); err != nil {
+		logger.Error("Failed to write prompt log record", zap.Error(err))
+	}
+}
+
+// openSink lazily opens the JSONL file in append mode. Caller holds mu.
+func openSink() bool {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.Error("Failed to create prompt log directory", zap.Error(err))
+		return false
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Error("Failed to open prompt log sink", zap.Error(err))
+		return false
+	}
+	sink = f
+	return true
+}
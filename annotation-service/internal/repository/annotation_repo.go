@@ -3,28 +3,53 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"annotation-service/internal/models"
 
-	_ "modernc.org/sqlite"
 	"go.uber.org/zap"
+
+	_ "github.com/lib/pq"  // PostgreSQL driver
+	_ "modernc.org/sqlite" // SQLite driver
 )
 
-// AnnotationRepository handles data storage
+// AnnotationRepository handles data storage. It supports the "sqlite" driver
+// (local dev, dsn is a file path) and "postgres" (production, dsn is a
+// connection URL).
 type AnnotationRepository struct {
 	db     *sql.DB
+	driver string
 	logger *zap.Logger
 }
 
-// NewAnnotationRepository creates a new repository
-func NewAnnotationRepository(dbPath string, logger *zap.Logger) (*AnnotationRepository, error) {
-	db, err := sql.Open("sqlite", dbPath)
+// NewAnnotationRepository creates a new repository for the given driver.
+// An empty driver defaults to sqlite.
+func NewAnnotationRepository(driver, dsn string, logger *zap.Logger) (*AnnotationRepository, error) {
+	if driver == "" {
+		driver = "sqlite"
+	}
+	if driver != "sqlite" && driver != "postgres" {
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+
+	if driver == "sqlite" {
+		// Batch jobs hit the database from several goroutines at once; a
+		// bare file open answers lock contention with SQLITE_BUSY instead
+		// of waiting. WAL lets readers proceed during writes, and the busy
+		// timeout makes writers queue rather than fail.
+		if !strings.Contains(dsn, "?") {
+			dsn += "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+		}
+	}
+
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	repo := &AnnotationRepository{
 		db:     db,
+		driver: driver,
 		logger: logger,
 	}
 
@@ -32,26 +57,59 @@ func NewAnnotationRepository(dbPath string, logger *zap.Logger) (*AnnotationRepo
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	logger.Info("Annotation repository initialized", zap.String("db_path", dbPath))
+	logger.Info("Annotation repository initialized",
+		zap.String("driver", driver),
+		zap.String("dsn", dsn))
 
 	return repo, nil
 }
 
+// rebind converts the repository's `?` placeholders into the `$n` form
+// expected by Postgres. SQLite queries pass through unchanged.
+func (r *AnnotationRepository) rebind(query string) string {
+	if r.driver != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, ch := range query {
+		if ch == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
 // migrate creates tables
 func (r *AnnotationRepository) migrate() error {
-	schema := `
+	idColumn := "INTEGER PRIMARY KEY AUTOINCREMENT"
+	timestampType := "DATETIME"
+	booleanDefault := "BOOLEAN DEFAULT 0"
+	if r.driver == "postgres" {
+		idColumn = "BIGSERIAL PRIMARY KEY"
+		timestampType = "TIMESTAMP"
+		booleanDefault = "BOOLEAN DEFAULT FALSE"
+	}
+
+	schema := fmt.Sprintf(`
 	CREATE TABLE IF NOT EXISTS annotations (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		id %s,
 		message_id INTEGER,
 		text TEXT NOT NULL,
 		category_id INTEGER NOT NULL,
 		category_name TEXT NOT NULL,
 		justification TEXT,
+		justification_language TEXT,
+		message_language TEXT,
 		confidence REAL,
-		annotated_at DATETIME NOT NULL,
+		raw_confidence REAL,
+		annotated_at %s NOT NULL,
 		provider TEXT NOT NULL,
 		model_version TEXT,
-		is_validated BOOLEAN DEFAULT 0
+		is_validated %s
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_category_id ON annotations(category_id);
@@ -64,16 +122,34 @@ func (r *AnnotationRepository) migrate() error {
 		total_count INTEGER NOT NULL,
 		processed_count INTEGER DEFAULT 0,
 		failed_count INTEGER DEFAULT 0,
-		created_at DATETIME NOT NULL,
-		completed_at DATETIME,
+		created_at %s NOT NULL,
+		completed_at %s,
 		error_message TEXT
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_job_status ON jobs(status);
-	`
+	`, idColumn, timestampType, booleanDefault, timestampType, timestampType)
 
-	_, err := r.db.Exec(schema)
-	return err
+	if _, err := r.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Databases created before the column existed need it added; the error for
+	// an already-present column is safe to ignore on both drivers.
+	if _, err := r.db.Exec(`ALTER TABLE annotations ADD COLUMN justification_language TEXT`); err != nil {
+		r.logger.Debug("justification_language column already present", zap.Error(err))
+	}
+	if _, err := r.db.Exec(`ALTER TABLE annotations ADD COLUMN message_language TEXT`); err != nil {
+		r.logger.Debug("message_language column already present", zap.Error(err))
+	}
+	if _, err := r.db.Exec(`ALTER TABLE annotations ADD COLUMN raw_confidence REAL`); err != nil {
+		r.logger.Debug("raw_confidence column already present", zap.Error(err))
+	}
+	if _, err := r.db.Exec(`ALTER TABLE annotations ADD COLUMN grooming_stage TEXT`); err != nil {
+		r.logger.Debug("grooming_stage column already present", zap.Error(err))
+	}
+
+	return nil
 }
 
 // SaveAnnotation saves a single annotation
@@ -81,23 +157,36 @@ func (r *AnnotationRepository) SaveAnnotation(ann *models.Annotation) error {
 	query := `
 		INSERT INTO annotations (
 			message_id, text, category_id, category_name, justification,
-			confidence, annotated_at, provider, model_version, is_validated
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			justification_language, message_language, confidence, raw_confidence, grooming_stage, annotated_at, provider, model_version, is_validated
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(query,
+	args := []interface{}{
 		ann.MessageID,
 		ann.Text,
 		ann.Category,
 		ann.CategoryName,
 		ann.Justification,
+		ann.Language,
+		ann.MessageLanguage,
 		ann.Confidence,
+		ann.RawConfidence,
+		ann.GroomingStage,
 		ann.AnnotatedAt,
 		ann.Provider,
 		ann.ModelVersion,
 		ann.IsValidated,
-	)
+	}
+
+	// lib/pq does not support LastInsertId, so Postgres uses RETURNING.
+	if r.driver == "postgres" {
+		if err := r.db.QueryRow(r.rebind(query+" RETURNING id"), args...).Scan(&ann.ID); err != nil {
+			return fmt.Errorf("failed to save annotation: %w", err)
+		}
+		return nil
+	}
 
+	result, err := r.db.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to save annotation: %w", err)
 	}
@@ -115,7 +204,7 @@ func (r *AnnotationRepository) SaveAnnotation(ann *models.Annotation) error {
 func (r *AnnotationRepository) GetAllAnnotations() ([]*models.Annotation, error) {
 	query := `
 		SELECT id, message_id, text, category_id, category_name, justification,
-		       confidence, annotated_at, provider, model_version, is_validated
+		       COALESCE(justification_language, ''), COALESCE(message_language, ''), confidence, COALESCE(raw_confidence, 0), COALESCE(grooming_stage, ''), annotated_at, provider, model_version, is_validated
 		FROM annotations
 		ORDER BY annotated_at DESC
 	`
@@ -136,7 +225,11 @@ func (r *AnnotationRepository) GetAllAnnotations() ([]*models.Annotation, error)
 			&ann.Category,
 			&ann.CategoryName,
 			&ann.Justification,
+			&ann.Language,
+			&ann.MessageLanguage,
 			&ann.Confidence,
+			&ann.RawConfidence,
+			&ann.GroomingStage,
 			&ann.AnnotatedAt,
 			&ann.Provider,
 			&ann.ModelVersion,
@@ -156,13 +249,13 @@ func (r *AnnotationRepository) GetAllAnnotations() ([]*models.Annotation, error)
 func (r *AnnotationRepository) GetAnnotationsByCategory(categoryID int) ([]*models.Annotation, error) {
 	query := `
 		SELECT id, message_id, text, category_id, category_name, justification,
-		       confidence, annotated_at, provider, model_version, is_validated
+		       COALESCE(justification_language, ''), COALESCE(message_language, ''), confidence, COALESCE(raw_confidence, 0), COALESCE(grooming_stage, ''), annotated_at, provider, model_version, is_validated
 		FROM annotations
 		WHERE category_id = ?
 		ORDER BY annotated_at DESC
 	`
 
-	rows, err := r.db.Query(query, categoryID)
+	rows, err := r.db.Query(r.rebind(query), categoryID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query annotations by category: %w", err)
 	}
@@ -178,7 +271,11 @@ func (r *AnnotationRepository) GetAnnotationsByCategory(categoryID int) ([]*mode
 			&ann.Category,
 			&ann.CategoryName,
 			&ann.Justification,
+			&ann.Language,
+			&ann.MessageLanguage,
 			&ann.Confidence,
+			&ann.RawConfidence,
+			&ann.GroomingStage,
 			&ann.AnnotatedAt,
 			&ann.Provider,
 			&ann.ModelVersion,
@@ -234,6 +331,174 @@ func (r *AnnotationRepository) GetStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// GetAnnotationsPaged retrieves one page of annotations matching the filter,
+// newest first, along with the total match count so callers can paginate.
+// The annotated_at bounds ride the existing idx_annotated_at index.
+func (r *AnnotationRepository) GetAnnotationsPaged(filter models.AnnotationFilter) ([]*models.Annotation, int, error) {
+	where := "WHERE 1=1"
+	var args []interface{}
+	if filter.From != nil {
+		where += " AND annotated_at >= ?"
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		where += " AND annotated_at < ?"
+		args = append(args, *filter.To)
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM annotations " + where
+	if err := r.db.QueryRow(r.rebind(countQuery), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count annotations: %w", err)
+	}
+
+	query := `
+		SELECT id, message_id, text, category_id, category_name, justification,
+		       COALESCE(justification_language, ''), COALESCE(message_language, ''), confidence, COALESCE(raw_confidence, 0), COALESCE(grooming_stage, ''), annotated_at, provider, model_version, is_validated
+		FROM annotations
+		` + where + `
+		ORDER BY annotated_at DESC
+		LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.Query(r.rebind(query), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query annotations page: %w", err)
+	}
+	defer rows.Close()
+
+	var annotations []*models.Annotation
+	for rows.Next() {
+		ann := &models.Annotation{}
+		err := rows.Scan(
+			&ann.ID,
+			&ann.MessageID,
+			&ann.Text,
+			&ann.Category,
+			&ann.CategoryName,
+			&ann.Justification,
+			&ann.Language,
+			&ann.MessageLanguage,
+			&ann.Confidence,
+			&ann.RawConfidence,
+			&ann.GroomingStage,
+			&ann.AnnotatedAt,
+			&ann.Provider,
+			&ann.ModelVersion,
+			&ann.IsValidated,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan annotation", zap.Error(err))
+			continue
+		}
+		annotations = append(annotations, ann)
+	}
+
+	return annotations, total, nil
+}
+
+// StreamAnnotations invokes fn for each annotation, newest first, scanning
+// row by row so exports never hold the whole table in memory. When
+// validatedOnly is set, only validated annotations are visited. A non-nil
+// error from fn aborts the iteration and is returned.
+func (r *AnnotationRepository) StreamAnnotations(validatedOnly bool, fn func(*models.Annotation) error) error {
+	query := `
+		SELECT id, message_id, text, category_id, category_name, justification,
+		       COALESCE(justification_language, ''), COALESCE(message_language, ''), confidence, COALESCE(raw_confidence, 0), COALESCE(grooming_stage, ''), annotated_at, provider, model_version, is_validated
+		FROM annotations`
+	if validatedOnly {
+		query += `
+		WHERE is_validated = ?`
+	}
+	query += `
+		ORDER BY annotated_at DESC`
+
+	var args []interface{}
+	if validatedOnly {
+		args = append(args, true)
+	}
+
+	rows, err := r.db.Query(r.rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to query annotations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ann := &models.Annotation{}
+		err := rows.Scan(
+			&ann.ID,
+			&ann.MessageID,
+			&ann.Text,
+			&ann.Category,
+			&ann.CategoryName,
+			&ann.Justification,
+			&ann.Language,
+			&ann.MessageLanguage,
+			&ann.Confidence,
+			&ann.RawConfidence,
+			&ann.GroomingStage,
+			&ann.AnnotatedAt,
+			&ann.Provider,
+			&ann.ModelVersion,
+			&ann.IsValidated,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan annotation", zap.Error(err))
+			continue
+		}
+		if err := fn(ann); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetConfidenceHistogram buckets stored annotations by 0.1-wide confidence
+// ranges, grouped by provider and category, so calibration can be compared
+// across providers. A confidence of exactly 1.0 is folded into the top
+// bucket rather than getting one of its own.
+func (r *AnnotationRepository) GetConfidenceHistogram() ([]*models.ConfidenceBucket, error) {
+	// SQLite truncates on CAST to integer while Postgres rounds, so the
+	// latter needs an explicit FLOOR to keep the buckets half-open.
+	bucketExpr := "CAST(confidence * 10 AS INTEGER)"
+	if r.driver == "postgres" {
+		bucketExpr = "FLOOR(confidence * 10)::int"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT provider, category_id, category_name,
+		       CASE WHEN confidence >= 1 THEN 9 ELSE %s END AS bucket,
+		       COUNT(*) AS count,
+		       AVG(confidence) AS avg_confidence
+		FROM annotations
+		GROUP BY provider, category_id, category_name, bucket
+		ORDER BY provider, category_id, bucket
+	`, bucketExpr)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query confidence histogram: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []*models.ConfidenceBucket
+	for rows.Next() {
+		b := &models.ConfidenceBucket{}
+		var bucket int
+		if err := rows.Scan(&b.Provider, &b.CategoryID, &b.CategoryName, &bucket, &b.Count, &b.AvgConfidence); err != nil {
+			return nil, fmt.Errorf("failed to scan confidence bucket: %w", err)
+		}
+		if bucket < 0 {
+			bucket = 0
+		}
+		b.Bucket = fmt.Sprintf("%.1f-%.1f", float64(bucket)/10, float64(bucket+1)/10)
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
 // CreateJob creates a new annotation job
 func (r *AnnotationRepository) CreateJob(job *models.Job) error {
 	query := `
@@ -241,7 +506,7 @@ func (r *AnnotationRepository) CreateJob(job *models.Job) error {
 		VALUES (?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query, job.ID, job.Status, job.TotalCount, job.CreatedAt)
+	_, err := r.db.Exec(r.rebind(query), job.ID, job.Status, job.TotalCount, job.CreatedAt)
 	return err
 }
 
@@ -253,20 +518,55 @@ func (r *AnnotationRepository) UpdateJob(job *models.Job) error {
 		WHERE id = ?
 	`
 
-	_, err := r.db.Exec(query, job.Status, job.ProcessedCount, job.FailedCount, job.CompletedAt, job.ErrorMessage, job.ID)
+	_, err := r.db.Exec(r.rebind(query), job.Status, job.ProcessedCount, job.FailedCount, job.CompletedAt, job.ErrorMessage, job.ID)
 	return err
 }
 
+// GetActiveJobs returns batch jobs that have not finished yet.
+func (r *AnnotationRepository) GetActiveJobs() ([]*models.Job, error) {
+	query := `
+		SELECT id, status, total_count, processed_count, failed_count, created_at, completed_at, COALESCE(error_message, '')
+		FROM jobs
+		WHERE status IN ('pending', 'processing')
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job := &models.Job{}
+		if err := rows.Scan(
+			&job.ID,
+			&job.Status,
+			&job.TotalCount,
+			&job.ProcessedCount,
+			&job.FailedCount,
+			&job.CreatedAt,
+			&job.CompletedAt,
+			&job.ErrorMessage,
+		); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
 // GetJob retrieves a job by ID
 func (r *AnnotationRepository) GetJob(jobID string) (*models.Job, error) {
 	query := `
-		SELECT id, status, total_count, processed_count, failed_count, created_at, completed_at, error_message
+		SELECT id, status, total_count, processed_count, failed_count, created_at, completed_at, COALESCE(error_message, '')
 		FROM jobs
 		WHERE id = ?
 	`
 
 	job := &models.Job{}
-	err := r.db.QueryRow(query, jobID).Scan(
+	err := r.db.QueryRow(r.rebind(query), jobID).Scan(
 		&job.ID,
 		&job.Status,
 		&job.TotalCount,
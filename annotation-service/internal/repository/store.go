@@ -0,0 +1,23 @@
+package repository
+
+import "annotation-service/internal/models"
+
+// Store abstracts annotation persistence so the service can run against
+// SQLite for local development or share the backend's Postgres in production.
+// AnnotationRepository implements it for both drivers.
+type Store interface {
+	SaveAnnotation(ann *models.Annotation) error
+	GetAllAnnotations() ([]*models.Annotation, error)
+	GetAnnotationsPaged(filter models.AnnotationFilter) ([]*models.Annotation, int, error)
+	StreamAnnotations(validatedOnly bool, fn func(*models.Annotation) error) error
+	GetAnnotationsByCategory(categoryID int) ([]*models.Annotation, error)
+	GetStats() (map[string]interface{}, error)
+	GetConfidenceHistogram() ([]*models.ConfidenceBucket, error)
+	CreateJob(job *models.Job) error
+	UpdateJob(job *models.Job) error
+	GetJob(jobID string) (*models.Job, error)
+	GetActiveJobs() ([]*models.Job, error)
+	Close() error
+}
+
+var _ Store = (*AnnotationRepository)(nil)
@@ -2,9 +2,17 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/sync/singleflight"
+
+	"annotation-service/internal/calibration"
+	"annotation-service/internal/langdetect"
 	"annotation-service/internal/models"
 	"annotation-service/internal/repository"
 
@@ -21,56 +29,235 @@ type LLMClient interface {
 
 // Annotator handles annotation business logic
 type Annotator struct {
-	llmClient LLMClient
-	repo      *repository.AnnotationRepository
-	logger    *zap.Logger
+	llmClient       LLMClient
+	repo            repository.Store
+	language        string                  // required justification language ("ru" or "en")
+	enforceLanguage bool                    // re-request once when the justification language is wrong
+	languageRouting bool                    // expect justifications in the message's language instead
+	batchWorkers    int                     // concurrent LLM calls per batch job
+	calibrator      *calibration.Calibrator // nil when calibration is off
+	single          singleflight.Group
+	logger          *zap.Logger
+	// jobCtx is cancelled on shutdown so running batch jobs stop cleanly and
+	// mark themselves cancelled instead of staying "processing" forever.
+	jobCtx    context.Context
+	jobCancel context.CancelFunc
+	// jobWG tracks running batch-job goroutines so Shutdown can wait for
+	// their cancelled/completed status writes to land before the process
+	// exits.
+	jobWG sync.WaitGroup
 }
 
 // NewAnnotator creates a new annotator service
 func NewAnnotator(
 	llmClient LLMClient,
-	repo *repository.AnnotationRepository,
+	repo repository.Store,
+	language string,
+	enforceLanguage bool,
+	languageRouting bool,
+	batchWorkers int,
+	calibrator *calibration.Calibrator,
 	logger *zap.Logger,
 ) *Annotator {
+	if batchWorkers < 1 {
+		batchWorkers = 1
+	}
+	jobCtx, jobCancel := context.WithCancel(context.Background())
 	return &Annotator{
-		llmClient: llmClient,
-		repo:      repo,
-		logger:    logger,
+		llmClient:       llmClient,
+		repo:            repo,
+		language:        language,
+		enforceLanguage: enforceLanguage,
+		languageRouting: languageRouting,
+		batchWorkers:    batchWorkers,
+		jobCtx:          jobCtx,
+		jobCancel:       jobCancel,
+		calibrator:      calibrator,
+		logger:          logger,
+	}
+}
+
+// annotateResult is the value shared between coalesced identical requests.
+type annotateResult struct {
+	resp     *models.AnnotationResponse
+	language string
+}
+
+// annotateCoalesced collapses concurrent identical requests into a single
+// LLM call: the same viral scam hitting many chats at once is annotated once
+// and the result is shared. Sequential duplicates are handled separately by
+// the content-hash dedup.
+func (a *Annotator) annotateCoalesced(ctx context.Context, text string) (*models.AnnotationResponse, string, error) {
+	hash := sha256.Sum256([]byte(text))
+	key := hex.EncodeToString(hash[:])
+
+	v, err, shared := a.single.Do(key, func() (interface{}, error) {
+		resp, language, err := a.annotate(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		return &annotateResult{resp: resp, language: language}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if shared {
+		a.logger.Debug("Annotation coalesced with concurrent identical request")
+	}
+
+	result := v.(*annotateResult)
+	return result.resp, result.language, nil
+}
+
+// annotate calls the LLM and, when language enforcement is on, re-requests
+// once if the justification is detectably in the wrong language. It returns
+// the response along with the detected justification language.
+func (a *Annotator) annotate(ctx context.Context, text string) (*models.AnnotationResponse, string, error) {
+	resp, err := a.llmClient.Annotate(ctx, text)
+	if err != nil {
+		return nil, "", err
 	}
+
+	// With language routing the justification is expected in the message's
+	// own language, not the single configured one.
+	expected := a.language
+	if a.languageRouting {
+		if msgLang := langdetect.Detect(text); msgLang != "" {
+			expected = msgLang
+		}
+	}
+
+	detected := langdetect.Detect(resp.Justification)
+	if a.enforceLanguage && detected != "" && detected != expected {
+		a.logger.Warn("Justification in wrong language, re-requesting annotation",
+			zap.String("expected", expected),
+			zap.String("detected", detected))
+
+		retry, err := a.llmClient.Annotate(ctx, text)
+		if err != nil {
+			// Keep the first answer: a wrong-language justification is still
+			// better than no annotation at all.
+			return resp, detected, nil
+		}
+		resp = retry
+		detected = langdetect.Detect(resp.Justification)
+	}
+
+	return resp, detected, nil
+}
+
+// secondOpinionClient is implemented by LLM clients that can produce an
+// independent second verdict (the multi-provider client rotates to another
+// provider). Clients without the method just get asked again.
+type secondOpinionClient interface {
+	AnnotateSecondOpinion(ctx context.Context, text string) (*models.AnnotationResponse, error)
 }
 
 // AnnotateSingle annotates a single message and saves it
 func (a *Annotator) AnnotateSingle(ctx context.Context, text string) (*models.Annotation, error) {
-	// Call LLM provider
-	resp, err := a.llmClient.Annotate(ctx, text)
+	// Call LLM provider (concurrent identical requests share one call)
+	resp, language, err := a.annotateCoalesced(ctx, text)
 	if err != nil {
 		return nil, fmt.Errorf("llm annotation failed: %w", err)
 	}
 
-	// Get provider info
-	modelInfo := a.llmClient.GetModelInfo()
-	provider := "unknown"
-	modelVersion := "unknown"
-	if p, ok := modelInfo["provider"].(string); ok {
-		provider = p
+	return a.saveAnnotation(text, resp, language)
+}
+
+// AnnotateSingleDryRun annotates a single message without persisting the
+// result, so prompts can be iterated on without polluting the annotations
+// table. The returned annotation has no ID since no row was written.
+func (a *Annotator) AnnotateSingleDryRun(ctx context.Context, text string) (*models.Annotation, error) {
+	resp, language, err := a.annotateCoalesced(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("llm annotation failed: %w", err)
 	}
-	if m, ok := modelInfo["model"].(string); ok {
-		modelVersion = m
+
+	annotation := a.buildAnnotation(text, resp, language, a.llmClient.GetModelInfo())
+	a.logger.Info("Message annotated (dry run, not persisted)",
+		zap.String("category", annotation.CategoryName))
+	return annotation, nil
+}
+
+// AnnotateSecondOpinion re-annotates a message whose first verdict the caller
+// distrusts (typically Neutral despite risk pre-signals). The call bypasses
+// request coalescing so it cannot be collapsed with the original request, and
+// prefers a different provider when the client supports it.
+func (a *Annotator) AnnotateSecondOpinion(ctx context.Context, text string) (*models.Annotation, error) {
+	var resp *models.AnnotationResponse
+	var err error
+	if so, ok := a.llmClient.(secondOpinionClient); ok {
+		resp, err = so.AnnotateSecondOpinion(ctx, text)
+	} else {
+		resp, err = a.llmClient.Annotate(ctx, text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("llm second-opinion annotation failed: %w", err)
 	}
 
-	// Create annotation model
-	annotation := &models.Annotation{
-		Text:          text,
-		Category:      models.ThreatCategory(resp.CategoryID),
-		CategoryName:  resp.CategoryName,
-		Justification: resp.Justification,
-		Confidence:    resp.Confidence,
-		AnnotatedAt:   time.Now(),
-		Provider:      provider,
-		ModelVersion:  modelVersion,
-		IsValidated:   false,
+	return a.saveAnnotation(text, resp, langdetect.Detect(resp.Justification))
+}
+
+// pinnedProviderClient is implemented by LLM clients that can route one
+// request to a named provider, bypassing the rotation.
+type pinnedProviderClient interface {
+	AnnotateWith(ctx context.Context, providerType, text string) (*models.AnnotationResponse, error)
+	ProviderModelInfo(providerType string) (map[string]interface{}, bool)
+}
+
+// AnnotateWithProvider annotates a message with one explicitly named
+// provider, for A/B comparisons and debugging. The saved annotation records
+// the pinned provider so callers can see who actually served the request.
+func (a *Annotator) AnnotateWithProvider(ctx context.Context, providerType, text string) (*models.Annotation, error) {
+	resp, modelInfo, err := a.annotateWithProvider(ctx, providerType, text)
+	if err != nil {
+		return nil, err
+	}
+	return a.saveAnnotationWithInfo(text, resp, langdetect.Detect(resp.Justification), modelInfo)
+}
+
+// AnnotateWithProviderDryRun is AnnotateWithProvider without persistence, so
+// a specific provider can be prompt-tuned without polluting the annotations
+// table. The returned annotation has no ID since no row was written.
+func (a *Annotator) AnnotateWithProviderDryRun(ctx context.Context, providerType, text string) (*models.Annotation, error) {
+	resp, modelInfo, err := a.annotateWithProvider(ctx, providerType, text)
+	if err != nil {
+		return nil, err
+	}
+
+	annotation := a.buildAnnotation(text, resp, langdetect.Detect(resp.Justification), modelInfo)
+	a.logger.Info("Message annotated (dry run, not persisted)",
+		zap.String("provider", providerType),
+		zap.String("category", annotation.CategoryName))
+	return annotation, nil
+}
+
+// annotateWithProvider runs one request against an explicitly named provider
+// and returns the response together with that provider's model info.
+func (a *Annotator) annotateWithProvider(ctx context.Context, providerType, text string) (*models.AnnotationResponse, map[string]interface{}, error) {
+	pc, ok := a.llmClient.(pinnedProviderClient)
+	if !ok {
+		return nil, nil, fmt.Errorf("provider pinning is not supported by this LLM client")
 	}
 
+	resp, err := pc.AnnotateWith(ctx, providerType, text)
+	if err != nil {
+		return nil, nil, fmt.Errorf("llm annotation failed: %w", err)
+	}
+
+	modelInfo, _ := pc.ProviderModelInfo(providerType)
+	return resp, modelInfo, nil
+}
+
+// saveAnnotation builds the annotation record from an LLM response and
+// persists it.
+func (a *Annotator) saveAnnotation(text string, resp *models.AnnotationResponse, language string) (*models.Annotation, error) {
+	return a.saveAnnotationWithInfo(text, resp, language, a.llmClient.GetModelInfo())
+}
+
+func (a *Annotator) saveAnnotationWithInfo(text string, resp *models.AnnotationResponse, language string, modelInfo map[string]interface{}) (*models.Annotation, error) {
+	annotation := a.buildAnnotation(text, resp, language, modelInfo)
+
 	// Save to database
 	if err := a.repo.SaveAnnotation(annotation); err != nil {
 		return nil, fmt.Errorf("failed to save annotation: %w", err)
@@ -83,15 +270,69 @@ func (a *Annotator) AnnotateSingle(ctx context.Context, text string) (*models.An
 	return annotation, nil
 }
 
+// buildAnnotation assembles the annotation record from an LLM response,
+// applying confidence calibration and the canonical category name, without
+// touching storage.
+func (a *Annotator) buildAnnotation(text string, resp *models.AnnotationResponse, language string, modelInfo map[string]interface{}) *models.Annotation {
+	provider := "unknown"
+	modelVersion := "unknown"
+	if p, ok := modelInfo["provider"].(string); ok {
+		provider = p
+	}
+	if m, ok := modelInfo["model"].(string); ok {
+		modelVersion = m
+	}
+
+	annotation := &models.Annotation{
+		Text:            text,
+		Category:        models.ThreatCategory(resp.CategoryID),
+		CategoryName:    resp.CategoryName,
+		Justification:   resp.Justification,
+		Language:        language,
+		MessageLanguage: langdetect.Detect(text),
+		Confidence:      resp.Confidence,
+		RawConfidence:   resp.Confidence,
+		GroomingStage:   resp.GroomingStage,
+		AnnotatedAt:     time.Now(),
+		Provider:        provider,
+		ModelVersion:    modelVersion,
+		IsValidated:     false,
+	}
+
+	if a.calibrator != nil {
+		annotation.Confidence = a.calibrator.Calibrate(provider, annotation.RawConfidence)
+	}
+
+	// Stored category_name must round-trip with category_id; anything else
+	// (a provider quirk that slipped past parsing) is corrected here.
+	if canonical := annotation.Category.Name(); annotation.CategoryName != canonical {
+		a.logger.Warn("category_name does not match category_id, storing canonical name",
+			zap.Int("category_id", int(annotation.Category)),
+			zap.String("category_name", annotation.CategoryName))
+		annotation.CategoryName = canonical
+	}
+
+	return annotation
+}
+
+// CalibrationMapping describes the active confidence calibration, or nil
+// when calibration is disabled.
+func (a *Annotator) CalibrationMapping() map[string]interface{} {
+	if a.calibrator == nil {
+		return nil
+	}
+	return a.calibrator.Mapping()
+}
+
 // AnnotateBatch starts async batch annotation
 func (a *Annotator) AnnotateBatch(ctx context.Context, messages []models.MessageInput) (string, error) {
 	jobID := uuid.New().String()
 
 	job := &models.Job{
-		ID:          jobID,
-		Status:      "pending",
-		TotalCount:  len(messages),
-		CreatedAt:   time.Now(),
+		ID:         jobID,
+		Status:     "pending",
+		TotalCount: len(messages),
+		CreatedAt:  time.Now(),
 	}
 
 	if err := a.repo.CreateJob(job); err != nil {
@@ -99,14 +340,40 @@ func (a *Annotator) AnnotateBatch(ctx context.Context, messages []models.Message
 	}
 
 	// Start async processing
-	go a.processBatchJob(jobID, messages)
+	a.jobWG.Add(1)
+	go func() {
+		defer a.jobWG.Done()
+		a.processBatchJob(jobID, messages)
+	}()
 
 	return jobID, nil
 }
 
+// shutdownDrainTimeout bounds how long Shutdown waits for cancelled batch
+// jobs to persist their final status.
+const shutdownDrainTimeout = 10 * time.Second
+
+// Shutdown cancels running batch jobs and waits (bounded) until they have
+// marked themselves cancelled, so a restart does not find them stuck in
+// "processing".
+func (a *Annotator) Shutdown() {
+	a.jobCancel()
+
+	done := make(chan struct{})
+	go func() {
+		a.jobWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownDrainTimeout):
+		a.logger.Warn("Batch jobs did not finish cancelling before the shutdown deadline")
+	}
+}
+
 // processBatchJob processes batch annotation job asynchronously
 func (a *Annotator) processBatchJob(jobID string, messages []models.MessageInput) {
-	ctx := context.Background()
+	ctx := a.jobCtx
 
 	job, err := a.repo.GetJob(jobID)
 	if err != nil {
@@ -117,59 +384,89 @@ func (a *Annotator) processBatchJob(jobID string, messages []models.MessageInput
 	job.Status = "processing"
 	a.repo.UpdateJob(job)
 
-	for i, msg := range messages {
-		annotation, err := a.llmClient.Annotate(ctx, msg.Text)
-		if err != nil {
-			a.logger.Error("Failed to annotate message in batch",
-				zap.String("job_id", jobID),
-				zap.Int("index", i),
-				zap.Error(err))
-			job.FailedCount++
-		} else {
-			// Get provider info
-			modelInfo := a.llmClient.GetModelInfo()
-			provider := "unknown"
-			modelVersion := "unknown"
-			if p, ok := modelInfo["provider"].(string); ok {
-				provider = p
-			}
-			if m, ok := modelInfo["model"].(string); ok {
-				modelVersion = m
-			}
-
-			// Save annotation
-			ann := &models.Annotation{
-				MessageID:     msg.ID,
-				Text:          msg.Text,
-				Category:      models.ThreatCategory(annotation.CategoryID),
-				CategoryName:  annotation.CategoryName,
-				Justification: annotation.Justification,
-				Confidence:    annotation.Confidence,
-				AnnotatedAt:   time.Now(),
-				Provider:      provider,
-				ModelVersion:  modelVersion,
-				IsValidated:   false,
-			}
+	var processed, failed atomic.Int64
 
+	// A single writer goroutine serializes annotation saves and job-progress
+	// updates, so concurrent classification never interleaves SQLite writes
+	// or loses counter updates. Progress rows are debounced: the job record
+	// is rewritten at most every flushInterval, not on every message.
+	const flushInterval = 500 * time.Millisecond
+	saveCh := make(chan *models.Annotation, len(messages))
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		lastFlush := time.Now()
+		for ann := range saveCh {
 			if err := a.repo.SaveAnnotation(ann); err != nil {
 				a.logger.Error("Failed to save annotation", zap.Error(err))
-				job.FailedCount++
+				failed.Add(1)
 			} else {
-				job.ProcessedCount++
+				processed.Add(1)
+			}
+			if time.Since(lastFlush) >= flushInterval {
+				job.ProcessedCount = int(processed.Load())
+				job.FailedCount = int(failed.Load())
+				a.repo.UpdateJob(job)
+				lastFlush = time.Now()
 			}
 		}
+	}()
 
-		// Update job progress
-		a.repo.UpdateJob(job)
+	// Classification runs on a bounded worker pool; the provider-level rate
+	// limiter paces the actual LLM requests.
+	sem := make(chan struct{}, a.batchWorkers)
+	var wg sync.WaitGroup
+	for i, msg := range messages {
+		// Stop dispatching once shutdown has been requested; in-flight
+		// workers abort via the same context.
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, msg models.MessageInput) {
+			defer wg.Done()
+			defer func() { <-sem }()
 
-		// Rate limiting: small delay between requests
-		time.Sleep(100 * time.Millisecond)
+			annotation, language, err := a.annotate(ctx, msg.Text)
+			if err != nil {
+				a.logger.Error("Failed to annotate message in batch",
+					zap.String("job_id", jobID),
+					zap.Int("index", i),
+					zap.Error(err))
+				failed.Add(1)
+				return
+			}
+
+			// Same construction as the single-message path: calibration,
+			// raw-confidence retention, grooming stage, canonical name.
+			ann := a.buildAnnotation(msg.Text, annotation, language, a.llmClient.GetModelInfo())
+			ann.MessageID = msg.ID
+			saveCh <- ann
+		}(i, msg)
 	}
+	wg.Wait()
+	close(saveCh)
+	<-writerDone
 
-	// Mark job as completed
-	job.Status = "completed"
+	job.ProcessedCount = int(processed.Load())
+	job.FailedCount = int(failed.Load())
 	completedAt := time.Now()
 	job.CompletedAt = &completedAt
+
+	if ctx.Err() != nil {
+		job.Status = "cancelled"
+		job.ErrorMessage = "cancelled by shutdown"
+		a.repo.UpdateJob(job)
+		a.logger.Warn("Batch job cancelled by shutdown",
+			zap.String("job_id", jobID),
+			zap.Int("processed", job.ProcessedCount),
+			zap.Int("failed", job.FailedCount))
+		return
+	}
+
+	// Mark job as completed
+	job.Status = "completed"
 	a.repo.UpdateJob(job)
 
 	a.logger.Info("Batch job completed",
@@ -188,6 +485,18 @@ func (a *Annotator) GetAllAnnotations() ([]*models.Annotation, error) {
 	return a.repo.GetAllAnnotations()
 }
 
+// GetAnnotationsPaged returns one page of annotations matching the filter
+// plus the total match count.
+func (a *Annotator) GetAnnotationsPaged(filter models.AnnotationFilter) ([]*models.Annotation, int, error) {
+	return a.repo.GetAnnotationsPaged(filter)
+}
+
+// StreamAnnotations invokes fn for each stored annotation without loading
+// the whole table, for streaming exports.
+func (a *Annotator) StreamAnnotations(validatedOnly bool, fn func(*models.Annotation) error) error {
+	return a.repo.StreamAnnotations(validatedOnly, fn)
+}
+
 // GetAnnotationsByCategory returns annotations by category
 func (a *Annotator) GetAnnotationsByCategory(categoryID int) ([]*models.Annotation, error) {
 	return a.repo.GetAnnotationsByCategory(categoryID)
@@ -197,3 +506,68 @@ func (a *Annotator) GetAnnotationsByCategory(categoryID int) ([]*models.Annotati
 func (a *Annotator) GetStats() (map[string]interface{}, error) {
 	return a.repo.GetStats()
 }
+
+// GetConfidenceHistogram returns per-provider, per-category confidence
+// bucket counts for calibration review.
+func (a *Annotator) GetConfidenceHistogram() ([]*models.ConfidenceBucket, error) {
+	return a.repo.GetConfidenceHistogram()
+}
+
+// GetActiveJobs returns batch jobs still pending or processing.
+func (a *Annotator) GetActiveJobs() ([]*models.Job, error) {
+	return a.repo.GetActiveJobs()
+}
+
+// GetModelInfo returns information about the provider currently serving
+// requests.
+func (a *Annotator) GetModelInfo() map[string]interface{} {
+	return a.llmClient.GetModelInfo()
+}
+
+// providerToggleClient is implemented by LLM clients whose providers can be
+// soft-paused at runtime.
+type providerToggleClient interface {
+	SetProviderEnabled(index int, enabled bool) error
+}
+
+// SetProviderEnabled soft-pauses (enabled=false) or resumes one provider in
+// the rotation. Only meaningful for the multi-provider client.
+func (a *Annotator) SetProviderEnabled(index int, enabled bool) error {
+	tc, ok := a.llmClient.(providerToggleClient)
+	if !ok {
+		return fmt.Errorf("provider toggling is not supported by this LLM client")
+	}
+	return tc.SetProviderEnabled(index, enabled)
+}
+
+// providerAccuracyClient is implemented by LLM clients that can fold
+// measured historical accuracy back into provider selection.
+type providerAccuracyClient interface {
+	ReportProviderAccuracy(providerType string, samples int, accuracy float64) error
+}
+
+// ReportProviderAccuracy passes externally measured provider accuracy (from
+// validated dataset entries) to the LLM client. Only meaningful for the
+// multi-provider client.
+func (a *Annotator) ReportProviderAccuracy(providerType string, samples int, accuracy float64) error {
+	ac, ok := a.llmClient.(providerAccuracyClient)
+	if !ok {
+		return fmt.Errorf("provider accuracy ordering is not supported by this LLM client")
+	}
+	return ac.ReportProviderAccuracy(providerType, samples, accuracy)
+}
+
+// multiProviderInfoClient is implemented by LLM clients that rotate across
+// several providers and can report on all of them.
+type multiProviderInfoClient interface {
+	GetProvidersInfo() []map[string]interface{}
+}
+
+// GetProvidersInfo returns model and usage info for every configured
+// provider; single-provider clients yield a one-element slice.
+func (a *Annotator) GetProvidersInfo() []map[string]interface{} {
+	if mc, ok := a.llmClient.(multiProviderInfoClient); ok {
+		return mc.GetProvidersInfo()
+	}
+	return []map[string]interface{}{a.llmClient.GetModelInfo()}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"annotation-service/internal/models"
+	"annotation-service/internal/repository"
+)
+
+// fakeLLM is a deterministic LLMClient for batch tests. Annotate blocks for
+// delay (when set) so tests can catch a job mid-flight.
+type fakeLLM struct {
+	delay time.Duration
+	calls atomic.Int64
+}
+
+func (f *fakeLLM) Annotate(ctx context.Context, text string) (*models.AnnotationResponse, error) {
+	f.calls.Add(1)
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &models.AnnotationResponse{
+		CategoryName:  "Груминг",
+		CategoryID:    9,
+		Justification: "Просьба сохранить переписку в тайне",
+		Confidence:    0.85,
+		Provider:      "fake",
+		ModelVersion:  "fake-1",
+	}, nil
+}
+
+func (f *fakeLLM) Close() error { return nil }
+
+func (f *fakeLLM) GetModelInfo() map[string]interface{} {
+	return map[string]interface{}{"provider": "fake", "model": "fake-1"}
+}
+
+func newTestRepo(t *testing.T) *repository.AnnotationRepository {
+	t.Helper()
+	repo, err := repository.NewAnnotationRepository("sqlite", t.TempDir()+"/annotations.db", zap.NewNop())
+	if err != nil {
+		t.Fatalf("creating repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func waitForJobStatus(t *testing.T, annotator *Annotator, jobID string, want string) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := annotator.GetJobStatus(jobID)
+		if err != nil {
+			t.Fatalf("getting job status: %v", err)
+		}
+		if job.Status == want {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job, _ := annotator.GetJobStatus(jobID)
+	t.Fatalf("job never reached status %q, last seen %q", want, job.Status)
+	return nil
+}
+
+// TestAnnotateBatchConcurrent runs a batch across several workers against a
+// real SQLite store; run under -race it proves the worker pool, the single
+// writer goroutine and the progress counters do not race.
+func TestAnnotateBatchConcurrent(t *testing.T) {
+	llm := &fakeLLM{}
+	annotator := NewAnnotator(llm, newTestRepo(t), "ru", false, false, 4, nil, zap.NewNop())
+
+	const total = 40
+	messages := make([]models.MessageInput, total)
+	for i := range messages {
+		// Distinct texts so request coalescing does not collapse the batch.
+		messages[i] = models.MessageInput{Text: fmt.Sprintf("сообщение номер %d", i)}
+	}
+
+	jobID, err := annotator.AnnotateBatch(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("AnnotateBatch: %v", err)
+	}
+
+	job := waitForJobStatus(t, annotator, jobID, "completed")
+	if job.ProcessedCount != total || job.FailedCount != 0 {
+		t.Fatalf("processed %d, failed %d, want %d/0", job.ProcessedCount, job.FailedCount, total)
+	}
+	if llm.calls.Load() != total {
+		t.Errorf("LLM called %d times, want %d", llm.calls.Load(), total)
+	}
+
+	annotations, err := annotator.GetAllAnnotations()
+	if err != nil {
+		t.Fatalf("GetAllAnnotations: %v", err)
+	}
+	if len(annotations) != total {
+		t.Fatalf("stored %d annotations, want %d", len(annotations), total)
+	}
+	for _, ann := range annotations {
+		if ann.Category != 9 || ann.Provider != "fake" {
+			t.Errorf("annotation lost verdict fields: %+v", ann)
+		}
+		if ann.RawConfidence != 0.85 {
+			t.Errorf("annotation lost raw confidence: %v", ann.RawConfidence)
+		}
+	}
+}
+
+// TestShutdownWaitsForCancelledStatus asserts Shutdown does not return until
+// the interrupted batch job has persisted its "cancelled" status, so a
+// restart never finds it stuck in "processing".
+func TestShutdownWaitsForCancelledStatus(t *testing.T) {
+	// Workers block until the job context is cancelled.
+	llm := &fakeLLM{delay: time.Minute}
+	annotator := NewAnnotator(llm, newTestRepo(t), "ru", false, false, 2, nil, zap.NewNop())
+
+	messages := make([]models.MessageInput, 8)
+	for i := range messages {
+		messages[i] = models.MessageInput{Text: fmt.Sprintf("сообщение %d", i)}
+	}
+
+	jobID, err := annotator.AnnotateBatch(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("AnnotateBatch: %v", err)
+	}
+	waitForJobStatus(t, annotator, jobID, "processing")
+
+	annotator.Shutdown()
+
+	// No polling here: the status write must already be visible.
+	job, err := annotator.GetJobStatus(jobID)
+	if err != nil {
+		t.Fatalf("getting job status after shutdown: %v", err)
+	}
+	if job.Status != "cancelled" {
+		t.Fatalf("job status after Shutdown is %q, want %q", job.Status, "cancelled")
+	}
+	if job.ErrorMessage != "cancelled by shutdown" {
+		t.Errorf("unexpected error message %q", job.ErrorMessage)
+	}
+}
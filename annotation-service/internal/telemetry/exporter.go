@@ -0,0 +1,201 @@
+// Package telemetry implements an opt-in exporter of anonymized aggregate
+// signals (category distribution, provider accuracy, confidence histograms)
+// for cross-deployment model improvement. Given the sensitive domain it is
+// strictly default-off, and the payload is assembled exclusively from
+// aggregate counters — message content, usernames, chat names, and any other
+// identifying data never enter it.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"annotation-service/internal/httpx"
+	"annotation-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// StatsSource provides the aggregate figures the exporter ships. The
+// Annotator satisfies it.
+type StatsSource interface {
+	GetStats() (map[string]interface{}, error)
+	GetConfidenceHistogram() ([]*models.ConfidenceBucket, error)
+	GetProvidersInfo() []map[string]interface{}
+}
+
+// Config tunes the exporter.
+type Config struct {
+	Endpoint string        // Where payloads are POSTed; required unless DryRun
+	Interval time.Duration // Time between exports
+	// DryRun logs the payload that would be sent instead of sending it, so
+	// operators can inspect exactly what leaves the deployment before
+	// opting in for real.
+	DryRun bool
+}
+
+// providerStats is the per-provider slice of the payload: model identity and
+// reported accuracy only, never keys or request contents.
+type providerStats struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	ReportedAccuracy float64 `json:"reported_accuracy,omitempty"`
+	AccuracySamples  int     `json:"accuracy_samples,omitempty"`
+}
+
+// payload is the full export. Every field is an aggregate; nothing in it can
+// be traced back to a message, sender, or chat.
+type payload struct {
+	SchemaVersion       int                        `json:"schema_version"`
+	ExportedAt          time.Time                  `json:"exported_at"`
+	TotalAnnotations    int                        `json:"total_annotations"`
+	CategoryCounts      map[string]int             `json:"category_counts"`
+	ConfidenceHistogram []*models.ConfidenceBucket `json:"confidence_histogram"`
+	Providers           []providerStats            `json:"providers"`
+}
+
+// Exporter periodically assembles and ships the anonymized payload.
+type Exporter struct {
+	cfg        Config
+	source     StatsSource
+	httpClient *http.Client
+	logger     *zap.Logger
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// NewExporter creates an exporter. It returns an error when the configuration
+// cannot result in a meaningful export (no endpoint outside dry-run mode).
+func NewExporter(cfg Config, source StatsSource, logger *zap.Logger) (*Exporter, error) {
+	if cfg.Endpoint == "" && !cfg.DryRun {
+		return nil, fmt.Errorf("telemetry endpoint is required unless dry_run is set")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+
+	return &Exporter{
+		cfg:        cfg,
+		source:     source,
+		httpClient: httpx.NewClient(30 * time.Second),
+		logger:     logger,
+		stop:       make(chan struct{}),
+	}, nil
+}
+
+// Run exports on the configured interval until Stop is called. Call it in a
+// goroutine; failures are logged and retried on the next tick.
+func (e *Exporter) Run() {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if err := e.Export(ctx); err != nil {
+				e.logger.Warn("Telemetry export failed", zap.Error(err))
+			}
+			cancel()
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Stop terminates the export loop.
+func (e *Exporter) Stop() {
+	e.stopOnce.Do(func() { close(e.stop) })
+}
+
+// Export assembles the payload and ships it (or, in dry-run mode, logs it).
+func (e *Exporter) Export(ctx context.Context) error {
+	p, err := e.buildPayload()
+	if err != nil {
+		return fmt.Errorf("failed to build telemetry payload: %w", err)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry payload: %w", err)
+	}
+
+	if e.cfg.DryRun {
+		e.logger.Info("Telemetry dry run, payload not sent",
+			zap.String("endpoint", e.cfg.Endpoint),
+			zap.String("payload", string(data)))
+		return nil
+	}
+
+	_, _, err = httpx.DoWithRetry(ctx, e.httpClient, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", e.cfg.Endpoint, bytes.NewBuffer(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, httpx.Options{Name: "telemetry", Logger: e.logger})
+	if err != nil {
+		return err
+	}
+
+	e.logger.Info("Telemetry exported",
+		zap.Int("total_annotations", p.TotalAnnotations),
+		zap.Int("histogram_buckets", len(p.ConfidenceHistogram)))
+	return nil
+}
+
+// buildPayload gathers the aggregates. Provider info is filtered down to an
+// allow-list of fields so nothing sensitive can leak through the generic
+// GetProvidersInfo maps.
+func (e *Exporter) buildPayload() (*payload, error) {
+	stats, err := e.source.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	histogram, err := e.source.GetConfidenceHistogram()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &payload{
+		SchemaVersion:       1,
+		ExportedAt:          time.Now().UTC(),
+		CategoryCounts:      map[string]int{},
+		ConfidenceHistogram: histogram,
+	}
+
+	if total, ok := stats["total"].(int); ok {
+		p.TotalAnnotations = total
+	}
+	if byCategory, ok := stats["by_category"].(map[string]int); ok {
+		for name, count := range byCategory {
+			p.CategoryCounts[name] = count
+		}
+	}
+
+	for _, info := range e.source.GetProvidersInfo() {
+		ps := providerStats{}
+		if v, ok := info["provider"].(string); ok {
+			ps.Provider = v
+		}
+		if v, ok := info["model"].(string); ok {
+			ps.Model = v
+		}
+		if v, ok := info["reported_accuracy"].(float64); ok {
+			ps.ReportedAccuracy = v
+		}
+		if v, ok := info["accuracy_samples"].(int); ok {
+			ps.AccuracySamples = v
+		}
+		p.Providers = append(p.Providers, ps)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,126 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"annotation-service/internal/models"
+)
+
+// fakeStats feeds the exporter stats that deliberately contain sensitive
+// strings outside the aggregate fields, so the tests can assert none of them
+// survive into the payload.
+type fakeStats struct{}
+
+func (fakeStats) GetStats() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"total": 7,
+		"by_category": map[string]int{
+			"Груминг":     3,
+			"Нейтральное": 4,
+		},
+		// Must never be exported: not part of the payload schema.
+		"last_message_text": "это наш секрет, никому не говори",
+	}, nil
+}
+
+func (fakeStats) GetConfidenceHistogram() ([]*models.ConfidenceBucket, error) {
+	return []*models.ConfidenceBucket{
+		{Provider: "gemini", CategoryID: 9, CategoryName: "Груминг", Bucket: "0.8-0.9", Count: 3},
+	}, nil
+}
+
+func (fakeStats) GetProvidersInfo() []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"provider":          "gemini",
+			"model":             "gemini-1.5-flash",
+			"reported_accuracy": 0.92,
+			"accuracy_samples":  50,
+			// Must be filtered out by the allow-list.
+			"api_key":      "sk-very-secret",
+			"last_request": "привет, как дела @username",
+		},
+	}
+}
+
+func TestExportPayloadContainsOnlyAggregates(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	exporter, err := NewExporter(Config{Endpoint: srv.URL}, fakeStats{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	if err := exporter.Export(context.Background()); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// No message text, usernames, or credentials anywhere in the payload.
+	raw := string(body)
+	for _, leak := range []string{"секрет", "@username", "sk-very-secret", "last_message_text", "api_key", "last_request"} {
+		if strings.Contains(raw, leak) {
+			t.Errorf("payload leaked %q: %s", leak, raw)
+		}
+	}
+
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("parsing payload: %v", err)
+	}
+	if p.SchemaVersion != 1 || p.TotalAnnotations != 7 {
+		t.Errorf("unexpected aggregates: %+v", p)
+	}
+	if p.CategoryCounts["Груминг"] != 3 || p.CategoryCounts["Нейтральное"] != 4 {
+		t.Errorf("category counts lost: %v", p.CategoryCounts)
+	}
+	if len(p.Providers) != 1 {
+		t.Fatalf("got %d providers, want 1", len(p.Providers))
+	}
+	if p.Providers[0].Provider != "gemini" || p.Providers[0].Model != "gemini-1.5-flash" ||
+		p.Providers[0].ReportedAccuracy != 0.92 || p.Providers[0].AccuracySamples != 50 {
+		t.Errorf("allow-listed provider fields lost: %+v", p.Providers[0])
+	}
+}
+
+func TestExportDryRunSendsNothing(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	exporter, err := NewExporter(Config{Endpoint: srv.URL, DryRun: true}, fakeStats{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	if err := exporter.Export(context.Background()); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("dry run sent %d requests, want 0", requests)
+	}
+}
+
+func TestNewExporterRequiresEndpoint(t *testing.T) {
+	if _, err := NewExporter(Config{}, fakeStats{}, zap.NewNop()); err == nil {
+		t.Error("expected an error for a missing endpoint outside dry-run mode")
+	}
+	if _, err := NewExporter(Config{DryRun: true}, fakeStats{}, zap.NewNop()); err != nil {
+		t.Errorf("dry run without an endpoint must be allowed: %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"time"
 
+	"backend/internal/httpx"
+
 	"go.uber.org/zap"
 )
 
@@ -21,36 +23,50 @@ type Client struct {
 // AnnotateRequest represents the request to annotate a single message
 type AnnotateRequest struct {
 	Text string `json:"text"`
+	// SecondOpinion asks the service for an independent re-annotation,
+	// preferring a different provider over the one that answered first.
+	SecondOpinion bool `json:"second_opinion,omitempty"`
 }
 
 // AnnotationResponse represents the response from annotation service
 type AnnotationResponse struct {
-	ID             int64     `json:"id"`
-	Text           string    `json:"text"`
-	CategoryID     int       `json:"category_id"`
-	CategoryName   string    `json:"category_name"`
-	Justification  string    `json:"justification"`
-	AnnotatedAt    time.Time `json:"annotated_at"`
-	Provider       string    `json:"provider"`
-	ModelVersion   string    `json:"model_version"`
-	IsValidated    bool      `json:"is_validated"`
+	ID            int64     `json:"id"`
+	Text          string    `json:"text"`
+	CategoryID    int       `json:"category_id"`
+	CategoryName  string    `json:"category_name"`
+	Justification string    `json:"justification"`
+	Confidence    float64   `json:"confidence,omitempty"`     // 0 when the provider reports none
+	GroomingStage string    `json:"grooming_stage,omitempty"` // Optional stage tag when extraction is enabled service-side
+	AnnotatedAt   time.Time `json:"annotated_at"`
+	Provider      string    `json:"provider"`
+	ModelVersion  string    `json:"model_version"`
+	IsValidated   bool      `json:"is_validated"`
 }
 
 // NewClient creates a new Annotation Service client
 func NewClient(baseURL string, logger *zap.Logger) *Client {
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second, // Gemini API can be slow
-		},
-		logger: logger,
+		baseURL:    baseURL,
+		httpClient: httpx.NewClient(30 * time.Second), // Gemini API can be slow
+		logger:     logger,
 	}
 }
 
 // AnnotateSingle sends a single message for annotation
 func (c *Client) AnnotateSingle(ctx context.Context, text string) (*AnnotationResponse, error) {
+	return c.annotate(ctx, text, false)
+}
+
+// AnnotateSecondOpinion requests an independent re-annotation of a message
+// whose first verdict looks suspect.
+func (c *Client) AnnotateSecondOpinion(ctx context.Context, text string) (*AnnotationResponse, error) {
+	return c.annotate(ctx, text, true)
+}
+
+func (c *Client) annotate(ctx context.Context, text string, secondOpinion bool) (*AnnotationResponse, error) {
 	reqBody := AnnotateRequest{
-		Text: text,
+		Text:          text,
+		SecondOpinion: secondOpinion,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -58,25 +74,20 @@ func (c *Client) AnnotateSingle(ctx context.Context, text string) (*AnnotationRe
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/annotate/single", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/annotate/single", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, httpx.Options{Name: "annotation service", Logger: c.logger})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("annotation service returned status %d", resp.StatusCode)
+		return nil, err
 	}
 
 	var annotationResp AnnotationResponse
-	if err := json.NewDecoder(resp.Body).Decode(&annotationResp); err != nil {
+	if err := json.Unmarshal(body, &annotationResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
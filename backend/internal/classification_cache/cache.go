@@ -0,0 +1,124 @@
+// Package classification_cache provides an in-process LRU cache of classifier
+// results keyed by normalized-content hash. Identical content (the same
+// forwarded scam hitting many chats) is classified once instead of burning an
+// ML/LLM call per copy.
+package classification_cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Stats is a snapshot of cache effectiveness counters.
+type Stats struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	Size    int     `json:"size"`
+}
+
+type entry struct {
+	key      string
+	result   interface{}
+	storedAt time.Time
+}
+
+// Cache is a TTL-bounded LRU cache of classification results. The whole cache
+// is invalidated when the classifier model version changes.
+type Cache struct {
+	mu           sync.Mutex
+	entries      map[string]*list.Element
+	order        *list.List // front = most recently used
+	maxEntries   int
+	ttl          time.Duration
+	modelVersion string
+	hits         uint64
+	misses       uint64
+	logger       *zap.Logger
+}
+
+// New creates a cache holding up to maxEntries results for at most ttl each.
+func New(maxEntries int, ttl time.Duration, logger *zap.Logger) *Cache {
+	return &Cache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		logger:     logger,
+	}
+}
+
+// Get returns the cached result for a content hash, or nil when absent or
+// expired.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	if time.Since(e.storedAt) > c.ttl {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		c.misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return e.result, true
+}
+
+// Put stores a classification result. A model version different from the one
+// the cache was filled with invalidates every existing entry first, so stale
+// results from an older model are never served.
+func (c *Cache) Put(key, modelVersion string, result interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if modelVersion != c.modelVersion {
+		if c.modelVersion != "" {
+			c.logger.Info("Classifier model version changed; invalidating classification cache",
+				zap.String("old_version", c.modelVersion),
+				zap.String("new_version", modelVersion),
+				zap.Int("dropped_entries", len(c.entries)))
+		}
+		c.entries = make(map[string]*list.Element)
+		c.order.Init()
+		c.modelVersion = modelVersion
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*entry).result = result
+		elem.Value.(*entry).storedAt = time.Now()
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, result: result, storedAt: time.Now()})
+
+	for len(c.entries) > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// Stats returns a snapshot of the hit/miss counters and current size.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{Hits: c.hits, Misses: c.misses, Size: len(c.entries)}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
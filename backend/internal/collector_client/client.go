@@ -3,23 +3,49 @@ package collector_client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"backend/internal/httpx"
+
 	"go.uber.org/zap"
 )
 
+// ErrInsufficientVKScope indicates the collector's VK token lacks the
+// messages scope, so private-message endpoints are unavailable until the
+// operator re-authorizes VK with that permission.
+var ErrInsufficientVKScope = errors.New("collector: VK token lacks messages scope")
+
+// ErrVKPeerInaccessible indicates the VK peer behind a chat is deactivated,
+// banned, or otherwise unreadable; the chat should be marked inaccessible and
+// skipped instead of retried every cycle.
+var ErrVKPeerInaccessible = errors.New("collector: VK peer is deactivated or inaccessible")
+
 // Message represents a simplified structure of a message from the collector.
 // This should match the structure returned by the collector's /collect endpoint.
 type Message struct {
 	ID             int64     `json:"id"`
 	ChatID         int64     `json:"chat_id"`
+	SenderID       int64     `json:"sender_id"` // Stable platform ID (Telegram user ID / VK from_id); 0 when unknown
 	SenderUsername string    `json:"sender_username"`
 	Timestamp      time.Time `json:"timestamp"`
 	Text           string    `json:"text"`
-	Type           string    `json:"type"`           // "message", "post", "comment"
-	Source         string    `json:"source"`         // "telegram" or "vk"
+	Truncated      bool      `json:"truncated"` // Content was cut to the collector's length limit
+	Type           string    `json:"type"`      // "message", "post", "comment"
+	Source         string    `json:"source"`    // "telegram" or "vk"
+
+	IsOutgoing bool `json:"is_outgoing,omitempty"` // Sent by the monitored account rather than received
+
+	Forwarded     bool   `json:"forwarded,omitempty"`      // Relayed from another chat or account
+	ForwardedFrom string `json:"forwarded_from,omitempty"` // Original author, when known
+
+	ReplyToMessageID *int64 `json:"reply_to_msg_id,omitempty"` // Source-side ID of the replied-to message
+	ReactionCount    int    `json:"reaction_count,omitempty"`  // Total reactions on the message
+
+	EditedAt *time.Time `json:"edited_at,omitempty"` // Set when the source reports an edit
+	Deleted  bool       `json:"deleted,omitempty"`   // Message was removed at the source
 }
 
 // Chat represents a simplified structure of a chat from the collector.
@@ -27,8 +53,8 @@ type Chat struct {
 	ID      int64  `json:"id"`
 	Name    string `json:"name"`
 	IsGroup bool   `json:"is_group"`
-	Type    string `json:"type"`    // "user", "chat", "channel", "group"
-	Source  string `json:"source"`  // "telegram" or "vk"
+	Type    string `json:"type"`   // "user", "chat", "channel", "group"
+	Source  string `json:"source"` // "telegram" or "vk"
 }
 
 // Client for interacting with the Telegram collector service.
@@ -36,44 +62,86 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	logger     *zap.Logger
+
+	// sem bounds the number of in-flight requests against the collector.
+	// The collector is a single instance shared by every caller in the
+	// backend, so callers queue here instead of stampeding it.
+	sem chan struct{}
 }
 
-// NewClient creates a new Collector API client.
-func NewClient(baseURL string, logger *zap.Logger) *Client {
+// NewClient creates a new Collector API client. maxConcurrent caps the
+// number of simultaneous outbound requests; excess callers wait for a
+// slot or bail out when their context is cancelled.
+func NewClient(baseURL string, maxConcurrent int, logger *zap.Logger) *Client {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 4
+	}
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second, // Configure appropriate timeout
-		},
-		logger: logger,
+		baseURL:    baseURL,
+		httpClient: httpx.NewClient(10 * time.Second),
+		logger:     logger,
+		sem:        make(chan struct{}, maxConcurrent),
 	}
 }
 
-// GetMessages fetches messages from the collector service.
-func (c *Client) GetMessages(ctx context.Context, chatID int64, lastCollectedMessageID int64) ([]Message, error) {
-	url := fmt.Sprintf("%s/telegram/collect?chat_id=%d&last_collected_message_id=%d", c.baseURL, chatID, lastCollectedMessageID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// acquire takes a slot on the concurrency semaphore, blocking until one
+// frees up or ctx is done. The returned release function must be called
+// exactly once when the request finishes.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	select {
+	case c.sem <- struct{}{}:
+		return func() { <-c.sem }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a collector request slot: %w", ctx.Err())
+	}
+}
+
+// Ping checks that the collector service is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	release, err := c.acquire(ctx)
 	if err != nil {
-		c.logger.Error("Failed to create request to collector", zap.Error(err))
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/ping", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create ping request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		c.logger.Error("Failed to make request to collector", zap.Error(err))
-		return nil, fmt.Errorf("failed to make request to collector: %w", err)
+		return fmt.Errorf("failed to ping collector: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("Collector returned non-OK status", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("collector returned status: %d", resp.StatusCode)
+		return fmt.Errorf("collector ping failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetMessages fetches messages from the collector service.
+func (c *Client) GetMessages(ctx context.Context, chatID int64, lastCollectedMessageID int64) ([]Message, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	url := fmt.Sprintf("%s/telegram/collect?chat_id=%d&last_collected_message_id=%d", c.baseURL, chatID, lastCollectedMessageID)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}, httpx.Options{Name: "collector", Logger: c.logger})
+	if err != nil {
+		c.logger.Error("Failed to fetch messages from collector", zap.Error(err))
+		return nil, err
 	}
 
 	var response struct {
 		Messages []Message `json:"messages"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Error("Failed to decode collector response", zap.Error(err))
 		return nil, fmt.Errorf("failed to decode collector response: %w", err)
 	}
@@ -84,29 +152,25 @@ func (c *Client) GetMessages(ctx context.Context, chatID int64, lastCollectedMes
 
 // GetChats fetches all available chats from the collector service.
 func (c *Client) GetChats(ctx context.Context) ([]Chat, error) {
-	url := fmt.Sprintf("%s/telegram/chats", c.baseURL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	release, err := c.acquire(ctx)
 	if err != nil {
-		c.logger.Error("Failed to create request to collector for chats", zap.Error(err))
-		return nil, fmt.Errorf("failed to create request for chats: %w", err)
+		return nil, err
 	}
+	defer release()
 
-	resp, err := c.httpClient.Do(req)
+	url := fmt.Sprintf("%s/telegram/chats", c.baseURL)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}, httpx.Options{Name: "collector", Logger: c.logger})
 	if err != nil {
-		c.logger.Error("Failed to make request to collector for chats", zap.Error(err))
-		return nil, fmt.Errorf("failed to make request to collector for chats: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("Collector returned non-OK status for chats", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("collector returned status for chats: %d", resp.StatusCode)
+		c.logger.Error("Failed to fetch chats from collector", zap.Error(err))
+		return nil, err
 	}
 
 	var response struct {
 		Chats []Chat `json:"chats"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Error("Failed to decode collector chats response", zap.Error(err))
 		return nil, fmt.Errorf("failed to decode collector chats response: %w", err)
 	}
@@ -125,27 +189,23 @@ type VKAuthURLResponse struct {
 
 // GetVKAuthURL fetches VK OAuth authorization URL from the collector
 func (c *Client) GetVKAuthURL(ctx context.Context) (*VKAuthURLResponse, error) {
-	url := fmt.Sprintf("%s/vk/auth/url", c.baseURL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	release, err := c.acquire(ctx)
 	if err != nil {
-		c.logger.Error("Failed to create request for VK auth URL", zap.Error(err))
-		return nil, fmt.Errorf("failed to create request for VK auth URL: %w", err)
+		return nil, err
 	}
+	defer release()
 
-	resp, err := c.httpClient.Do(req)
+	url := fmt.Sprintf("%s/vk/auth/url", c.baseURL)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}, httpx.Options{Name: "collector", Logger: c.logger})
 	if err != nil {
-		c.logger.Error("Failed to make request for VK auth URL", zap.Error(err))
-		return nil, fmt.Errorf("failed to make request for VK auth URL: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("Collector returned non-OK status for VK auth URL", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("collector returned status for VK auth URL: %d", resp.StatusCode)
+		c.logger.Error("Failed to fetch VK auth URL from collector", zap.Error(err))
+		return nil, err
 	}
 
 	var response VKAuthURLResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Error("Failed to decode VK auth URL response", zap.Error(err))
 		return nil, fmt.Errorf("failed to decode VK auth URL response: %w", err)
 	}
@@ -155,104 +215,117 @@ func (c *Client) GetVKAuthURL(ctx context.Context) (*VKAuthURLResponse, error) {
 
 // GetVKConversations fetches all available VK conversations from the collector service.
 func (c *Client) GetVKConversations(ctx context.Context) ([]Chat, error) {
-	url := fmt.Sprintf("%s/vk/conversations", c.baseURL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	release, err := c.acquire(ctx)
 	if err != nil {
-		c.logger.Error("Failed to create request to collector for VK conversations", zap.Error(err))
-		return nil, fmt.Errorf("failed to create request for VK conversations: %w", err)
+		return nil, err
 	}
+	defer release()
 
-	resp, err := c.httpClient.Do(req)
+	url := fmt.Sprintf("%s/vk/conversations", c.baseURL)
+	body, status, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}, httpx.Options{Name: "collector", Logger: c.logger})
 	if err != nil {
-		c.logger.Error("Failed to make request to collector for VK conversations", zap.Error(err))
-		return nil, fmt.Errorf("failed to make request to collector for VK conversations: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("Collector returned non-OK status for VK conversations", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("collector returned status for VK conversations: %d", resp.StatusCode)
+		if status == http.StatusForbidden {
+			c.logger.Warn("Collector reports VK token lacks messages scope", zap.Error(err))
+			return nil, ErrInsufficientVKScope
+		}
+		c.logger.Error("Failed to fetch VK conversations from collector", zap.Error(err))
+		return nil, err
 	}
 
 	var response struct {
-		Conversations []struct {
-			ID      int64  `json:"id"`
-			Name    string `json:"name"`
-			IsGroup bool   `json:"is_group"`
-			Type    string `json:"type"`
-		} `json:"conversations"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		Conversations []Chat `json:"conversations"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Error("Failed to decode VK conversations response", zap.Error(err))
 		return nil, fmt.Errorf("failed to decode VK conversations response: %w", err)
 	}
 
-	// Convert to Chat structs with source="vk"
-	chats := make([]Chat, len(response.Conversations))
-	for i, conv := range response.Conversations {
-		chats[i] = Chat{
-			ID:      conv.ID,
-			Name:    conv.Name,
-			IsGroup: conv.IsGroup,
-			Type:    conv.Type,
-			Source:  "vk",
-		}
+	// The collector now ships the canonical chat shape; just stamp the source
+	// in case an older collector omits it.
+	chats := response.Conversations
+	for i := range chats {
+		chats[i].Source = "vk"
 	}
 
 	c.logger.Info("Successfully fetched VK conversations from collector", zap.Int("count", len(chats)))
 	return chats, nil
 }
 
-// GetVKMessages fetches messages from a specific VK conversation.
-func (c *Client) GetVKMessages(ctx context.Context, peerID int64, lastMessageID int64) ([]Message, error) {
-	url := fmt.Sprintf("%s/vk/messages/collect?peer_id=%d&last_message_id=%d", c.baseURL, peerID, lastMessageID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// GetVKAccountID returns the VK user ID of the account the collector is
+// authorized as. The backend uses it to associate VK chats with the
+// monitored entity that owns that account.
+func (c *Client) GetVKAccountID(ctx context.Context) (int64, error) {
+	release, err := c.acquire(ctx)
 	if err != nil {
-		c.logger.Error("Failed to create request to collector for VK messages", zap.Error(err))
-		return nil, fmt.Errorf("failed to create request for VK messages: %w", err)
+		return 0, err
 	}
+	defer release()
 
-	resp, err := c.httpClient.Do(req)
+	url := fmt.Sprintf("%s/vk/account", c.baseURL)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}, httpx.Options{Name: "collector", Logger: c.logger})
 	if err != nil {
-		c.logger.Error("Failed to make request to collector for VK messages", zap.Error(err))
-		return nil, fmt.Errorf("failed to make request to collector for VK messages: %w", err)
+		c.logger.Error("Failed to fetch VK account ID from collector", zap.Error(err))
+		return 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("Collector returned non-OK status for VK messages", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("collector returned status for VK messages: %d", resp.StatusCode)
+	var response struct {
+		SelfID int64 `json:"self_id"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		c.logger.Error("Failed to decode VK account response", zap.Error(err))
+		return 0, fmt.Errorf("failed to decode VK account response: %w", err)
+	}
+	if response.SelfID == 0 {
+		return 0, fmt.Errorf("collector returned an empty VK account ID")
+	}
+
+	return response.SelfID, nil
+}
+
+// GetVKMessages fetches messages from a specific VK conversation.
+func (c *Client) GetVKMessages(ctx context.Context, peerID int64, lastMessageID int64) ([]Message, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	url := fmt.Sprintf("%s/vk/messages/collect?peer_id=%d&last_message_id=%d", c.baseURL, peerID, lastMessageID)
+	body, status, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}, httpx.Options{Name: "collector", Logger: c.logger})
+	if err != nil {
+		if status == http.StatusForbidden {
+			c.logger.Warn("Collector reports VK token lacks messages scope", zap.Error(err))
+			return nil, ErrInsufficientVKScope
+		}
+		if status == http.StatusGone {
+			c.logger.Warn("Collector reports VK peer is inaccessible", zap.Int64("peer_id", peerID), zap.Error(err))
+			return nil, ErrVKPeerInaccessible
+		}
+		c.logger.Error("Failed to fetch VK messages from collector", zap.Error(err))
+		return nil, err
 	}
 
 	var response struct {
-		Messages []struct {
-			ID             int64     `json:"id"`
-			ChatID         int64     `json:"chat_id"`
-			SenderUsername string    `json:"sender_username"`
-			Timestamp      time.Time `json:"timestamp"`
-			Text           string    `json:"text"`
-			Type           string    `json:"type"`
-		} `json:"messages"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		Messages []Message `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Error("Failed to decode VK messages response", zap.Error(err))
 		return nil, fmt.Errorf("failed to decode VK messages response: %w", err)
 	}
 
-	// Convert to Message structs with source="vk"
-	messages := make([]Message, len(response.Messages))
-	for i, msg := range response.Messages {
-		messages[i] = Message{
-			ID:             msg.ID,
-			ChatID:         msg.ChatID,
-			SenderUsername: msg.SenderUsername,
-			Timestamp:      msg.Timestamp,
-			Text:           msg.Text,
-			Type:           msg.Type,
-			Source:         "vk",
-		}
+	// The collector now ships the canonical message shape (including the
+	// truncated flag); just stamp the source in case an older collector omits it.
+	messages := response.Messages
+	for i := range messages {
+		messages[i].Source = "vk"
 	}
 
 	c.logger.Info("Successfully fetched VK messages from collector", zap.Int("count", len(messages)))
 	return messages, nil
-}
\ No newline at end of file
+}
@@ -3,20 +3,126 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// QuietWindow is one quiet-hours window with "HH:MM" bounds in Timezone; it
+// may span midnight (e.g. 22:00–07:00). Timezone is an IANA name; empty
+// means the server's local zone.
+type QuietWindow struct {
+	Start    string `yaml:"start"`
+	End      string `yaml:"end"`
+	Timezone string `yaml:"timezone"`
+}
+
+// SensitivityProfile tunes detection for one conversation type (e.g. a
+// gaming group vs. a direct message). Categories listed as muted never
+// produce incidents under the profile; per-category thresholds raise the
+// minimum model confidence required for an incident.
+type SensitivityProfile struct {
+	MutedCategories    []int           `yaml:"muted_categories"`
+	CategoryThresholds map[int]float64 `yaml:"category_thresholds"`
+}
+
 // Config holds the application's configuration.
 type Config struct {
 	Database struct {
 		URL string `yaml:"url"`
 	} `yaml:"database"`
 	Collector struct {
-		URL                string `yaml:"url"`
-		PollInterval       int64  `yaml:"poll_interval_seconds"`
-		ChatProcessDelay   int64  `yaml:"chat_process_delay_seconds"`
+		URL              string `yaml:"url"`
+		PollInterval     int64  `yaml:"poll_interval_seconds"`
+		ChatProcessDelay int64  `yaml:"chat_process_delay_seconds"`
+		// DrainTimeoutSeconds bounds the shutdown drain: after a stop
+		// signal the processor finishes in-flight work for at most this
+		// long before exiting (default 10).
+		DrainTimeoutSeconds int64 `yaml:"drain_timeout_seconds"`
+		// QuietIdleLogging demotes the per-chat "no new messages" and
+		// fetch lines to debug and logs one "N chats idle" summary per
+		// cycle instead, so idle cycles do not spam the info log.
+		// Defaults to true when omitted.
+		QuietIdleLogging *bool `yaml:"quiet_idle_logging"`
+		// MaxConcurrentRequests caps how many requests the backend keeps
+		// in flight against the collector at once; excess callers queue on
+		// a shared semaphore. The collector is a single instance, so this
+		// protects it from a stampede if chat processing is parallelized.
+		// Defaults to 4.
+		MaxConcurrentRequests int `yaml:"max_concurrent_requests"`
 	} `yaml:"collector"`
+	Classification struct {
+		// RecordNeutral stores category-9 (neutral) classifications in the
+		// classified_messages table for baselining. Off by default.
+		RecordNeutral bool `yaml:"record_neutral"`
+		// SessionGapSeconds groups consecutive flagged messages in the same
+		// chat into one session incident when they arrive within this gap of
+		// each other, instead of one incident per message. 0 disables
+		// grouping.
+		SessionGapSeconds int64 `yaml:"session_gap_seconds"`
+		// FalsePositiveSuppressSeconds suppresses new incidents whose
+		// content matches an incident a reviewer marked false_positive
+		// within this window, so recurring benign content is not re-flagged
+		// after being dismissed. 0 disables suppression.
+		FalsePositiveSuppressSeconds int64 `yaml:"false_positive_suppress_seconds"`
+		// NeutralSecondOpinion re-annotates a message once when the LLM says
+		// Neutral but cheap pre-signals (high urgency score, first contact)
+		// indicate risk. Off by default: every second opinion is an extra
+		// paid LLM call.
+		NeutralSecondOpinion bool `yaml:"neutral_second_opinion"`
+		// AnnotationDefaultConfidence is stored as the incident confidence
+		// when the LLM annotation reports none, so annotation and ML
+		// incidents stay comparable instead of every LLM label counting as
+		// a certain 1.0 (default 0.75).
+		AnnotationDefaultConfidence float64 `yaml:"annotation_default_confidence"`
+		// HighConfidenceThreshold is the model confidence above which the
+		// dashboard treats an incident as high confidence.
+		HighConfidenceThreshold float64 `yaml:"high_confidence_threshold"`
+		// UrgencyLexicon adds phrases to the built-in urgency/pressure lexicon
+		// used by the pre-classifier signal scorer.
+		UrgencyLexicon []string `yaml:"urgency_lexicon"`
+		// MaxIncidentsPerChatPerCycle caps incidents created for one chat in
+		// one polling cycle; incidents beyond the cap collapse into a single
+		// aggregate "burst" incident (default 20, 0 disables the cap).
+		MaxIncidentsPerChatPerCycle int `yaml:"max_incidents_per_chat_per_cycle"`
+		// CacheSize bounds the in-process classification result cache keyed
+		// by normalized-content hash (default 1000 entries).
+		CacheSize int `yaml:"classification_cache_size"`
+		// CacheTTLSeconds expires cached classification results (default 3600).
+		CacheTTLSeconds int64 `yaml:"classification_cache_ttl_seconds"`
+		// GenerateSummaries stores a concise neutral summary as the incident
+		// summary instead of the raw message text, so reviewers see the gist
+		// without the sensitive content. The raw text stays access-gated in
+		// the messages table. Off by default.
+		GenerateSummaries bool `yaml:"generate_summaries"`
+		// MLBatchSize enables cross-chat request batching to the ML
+		// service: messages from every chat in a cycle accumulate until
+		// this many are buffered (or the window elapses) and go out as one
+		// batch call. 0 keeps per-message classification.
+		MLBatchSize int `yaml:"ml_batch_size"`
+		// MLDatasetBatchSize buffers ML dataset entries and writes them
+		// with one multi-row insert once this many accumulate
+		// (default 50).
+		MLDatasetBatchSize int `yaml:"ml_dataset_batch_size"`
+		// MLDatasetFlushSeconds bounds how long buffered dataset entries
+		// wait before a flush regardless of batch size (default 5).
+		MLDatasetFlushSeconds int64 `yaml:"ml_dataset_flush_seconds"`
+		// MLBatchWindowSeconds bounds how long buffered messages wait
+		// before a partial batch is flushed (default 2).
+		MLBatchWindowSeconds int64 `yaml:"ml_batch_window_seconds"`
+		// SensitivityProfiles are named per-chat detection profiles,
+		// assigned via PUT /api/chats/:id/profile.
+		SensitivityProfiles map[string]SensitivityProfile `yaml:"sensitivity_profiles"`
+	} `yaml:"classification"`
+	Filtering struct {
+		// IgnoreOutgoing stores the child's own outgoing messages without
+		// classifying them. Off by default.
+		IgnoreOutgoing bool `yaml:"ignore_outgoing"`
+		// IgnoredSenders lists sender usernames (bots, service accounts)
+		// whose messages are stored but never classified. Matching is
+		// case-insensitive.
+		IgnoredSenders []string `yaml:"ignored_senders"`
+	} `yaml:"filtering"`
 	MLService struct {
 		URL string `yaml:"url"`
 	} `yaml:"ml_service"`
@@ -27,7 +133,90 @@ type Config struct {
 	AccessControl struct {
 		Enabled          bool   `yaml:"enabled"`
 		TelegramBotToken string `yaml:"telegram_bot_token"`
+		// AutoApproveAdmins grants incident access immediately for admin/trusted
+		// parents without asking the child. Off by default.
+		AutoApproveAdmins bool `yaml:"auto_approve_admins"`
+		// NotificationDedupSeconds suppresses a repeat bot notification for the
+		// same child and incident within this window (default 30).
+		NotificationDedupSeconds int64 `yaml:"notification_dedup_seconds"`
 	} `yaml:"access_control"`
+	Notifications struct {
+		// Enabled turns on incident alert routing through the Telegram bot.
+		Enabled bool `yaml:"enabled"`
+		// DefaultRecipients (Telegram chat IDs) receive alerts for
+		// categories without an explicit route, and for aggregate burst
+		// incidents.
+		DefaultRecipients []int64 `yaml:"default_recipients"`
+		// Routes maps a threat category ID to the Telegram chat IDs
+		// alerted when an incident of that category is created.
+		Routes map[int][]int64 `yaml:"routes"`
+		// QuietHours defers non-critical alerts raised inside the window
+		// into a per-recipient digest delivered when the window ends.
+		QuietHours struct {
+			Enabled bool `yaml:"enabled"`
+			// Default is the window applied to every recipient
+			// without an override.
+			Default QuietWindow `yaml:"default"`
+			// Recipients overrides the window per recipient chat ID.
+			Recipients map[int64]QuietWindow `yaml:"recipients"`
+			// CriticalCategories alert immediately regardless of the
+			// window (default grooming and suicide inducement).
+			CriticalCategories []int `yaml:"critical_categories"`
+		} `yaml:"quiet_hours"`
+	} `yaml:"notifications"`
+	HTTPClient struct {
+		// MaxIdleConnsPerHost bounds pooled keep-alive connections per host
+		// for outbound service clients (default 10).
+		MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host"`
+		// IdleConnTimeoutSeconds closes pooled connections idle this long
+		// (default 90).
+		IdleConnTimeoutSeconds int64 `yaml:"idle_conn_timeout_seconds"`
+		// ProxyURL routes outbound requests through an HTTP proxy; empty
+		// falls back to the standard proxy environment variables.
+		ProxyURL string `yaml:"proxy_url"`
+	} `yaml:"http_client"`
+	Monitoring struct {
+		// Enabled is the global monitoring kill switch: when false, the
+		// processor skips collection, classification, and incident creation
+		// entirely. Defaults to true when omitted.
+		Enabled *bool `yaml:"enabled"`
+		// ObserveOnlyNewChatHours starts newly discovered chats in an
+		// observe-only warm-up for this many hours: their messages are
+		// classified and incidents stored, but no alerts are sent until the
+		// period elapses or a parent confirms monitoring for the chat. 0
+		// (the default) alerts on new chats immediately, as before.
+		ObserveOnlyNewChatHours int64 `yaml:"observe_only_new_chat_hours"`
+	} `yaml:"monitoring"`
+	SLA struct {
+		// Enabled turns on the periodic overdue-review check that alerts
+		// the default notification recipients about breached SLAs. The
+		// /api/events/overdue endpoint works regardless of this flag.
+		Enabled bool `yaml:"enabled"`
+		// ReviewMinutes maps a severity ("high", "medium", "low") to the
+		// maximum time an incident of that severity may sit in status
+		// "new" before its review is overdue. A severity absent from the
+		// map has no SLA.
+		ReviewMinutes map[string]int64 `yaml:"review_minutes"`
+		// HighConfidence and MediumConfidence are the model-confidence
+		// boundaries between the severity buckets (defaults 0.85 / 0.6).
+		HighConfidence   float64 `yaml:"high_confidence"`
+		MediumConfidence float64 `yaml:"medium_confidence"`
+		// CheckIntervalSeconds is how often the overdue check runs
+		// (default 300).
+		CheckIntervalSeconds int64 `yaml:"check_interval_seconds"`
+	} `yaml:"sla"`
+	Readiness struct {
+		// WaitTimeoutSeconds bounds how long startup waits for the
+		// collector/ML/annotation services before the processor starts
+		// anyway (default 60).
+		WaitTimeoutSeconds int64 `yaml:"wait_timeout_seconds"`
+		// ProbeTimeoutSeconds bounds each individual dependency probe
+		// (default 5).
+		ProbeTimeoutSeconds int64 `yaml:"probe_timeout_seconds"`
+	} `yaml:"readiness"`
+	Tagging struct {
+		SeedTags []string `yaml:"seed_tags"`
+	} `yaml:"tagging"`
 	Server struct {
 		Port string `yaml:"port"`
 	} `yaml:"server"`
@@ -48,5 +237,123 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to decode config file: %w", err)
 	}
 
+	if config.Classification.AnnotationDefaultConfidence == 0 {
+		config.Classification.AnnotationDefaultConfidence = 0.75
+	}
+
+	if config.Classification.HighConfidenceThreshold == 0 {
+		config.Classification.HighConfidenceThreshold = 0.8
+	}
+
+	if config.Classification.CacheSize == 0 {
+		config.Classification.CacheSize = 1000
+	}
+
+	if config.Classification.CacheTTLSeconds == 0 {
+		config.Classification.CacheTTLSeconds = 3600
+	}
+
+	if config.Classification.MaxIncidentsPerChatPerCycle == 0 {
+		config.Classification.MaxIncidentsPerChatPerCycle = 20
+	}
+
+	// Timing values are validated together: zero falls back to a sane
+	// default, negative fails startup — a non-positive poll interval would
+	// either panic the ticker or hammer the collector in a tight loop.
+	if config.Collector.PollInterval == 0 {
+		config.Collector.PollInterval = 60
+	}
+	if config.Collector.PollInterval < 0 {
+		return nil, fmt.Errorf("collector.poll_interval_seconds must be positive, got %d", config.Collector.PollInterval)
+	}
+	if config.Collector.ChatProcessDelay < 0 {
+		return nil, fmt.Errorf("collector.chat_process_delay_seconds must not be negative, got %d", config.Collector.ChatProcessDelay)
+	}
+	if config.Collector.DrainTimeoutSeconds == 0 {
+		config.Collector.DrainTimeoutSeconds = 10
+	}
+	if config.Collector.DrainTimeoutSeconds < 0 {
+		return nil, fmt.Errorf("collector.drain_timeout_seconds must be positive, got %d", config.Collector.DrainTimeoutSeconds)
+	}
+
+	if config.Classification.MLBatchWindowSeconds == 0 {
+		config.Classification.MLBatchWindowSeconds = 2
+	}
+
+	if config.Classification.MLDatasetBatchSize == 0 {
+		config.Classification.MLDatasetBatchSize = 50
+	}
+
+	if config.Classification.MLDatasetFlushSeconds == 0 {
+		config.Classification.MLDatasetFlushSeconds = 5
+	}
+
+	if config.Monitoring.Enabled == nil {
+		enabled := true
+		config.Monitoring.Enabled = &enabled
+	}
+
+	if config.Collector.QuietIdleLogging == nil {
+		quiet := true
+		config.Collector.QuietIdleLogging = &quiet
+	}
+
+	if config.Collector.MaxConcurrentRequests == 0 {
+		config.Collector.MaxConcurrentRequests = 4
+	}
+	if config.Collector.MaxConcurrentRequests < 0 {
+		return nil, fmt.Errorf("collector.max_concurrent_requests must be positive, got %d", config.Collector.MaxConcurrentRequests)
+	}
+
+	if config.Readiness.WaitTimeoutSeconds == 0 {
+		config.Readiness.WaitTimeoutSeconds = 60
+	}
+
+	if config.Readiness.ProbeTimeoutSeconds == 0 {
+		config.Readiness.ProbeTimeoutSeconds = 5
+	}
+
+	if config.SLA.ReviewMinutes == nil {
+		config.SLA.ReviewMinutes = map[string]int64{"high": 60, "medium": 240, "low": 1440}
+	}
+
+	if config.SLA.HighConfidence == 0 {
+		config.SLA.HighConfidence = 0.85
+	}
+
+	if config.SLA.MediumConfidence == 0 {
+		config.SLA.MediumConfidence = 0.6
+	}
+
+	if config.SLA.CheckIntervalSeconds == 0 {
+		config.SLA.CheckIntervalSeconds = 300
+	}
+
+	if config.AccessControl.NotificationDedupSeconds == 0 {
+		config.AccessControl.NotificationDedupSeconds = 30
+	}
+
+	// Grooming (1) and suicide inducement (4) break through quiet hours
+	// unless the deployment says otherwise.
+	if config.Notifications.QuietHours.CriticalCategories == nil {
+		config.Notifications.QuietHours.CriticalCategories = []int{1, 4}
+	}
+
 	return config, nil
 }
+
+// PollIntervalDuration returns the collector poll interval with units
+// applied, so callers never multiply raw seconds themselves.
+func (c *Config) PollIntervalDuration() time.Duration {
+	return time.Duration(c.Collector.PollInterval) * time.Second
+}
+
+// ChatProcessDelayDuration returns the between-chats processing delay.
+func (c *Config) ChatProcessDelayDuration() time.Duration {
+	return time.Duration(c.Collector.ChatProcessDelay) * time.Second
+}
+
+// DrainTimeoutDuration returns the shutdown drain budget.
+func (c *Config) DrainTimeoutDuration() time.Duration {
+	return time.Duration(c.Collector.DrainTimeoutSeconds) * time.Second
+}
@@ -0,0 +1,62 @@
+// Package conversation gives chats a typed source-plus-ID key so the code
+// that dispatches on chat source works with one validated identifier instead
+// of re-checking source strings and nullable ID columns at every call site.
+// It mirrors the collector's conventions: VK group walls have negative peer
+// IDs and multi-user chats sit past VK's chat peer-ID base.
+package conversation
+
+import (
+	"fmt"
+
+	"backend/internal/models"
+)
+
+// Source identifiers, matching the values stored on chats and messages.
+const (
+	SourceTelegram = "telegram"
+	SourceVK       = "vk"
+)
+
+// vkChatPeerBase is VK's peer-ID offset for multi-user chats:
+// peer_id = 2000000000 + local_id.
+const vkChatPeerBase = 2000000000
+
+// Key identifies one conversation in its source's canonical form: the chat
+// ID for Telegram, the peer ID for VK.
+type Key struct {
+	Source string `json:"source"`
+	ID     int64  `json:"id"`
+}
+
+// FromChat builds the key for a stored chat, validating that the chat
+// actually carries the ID its source requires.
+func FromChat(chat *models.Chat) (Key, error) {
+	switch chat.Source {
+	case SourceTelegram:
+		if chat.TelegramID == nil {
+			return Key{}, fmt.Errorf("telegram chat %d has no telegram_id", chat.ID)
+		}
+		return Key{Source: SourceTelegram, ID: *chat.TelegramID}, nil
+	case SourceVK:
+		if chat.VKPeerID == nil {
+			return Key{}, fmt.Errorf("vk chat %d has no vk_peer_id", chat.ID)
+		}
+		return Key{Source: SourceVK, ID: *chat.VKPeerID}, nil
+	default:
+		return Key{}, fmt.Errorf("chat %d has unknown source %q", chat.ID, chat.Source)
+	}
+}
+
+// VKPeerType classifies a VK peer ID the way VK's API does: group walls are
+// negative, multi-user chats sit past the chat peer-ID base, everything else
+// is a direct user dialog.
+func VKPeerType(peerID int64) string {
+	switch {
+	case peerID < 0:
+		return "group"
+	case peerID > vkChatPeerBase:
+		return "chat"
+	default:
+		return "user"
+	}
+}
@@ -56,32 +56,39 @@ func NewAccessRequestHandler(
 func (h *accessRequestHandler) CreateAccessRequest(c *gin.Context) {
 	// Check if access control is enabled
 	if !h.cfg.AccessControl.Enabled {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access control is disabled"})
+		respondError(c, http.StatusForbidden, CodeForbidden, "Access control is disabled")
 		return
 	}
 
 	var input models.CreateAccessRequestInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	scope, err := models.NormalizeAccessScopes(input.Scopes)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Get current user (parent) from context
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	parent, err := h.authRepo.GetUserByUsername(username.(string))
 	if err != nil {
 		h.logger.Error("Failed to get parent user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get user")
 		return
 	}
 
-	if parent.Role != "parent" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only parents can request access"})
+	isTrusted := parent.Role == "admin" || parent.Role == "trusted"
+	if parent.Role != "parent" && !isTrusted {
+		respondError(c, http.StatusForbidden, CodeForbidden, "Only parents can request access")
 		return
 	}
 
@@ -89,12 +96,12 @@ func (h *accessRequestHandler) CreateAccessRequest(c *gin.Context) {
 	incident, err := h.messageRepo.GetIncidentByID(input.IncidentID)
 	if err != nil {
 		h.logger.Error("Failed to get incident", zap.Int64("incident_id", input.IncidentID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get incident"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get incident")
 		return
 	}
 
 	if incident == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		respondError(c, http.StatusNotFound, CodeNotFound, "Incident not found")
 		return
 	}
 
@@ -102,12 +109,16 @@ func (h *accessRequestHandler) CreateAccessRequest(c *gin.Context) {
 	existingRequest, err := h.accessRequestRepo.GetByIncidentID(input.IncidentID)
 	if err != nil {
 		h.logger.Error("Failed to check existing request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing request"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to check existing request")
 		return
 	}
 
 	if existingRequest != nil && (existingRequest.Status == "pending" || existingRequest.Status == "approved") {
-		c.JSON(http.StatusConflict, gin.H{"error": "Access request already exists", "request": existingRequest})
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Code:    CodeConflict,
+			Message: "Access request already exists",
+			Details: gin.H{"request": existingRequest},
+		})
 		return
 	}
 
@@ -126,18 +137,52 @@ func (h *accessRequestHandler) CreateAccessRequest(c *gin.Context) {
 		ParentID:    parent.ID,
 		ChildID:     parent.ID, // TODO: Replace with actual child ID
 		Status:      "pending",
+		Scope:       scope,
 		RequestedAt: time.Now(),
 	}
 
 	err = h.accessRequestRepo.Create(accessRequest)
 	if err != nil {
 		h.logger.Error("Failed to create access request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create access request"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to create access request")
+		return
+	}
+
+	// Auto-approve path for admin/trusted parents: grant access immediately
+	// without involving the child. Audit-logged so approvals stay traceable.
+	if h.cfg.AccessControl.AutoApproveAdmins && isTrusted {
+		now := time.Now()
+		if err := h.accessRequestRepo.UpdateStatus(accessRequest.ID, "approved", now); err != nil {
+			h.logger.Error("Failed to auto-approve access request", zap.Error(err))
+			respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to approve access request")
+			return
+		}
+		accessRequest.Status = "approved"
+		accessRequest.RespondedAt = &now
+
+		if err := h.messageRepo.UpdateIncidentAccessGranted(input.IncidentID, true, &accessRequest.ID, scope); err != nil {
+			h.logger.Error("Failed to grant access to incident", zap.Error(err))
+			// Continue anyway, the request was approved
+		}
+
+		h.logger.Info("AUDIT: access request auto-approved",
+			zap.Int64("request_id", accessRequest.ID),
+			zap.Int64("incident_id", input.IncidentID),
+			zap.Int64("parent_id", parent.ID),
+			zap.String("scope", scope),
+			zap.String("role", parent.Role))
+
+		c.JSON(http.StatusCreated, gin.H{
+			"message":       "Access request auto-approved",
+			"request":       accessRequest,
+			"auto_approved": true,
+		})
 		return
 	}
 
-	// Update incident with the request ID
-	err = h.messageRepo.UpdateIncidentAccessGranted(input.IncidentID, false, &accessRequest.ID)
+	// Update incident with the request ID (and the requested scope, applied
+	// once the child approves)
+	err = h.messageRepo.UpdateIncidentAccessGranted(input.IncidentID, false, &accessRequest.ID, scope)
 	if err != nil {
 		h.logger.Error("Failed to update incident", zap.Error(err))
 		// Continue anyway, the request was created
@@ -188,19 +233,19 @@ func (h *accessRequestHandler) GetAccessRequestStatus(c *gin.Context) {
 	incidentIDStr := c.Param("id")
 	incidentID, err := strconv.ParseInt(incidentIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incident ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid incident ID")
 		return
 	}
 
 	request, err := h.accessRequestRepo.GetByIncidentID(incidentID)
 	if err != nil {
 		h.logger.Error("Failed to get access request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access request"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get access request")
 		return
 	}
 
 	if request == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No access request found"})
+		respondError(c, http.StatusNotFound, CodeNotFound, "No access request found")
 		return
 	}
 
@@ -212,21 +257,21 @@ func (h *accessRequestHandler) ApproveAccessRequest(c *gin.Context) {
 	requestIDStr := c.Param("id")
 	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request ID")
 		return
 	}
 
 	// Get current user (child) from context
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	user, err := h.authRepo.GetUserByUsername(username.(string))
 	if err != nil {
 		h.logger.Error("Failed to get user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get user")
 		return
 	}
 
@@ -234,23 +279,23 @@ func (h *accessRequestHandler) ApproveAccessRequest(c *gin.Context) {
 	request, err := h.accessRequestRepo.GetByID(requestID)
 	if err != nil {
 		h.logger.Error("Failed to get access request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access request"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get access request")
 		return
 	}
 
 	if request == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Access request not found"})
+		respondError(c, http.StatusNotFound, CodeNotFound, "Access request not found")
 		return
 	}
 
 	// Verify that the user is the child for this request
 	if request.ChildID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to approve this request"})
+		respondError(c, http.StatusForbidden, CodeForbidden, "You are not authorized to approve this request")
 		return
 	}
 
 	if request.Status != "pending" {
-		c.JSON(http.StatusConflict, gin.H{"error": "Request is not pending"})
+		respondError(c, http.StatusConflict, CodeConflict, "Request is not pending")
 		return
 	}
 
@@ -259,12 +304,12 @@ func (h *accessRequestHandler) ApproveAccessRequest(c *gin.Context) {
 	err = h.accessRequestRepo.UpdateStatus(requestID, "approved", now)
 	if err != nil {
 		h.logger.Error("Failed to update request status", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve request"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to approve request")
 		return
 	}
 
-	// Update incident access_granted
-	err = h.messageRepo.UpdateIncidentAccessGranted(request.IncidentID, true, &requestID)
+	// Update incident access_granted with the scope the parent asked for
+	err = h.messageRepo.UpdateIncidentAccessGranted(request.IncidentID, true, &requestID, request.Scope)
 	if err != nil {
 		h.logger.Error("Failed to grant access to incident", zap.Error(err))
 		// Continue anyway
@@ -280,21 +325,21 @@ func (h *accessRequestHandler) RejectAccessRequest(c *gin.Context) {
 	requestIDStr := c.Param("id")
 	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request ID")
 		return
 	}
 
 	// Get current user (child) from context
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	user, err := h.authRepo.GetUserByUsername(username.(string))
 	if err != nil {
 		h.logger.Error("Failed to get user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get user")
 		return
 	}
 
@@ -302,23 +347,23 @@ func (h *accessRequestHandler) RejectAccessRequest(c *gin.Context) {
 	request, err := h.accessRequestRepo.GetByID(requestID)
 	if err != nil {
 		h.logger.Error("Failed to get access request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access request"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get access request")
 		return
 	}
 
 	if request == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Access request not found"})
+		respondError(c, http.StatusNotFound, CodeNotFound, "Access request not found")
 		return
 	}
 
 	// Verify that the user is the child for this request
 	if request.ChildID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to reject this request"})
+		respondError(c, http.StatusForbidden, CodeForbidden, "You are not authorized to reject this request")
 		return
 	}
 
 	if request.Status != "pending" {
-		c.JSON(http.StatusConflict, gin.H{"error": "Request is not pending"})
+		respondError(c, http.StatusConflict, CodeConflict, "Request is not pending")
 		return
 	}
 
@@ -327,7 +372,7 @@ func (h *accessRequestHandler) RejectAccessRequest(c *gin.Context) {
 	err = h.accessRequestRepo.UpdateStatus(requestID, "rejected", now)
 	if err != nil {
 		h.logger.Error("Failed to update request status", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject request"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to reject request")
 		return
 	}
 
@@ -341,21 +386,21 @@ func (h *accessRequestHandler) GetPendingRequests(c *gin.Context) {
 	// Get current user (child) from context
 	username, exists := c.Get("username")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	user, err := h.authRepo.GetUserByUsername(username.(string))
 	if err != nil {
 		h.logger.Error("Failed to get user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get user")
 		return
 	}
 
 	requests, err := h.accessRequestRepo.GetPendingByChildID(user.ID)
 	if err != nil {
 		h.logger.Error("Failed to get pending requests", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pending requests"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get pending requests")
 		return
 	}
 
@@ -4,6 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"backend/internal/classification_cache"
+	"backend/internal/config"
+	"backend/internal/models"
 	"backend/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -12,49 +15,48 @@ import (
 
 type AnalyticsHandler interface {
 	GetDashboard(c *gin.Context)
+	GetConfidenceDistribution(c *gin.Context)
+	GetClassifierCacheStats(c *gin.Context)
 }
 
 type analyticsHandler struct {
 	messageRepo repository.MessageRepository
 	chatRepo    repository.ChatRepository
+	cfg         *config.Config
+	clsCache    *classification_cache.Cache
 	logger      *zap.Logger
 }
 
-func NewAnalyticsHandler(messageRepo repository.MessageRepository, chatRepo repository.ChatRepository, logger *zap.Logger) AnalyticsHandler {
+func NewAnalyticsHandler(messageRepo repository.MessageRepository, chatRepo repository.ChatRepository, cfg *config.Config, clsCache *classification_cache.Cache, logger *zap.Logger) AnalyticsHandler {
 	return &analyticsHandler{
 		messageRepo: messageRepo,
 		chatRepo:    chatRepo,
+		cfg:         cfg,
+		clsCache:    clsCache,
 		logger:      logger,
 	}
 }
 
-// DashboardStats represents the statistics for the dashboard
-type DashboardStats struct {
-	TotalIncidents       int                       `json:"total_incidents"`
-	NewIncidents         int                       `json:"new_incidents"`
-	ReviewedIncidents    int                       `json:"reviewed_incidents"`
-	FalsePositives       int                       `json:"false_positives"`
-	TotalChats           int                       `json:"total_chats"`
-	ActiveChats          int                       `json:"active_chats"`
-	IncidentsByThreat    map[string]int            `json:"incidents_by_threat"`
-	CategoryDistribution map[int]int               `json:"category_distribution"`  // Category ID -> Count
-	TotalMessages        int                       `json:"total_messages"`
-	DetectionRate        float64                   `json:"detection_rate"`
-	Incidents24h         int                       `json:"incidents_24h"`
-	RecentIncidents      interface{}               `json:"recent_incidents"`
+// GetClassifierCacheStats handles GET /api/analytics/classifier-cache
+// Reports hit/miss counters of the classification result cache.
+func (h *analyticsHandler) GetClassifierCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.clsCache.Stats())
 }
 
-// Threat type to category ID mapping
-var threatTypeToCategoryID = map[string]int{
-	"Склонение к сексуальным действиям (Груминг)":            1,
-	"Угрозы, шантаж, вымогательство":                         2,
-	"Физическое насилие/Буллинг":                             3,
-	"Склонение к суициду/Самоповреждение":                    4,
-	"Склонение к опасным играм/действиям":                    5,
-	"Пропаганда запрещенных веществ":                         6,
-	"Финансовое мошенничество":                               7,
-	"Сбор личных данных (Фишинг)":                            8,
-	"Нейтральное общение":                                    9,
+// DashboardStats represents the statistics for the dashboard
+type DashboardStats struct {
+	TotalIncidents       int            `json:"total_incidents"`
+	NewIncidents         int            `json:"new_incidents"`
+	ReviewedIncidents    int            `json:"reviewed_incidents"`
+	FalsePositives       int            `json:"false_positives"`
+	TotalChats           int            `json:"total_chats"`
+	ActiveChats          int            `json:"active_chats"`
+	IncidentsByThreat    map[string]int `json:"incidents_by_threat"`
+	CategoryDistribution map[int]int    `json:"category_distribution"` // Category ID -> Count
+	TotalMessages        int            `json:"total_messages"`
+	DetectionRate        float64        `json:"detection_rate"`
+	Incidents24h         int            `json:"incidents_24h"`
+	RecentIncidents      interface{}    `json:"recent_incidents"`
 }
 
 // GetDashboard handles GET /api/analytics/dashboard
@@ -63,7 +65,7 @@ func (h *analyticsHandler) GetDashboard(c *gin.Context) {
 	allIncidents, err := h.messageRepo.GetAllIncidents()
 	if err != nil {
 		h.logger.Error("Failed to get all incidents for dashboard", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dashboard data"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve dashboard data")
 		return
 	}
 
@@ -71,21 +73,21 @@ func (h *analyticsHandler) GetDashboard(c *gin.Context) {
 	newIncidents, err := h.messageRepo.GetIncidentsByStatus("new")
 	if err != nil {
 		h.logger.Error("Failed to get new incidents", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dashboard data"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve dashboard data")
 		return
 	}
 
 	reviewedIncidents, err := h.messageRepo.GetIncidentsByStatus("reviewed")
 	if err != nil {
 		h.logger.Error("Failed to get reviewed incidents", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dashboard data"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve dashboard data")
 		return
 	}
 
 	falsePositives, err := h.messageRepo.GetIncidentsByStatus("false_positive")
 	if err != nil {
 		h.logger.Error("Failed to get false positive incidents", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dashboard data"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve dashboard data")
 		return
 	}
 
@@ -93,7 +95,7 @@ func (h *analyticsHandler) GetDashboard(c *gin.Context) {
 	allChats, err := h.chatRepo.GetAllChats()
 	if err != nil {
 		h.logger.Error("Failed to get all chats for dashboard", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dashboard data"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve dashboard data")
 		return
 	}
 
@@ -118,7 +120,7 @@ func (h *analyticsHandler) GetDashboard(c *gin.Context) {
 		incidentsByThreat[incident.ThreatType]++
 
 		// Map to category ID
-		if categoryID, ok := threatTypeToCategoryID[incident.ThreatType]; ok {
+		if categoryID, ok := models.CategoryIDFromName(incident.ThreatType); ok {
 			categoryDistribution[categoryID]++
 		}
 
@@ -157,3 +159,20 @@ func (h *analyticsHandler) GetDashboard(c *gin.Context) {
 
 	c.JSON(http.StatusOK, stats)
 }
+
+// GetConfidenceDistribution handles GET /api/analytics/confidence
+// Returns per-category confidence statistics plus the configured display
+// threshold so operators can tune which incidents count as high confidence.
+func (h *analyticsHandler) GetConfidenceDistribution(c *gin.Context) {
+	stats, err := h.messageRepo.GetConfidenceDistribution()
+	if err != nil {
+		h.logger.Error("Failed to get confidence distribution", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve confidence distribution")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories":                stats,
+		"high_confidence_threshold": h.cfg.Classification.HighConfidenceThreshold,
+	})
+}
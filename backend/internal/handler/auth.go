@@ -23,7 +23,7 @@ func (h *authHandler) Logout(c *gin.Context) {
 	err := h.authService.Logout(username)
 	if err != nil {
 		h.logger.Error("Failed to logout user", zap.String("username", username), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to logout")
 		return
 	}
 
@@ -53,18 +53,18 @@ func (h *authHandler) RegisterParent(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind JSON for registration", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	user, err := h.authService.RegisterParent(req.Username, req.Password)
 	if err != nil {
 		if errors.Is(err, service.ErrUserAlreadyExists) {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			respondError(c, http.StatusConflict, CodeConflict, err.Error())
 			return
 		}
 		h.logger.Error("Failed to register parent", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to register user")
 		return
 	}
 
@@ -79,18 +79,18 @@ func (h *authHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind JSON for login", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	tokenString, expirationTime, err := h.authService.Login(req.Username, req.Password)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrInvalidCredentials) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Invalid credentials")
 			return
 		}
 		h.logger.Error("Failed to login user", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to login")
 		return
 	}
 
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler interface {
+	GetCategories(c *gin.Context)
+}
+
+type categoryHandler struct{}
+
+func NewCategoryHandler() CategoryHandler {
+	return &categoryHandler{}
+}
+
+// GetCategories handles GET /api/categories
+// Returns the threat taxonomy so clients can render category filters and
+// labels without hardcoding it.
+func (h *categoryHandler) GetCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"categories": models.Categories()})
+}
@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"backend/internal/config"
+	"backend/internal/message_processor"
+	"backend/internal/models"
 	"backend/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -15,15 +21,27 @@ type ChatHandler interface {
 	GetAllChats(c *gin.Context)
 	GetChatByID(c *gin.Context)
 	UpdateMonitoringStatus(c *gin.Context)
+	ConfirmMonitoring(c *gin.Context)
+	UpdateChatRules(c *gin.Context)
+	UpdateChatProfile(c *gin.Context)
+	CollectChat(c *gin.Context)
+}
+
+// ChatCollector triggers an immediate collection pass for one chat,
+// implemented by the message processor.
+type ChatCollector interface {
+	CollectChatNow(ctx context.Context, chat *models.Chat) (messages int, incidents int, err error)
 }
 
 type chatHandler struct {
-	chatRepo repository.ChatRepository
-	logger   *zap.Logger
+	chatRepo  repository.ChatRepository
+	collector ChatCollector
+	profiles  map[string]config.SensitivityProfile
+	logger    *zap.Logger
 }
 
-func NewChatHandler(chatRepo repository.ChatRepository, logger *zap.Logger) ChatHandler {
-	return &chatHandler{chatRepo: chatRepo, logger: logger}
+func NewChatHandler(chatRepo repository.ChatRepository, collector ChatCollector, profiles map[string]config.SensitivityProfile, logger *zap.Logger) ChatHandler {
+	return &chatHandler{chatRepo: chatRepo, collector: collector, profiles: profiles, logger: logger}
 }
 
 // GetAllChats handles GET /api/chats
@@ -31,7 +49,7 @@ func (h *chatHandler) GetAllChats(c *gin.Context) {
 	chats, err := h.chatRepo.GetAllChats()
 	if err != nil {
 		h.logger.Error("Failed to get chats", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve chats"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve chats")
 		return
 	}
 
@@ -44,23 +62,23 @@ func (h *chatHandler) GetChatByID(c *gin.Context) {
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		h.logger.Error("Invalid chat ID", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat ID")
 		return
 	}
 
 	chat, err := h.chatRepo.GetChatByID(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
+			respondError(c, http.StatusNotFound, CodeNotFound, "Chat not found")
 			return
 		}
 		h.logger.Error("Failed to get chat", zap.Int64("id", id), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve chat"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve chat")
 		return
 	}
 
 	if chat == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
+		respondError(c, http.StatusNotFound, CodeNotFound, "Chat not found")
 		return
 	}
 
@@ -77,23 +95,228 @@ func (h *chatHandler) UpdateMonitoringStatus(c *gin.Context) {
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		h.logger.Error("Invalid chat ID", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat ID")
 		return
 	}
 
 	var req UpdateMonitoringRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind JSON for monitoring update", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	err = h.chatRepo.UpdateMonitoringStatus(id, req.Active)
 	if err != nil {
 		h.logger.Error("Failed to update monitoring status", zap.Int64("id", id), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update monitoring status"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to update monitoring status")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Monitoring status updated successfully"})
 }
+
+// ConfirmMonitoring handles POST /api/chats/:id/confirm-monitoring. It ends
+// the chat's observe-only warm-up early: the parent has seen the
+// newly-discovered chat and wants alerts to start now.
+func (h *chatHandler) ConfirmMonitoring(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid chat ID", zap.String("id", idStr), zap.Error(err))
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat ID")
+		return
+	}
+
+	chat, err := h.chatRepo.GetChatByID(id)
+	if err != nil {
+		h.logger.Error("Failed to get chat", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve chat")
+		return
+	}
+	if chat == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Chat not found")
+		return
+	}
+
+	if err := h.chatRepo.ConfirmChatMonitoring(id); err != nil {
+		h.logger.Error("Failed to confirm chat monitoring", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to confirm monitoring")
+		return
+	}
+
+	h.logger.Info("Chat monitoring confirmed, observe-only warm-up ended", zap.Int64("chat_id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "Monitoring confirmed, alerts enabled"})
+}
+
+// UpdateChatProfileRequest is the payload for PUT /api/chats/:id/profile.
+type UpdateChatProfileRequest struct {
+	Profile string `json:"profile"`
+}
+
+// UpdateChatProfile handles PUT /api/chats/:id/profile
+// Assigns a configured sensitivity profile to the chat; an empty profile
+// clears the assignment.
+func (h *chatHandler) UpdateChatProfile(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid chat ID", zap.String("id", idStr), zap.Error(err))
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat ID")
+		return
+	}
+
+	var req UpdateChatProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if req.Profile != "" {
+		if _, ok := h.profiles[req.Profile]; !ok {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Unknown sensitivity profile")
+			return
+		}
+	}
+
+	chat, err := h.chatRepo.GetChatByID(id)
+	if err != nil {
+		h.logger.Error("Failed to get chat", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve chat")
+		return
+	}
+	if chat == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Chat not found")
+		return
+	}
+
+	if err := h.chatRepo.UpdateChatProfile(id, req.Profile); err != nil {
+		h.logger.Error("Failed to update chat profile", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to update chat profile")
+		return
+	}
+
+	h.logger.Info("Chat sensitivity profile updated",
+		zap.Int64("chat_id", id),
+		zap.String("profile", req.Profile))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Chat profile updated successfully",
+		"chat_id": id,
+		"profile": req.Profile,
+	})
+}
+
+// UpdateChatRulesRequest is the payload for PUT /api/chats/:id/rules.
+type UpdateChatRulesRequest struct {
+	Rule                        string   `json:"rule" binding:"required"`
+	ConfidenceThresholdOverride *float64 `json:"confidence_threshold_override"`
+}
+
+// UpdateChatRules handles PUT /api/chats/:id/rules
+// Sets the per-chat classification override: suppress incidents for trusted
+// chats or escalate them for flagged ones.
+func (h *chatHandler) UpdateChatRules(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid chat ID", zap.String("id", idStr), zap.Error(err))
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat ID")
+		return
+	}
+
+	var req UpdateChatRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	switch req.Rule {
+	case models.ChatRuleNone, models.ChatRuleSuppress, models.ChatRuleEscalate:
+	default:
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Rule must be one of: none, suppress, escalate")
+		return
+	}
+
+	if req.ConfidenceThresholdOverride != nil && (*req.ConfidenceThresholdOverride < 0 || *req.ConfidenceThresholdOverride > 1) {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "confidence_threshold_override must be between 0 and 1")
+		return
+	}
+
+	chat, err := h.chatRepo.GetChatByID(id)
+	if err != nil {
+		h.logger.Error("Failed to get chat", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve chat")
+		return
+	}
+	if chat == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Chat not found")
+		return
+	}
+
+	if err := h.chatRepo.UpdateChatRules(id, req.Rule, req.ConfidenceThresholdOverride); err != nil {
+		h.logger.Error("Failed to update chat rules", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to update chat rules")
+		return
+	}
+
+	h.logger.Info("Chat classification rules updated",
+		zap.Int64("chat_id", id),
+		zap.String("rule", req.Rule))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Chat rules updated successfully",
+		"chat_id": id,
+		"rule":    req.Rule,
+	})
+}
+
+// CollectChat handles POST /api/chats/:id/collect
+// Runs an immediate out-of-band collection+classification pass for one chat
+// so operators don't have to wait for the next poll cycle.
+func (h *chatHandler) CollectChat(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid chat ID", zap.String("id", idStr), zap.Error(err))
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat ID")
+		return
+	}
+
+	chat, err := h.chatRepo.GetChatByID(id)
+	if err != nil && err != sql.ErrNoRows {
+		h.logger.Error("Failed to get chat", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve chat")
+		return
+	}
+	if chat == nil || err == sql.ErrNoRows {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Chat not found")
+		return
+	}
+	if chat.Inaccessible {
+		respondError(c, http.StatusConflict, CodeInvalidRequest, "Chat is marked inaccessible")
+		return
+	}
+
+	// Bound the pass so a slow collector can't hold the request forever.
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	messages, incidents, err := h.collector.CollectChatNow(ctx, chat)
+	if err != nil {
+		if errors.Is(err, message_processor.ErrCollectionInProgress) {
+			respondError(c, http.StatusConflict, CodeInvalidRequest, "Collection already running for this chat")
+			return
+		}
+		h.logger.Error("Manual collection failed", zap.Int64("chat_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Collection failed")
+		return
+	}
+
+	h.logger.Info("Manual collection completed", zap.Int64("chat_id", id), zap.Int("messages", messages), zap.Int("incidents", incidents))
+	c.JSON(http.StatusOK, gin.H{
+		"chat_id":   id,
+		"messages":  messages,
+		"incidents": incidents,
+	})
+}
@@ -88,14 +88,14 @@ func (h *configHandler) UpdateTelegramConfig(c *gin.Context) {
 	var req UpdateTelegramConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind Telegram config request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// TODO: Call collector API to update Telegram config
 	// For now, return that this feature requires collector restart
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Telegram configuration updated. Please restart collector to apply changes.",
+		"message":          "Telegram configuration updated. Please restart collector to apply changes.",
 		"restart_required": true,
 	})
 }
@@ -111,14 +111,14 @@ func (h *configHandler) UpdateVKConfig(c *gin.Context) {
 	var req UpdateVKConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind VK config request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// TODO: Call collector API to update VK config
 	// For now, return that this feature requires collector restart
 	c.JSON(http.StatusOK, gin.H{
-		"message": "VK configuration updated. Please restart collector to apply changes.",
+		"message":          "VK configuration updated. Please restart collector to apply changes.",
 		"restart_required": true,
 	})
 }
@@ -133,7 +133,7 @@ func (h *configHandler) GetVKAuthURL(c *gin.Context) {
 	resp, err := h.collectorClient.GetVKAuthURL(ctx)
 	if err != nil {
 		h.logger.Error("Failed to get VK auth URL", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get VK auth URL from collector"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get VK auth URL from collector")
 		return
 	}
 
@@ -150,7 +150,7 @@ func (h *configHandler) TestCollectorConnection(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"connected": false,
-			"error": err.Error(),
+			"error":     err.Error(),
 		})
 		return
 	}
@@ -197,7 +197,7 @@ func (h *configHandler) SaveCollectorConfig(c *gin.Context) {
 	var req SaveCollectorConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind collector config request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -222,7 +222,7 @@ func (h *configHandler) SaveCollectorConfig(c *gin.Context) {
 		// Parse existing config
 		if err := yaml.Unmarshal(data, &cfg); err != nil {
 			h.logger.Error("Failed to parse existing config", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse existing config"})
+			respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to parse existing config")
 			return
 		}
 	}
@@ -245,7 +245,7 @@ func (h *configHandler) SaveCollectorConfig(c *gin.Context) {
 	newData, err := yaml.Marshal(&cfg)
 	if err != nil {
 		h.logger.Error("Failed to marshal config", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal config"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to marshal config")
 		return
 	}
 
@@ -261,7 +261,7 @@ func (h *configHandler) SaveCollectorConfig(c *gin.Context) {
 	h.logger.Info("Collector configuration saved successfully", zap.String("path", configPath))
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Configuration saved successfully. Please restart collector to apply changes.",
+		"message":          "Configuration saved successfully. Please restart collector to apply changes.",
 		"restart_required": true,
 	})
 }
@@ -289,7 +289,7 @@ func (h *configHandler) RestartCollector(c *gin.Context) {
 	if err != nil {
 		h.logger.Error("Failed to restart collector", zap.Error(err), zap.String("output", string(output)))
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to restart collector: %v", err),
+			"error":  fmt.Sprintf("Failed to restart collector: %v", err),
 			"output": string(output),
 		})
 		return
@@ -299,6 +299,6 @@ func (h *configHandler) RestartCollector(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Collector restarted successfully",
-		"output": string(output),
+		"output":  string(output),
 	})
 }
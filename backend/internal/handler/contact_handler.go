@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ContactHandler defines the interface for the per-child contacts roster and
+// related per-child analytics.
+type ContactHandler interface {
+	GetContacts(c *gin.Context)
+	GetGroomingTimeline(c *gin.Context)
+}
+
+type contactHandler struct {
+	entityRepo repository.MonitoredEntityRepository
+	logger     *zap.Logger
+}
+
+// NewContactHandler creates a new ContactHandler.
+func NewContactHandler(entityRepo repository.MonitoredEntityRepository, logger *zap.Logger) ContactHandler {
+	return &contactHandler{
+		entityRepo: entityRepo,
+		logger:     logger,
+	}
+}
+
+// GetContacts handles GET /api/children/:id/contacts
+// Returns a deduplicated roster of everyone the child talks to across their
+// monitored chats, with message counts, first/last seen timestamps and
+// incident counts. ?sort=risk orders by incident count, ?sort=recency
+// (the default) by most recent contact.
+func (h *contactHandler) GetContacts(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid child ID")
+		return
+	}
+
+	sortBy := c.DefaultQuery("sort", "recency")
+	if sortBy != "recency" && sortBy != "risk" {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Unknown sort: must be 'recency' or 'risk'")
+		return
+	}
+
+	entity, err := h.entityRepo.GetEntityByID(id)
+	if err != nil {
+		h.logger.Error("Failed to get monitored entity", zap.Int64("entity_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get child")
+		return
+	}
+	if entity == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Child not found")
+		return
+	}
+
+	contacts, err := h.entityRepo.GetContacts(id, sortBy)
+	if err != nil {
+		h.logger.Error("Failed to get contacts", zap.Int64("entity_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get contacts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"child_id": id,
+		"contacts": contacts,
+		"count":    len(contacts),
+	})
+}
+
+// GetGroomingTimeline handles GET /api/children/:id/grooming-timeline
+// Returns the child's stage-tagged incidents in chronological order, so
+// reviewers can see whether contact is escalating through the grooming
+// stages. The stage vocabulary is included in escalation order so clients
+// can render the progression without hardcoding it.
+func (h *contactHandler) GetGroomingTimeline(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid child ID")
+		return
+	}
+
+	entity, err := h.entityRepo.GetEntityByID(id)
+	if err != nil {
+		h.logger.Error("Failed to get monitored entity", zap.Int64("entity_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get child")
+		return
+	}
+	if entity == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Child not found")
+		return
+	}
+
+	timeline, err := h.entityRepo.GetGroomingTimeline(id)
+	if err != nil {
+		h.logger.Error("Failed to get grooming timeline", zap.Int64("entity_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get grooming timeline")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"child_id": id,
+		"stages":   models.GroomingStages,
+		"timeline": timeline,
+		"count":    len(timeline),
+	})
+}
@@ -1,14 +1,24 @@
 package handler
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"backend/internal/annotation_client"
 	"backend/internal/config"
 	"backend/internal/crypto"
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
+	"backend/internal/sla"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -16,29 +26,57 @@ import (
 
 type IncidentHandler interface {
 	GetAllIncidents(c *gin.Context)
+	CreateManualIncident(c *gin.Context)
+	GetIncidentChanges(c *gin.Context)
 	GetIncidentByID(c *gin.Context)
 	UpdateIncidentStatus(c *gin.Context)
+	MergeIncidents(c *gin.Context)
+	GetMessageEdits(c *gin.Context)
+	GetIncidentEvidence(c *gin.Context)
+	GetOverdueIncidents(c *gin.Context)
+	GetIncidentMessages(c *gin.Context)
+	AddIncidentTag(c *gin.Context)
+	RemoveIncidentTag(c *gin.Context)
+	ListIncidentTags(c *gin.Context)
 }
 
 type incidentHandler struct {
-	messageRepo  repository.MessageRepository
-	authRepo     repository.AuthRepository
-	cfg          *config.Config
-	logger       *zap.Logger
-	keyManager   *crypto.KeyManager
+	messageRepo      repository.MessageRepository
+	chatRepo         repository.ChatRepository
+	entityRepo       repository.MonitoredEntityRepository
+	authRepo         repository.AuthRepository
+	annotationClient *annotation_client.Client
+	cfg              *config.Config
+	logger           *zap.Logger
+	keyManager       *crypto.KeyManager
 }
 
-func NewIncidentHandler(messageRepo repository.MessageRepository, authRepo repository.AuthRepository, cfg *config.Config, logger *zap.Logger, keyManager *crypto.KeyManager) IncidentHandler {
+func NewIncidentHandler(messageRepo repository.MessageRepository, chatRepo repository.ChatRepository, entityRepo repository.MonitoredEntityRepository, authRepo repository.AuthRepository, annotationClient *annotation_client.Client, cfg *config.Config, logger *zap.Logger, keyManager *crypto.KeyManager) IncidentHandler {
 	return &incidentHandler{
-		messageRepo:  messageRepo,
-		authRepo:     authRepo,
-		cfg:          cfg,
-		logger:       logger,
-		keyManager:   keyManager,
+		messageRepo:      messageRepo,
+		chatRepo:         chatRepo,
+		entityRepo:       entityRepo,
+		authRepo:         authRepo,
+		annotationClient: annotationClient,
+		cfg:              cfg,
+		logger:           logger,
+		keyManager:       keyManager,
 	}
 }
 
-// decryptIncidentSummary расшифровывает summary_encrypted для инцидента
+// errDecryptionUnavailable marks summaries that could not be decrypted
+// because the system user's data key could not be loaded.
+var errDecryptionUnavailable = errors.New("decryption unavailable — system user key not loaded")
+
+// decryptionUnavailablePlaceholder is shown instead of the ciphertext when
+// decryption fails, so the dashboard explains the state rather than
+// displaying an encrypted blob.
+const decryptionUnavailablePlaceholder = "[Расшифровка недоступна — ключ не загружен]"
+
+// decryptIncidentSummary расшифровывает summary_encrypted для инцидента.
+// When the system user's key cannot be loaded the ciphertext is replaced
+// with an explanatory placeholder — encrypted blobs never reach the client —
+// and errDecryptionUnavailable is returned.
 func (h *incidentHandler) decryptIncidentSummary(incident *models.Incident) error {
 	if incident.SummaryEncrypted == "" {
 		return nil
@@ -48,7 +86,8 @@ func (h *incidentHandler) decryptIncidentSummary(incident *models.Incident) erro
 	systemUser, err := h.authRepo.GetUserByUsername("admin")
 	if err != nil {
 		h.logger.Error("Failed to get system user for decryption", zap.Error(err))
-		return err
+		incident.SummaryEncrypted = decryptionUnavailablePlaceholder
+		return fmt.Errorf("%w: %v", errDecryptionUnavailable, err)
 	}
 
 	// Decrypt the summary
@@ -57,59 +96,347 @@ func (h *incidentHandler) decryptIncidentSummary(incident *models.Incident) erro
 		h.logger.Error("Failed to decrypt incident summary",
 			zap.Int64("incident_id", incident.ID),
 			zap.Error(err))
-		return err
+		incident.SummaryEncrypted = decryptionUnavailablePlaceholder
+		return fmt.Errorf("%w: %v", errDecryptionUnavailable, err)
 	}
 
 	incident.SummaryEncrypted = decrypted
 	return nil
 }
 
-// filterIncidentText скрывает текст сообщения если access control включен и доступ не предоставлен
+// Placeholders shown in place of fields the caller's access grant does not
+// cover.
+const (
+	hiddenTextPlaceholder   = "[Для просмотра текста запросите доступ]"
+	hiddenSenderPlaceholder = "[Отправитель скрыт — запросите доступ]"
+)
+
+// filterIncidentText скрывает текст сообщения если access control включен и
+// доступ (со скоупом "text") не предоставлен. Тип угрозы, время и статус
+// видны всегда — родитель видит, что что-то произошло, и может запросить
+// только нужное поле.
 func (h *incidentHandler) filterIncidentText(incident *models.Incident) {
 	h.logger.Debug("Filtering incident text",
 		zap.Bool("enabled", h.cfg.AccessControl.Enabled),
 		zap.Bool("access_granted", incident.AccessGranted),
 		zap.Int64("incident_id", incident.ID),
 	)
-	if h.cfg.AccessControl.Enabled && !incident.AccessGranted {
+	if !h.cfg.AccessControl.Enabled {
+		return
+	}
+	if !incident.AccessGranted || !models.ScopeAllows(incident.AccessScope, models.AccessScopeText) {
 		h.logger.Info("Hiding incident text", zap.Int64("incident_id", incident.ID))
-		incident.SummaryEncrypted = "[Для просмотра текста запросите доступ]"
+		incident.SummaryEncrypted = hiddenTextPlaceholder
 	}
 }
 
+// attachIncidentTags populates Tags on each incident in a single query.
+func (h *incidentHandler) attachIncidentTags(incidents []*models.Incident) {
+	ids := make([]int64, 0, len(incidents))
+	for _, incident := range incidents {
+		ids = append(ids, incident.ID)
+	}
+
+	tags, err := h.messageRepo.GetTagsForIncidents(ids)
+	if err != nil {
+		h.logger.Warn("Failed to load incident tags", zap.Error(err))
+		return
+	}
+
+	for _, incident := range incidents {
+		incident.Tags = tags[incident.ID]
+	}
+}
+
+// ManualIncidentRequest is the payload for POST /api/events/manual.
+type ManualIncidentRequest struct {
+	Text     string `json:"text" binding:"required"`
+	Sender   string `json:"sender"`
+	ChatName string `json:"chat_name"`
+}
+
+// CreateManualIncident handles POST /api/events/manual: a parent reports a
+// threatening message seen outside the monitored channels. The text is
+// classified, encrypted and recorded as a regular incident under a synthetic
+// "manual" chat, so it flows through the same review, encryption and
+// access-control paths as automated incidents.
+func (h *incidentHandler) CreateManualIncident(c *gin.Context) {
+	var req ManualIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if h.annotationClient == nil {
+		respondError(c, http.StatusServiceUnavailable, CodeUnavailable, "Classification service is not configured")
+		return
+	}
+
+	annotateCtx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	annotation, err := h.annotationClient.AnnotateSingle(annotateCtx, req.Text)
+	if err != nil {
+		h.logger.Error("Failed to classify manual submission", zap.Error(err))
+		respondError(c, http.StatusServiceUnavailable, CodeUnavailable, "Classification failed, try again later")
+		return
+	}
+
+	chatName := req.ChatName
+	if chatName == "" {
+		chatName = "Ручные обращения"
+	}
+	chat, err := h.chatRepo.GetChatBySourceAndName("manual", chatName)
+	if err != nil {
+		h.logger.Error("Failed to look up manual chat", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to record submission")
+		return
+	}
+	if chat == nil {
+		chat = &models.Chat{
+			Source:           "manual",
+			Name:             chatName,
+			ChatType:         "manual",
+			MonitoringActive: false, // Nothing to collect; exists only to hold submissions
+		}
+		// Best effort: when a single child is monitored, attribute the
+		// submission to them. With several children the chat stays unassigned
+		// and can be linked manually.
+		if entities, err := h.entityRepo.GetAllEntities(); err != nil {
+			h.logger.Warn("Failed to resolve monitored entity for manual submission", zap.Error(err))
+		} else if len(entities) == 1 {
+			chat.MonitoredEntityID = &entities[0].ID
+		}
+		if err := h.chatRepo.CreateChat(chat); err != nil {
+			h.logger.Error("Failed to create manual chat", zap.Error(err))
+			respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to record submission")
+			return
+		}
+	}
+
+	systemUser, err := h.authRepo.GetUserByUsername("admin")
+	if err != nil {
+		h.logger.Error("Failed to get system user for encryption", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to record submission")
+		return
+	}
+	encryptedText, err := h.keyManager.EncryptMessage(req.Text, systemUser.ID, systemUser.DKEncrypted)
+	if err != nil {
+		h.logger.Error("Failed to encrypt manual submission", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to record submission")
+		return
+	}
+
+	msg := &models.Message{
+		ChatID:           chat.ID,
+		Source:           "manual",
+		MessageType:      "manual",
+		SenderUsername:   req.Sender,
+		Timestamp:        time.Now(),
+		ContentEncrypted: encryptedText,
+	}
+	if err := h.messageRepo.SaveMessage(msg); err != nil {
+		h.logger.Error("Failed to save manual submission message", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to record submission")
+		return
+	}
+
+	confidence := annotation.Confidence
+	confidenceSource := "model"
+	if confidence == 0 {
+		confidence = h.cfg.Classification.AnnotationDefaultConfidence
+		confidenceSource = "default"
+	}
+
+	incident := &models.Incident{
+		MessageID:        msg.ID,
+		ThreatType:       annotation.CategoryName,
+		ModelConfidence:  confidence,
+		ModelProvider:    annotation.Provider,
+		ModelVersion:     annotation.ModelVersion,
+		ConfidenceSource: confidenceSource,
+		Status:           "new",
+		SummaryEncrypted: encryptedText,
+	}
+	if err := h.messageRepo.SaveIncident(incident); err != nil {
+		h.logger.Error("Failed to save manual incident", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to record submission")
+		return
+	}
+
+	h.logger.Info("Manual incident created",
+		zap.Int64("incident_id", incident.ID),
+		zap.String("threat_type", incident.ThreatType),
+		zap.Int64("chat_id", chat.ID))
+
+	incident.ChatTitle = chat.Name
+	incident.Source = "manual"
+	if err := h.decryptIncidentSummary(incident); err == nil {
+		if h.cfg.AccessControl.Enabled {
+			h.filterIncidentText(incident)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"incident":      incident,
+		"category_id":   annotation.CategoryID,
+		"justification": annotation.Justification,
+	})
+}
+
 // GetAllIncidents handles GET /api/events
 // Query parameters:
-// - status: filter by status (optional)
-// - threat_type: filter by threat type (optional)
+//   - status: filter by status (optional)
+//   - threat_type: filter by threat type (optional)
+//   - tag: filter by triage tag (optional)
+//   - min_confidence: only incidents at or above this model confidence (optional)
+//   - from, to: creation time range, RFC3339 or YYYY-MM-DD; from is inclusive,
+//     to is exclusive (optional, combinable with status and threat_type)
+//   - after: opaque keyset cursor from a previous page (optional)
+//   - limit: page size, enables cursor pagination (optional)
 func (h *incidentHandler) GetAllIncidents(c *gin.Context) {
 	status := c.Query("status")
 	threatType := c.Query("threat_type")
+	tag := c.Query("tag")
+	modelVersion := c.Query("model_version")
+	afterToken := c.Query("after")
+	limitStr := c.Query("limit")
+
+	from, ok := parseTimeBound(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseTimeBound(c, "to")
+	if !ok {
+		return
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "from must be before to")
+		return
+	}
+
+	var minConfidence float64
+	if raw := c.Query("min_confidence"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid min_confidence (must be 0-1)")
+			return
+		}
+		minConfidence = parsed
+	}
+
+	// Cursor-based pagination path: keeps listing fast regardless of depth.
+	if afterToken != "" || limitStr != "" {
+		limit := 50
+		if limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed < 1 || parsed > 500 {
+				respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid limit (must be 1-500)")
+				return
+			}
+			limit = parsed
+		}
+
+		var after *pagination.Cursor
+		if afterToken != "" {
+			parsed, err := pagination.Decode(afterToken)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid cursor")
+				return
+			}
+			after = parsed
+		}
+
+		// The parsed filters ride along with the cursor, so paginating a
+		// filtered listing pages over that listing rather than the full set.
+		filter := models.IncidentFilter{
+			Status:        status,
+			ThreatType:    threatType,
+			From:          from,
+			To:            to,
+			Tag:           tag,
+			MinConfidence: minConfidence,
+			ModelVersion:  modelVersion,
+		}
+
+		incidents, err := h.messageRepo.GetIncidentsPage(filter, after, limit)
+		if err != nil {
+			h.logger.Error("Failed to get incidents page", zap.Error(err))
+			respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incidents")
+			return
+		}
+
+		for _, incident := range incidents {
+			if err := h.decryptIncidentSummary(incident); err != nil {
+				h.logger.Warn("Failed to decrypt incident summary, returning placeholder",
+					zap.Int64("incident_id", incident.ID),
+					zap.Error(err))
+			}
+			if h.cfg.AccessControl.Enabled {
+				h.filterIncidentText(incident)
+			}
+		}
+
+		nextCursor := ""
+		if len(incidents) == limit {
+			last := incidents[len(incidents)-1]
+			nextCursor = (&pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+		}
+
+		h.attachIncidentTags(incidents)
+
+		c.JSON(http.StatusOK, gin.H{"incidents": incidents, "next_cursor": nextCursor})
+		return
+	}
 
 	var incidents []*models.Incident
 	var err error
 
-	if status != "" {
+	if from != nil || to != nil {
+		incidents, err = h.messageRepo.GetIncidentsFiltered(models.IncidentFilter{
+			Status:     status,
+			ThreatType: threatType,
+			From:       from,
+			To:         to,
+		})
+	} else if tag != "" {
+		incidents, err = h.messageRepo.GetIncidentsByTag(tag)
+	} else if status != "" {
 		incidents, err = h.messageRepo.GetIncidentsByStatus(status)
 	} else if threatType != "" {
 		incidents, err = h.messageRepo.GetIncidentsByThreatType(threatType)
+	} else if minConfidence > 0 {
+		incidents, err = h.messageRepo.GetIncidentsByMinConfidence(minConfidence)
+	} else if modelVersion != "" {
+		incidents, err = h.messageRepo.GetIncidentsByModelVersion(modelVersion)
 	} else {
 		incidents, err = h.messageRepo.GetAllIncidents()
 	}
 
 	if err != nil {
 		h.logger.Error("Failed to get incidents", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve incidents"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incidents")
 		return
 	}
 
+	// Apply the confidence filter on top of the other filters when combined.
+	if minConfidence > 0 {
+		filtered := incidents[:0]
+		for _, incident := range incidents {
+			if incident.ModelConfidence >= minConfidence {
+				filtered = append(filtered, incident)
+			}
+		}
+		incidents = filtered
+	}
+
 	// Decrypt and filter incidents
 	for _, incident := range incidents {
 		// First decrypt the summary
 		if err := h.decryptIncidentSummary(incident); err != nil {
-			h.logger.Warn("Failed to decrypt incident summary, using encrypted value",
+			h.logger.Warn("Failed to decrypt incident summary, returning placeholder",
 				zap.Int64("incident_id", incident.ID),
 				zap.Error(err))
-			// Continue with encrypted value
+			// Continue with the placeholder summary
 		}
 
 		// Then filter based on access control
@@ -118,33 +445,144 @@ func (h *incidentHandler) GetAllIncidents(c *gin.Context) {
 		}
 	}
 
+	h.attachIncidentTags(incidents)
+
 	c.JSON(http.StatusOK, gin.H{"incidents": incidents})
 }
 
+// parseTimeBound reads an optional time query parameter, accepting RFC3339 or
+// a bare YYYY-MM-DD date (interpreted as midnight UTC). On a malformed value
+// it writes the error response and returns ok=false.
+func parseTimeBound(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		parsed, err = time.Parse("2006-01-02", raw)
+	}
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("Invalid %s (expected RFC3339 or YYYY-MM-DD)", name))
+		return nil, false
+	}
+	return &parsed, true
+}
+
+// GetMessageEdits handles GET /api/messages/:id/edits
+// Returns the edit history of a collected message with both versions decrypted.
+func (h *incidentHandler) GetMessageEdits(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid message ID")
+		return
+	}
+
+	edits, err := h.messageRepo.GetMessageEdits(id)
+	if err != nil {
+		h.logger.Error("Failed to get message edits", zap.Int64("message_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve edit history")
+		return
+	}
+
+	systemUser, err := h.authRepo.GetUserByUsername("admin")
+	if err != nil {
+		h.logger.Error("Failed to get system user for decryption", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve edit history")
+		return
+	}
+
+	for _, edit := range edits {
+		if decrypted, err := h.keyManager.DecryptMessage(edit.OldContentEncrypted, systemUser.ID, systemUser.DKEncrypted); err == nil {
+			edit.OldContentEncrypted = decrypted
+		}
+		if decrypted, err := h.keyManager.DecryptMessage(edit.NewContentEncrypted, systemUser.ID, systemUser.DKEncrypted); err == nil {
+			edit.NewContentEncrypted = decrypted
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message_id": id, "edits": edits, "count": len(edits)})
+}
+
 // GetIncidentByID handles GET /api/events/:id
+// GetIncidentChanges handles GET /api/events/changes?since=<RFC3339>
+// Returns incidents created or modified after the timestamp so external
+// integrations can sync incrementally. Incidents archived by a merge come
+// back as tombstones.
+func (h *incidentHandler) GetIncidentChanges(c *gin.Context) {
+	sinceStr := c.Query("since")
+	if sinceStr == "" {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "since query parameter is required (RFC3339)")
+		return
+	}
+	since, err := time.Parse(time.RFC3339, sinceStr)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid since timestamp (expected RFC3339)")
+		return
+	}
+
+	incidents, err := h.messageRepo.GetIncidentChangesSince(since)
+	if err != nil {
+		h.logger.Error("Failed to get incident changes", zap.Error(err), zap.Time("since", since))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incident changes")
+		return
+	}
+
+	changes := make([]*models.IncidentChange, 0, len(incidents))
+	for _, incident := range incidents {
+		if err := h.decryptIncidentSummary(incident); err != nil {
+			h.logger.Warn("Failed to decrypt incident summary, returning placeholder",
+				zap.Int64("incident_id", incident.ID),
+				zap.Error(err))
+		}
+		if h.cfg.AccessControl.Enabled {
+			h.filterIncidentText(incident)
+		}
+
+		change := &models.IncidentChange{Incident: incident, ChangedAt: incident.UpdatedAt}
+		switch {
+		case incident.Status == "merged":
+			change.ChangeType = "archived"
+			change.Tombstone = true
+		case incident.CreatedAt.After(since):
+			change.ChangeType = "created"
+		default:
+			change.ChangeType = "updated"
+		}
+		changes = append(changes, change)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes": changes,
+		"count":   len(changes),
+		"since":   since,
+	})
+}
+
 func (h *incidentHandler) GetIncidentByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		h.logger.Error("Invalid incident ID", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incident ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid incident ID")
 		return
 	}
 
 	incident, err := h.messageRepo.GetIncidentByID(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+			respondError(c, http.StatusNotFound, CodeNotFound, "Incident not found")
 			return
 		}
 		h.logger.Error("Failed to get incident", zap.Int64("id", id), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve incident"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incident")
 		return
 	}
 
 	// First decrypt the summary
 	if err := h.decryptIncidentSummary(incident); err != nil {
-		h.logger.Warn("Failed to decrypt incident summary, using encrypted value",
+		h.logger.Warn("Failed to decrypt incident summary, returning placeholder",
 			zap.Int64("incident_id", incident.ID),
 			zap.Error(err))
 		// Continue with encrypted value
@@ -155,9 +593,323 @@ func (h *incidentHandler) GetIncidentByID(c *gin.Context) {
 		h.filterIncidentText(incident)
 	}
 
+	h.attachIncidentTags([]*models.Incident{incident})
+
 	c.JSON(http.StatusOK, gin.H{"incident": incident})
 }
 
+// EvidenceBundleSchemaVersion identifies the evidence bundle layout so a
+// bundle can be interpreted long after the code that produced it changed.
+const EvidenceBundleSchemaVersion = 1
+
+// evidenceMessage is one decrypted message inside an evidence bundle.
+type evidenceMessage struct {
+	ID            int64     `json:"id"`
+	Sender        string    `json:"sender"`
+	Timestamp     time.Time `json:"timestamp"`
+	Text          string    `json:"text"`
+	Source        string    `json:"source"`
+	IsOutgoing    bool      `json:"is_outgoing"`
+	Forwarded     bool      `json:"forwarded"`
+	ForwardedFrom string    `json:"forwarded_from,omitempty"`
+	Deleted       bool      `json:"deleted"`
+}
+
+// evidenceBundle is the self-contained export of one incident: the flagged
+// message, surrounding conversation, edit history, and the classification
+// verdict, assembled for handing to authorities.
+type evidenceBundle struct {
+	SchemaVersion   int                   `json:"schema_version"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+	IncidentID      int64                 `json:"incident_id"`
+	ThreatType      string                `json:"threat_type"`
+	ModelConfidence float64               `json:"model_confidence"`
+	Status          string                `json:"status"`
+	ChatTitle       string                `json:"chat_title"`
+	CreatedAt       time.Time             `json:"created_at"`
+	Summary         string                `json:"summary"`
+	FirstContact    bool                  `json:"first_contact"`
+	OccurrenceCount int                   `json:"occurrence_count"`
+	Tags            []string              `json:"tags"`
+	Message         evidenceMessage       `json:"message"`
+	Context         []evidenceMessage     `json:"context"`
+	EditHistory     []*models.MessageEdit `json:"edit_history"`
+}
+
+// GetIncidentEvidence handles GET /api/events/:id/evidence
+// Produces the high-stakes evidence export: unlike the list endpoints it
+// refuses outright (403) when access control has not granted this incident,
+// and every export is audit-logged. The response carries a SHA-256 manifest
+// over the bundle JSON so tampering after export is detectable.
+// GetOverdueIncidents handles GET /api/events/overdue
+// Lists incidents still in status "new" whose review deadline has passed,
+// with severity and how far past the deadline each one is.
+func (h *incidentHandler) GetOverdueIncidents(c *gin.Context) {
+	incidents, err := h.messageRepo.GetIncidentsByStatus("new")
+	if err != nil {
+		h.logger.Error("Failed to get open incidents", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to get incidents")
+		return
+	}
+
+	checker := sla.NewChecker(h.cfg)
+	now := time.Now()
+	overdue := make([]gin.H, 0)
+	for _, incident := range incidents {
+		isOverdue, severity, deadline := checker.Evaluate(incident, now)
+		if !isOverdue {
+			continue
+		}
+		overdue = append(overdue, gin.H{
+			"incident":        incident,
+			"severity":        severity,
+			"deadline":        deadline,
+			"overdue_seconds": int64(now.Sub(deadline).Seconds()),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incidents": overdue,
+		"count":     len(overdue),
+	})
+}
+
+// GetIncidentMessages handles GET /api/events/:id/messages
+// Returns the full flagged-message set of an incident: the primary message
+// plus any appended by session grouping. Content is decrypted, subject to the
+// same access rule as the evidence export.
+func (h *incidentHandler) GetIncidentMessages(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid incident ID")
+		return
+	}
+
+	incident, err := h.messageRepo.GetIncidentByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, CodeNotFound, "Incident not found")
+			return
+		}
+		h.logger.Error("Failed to get incident", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incident")
+		return
+	}
+	if incident == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Incident not found")
+		return
+	}
+
+	if h.cfg.AccessControl.Enabled && !incident.AccessGranted {
+		respondError(c, http.StatusForbidden, CodeForbidden, "Access to incident content has not been granted")
+		return
+	}
+
+	messages, err := h.messageRepo.GetIncidentMessages(id)
+	if err != nil {
+		h.logger.Error("Failed to get incident messages", zap.Int64("incident_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incident messages")
+		return
+	}
+
+	systemUser, err := h.authRepo.GetUserByUsername("admin")
+	if err != nil {
+		h.logger.Error("Failed to get system user for decryption", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incident messages")
+		return
+	}
+
+	// Partial grants: a scope that does not cover a field redacts just that
+	// field instead of refusing the whole endpoint.
+	allowText := !h.cfg.AccessControl.Enabled || models.ScopeAllows(incident.AccessScope, models.AccessScopeText)
+	allowSender := !h.cfg.AccessControl.Enabled || models.ScopeAllows(incident.AccessScope, models.AccessScopeSender)
+
+	items := make([]gin.H, 0, len(messages))
+	for _, msg := range messages {
+		text := hiddenTextPlaceholder
+		if allowText {
+			decrypted, decErr := h.keyManager.DecryptMessage(msg.ContentEncrypted, systemUser.ID, systemUser.DKEncrypted)
+			if decErr != nil {
+				h.logger.Warn("Failed to decrypt incident message",
+					zap.Int64("incident_id", id), zap.Int64("message_id", msg.ID), zap.Error(decErr))
+				decrypted = "[decryption failed]"
+			}
+			text = decrypted
+		}
+		sender := msg.SenderUsername
+		if !allowSender {
+			sender = hiddenSenderPlaceholder
+		}
+		items = append(items, gin.H{
+			"id":             msg.ID,
+			"sender":         sender,
+			"timestamp":      msg.Timestamp,
+			"text":           text,
+			"source":         msg.Source,
+			"is_outgoing":    msg.IsOutgoing,
+			"reaction_count": msg.ReactionCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incident_id": id,
+		"messages":    items,
+		"count":       len(items),
+	})
+}
+
+func (h *incidentHandler) GetIncidentEvidence(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid incident ID")
+		return
+	}
+
+	incident, err := h.messageRepo.GetIncidentByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, CodeNotFound, "Incident not found")
+			return
+		}
+		h.logger.Error("Failed to get incident", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incident")
+		return
+	}
+
+	if h.cfg.AccessControl.Enabled && !incident.AccessGranted {
+		respondError(c, http.StatusForbidden, CodeForbidden, "Access to incident content has not been granted")
+		return
+	}
+
+	systemUser, err := h.authRepo.GetUserByUsername("admin")
+	if err != nil {
+		h.logger.Error("Failed to get system user for decryption", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to build evidence bundle")
+		return
+	}
+	// Partial grants redact individual fields of the bundle; the export
+	// itself still requires a grant (checked above).
+	allowText := !h.cfg.AccessControl.Enabled || models.ScopeAllows(incident.AccessScope, models.AccessScopeText)
+	allowSender := !h.cfg.AccessControl.Enabled || models.ScopeAllows(incident.AccessScope, models.AccessScopeSender)
+	allowIndicators := !h.cfg.AccessControl.Enabled || models.ScopeAllows(incident.AccessScope, models.AccessScopeIndicators)
+
+	decrypt := func(encrypted string) string {
+		if !allowText {
+			return hiddenTextPlaceholder
+		}
+		if encrypted == "" {
+			return ""
+		}
+		decrypted, err := h.keyManager.DecryptMessage(encrypted, systemUser.ID, systemUser.DKEncrypted)
+		if err != nil {
+			h.logger.Warn("Failed to decrypt content for evidence bundle",
+				zap.Int64("incident_id", id), zap.Error(err))
+			return "[decryption failed]"
+		}
+		return decrypted
+	}
+
+	message, err := h.messageRepo.GetMessageByID(incident.MessageID)
+	if err != nil {
+		h.logger.Error("Failed to get flagged message", zap.Int64("message_id", incident.MessageID), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to build evidence bundle")
+		return
+	}
+
+	context, err := h.messageRepo.GetMessageContext(message.ChatID, message.Timestamp, 10)
+	if err != nil {
+		h.logger.Error("Failed to get message context", zap.Int64("chat_id", message.ChatID), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to build evidence bundle")
+		return
+	}
+
+	edits, err := h.messageRepo.GetMessageEdits(message.ID)
+	if err != nil {
+		h.logger.Error("Failed to get message edits", zap.Int64("message_id", message.ID), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to build evidence bundle")
+		return
+	}
+	for _, edit := range edits {
+		edit.OldContentEncrypted = decrypt(edit.OldContentEncrypted)
+		edit.NewContentEncrypted = decrypt(edit.NewContentEncrypted)
+	}
+
+	toEvidenceMessage := func(msg *models.Message) evidenceMessage {
+		sender := msg.SenderUsername
+		if !allowSender {
+			sender = hiddenSenderPlaceholder
+		}
+		return evidenceMessage{
+			ID:            msg.ID,
+			Sender:        sender,
+			Timestamp:     msg.Timestamp,
+			Text:          decrypt(msg.ContentEncrypted),
+			Source:        msg.Source,
+			IsOutgoing:    msg.IsOutgoing,
+			Forwarded:     msg.Forwarded,
+			ForwardedFrom: msg.ForwardedFrom,
+			Deleted:       msg.Deleted,
+		}
+	}
+
+	contextMessages := make([]evidenceMessage, 0, len(context))
+	for _, msg := range context {
+		contextMessages = append(contextMessages, toEvidenceMessage(msg))
+	}
+
+	// The surrounding conversation and edit history are supporting evidence,
+	// gated behind the "indicators" scope.
+	if !allowIndicators {
+		contextMessages = []evidenceMessage{}
+		edits = []*models.MessageEdit{}
+	}
+
+	h.attachIncidentTags([]*models.Incident{incident})
+	if incident.Tags == nil {
+		incident.Tags = []string{}
+	}
+
+	bundle := evidenceBundle{
+		SchemaVersion:   EvidenceBundleSchemaVersion,
+		GeneratedAt:     time.Now().UTC(),
+		IncidentID:      incident.ID,
+		ThreatType:      incident.ThreatType,
+		ModelConfidence: incident.ModelConfidence,
+		Status:          incident.Status,
+		ChatTitle:       incident.ChatTitle,
+		CreatedAt:       incident.CreatedAt,
+		Summary:         decrypt(incident.SummaryEncrypted),
+		FirstContact:    incident.FirstContact,
+		OccurrenceCount: incident.OccurrenceCount,
+		Tags:            incident.Tags,
+		Message:         toEvidenceMessage(message),
+		Context:         contextMessages,
+		EditHistory:     edits,
+	}
+
+	bundleJSON, err := json.Marshal(bundle)
+	if err != nil {
+		h.logger.Error("Failed to marshal evidence bundle", zap.Int64("incident_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to build evidence bundle")
+		return
+	}
+	digest := sha256.Sum256(bundleJSON)
+
+	h.logger.Info("AUDIT: Incident evidence bundle exported",
+		zap.Int64("incident_id", id),
+		zap.String("sha256", hex.EncodeToString(digest[:])))
+
+	c.JSON(http.StatusOK, gin.H{
+		"bundle": json.RawMessage(bundleJSON),
+		"manifest": gin.H{
+			"algorithm": "sha256",
+			"digest":    hex.EncodeToString(digest[:]),
+		},
+	})
+}
+
 // UpdateIncidentStatus handles PUT /api/events/:id/status
 type UpdateStatusRequest struct {
 	Status string `json:"status" binding:"required"`
@@ -168,34 +920,160 @@ func (h *incidentHandler) UpdateIncidentStatus(c *gin.Context) {
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		h.logger.Error("Invalid incident ID", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incident ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid incident ID")
 		return
 	}
 
 	var req UpdateStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind JSON for status update", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Validate status values
 	validStatuses := map[string]bool{
 		"new":            true,
+		"escalated":      true,
 		"reviewed":       true,
 		"false_positive": true,
 	}
 	if !validStatuses[req.Status] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values: new, reviewed, false_positive"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid status. Valid values: new, escalated, reviewed, false_positive")
 		return
 	}
 
 	err = h.messageRepo.UpdateIncidentStatus(id, req.Status)
 	if err != nil {
 		h.logger.Error("Failed to update incident status", zap.Int64("id", id), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update incident status"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to update incident status")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Incident status updated successfully"})
 }
+
+// MergeIncidentsRequest represents the request body for merging duplicate incidents
+type MergeIncidentsRequest struct {
+	PrimaryID    int64   `json:"primary_id" binding:"required"`
+	DuplicateIDs []int64 `json:"duplicate_ids" binding:"required,min=1"`
+}
+
+// MergeIncidents handles POST /api/events/merge
+// Folds duplicate incidents into a primary: occurrence counts and tags are
+// combined and the duplicates are archived with status "merged".
+func (h *incidentHandler) MergeIncidents(c *gin.Context) {
+	var req MergeIncidentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	for _, id := range req.DuplicateIDs {
+		if id == req.PrimaryID {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "primary_id must not appear in duplicate_ids")
+			return
+		}
+	}
+
+	if err := h.messageRepo.MergeIncidents(req.PrimaryID, req.DuplicateIDs); err != nil {
+		h.logger.Error("Failed to merge incidents", zap.Int64("primary_id", req.PrimaryID), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to merge incidents")
+		return
+	}
+
+	h.logger.Info("AUDIT: incidents merged",
+		zap.Int64("primary_id", req.PrimaryID),
+		zap.Int64s("duplicate_ids", req.DuplicateIDs))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Incidents merged successfully",
+		"primary_id":   req.PrimaryID,
+		"merged_count": len(req.DuplicateIDs),
+	})
+}
+
+// AddIncidentTag handles POST /api/events/:id/tags
+type AddTagRequest struct {
+	Tag string `json:"tag" binding:"required"`
+}
+
+func (h *incidentHandler) AddIncidentTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid incident ID")
+		return
+	}
+
+	var req AddTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	incident, err := h.messageRepo.GetIncidentByID(id)
+	if err != nil {
+		h.logger.Error("Failed to get incident for tagging", zap.Int64("id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve incident")
+		return
+	}
+	if incident == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Incident not found")
+		return
+	}
+
+	if err := h.messageRepo.AddIncidentTag(id, req.Tag); err != nil {
+		h.logger.Error("Failed to add incident tag", zap.Int64("id", id), zap.String("tag", req.Tag), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to add tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag added successfully", "incident_id": id, "tag": req.Tag})
+}
+
+// RemoveIncidentTag handles DELETE /api/events/:id/tags/:tag
+func (h *incidentHandler) RemoveIncidentTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid incident ID")
+		return
+	}
+
+	tag := c.Param("tag")
+	if err := h.messageRepo.RemoveIncidentTag(id, tag); err != nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag removed successfully", "incident_id": id, "tag": tag})
+}
+
+// ListIncidentTags handles GET /api/events/tags
+// Returns the tagging taxonomy: seed tags from config plus every tag already in use.
+func (h *incidentHandler) ListIncidentTags(c *gin.Context) {
+	used, err := h.messageRepo.ListIncidentTags()
+	if err != nil {
+		h.logger.Error("Failed to list incident tags", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to list tags")
+		return
+	}
+
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(used)+len(h.cfg.Tagging.SeedTags))
+	for _, tag := range h.cfg.Tagging.SeedTags {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range used {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
@@ -1,12 +1,18 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
+	"backend/internal/annotation_client"
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -14,25 +20,78 @@ import (
 
 // MLDatasetHandler handles ML dataset-related requests.
 type MLDatasetHandler struct {
-	mlDatasetRepo repository.MLDatasetRepository
-	logger        *zap.Logger
+	mlDatasetRepo    repository.MLDatasetRepository
+	annotationClient *annotation_client.Client
+	logger           *zap.Logger
+
+	// jobMu guards reannotation, the current (or most recent)
+	// re-annotation job. Only one job runs at a time.
+	jobMu        sync.Mutex
+	reannotation *reannotationJob
 }
 
 // NewMLDatasetHandler creates a new ML dataset handler.
-func NewMLDatasetHandler(db *sql.DB, logger *zap.Logger) *MLDatasetHandler {
+func NewMLDatasetHandler(db *sql.DB, annotationClient *annotation_client.Client, logger *zap.Logger) *MLDatasetHandler {
 	return &MLDatasetHandler{
-		mlDatasetRepo: repository.NewMLDatasetRepository(db),
-		logger:        logger,
+		mlDatasetRepo:    repository.NewMLDatasetRepository(db),
+		annotationClient: annotationClient,
+		logger:           logger,
 	}
 }
 
 // GetAllEntries returns all ML dataset entries.
+// Supports keyset pagination via `after` (opaque cursor) and `limit` query parameters.
 // GET /api/ml-dataset
 func (h *MLDatasetHandler) GetAllEntries(c *gin.Context) {
+	afterToken := c.Query("after")
+	limitStr := c.Query("limit")
+
+	if afterToken != "" || limitStr != "" {
+		limit := 50
+		if limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed < 1 || parsed > 500 {
+				respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid limit (must be 1-500)")
+				return
+			}
+			limit = parsed
+		}
+
+		var after *pagination.Cursor
+		if afterToken != "" {
+			parsed, err := pagination.Decode(afterToken)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid cursor")
+				return
+			}
+			after = parsed
+		}
+
+		entries, err := h.mlDatasetRepo.GetEntriesPage(after, limit)
+		if err != nil {
+			h.logger.Error("Failed to get ML dataset page", zap.Error(err))
+			respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch dataset entries")
+			return
+		}
+
+		nextCursor := ""
+		if len(entries) == limit {
+			last := entries[len(entries)-1]
+			nextCursor = (&pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"entries":     entries,
+			"count":       len(entries),
+			"next_cursor": nextCursor,
+		})
+		return
+	}
+
 	entries, err := h.mlDatasetRepo.GetAllEntries()
 	if err != nil {
 		h.logger.Error("Failed to get ML dataset entries", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dataset entries"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch dataset entries")
 		return
 	}
 
@@ -48,14 +107,14 @@ func (h *MLDatasetHandler) GetEntriesByCategory(c *gin.Context) {
 	categoryIDStr := c.Param("category_id")
 	categoryID, err := strconv.Atoi(categoryIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid category ID")
 		return
 	}
 
 	entries, err := h.mlDatasetRepo.GetEntriesByCategory(categoryID)
 	if err != nil {
 		h.logger.Error("Failed to get ML dataset entries by category", zap.Error(err), zap.Int("category_id", categoryID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dataset entries"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch dataset entries")
 		return
 	}
 
@@ -72,7 +131,7 @@ func (h *MLDatasetHandler) GetValidatedEntries(c *gin.Context) {
 	entries, err := h.mlDatasetRepo.GetValidatedEntries()
 	if err != nil {
 		h.logger.Error("Failed to get validated ML dataset entries", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch validated entries"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch validated entries")
 		return
 	}
 
@@ -88,7 +147,7 @@ func (h *MLDatasetHandler) GetUnvalidatedEntries(c *gin.Context) {
 	entries, err := h.mlDatasetRepo.GetUnvalidatedEntries()
 	if err != nil {
 		h.logger.Error("Failed to get unvalidated ML dataset entries", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch unvalidated entries"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch unvalidated entries")
 		return
 	}
 
@@ -104,7 +163,7 @@ func (h *MLDatasetHandler) ValidateEntry(c *gin.Context) {
 	entryIDStr := c.Param("id")
 	entryID, err := strconv.ParseInt(entryIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid entry ID")
 		return
 	}
 
@@ -116,7 +175,7 @@ func (h *MLDatasetHandler) ValidateEntry(c *gin.Context) {
 	err = h.mlDatasetRepo.ValidateEntry(entryID, validatedBy)
 	if err != nil {
 		h.logger.Error("Failed to validate ML dataset entry", zap.Error(err), zap.Int64("entry_id", entryID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate entry"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to validate entry")
 		return
 	}
 
@@ -126,13 +185,203 @@ func (h *MLDatasetHandler) ValidateEntry(c *gin.Context) {
 	})
 }
 
+// ValidateBulkEntries marks a batch of entries as validated in one request.
+// POST /api/ml-dataset/validate-bulk
+func (h *MLDatasetHandler) ValidateBulkEntries(c *gin.Context) {
+	var req struct {
+		IDs         []int64 `json:"ids" binding:"required,min=1"`
+		ValidatedBy int64   `json:"validated_by"`
+		Corrections []struct {
+			ID           int64  `json:"id" binding:"required"`
+			CategoryID   int    `json:"category_id" binding:"required"`
+			CategoryName string `json:"category_name" binding:"required"`
+		} `json:"corrections"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// TODO: Get actual user ID from JWT token (same placeholder as ValidateEntry)
+	validatedBy := req.ValidatedBy
+	if validatedBy == 0 {
+		validatedBy = 1
+	}
+
+	corrections := make(map[int64]models.CategoryCorrection, len(req.Corrections))
+	for _, correction := range req.Corrections {
+		corrections[correction.ID] = models.CategoryCorrection{
+			CategoryID:   correction.CategoryID,
+			CategoryName: correction.CategoryName,
+		}
+	}
+
+	validated, err := h.mlDatasetRepo.ValidateEntries(req.IDs, validatedBy, corrections)
+	if err != nil {
+		h.logger.Error("Failed to bulk-validate ML dataset entries", zap.Error(err), zap.Int("requested", len(req.IDs)))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to validate entries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Entries validated successfully",
+		"validated_count": validated,
+		"requested_count": len(req.IDs),
+	})
+}
+
+// reannotationJob tracks the progress of one background dataset
+// re-annotation run.
+type reannotationJob struct {
+	ID               string     `json:"id"`
+	Status           string     `json:"status"` // "running" or "completed"
+	Total            int        `json:"total"`
+	Processed        int        `json:"processed"`
+	Updated          int        `json:"updated"`
+	Failed           int        `json:"failed"`
+	SkippedValidated int        `json:"skipped_validated"`
+	StartedAt        time.Time  `json:"started_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+}
+
+// ReannotateDataset starts a background job that streams unvalidated entries
+// back through the annotation service and replaces their machine labels, so
+// the dataset improves when a better model arrives. Human-validated entries
+// are never touched. Admin only.
+// POST /api/ml-dataset/reannotate?only_unvalidated=true
+func (h *MLDatasetHandler) ReannotateDataset(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	if role != "admin" {
+		respondError(c, http.StatusForbidden, CodeForbidden, "Admin role required")
+		return
+	}
+
+	if h.annotationClient == nil {
+		respondError(c, http.StatusServiceUnavailable, CodeUnavailable, "Annotation service is not enabled")
+		return
+	}
+
+	// Human-confirmed labels are never overwritten; the parameter exists so
+	// callers state that explicitly, and anything else is rejected.
+	if c.DefaultQuery("only_unvalidated", "true") != "true" {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "only_unvalidated=false is not supported: validated entries are never re-annotated")
+		return
+	}
+
+	entries, err := h.mlDatasetRepo.GetUnvalidatedEntries()
+	if err != nil {
+		h.logger.Error("Failed to get unvalidated entries for re-annotation", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch unvalidated entries")
+		return
+	}
+
+	h.jobMu.Lock()
+	if h.reannotation != nil && h.reannotation.Status == "running" {
+		h.jobMu.Unlock()
+		respondError(c, http.StatusConflict, CodeConflict, "A re-annotation job is already running")
+		return
+	}
+	job := &reannotationJob{
+		ID:        fmt.Sprintf("reannotate-%d", time.Now().UnixNano()),
+		Status:    "running",
+		Total:     len(entries),
+		StartedAt: time.Now(),
+	}
+	h.reannotation = job
+	snapshot := *job
+	h.jobMu.Unlock()
+
+	go h.runReannotation(job, entries)
+
+	h.logger.Info("Dataset re-annotation job started",
+		zap.String("job_id", job.ID),
+		zap.Int("total", len(entries)))
+
+	c.JSON(http.StatusAccepted, gin.H{"job": snapshot})
+}
+
+// GetReannotationStatus reports the progress of the current (or most recent)
+// re-annotation job.
+// GET /api/ml-dataset/reannotate/status
+func (h *MLDatasetHandler) GetReannotationStatus(c *gin.Context) {
+	h.jobMu.Lock()
+	defer h.jobMu.Unlock()
+
+	if h.reannotation == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "No re-annotation job has been started")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": *h.reannotation})
+}
+
+// runReannotation re-annotates the entries one at a time, updating the job's
+// counters as it goes. Per-entry failures are counted and skipped so one bad
+// message doesn't abort the run.
+func (h *MLDatasetHandler) runReannotation(job *reannotationJob, entries []*models.MLDatasetEntry) {
+	for _, entry := range entries {
+		if entry.IsValidated {
+			h.jobMu.Lock()
+			job.Processed++
+			job.SkippedValidated++
+			h.jobMu.Unlock()
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		annotation, err := h.annotationClient.AnnotateSingle(ctx, entry.MessageText)
+		cancel()
+		if err != nil {
+			h.logger.Error("Re-annotation failed for entry", zap.Int64("entry_id", entry.ID), zap.Error(err))
+			h.jobMu.Lock()
+			job.Processed++
+			job.Failed++
+			h.jobMu.Unlock()
+			continue
+		}
+
+		updated, err := h.mlDatasetRepo.ReannotateEntry(entry.ID, annotation.CategoryID,
+			annotation.CategoryName, annotation.Justification, annotation.Provider,
+			annotation.ModelVersion, time.Now())
+
+		h.jobMu.Lock()
+		job.Processed++
+		switch {
+		case err != nil:
+			job.Failed++
+		case !updated:
+			// Validated (or deleted) while the job was running.
+			job.SkippedValidated++
+		default:
+			job.Updated++
+		}
+		h.jobMu.Unlock()
+		if err != nil {
+			h.logger.Error("Failed to update re-annotated entry", zap.Int64("entry_id", entry.ID), zap.Error(err))
+		}
+	}
+
+	now := time.Now()
+	h.jobMu.Lock()
+	job.Status = "completed"
+	job.CompletedAt = &now
+	finished := *job
+	h.jobMu.Unlock()
+
+	h.logger.Info("Dataset re-annotation job finished",
+		zap.String("job_id", finished.ID),
+		zap.Int("updated", finished.Updated),
+		zap.Int("failed", finished.Failed),
+		zap.Int("skipped_validated", finished.SkippedValidated))
+}
+
 // GetDatasetStats returns statistics about the ML dataset.
 // GET /api/ml-dataset/stats
 func (h *MLDatasetHandler) GetDatasetStats(c *gin.Context) {
 	stats, err := h.mlDatasetRepo.GetDatasetStats()
 	if err != nil {
 		h.logger.Error("Failed to get ML dataset stats", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dataset statistics"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch dataset statistics")
 		return
 	}
 
@@ -153,7 +402,7 @@ func (h *MLDatasetHandler) CreateEntry(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -171,7 +420,7 @@ func (h *MLDatasetHandler) CreateEntry(c *gin.Context) {
 
 	if err := h.mlDatasetRepo.SaveEntry(entry); err != nil {
 		h.logger.Error("Failed to save ML dataset entry", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to save entry")
 		return
 	}
 
@@ -181,13 +430,26 @@ func (h *MLDatasetHandler) CreateEntry(c *gin.Context) {
 	})
 }
 
-// ExportDataset exports the ML dataset in JSON format.
-// GET /api/ml-dataset/export
+// DatasetExportSchemaVersion identifies the shape of exported dataset
+// records. Bump it whenever exported fields change (new columns, renames) so
+// downstream ingestion pipelines can detect schema drift instead of breaking
+// silently.
+const DatasetExportSchemaVersion = 1
+
+// ExportDataset exports the ML dataset.
+// GET /api/ml-dataset/export?format=json|jsonl
+// Every format carries a schema_version and exported_at envelope.
 func (h *MLDatasetHandler) ExportDataset(c *gin.Context) {
 	// Optional: filter by validated status
 	onlyValidated := c.Query("validated") == "true"
 
-	var entries interface{}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "jsonl" {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid format. Valid values: json, jsonl")
+		return
+	}
+
+	var entries []*models.MLDatasetEntry
 	var err error
 
 	if onlyValidated {
@@ -198,12 +460,33 @@ func (h *MLDatasetHandler) ExportDataset(c *gin.Context) {
 
 	if err != nil {
 		h.logger.Error("Failed to export ML dataset", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export dataset"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to export dataset")
+		return
+	}
+
+	exportedAt := time.Now().UTC()
+
+	if format == "jsonl" {
+		// JSONL: envelope on the first line, one entry per following line.
+		c.Header("Content-Disposition", "attachment; filename=ml_dataset.jsonl")
+		c.Header("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(c.Writer)
+		encoder.Encode(gin.H{
+			"schema_version": DatasetExportSchemaVersion,
+			"exported_at":    exportedAt,
+		})
+		for _, entry := range entries {
+			encoder.Encode(entry)
+		}
 		return
 	}
 
 	// Set headers for file download
 	c.Header("Content-Disposition", "attachment; filename=ml_dataset.json")
 	c.Header("Content-Type", "application/json")
-	c.JSON(http.StatusOK, entries)
+	c.JSON(http.StatusOK, gin.H{
+		"schema_version": DatasetExportSchemaVersion,
+		"exported_at":    exportedAt,
+		"entries":        entries,
+	})
 }
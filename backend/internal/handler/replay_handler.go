@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/annotation_client"
+	"backend/internal/crypto"
+	"backend/internal/ml_client"
+	"backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReplayHandler defines the interface for the message replay debugging endpoint.
+type ReplayHandler interface {
+	ReplayMessage(c *gin.Context)
+}
+
+type replayHandler struct {
+	messageRepo      repository.MessageRepository
+	authRepo         repository.AuthRepository
+	mlClient         *ml_client.Client
+	annotationClient *annotation_client.Client
+	keyManager       *crypto.KeyManager
+	logger           *zap.Logger
+}
+
+// NewReplayHandler creates a new ReplayHandler.
+func NewReplayHandler(messageRepo repository.MessageRepository, authRepo repository.AuthRepository, mlClient *ml_client.Client, annotationClient *annotation_client.Client, keyManager *crypto.KeyManager, logger *zap.Logger) ReplayHandler {
+	return &replayHandler{
+		messageRepo:      messageRepo,
+		authRepo:         authRepo,
+		mlClient:         mlClient,
+		annotationClient: annotationClient,
+		keyManager:       keyManager,
+		logger:           logger,
+	}
+}
+
+// ReplayMessage handles POST /api/admin/messages/:id/replay
+// Re-runs a single stored message through the configured classifier and
+// returns the raw classifier output without touching any incident. Admin-only
+// debugging tool for investigating suspicious classification results.
+func (h *replayHandler) ReplayMessage(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	if role != "admin" {
+		respondError(c, http.StatusForbidden, CodeForbidden, "Admin role required")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid message ID")
+		return
+	}
+
+	msg, err := h.messageRepo.GetMessageByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(c, http.StatusNotFound, CodeNotFound, "Message not found")
+			return
+		}
+		h.logger.Error("Failed to get message for replay", zap.Int64("message_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve message")
+		return
+	}
+
+	systemUser, err := h.authRepo.GetUserByUsername("admin")
+	if err != nil {
+		h.logger.Error("Failed to get system user for decryption", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to decrypt message")
+		return
+	}
+
+	text, err := h.keyManager.DecryptMessage(msg.ContentEncrypted, systemUser.ID, systemUser.DKEncrypted)
+	if err != nil {
+		h.logger.Error("Failed to decrypt message for replay", zap.Int64("message_id", id), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to decrypt message")
+		return
+	}
+
+	// Mirror the processor's classifier preference: the annotation service
+	// when configured, the ML service otherwise.
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	var (
+		classifier string
+		result     interface{}
+	)
+	if h.annotationClient != nil {
+		classifier = "annotation"
+		result, err = h.annotationClient.AnnotateSingle(ctx, text)
+	} else {
+		classifier = "ml"
+		result, err = h.mlClient.ClassifySingle(ctx, text)
+	}
+	latency := time.Since(start)
+
+	if err != nil {
+		h.logger.Error("Replay classification failed", zap.Int64("message_id", id), zap.String("classifier", classifier), zap.Error(err))
+		respondError(c, http.StatusBadGateway, CodeUnavailable, "Classifier request failed")
+		return
+	}
+
+	h.logger.Info("AUDIT: message replayed through classifier",
+		zap.Int64("message_id", id),
+		zap.String("classifier", classifier),
+		zap.Duration("latency", latency))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message_id": id,
+		"classifier": classifier,
+		"latency_ms": float64(latency.Microseconds()) / 1000.0,
+		"result":     result,
+	})
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/internal/annotation_client"
+	"backend/internal/crypto"
+	"backend/internal/ml_client"
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReprocessHandler defines the interface for the failed-classification
+// replay endpoints.
+type ReprocessHandler interface {
+	ReprocessFailed(c *gin.Context)
+	GetReprocessStatus(c *gin.Context)
+}
+
+type reprocessHandler struct {
+	messageRepo      repository.MessageRepository
+	failedRepo       repository.FailedClassificationRepository
+	authRepo         repository.AuthRepository
+	mlClient         *ml_client.Client
+	annotationClient *annotation_client.Client
+	keyManager       *crypto.KeyManager
+	logger           *zap.Logger
+
+	jobMu sync.Mutex
+	job   *reprocessJob
+}
+
+// reprocessJob is the progress snapshot of one reprocess run. One run at a
+// time; the last job stays queryable after completion.
+type reprocessJob struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"` // "running", "completed"
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Incidents   int        `json:"incidents"`
+	Failed      int        `json:"failed"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// NewReprocessHandler creates a new ReprocessHandler.
+func NewReprocessHandler(messageRepo repository.MessageRepository, failedRepo repository.FailedClassificationRepository, authRepo repository.AuthRepository, mlClient *ml_client.Client, annotationClient *annotation_client.Client, keyManager *crypto.KeyManager, logger *zap.Logger) ReprocessHandler {
+	return &reprocessHandler{
+		messageRepo:      messageRepo,
+		failedRepo:       failedRepo,
+		authRepo:         authRepo,
+		mlClient:         mlClient,
+		annotationClient: annotationClient,
+		keyManager:       keyManager,
+		logger:           logger,
+	}
+}
+
+// ReprocessFailed handles POST /api/admin/reprocess-failed
+// Query parameters:
+// - chat_id: limit the replay to one chat (optional)
+// - since: only failures at or after this RFC3339 timestamp (optional)
+// - dry_run: report how many entries would be retried without starting (optional)
+// Re-runs classification for dead-lettered messages after an ML/LLM outage is
+// resolved. Starts a background job and returns its ID; progress is available
+// at GET /api/admin/reprocess-failed/status. Admin only.
+func (h *reprocessHandler) ReprocessFailed(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	if role != "admin" {
+		respondError(c, http.StatusForbidden, CodeForbidden, "Admin role required")
+		return
+	}
+
+	var chatID *int64
+	if raw := c.Query("chat_id"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat_id")
+			return
+		}
+		chatID = &parsed
+	}
+
+	var since *time.Time
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid since timestamp (expected RFC3339)")
+			return
+		}
+		since = &parsed
+	}
+
+	failures, err := h.failedRepo.GetPendingFailures(chatID, since)
+	if err != nil {
+		h.logger.Error("Failed to get pending classification failures", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to fetch failed classifications")
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "would_retry": len(failures)})
+		return
+	}
+
+	if h.annotationClient == nil && h.mlClient == nil {
+		respondError(c, http.StatusServiceUnavailable, CodeUnavailable, "No classifier is configured")
+		return
+	}
+
+	h.jobMu.Lock()
+	if h.job != nil && h.job.Status == "running" {
+		h.jobMu.Unlock()
+		respondError(c, http.StatusConflict, CodeConflict, "A reprocess job is already running")
+		return
+	}
+	job := &reprocessJob{
+		ID:        fmt.Sprintf("reprocess-%d", time.Now().UnixNano()),
+		Status:    "running",
+		Total:     len(failures),
+		StartedAt: time.Now(),
+	}
+	h.job = job
+	snapshot := *job
+	h.jobMu.Unlock()
+
+	go h.runReprocess(job, failures)
+
+	h.logger.Info("AUDIT: failed-classification reprocess job started",
+		zap.String("job_id", job.ID),
+		zap.Int("total", len(failures)))
+
+	c.JSON(http.StatusAccepted, gin.H{"job": snapshot})
+}
+
+// GetReprocessStatus reports the progress of the current (or most recent)
+// reprocess job.
+// GET /api/admin/reprocess-failed/status
+func (h *reprocessHandler) GetReprocessStatus(c *gin.Context) {
+	h.jobMu.Lock()
+	defer h.jobMu.Unlock()
+
+	if h.job == nil {
+		respondError(c, http.StatusNotFound, CodeNotFound, "No reprocess job has been started")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": *h.job})
+}
+
+// runReprocess replays the dead-lettered messages one at a time. Per-entry
+// failures are counted and left pending, so the entry can be retried on the
+// next run.
+func (h *reprocessHandler) runReprocess(job *reprocessJob, failures []*models.FailedClassification) {
+	systemUser, err := h.authRepo.GetUserByUsername("admin")
+	if err != nil {
+		h.logger.Error("Failed to get system user for reprocess", zap.Error(err))
+		h.finishReprocess(job)
+		return
+	}
+
+	for _, failure := range failures {
+		createdIncident, err := h.reprocessOne(failure, systemUser.ID, systemUser.DKEncrypted)
+
+		h.jobMu.Lock()
+		job.Processed++
+		if err != nil {
+			job.Failed++
+		} else if createdIncident {
+			job.Incidents++
+		}
+		h.jobMu.Unlock()
+
+		if err != nil {
+			h.logger.Error("Reprocess failed for entry",
+				zap.Int64("entry_id", failure.ID),
+				zap.Int64("message_id", failure.MessageID),
+				zap.Error(err))
+		}
+	}
+
+	h.finishReprocess(job)
+}
+
+func (h *reprocessHandler) finishReprocess(job *reprocessJob) {
+	h.jobMu.Lock()
+	now := time.Now()
+	job.Status = "completed"
+	job.CompletedAt = &now
+	h.jobMu.Unlock()
+
+	h.logger.Info("Failed-classification reprocess job finished",
+		zap.String("job_id", job.ID),
+		zap.Int("processed", job.Processed),
+		zap.Int("incidents", job.Incidents),
+		zap.Int("failed", job.Failed))
+}
+
+// reprocessOne re-runs classification for one dead-lettered message and saves
+// an incident when the verdict is a threat. The entry is marked reprocessed
+// on any successful classification, threat or not.
+func (h *reprocessHandler) reprocessOne(failure *models.FailedClassification, systemUserID int64, systemUserDKEnc string) (bool, error) {
+	msg, err := h.messageRepo.GetMessageByID(failure.MessageID)
+	if err != nil {
+		return false, fmt.Errorf("get message: %w", err)
+	}
+
+	text, err := h.keyManager.DecryptMessage(msg.ContentEncrypted, systemUserID, systemUserDKEnc)
+	if err != nil {
+		return false, fmt.Errorf("decrypt message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	incident, err := h.classify(ctx, text)
+	if err != nil {
+		return false, err
+	}
+
+	createdIncident := false
+	if incident != nil {
+		incident.MessageID = failure.MessageID
+		encrypted, encErr := h.keyManager.EncryptMessage(text, systemUserID, systemUserDKEnc)
+		if encErr != nil {
+			h.logger.Error("Failed to encrypt reprocessed incident summary", zap.Error(encErr), zap.Int64("message_id", failure.MessageID))
+		} else {
+			incident.SummaryEncrypted = encrypted
+		}
+		if err := h.messageRepo.SaveIncident(incident); err != nil {
+			return false, fmt.Errorf("save incident: %w", err)
+		}
+		createdIncident = true
+	}
+
+	if err := h.failedRepo.MarkReprocessed(failure.ID); err != nil {
+		h.logger.Error("Failed to mark entry reprocessed", zap.Error(err), zap.Int64("entry_id", failure.ID))
+	}
+	return createdIncident, nil
+}
+
+// classify runs the message through the same classifier preference as the
+// processor (annotation service when configured, ML service otherwise) and
+// returns an incident skeleton for threat verdicts, nil for neutral ones.
+func (h *reprocessHandler) classify(ctx context.Context, text string) (*models.Incident, error) {
+	if h.annotationClient != nil {
+		annotation, err := h.annotationClient.AnnotateSingle(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("annotate: %w", err)
+		}
+		if annotation.CategoryID == 9 {
+			return nil, nil
+		}
+		groomingStage := annotation.GroomingStage
+		if !models.ValidGroomingStage(groomingStage) {
+			groomingStage = ""
+		}
+		return &models.Incident{
+			ThreatType:       annotation.CategoryName,
+			ModelConfidence:  annotation.Confidence,
+			ModelProvider:    annotation.Provider,
+			ModelVersion:     annotation.ModelVersion,
+			ConfidenceSource: "model",
+			Status:           "new",
+			GroomingStage:    groomingStage,
+		}, nil
+	}
+
+	classification, err := h.mlClient.ClassifySingle(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("classify: %w", err)
+	}
+	if !classification.IsAttack {
+		return nil, nil
+	}
+	category := classification.Category
+	confidence := classification.Confidence
+	if category == "" && classification.PrimaryCategory != "" {
+		category = classification.PrimaryCategory
+		confidence = classification.PrimaryConfidence
+	}
+	return &models.Incident{
+		ThreatType:       category,
+		ModelConfidence:  confidence,
+		ModelProvider:    "ml_service",
+		ConfidenceSource: "model",
+		Status:           "new",
+	}, nil
+}
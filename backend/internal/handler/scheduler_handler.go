@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/scheduler"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SchedulerHandler exposes the state of the background scheduler.
+type SchedulerHandler struct {
+	scheduler *scheduler.BackgroundScheduler
+	logger    *zap.Logger
+}
+
+// NewSchedulerHandler creates a new scheduler handler.
+func NewSchedulerHandler(sched *scheduler.BackgroundScheduler, logger *zap.Logger) *SchedulerHandler {
+	return &SchedulerHandler{
+		scheduler: sched,
+		logger:    logger,
+	}
+}
+
+// GetSchedulerStatus handles GET /api/admin/scheduler
+// Returns all registered tasks with their intervals, last run times and last errors.
+func (h *SchedulerHandler) GetSchedulerStatus(c *gin.Context) {
+	if h.scheduler == nil {
+		respondError(c, http.StatusServiceUnavailable, CodeUnavailable, "Background scheduler is not running")
+		return
+	}
+
+	tasks := h.scheduler.TasksInfo()
+	c.JSON(http.StatusOK, gin.H{
+		"tasks": tasks,
+		"count": len(tasks),
+	})
+}
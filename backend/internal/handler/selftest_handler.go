@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"backend/internal/annotation_client"
+	"backend/internal/crypto"
+	"backend/internal/ml_client"
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// SelfTestHandler defines the interface for the deployment self-test endpoint.
+type SelfTestHandler interface {
+	RunSelfTest(c *gin.Context)
+}
+
+type selfTestHandler struct {
+	db               *sqlx.DB
+	authRepo         repository.AuthRepository
+	mlClient         *ml_client.Client
+	annotationClient *annotation_client.Client
+	keyManager       *crypto.KeyManager
+	logger           *zap.Logger
+}
+
+// NewSelfTestHandler creates a new SelfTestHandler.
+func NewSelfTestHandler(db *sqlx.DB, authRepo repository.AuthRepository, mlClient *ml_client.Client, annotationClient *annotation_client.Client, keyManager *crypto.KeyManager, logger *zap.Logger) SelfTestHandler {
+	return &selfTestHandler{
+		db:               db,
+		authRepo:         authRepo,
+		mlClient:         mlClient,
+		annotationClient: annotationClient,
+		keyManager:       keyManager,
+		logger:           logger,
+	}
+}
+
+// selfTestStage is one pipeline stage's pass/fail result.
+type selfTestStage struct {
+	Stage   string `json:"stage"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Canned self-test inputs: one obvious threat and one benign message. They
+// never leave the request context and nothing is persisted.
+const (
+	selfTestThreatText = "Срочно переведи деньги, иначе всем расскажу твой секрет!"
+	selfTestBenignText = "Привет! Как прошёл твой день в школе?"
+)
+
+// RunSelfTest handles GET /api/admin/selftest
+// Exercises the live classification pipeline end to end with canned messages:
+// database, encryption round-trip, classifier verdicts for a threat and a
+// benign message, annotation service reachability, and in-memory incident
+// assembly. Nothing is written to real incident data; this is the one-button
+// smoke test after a deployment or config change.
+func (h *selfTestHandler) RunSelfTest(c *gin.Context) {
+	role := c.MustGet("role").(string)
+	if role != "admin" {
+		respondError(c, http.StatusForbidden, CodeForbidden, "Admin role required")
+		return
+	}
+
+	var stages []selfTestStage
+	addStage := func(stage selfTestStage) {
+		stages = append(stages, stage)
+	}
+
+	// Database connectivity
+	if err := h.db.Ping(); err != nil {
+		addStage(selfTestStage{Stage: "database", Detail: err.Error()})
+	} else {
+		addStage(selfTestStage{Stage: "database", Passed: true})
+	}
+
+	// Encryption round-trip with the system user's data key
+	encryptedSummary := ""
+	systemUser, err := h.authRepo.GetUserByUsername("admin")
+	if err != nil {
+		addStage(selfTestStage{Stage: "encryption", Detail: "system user not found: " + err.Error()})
+	} else {
+		encrypted, encErr := h.keyManager.EncryptMessage(selfTestThreatText, systemUser.ID, systemUser.DKEncrypted)
+		if encErr == nil {
+			encryptedSummary = encrypted
+			decrypted, decErr := h.keyManager.DecryptMessage(encrypted, systemUser.ID, systemUser.DKEncrypted)
+			if decErr == nil && decrypted == selfTestThreatText {
+				addStage(selfTestStage{Stage: "encryption", Passed: true})
+			} else {
+				addStage(selfTestStage{Stage: "encryption", Detail: "round-trip mismatch"})
+			}
+		} else {
+			addStage(selfTestStage{Stage: "encryption", Detail: encErr.Error()})
+		}
+	}
+
+	// Classifier verdicts for the canned threat and benign messages
+	var threatClassification *ml_client.ClassifyResponse
+	classify := func(stage, text string, expectAttack bool) {
+		mlCtx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+		defer cancel()
+		classification, err := h.mlClient.ClassifySingle(mlCtx, text)
+		if err != nil {
+			addStage(selfTestStage{Stage: stage, Detail: err.Error()})
+			return
+		}
+		if classification.IsAttack != expectAttack {
+			addStage(selfTestStage{Stage: stage, Detail: "unexpected verdict"})
+			return
+		}
+		if expectAttack {
+			threatClassification = classification
+		}
+		addStage(selfTestStage{Stage: stage, Passed: true})
+	}
+	classify("classifier_threat", selfTestThreatText, true)
+	classify("classifier_benign", selfTestBenignText, false)
+
+	// Annotation service reachability (optional dependency)
+	if h.annotationClient == nil {
+		addStage(selfTestStage{Stage: "annotation_service", Skipped: true, Detail: "annotation service disabled"})
+	} else {
+		pingCtx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		err := h.annotationClient.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			addStage(selfTestStage{Stage: "annotation_service", Detail: err.Error()})
+		} else {
+			addStage(selfTestStage{Stage: "annotation_service", Passed: true})
+		}
+	}
+
+	// Incident assembly from the classifier verdict, in memory only so real
+	// incident data stays untouched.
+	if threatClassification == nil || encryptedSummary == "" {
+		addStage(selfTestStage{Stage: "incident_assembly", Skipped: true, Detail: "prerequisite stage failed"})
+	} else {
+		incident := &models.Incident{
+			ThreatType:       threatClassification.Category,
+			ModelConfidence:  threatClassification.Confidence,
+			Status:           "new",
+			SummaryEncrypted: encryptedSummary,
+		}
+		if incident.ThreatType == "" {
+			incident.ThreatType = threatClassification.PrimaryCategory
+			incident.ModelConfidence = threatClassification.PrimaryConfidence
+		}
+		if incident.ThreatType != "" && incident.SummaryEncrypted != "" {
+			addStage(selfTestStage{Stage: "incident_assembly", Passed: true})
+		} else {
+			addStage(selfTestStage{Stage: "incident_assembly", Detail: "classifier verdict carried no category"})
+		}
+	}
+
+	passed := true
+	for _, stage := range stages {
+		if !stage.Passed && !stage.Skipped {
+			passed = false
+		}
+	}
+
+	h.logger.Info("AUDIT: Pipeline self-test executed",
+		zap.Bool("passed", passed),
+		zap.Int("stages", len(stages)))
+
+	status := http.StatusOK
+	if !passed {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"passed": passed, "stages": stages})
+}
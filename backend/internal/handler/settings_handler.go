@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"backend/internal/config"
+	"backend/internal/monitoring"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -15,17 +16,20 @@ import (
 type SettingsHandler interface {
 	GetSettings(c *gin.Context)
 	UpdateSettings(c *gin.Context)
+	UpdateMonitoring(c *gin.Context)
 }
 
 type settingsHandler struct {
-	cfg    *config.Config
-	logger *zap.Logger
+	cfg        *config.Config
+	monitoring *monitoring.State
+	logger     *zap.Logger
 }
 
-func NewSettingsHandler(cfg *config.Config, logger *zap.Logger) SettingsHandler {
+func NewSettingsHandler(cfg *config.Config, monitoringState *monitoring.State, logger *zap.Logger) SettingsHandler {
 	return &settingsHandler{
-		cfg:    cfg,
-		logger: logger,
+		cfg:        cfg,
+		monitoring: monitoringState,
+		logger:     logger,
 	}
 }
 
@@ -38,18 +42,93 @@ type SettingsResponse struct {
 	AnnotationService struct {
 		Enabled bool `json:"enabled"`
 	} `json:"annotationService"`
+	Monitoring struct {
+		Enabled bool `json:"enabled"`
+	} `json:"monitoring"`
 }
 
 // GetSettings handles GET /api/settings
 func (h *settingsHandler) GetSettings(c *gin.Context) {
 	response := SettingsResponse{}
 	response.AccessControl.RequireAccessRequest = h.cfg.AccessControl.Enabled
-	response.AccessControl.AutoApproveAdmins = false // TODO: добавить в конфиг если нужно
+	response.AccessControl.AutoApproveAdmins = h.cfg.AccessControl.AutoApproveAdmins
 	response.AnnotationService.Enabled = h.cfg.AnnotationService.Enabled
+	response.Monitoring.Enabled = h.monitoring.Enabled()
 
 	c.JSON(http.StatusOK, response)
 }
 
+// GlobalMonitoringRequest represents the monitoring kill-switch request
+type GlobalMonitoringRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// UpdateMonitoring handles POST /api/settings/monitoring
+// Flips the global monitoring kill switch: when off, the processor skips all
+// collection, classification, and incident creation while the API stays up.
+func (h *settingsHandler) UpdateMonitoring(c *gin.Context) {
+	var req GlobalMonitoringRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	previous := h.monitoring.Enabled()
+	h.monitoring.SetEnabled(*req.Enabled)
+
+	if previous != *req.Enabled {
+		h.logger.Info("AUDIT: global monitoring switch changed",
+			zap.Bool("enabled", *req.Enabled))
+	}
+
+	// Persist the switch so it survives restarts.
+	if err := h.persistMonitoringSetting(*req.Enabled); err != nil {
+		h.logger.Error("Failed to persist monitoring setting", zap.Error(err))
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "Monitoring setting applied but not persisted",
+			"enabled":   *req.Enabled,
+			"persisted": false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Monitoring setting updated successfully",
+		"enabled":   *req.Enabled,
+		"persisted": true,
+	})
+}
+
+// persistMonitoringSetting writes the switch position into the config file,
+// preserving the rest of the file's structure.
+func (h *settingsHandler) persistMonitoringSetting(enabled bool) error {
+	configPath := "configs/config.yml"
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		configPath = "/root/configs/config.yml"
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	var configData map[string]interface{}
+	if err := yaml.Unmarshal(data, &configData); err != nil {
+		return err
+	}
+
+	if configData["monitoring"] == nil {
+		configData["monitoring"] = make(map[string]interface{})
+	}
+	configData["monitoring"].(map[string]interface{})["enabled"] = enabled
+
+	newData, err := yaml.Marshal(&configData)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, newData, 0644)
+}
+
 // UpdateSettingsRequest represents the settings update request
 type UpdateSettingsRequest struct {
 	AccessControl *struct {
@@ -66,7 +145,7 @@ func (h *settingsHandler) UpdateSettings(c *gin.Context) {
 	var req UpdateSettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind settings request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -83,7 +162,7 @@ func (h *settingsHandler) UpdateSettings(c *gin.Context) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		h.logger.Error("Failed to read config file", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read config file"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to read config file")
 		return
 	}
 
@@ -91,7 +170,7 @@ func (h *settingsHandler) UpdateSettings(c *gin.Context) {
 	var configData map[string]interface{}
 	if err := yaml.Unmarshal(data, &configData); err != nil {
 		h.logger.Error("Failed to parse config file", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse config file"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to parse config file")
 		return
 	}
 
@@ -108,6 +187,13 @@ func (h *settingsHandler) UpdateSettings(c *gin.Context) {
 			h.cfg.AccessControl.Enabled = *req.AccessControl.RequireAccessRequest
 			h.logger.Info("Access control setting updated", zap.Bool("enabled", *req.AccessControl.RequireAccessRequest))
 		}
+
+		if req.AccessControl.AutoApproveAdmins != nil {
+			accessControl["auto_approve_admins"] = *req.AccessControl.AutoApproveAdmins
+			// Update in-memory config
+			h.cfg.AccessControl.AutoApproveAdmins = *req.AccessControl.AutoApproveAdmins
+			h.logger.Info("Auto-approve admins setting updated", zap.Bool("enabled", *req.AccessControl.AutoApproveAdmins))
+		}
 	}
 
 	// Update annotation_service settings if provided
@@ -129,7 +215,7 @@ func (h *settingsHandler) UpdateSettings(c *gin.Context) {
 	newData, err := yaml.Marshal(&configData)
 	if err != nil {
 		h.logger.Error("Failed to marshal config", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal config"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to marshal config")
 		return
 	}
 
@@ -145,7 +231,7 @@ func (h *settingsHandler) UpdateSettings(c *gin.Context) {
 	h.logger.Info("Settings updated successfully", zap.String("path", configPath))
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Settings updated successfully",
+		"message":          "Settings updated successfully",
 		"restart_required": false, // Settings applied immediately in-memory
 	})
 }
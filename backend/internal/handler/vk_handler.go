@@ -2,10 +2,12 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"backend/internal/collector_client"
+	"backend/internal/conversation"
 	"backend/internal/models"
 	"backend/internal/repository"
 
@@ -22,13 +24,15 @@ type VKHandler interface {
 type vkHandler struct {
 	collectorClient *collector_client.Client
 	chatRepo        repository.ChatRepository
+	entityRepo      repository.MonitoredEntityRepository
 	logger          *zap.Logger
 }
 
-func NewVKHandler(collectorClient *collector_client.Client, chatRepo repository.ChatRepository, logger *zap.Logger) VKHandler {
+func NewVKHandler(collectorClient *collector_client.Client, chatRepo repository.ChatRepository, entityRepo repository.MonitoredEntityRepository, logger *zap.Logger) VKHandler {
 	return &vkHandler{
 		collectorClient: collectorClient,
 		chatRepo:        chatRepo,
+		entityRepo:      entityRepo,
 		logger:          logger,
 	}
 }
@@ -40,8 +44,12 @@ func (h *vkHandler) GetVKConversations(c *gin.Context) {
 
 	conversations, err := h.collectorClient.GetVKConversations(ctx)
 	if err != nil {
+		if errors.Is(err, collector_client.ErrInsufficientVKScope) {
+			respondError(c, http.StatusForbidden, CodeForbidden, "VK token lacks the messages scope; re-authorize VK with the messages permission")
+			return
+		}
 		h.logger.Error("Failed to get VK conversations from collector", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve VK conversations"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve VK conversations")
 		return
 	}
 
@@ -62,15 +70,21 @@ func (h *vkHandler) AddVKChatToMonitoring(c *gin.Context) {
 	var req AddVKChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Failed to bind JSON for adding VK chat", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
 		return
 	}
 
+	// Derive the conversation type from the peer ID when the client didn't
+	// supply one (group walls are negative, multi-user chats are offset).
+	if req.Type == "" {
+		req.Type = conversation.VKPeerType(req.PeerID)
+	}
+
 	// Check if chat already exists
 	existingChat, err := h.chatRepo.GetChatByVKPeerID(req.PeerID)
 	if err != nil {
 		h.logger.Error("Failed to check existing VK chat", zap.Int64("peer_id", req.PeerID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing chat"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to check existing chat")
 		return
 	}
 
@@ -94,10 +108,20 @@ func (h *vkHandler) AddVKChatToMonitoring(c *gin.Context) {
 		LastCollectedMessageID: 0,
 	}
 
+	// Best effort: associate the chat with the entity that owns the VK account
+	// the collector is authorized as. Failure here never blocks adding the chat.
+	if accountID, err := h.collectorClient.GetVKAccountID(c.Request.Context()); err != nil {
+		h.logger.Warn("Failed to resolve VK account ID; chat will not be linked to an entity", zap.Error(err))
+	} else if entity, err := h.entityRepo.GetEntityByVKAccountID(accountID); err != nil {
+		h.logger.Warn("Failed to look up monitored entity for VK account", zap.Int64("account_id", accountID), zap.Error(err))
+	} else if entity != nil {
+		chat.MonitoredEntityID = &entity.ID
+	}
+
 	err = h.chatRepo.CreateChat(chat)
 	if err != nil {
 		h.logger.Error("Failed to create VK chat", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chat"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to create chat")
 		return
 	}
 
@@ -115,7 +139,7 @@ func (h *vkHandler) CollectVKMessages(c *gin.Context) {
 	chatID, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		h.logger.Error("Invalid chat ID", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat ID"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid chat ID")
 		return
 	}
 
@@ -123,24 +147,24 @@ func (h *vkHandler) CollectVKMessages(c *gin.Context) {
 	chat, err := h.chatRepo.GetChatByID(chatID)
 	if err != nil {
 		h.logger.Error("Failed to get chat", zap.Int64("id", chatID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve chat"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to retrieve chat")
 		return
 	}
 
 	if chat == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
+		respondError(c, http.StatusNotFound, CodeNotFound, "Chat not found")
 		return
 	}
 
 	// Verify it's a VK chat
 	if chat.Source != "vk" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Chat is not a VK conversation"})
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Chat is not a VK conversation")
 		return
 	}
 
 	if chat.VKPeerID == nil {
 		h.logger.Error("VK chat has no peer_id", zap.Int64("chat_id", chatID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid VK chat configuration"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Invalid VK chat configuration")
 		return
 	}
 
@@ -148,8 +172,12 @@ func (h *vkHandler) CollectVKMessages(c *gin.Context) {
 	ctx := context.Background()
 	messages, err := h.collectorClient.GetVKMessages(ctx, *chat.VKPeerID, chat.LastCollectedMessageID)
 	if err != nil {
+		if errors.Is(err, collector_client.ErrInsufficientVKScope) {
+			respondError(c, http.StatusForbidden, CodeForbidden, "VK token lacks the messages scope; re-authorize VK with the messages permission")
+			return
+		}
 		h.logger.Error("Failed to collect VK messages", zap.Int64("peer_id", *chat.VKPeerID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to collect messages from VK"})
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to collect messages from VK")
 		return
 	}
 
@@ -162,8 +190,8 @@ func (h *vkHandler) CollectVKMessages(c *gin.Context) {
 	// This will be implemented in the next step
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":        "VK messages collected successfully",
-		"message_count":  len(messages),
-		"messages":       messages,
+		"message":       "VK messages collected successfully",
+		"message_count": len(messages),
+		"messages":      messages,
 	})
 }
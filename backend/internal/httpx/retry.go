@@ -0,0 +1,154 @@
+// Package httpx centralizes HTTP retry handling for the backend's outbound
+// service clients (collector, ML, annotation) so rate limiting and transient
+// failures are treated uniformly instead of per-client.
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Options configures DoWithRetry.
+type Options struct {
+	MaxAttempts int           // total attempts including the first (default 3)
+	BaseDelay   time.Duration // initial backoff delay (default 1s)
+	MaxDelay    time.Duration // upper bound for the backoff delay (default 30s)
+	Name        string        // label used in log and error messages
+	Logger      *zap.Logger   // optional; retries are logged at Warn level
+}
+
+// DoWithRetry executes the request produced by newRequest, retrying transport
+// errors and retryable statuses (408, 429, 5xx) with exponential backoff and
+// jitter. A Retry-After header on the response overrides the computed backoff,
+// and context cancellation aborts both waits and in-flight requests.
+//
+// newRequest is invoked once per attempt so request bodies are rebuilt for
+// each try. On success the response body and status code are returned.
+func DoWithRetry(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error), opts Options) ([]byte, int, error) {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 30 * time.Second
+	}
+	name := opts.Name
+	if name == "" {
+		name = "http"
+	}
+
+	var (
+		lastErr    error
+		lastBody   []byte
+		lastStatus int
+		delay      time.Duration
+	)
+
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if opts.Logger != nil {
+				opts.Logger.Warn("Retrying HTTP request",
+					zap.String("client", name),
+					zap.Int("attempt", attempt),
+					zap.Int("max_attempts", opts.MaxAttempts),
+					zap.Duration("delay", delay))
+			}
+			select {
+			case <-ctx.Done():
+				return lastBody, lastStatus, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create %s request: %w", name, err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return lastBody, lastStatus, ctx.Err()
+			}
+			lastErr = fmt.Errorf("%s request failed: %w", name, err)
+			delay = backoff(opts, attempt)
+			continue
+		}
+
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			lastErr = fmt.Errorf("failed to read %s response: %w", name, readErr)
+			delay = backoff(opts, attempt)
+			continue
+		}
+
+		lastBody = body
+		lastStatus = resp.StatusCode
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return body, resp.StatusCode, nil
+		}
+
+		lastErr = fmt.Errorf("%s returned status %d: %s", name, resp.StatusCode, string(body))
+		if !retryableStatus(resp.StatusCode) {
+			return body, resp.StatusCode, lastErr
+		}
+
+		delay = backoff(opts, attempt)
+		if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			delay = ra
+			if delay > opts.MaxDelay {
+				delay = opts.MaxDelay
+			}
+		}
+	}
+
+	return lastBody, lastStatus, fmt.Errorf("%s: giving up after %d attempts: %w", name, opts.MaxAttempts, lastErr)
+}
+
+// retryableStatus reports whether a status code is worth retrying.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 500
+}
+
+// backoff computes the exponential delay for the given attempt with up to 50%
+// added jitter, capped at MaxDelay.
+func backoff(opts Options, attempt int) time.Duration {
+	delay := opts.BaseDelay << (attempt - 1)
+	if delay > opts.MaxDelay || delay <= 0 {
+		delay = opts.MaxDelay
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// parseRetryAfter parses a Retry-After header as either a delay in seconds or
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
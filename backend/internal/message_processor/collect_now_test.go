@@ -0,0 +1,172 @@
+package message_processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"backend/internal/annotation_client"
+	"backend/internal/classification_cache"
+	"backend/internal/collector_client"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/signals"
+)
+
+// collectNowMessageRepo stubs the message-path repository methods a manual
+// collection pass touches.
+type collectNowMessageRepo struct {
+	repository.MessageRepository
+	saved []*models.Message
+}
+
+func (r *collectNowMessageRepo) GetMessageBySourceID(chatID int64, source string, sourceMessageID int64) (*models.Message, error) {
+	return nil, nil
+}
+
+func (r *collectNowMessageRepo) SaveMessage(msg *models.Message) error {
+	msg.ID = int64(len(r.saved) + 1)
+	r.saved = append(r.saved, msg)
+	return nil
+}
+
+func (r *collectNowMessageRepo) MarkSenderSeen(chatID int64, senderUsername string) (bool, error) {
+	return false, nil
+}
+
+type collectNowChatRepo struct {
+	repository.ChatRepository
+	lastCollected atomic.Int64
+}
+
+func (r *collectNowChatRepo) UpdateLastCollectedMessageID(chatID, lastCollectedMessageID int64) error {
+	r.lastCollected.Store(lastCollectedMessageID)
+	return nil
+}
+
+// mockCollector serves the collector endpoints a manual pass and the startup
+// chat discovery hit.
+func mockCollector(t *testing.T, messages []collector_client.Message) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/telegram/chats", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"chats": []interface{}{}})
+	})
+	mux.HandleFunc("/telegram/collect", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"messages": messages})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// mockAnnotationService answers every classification with Neutral.
+func mockAnnotationService(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"category_id":   9,
+			"category_name": "Нейтральное",
+			"confidence":    0.95,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func collectNowProcessor(t *testing.T, collectorURL, annotationURL string) (*Processor, *collectNowMessageRepo, *collectNowChatRepo) {
+	t.Helper()
+	km, dkEnc := newTestKeyManager(t)
+	msgRepo := &collectNowMessageRepo{}
+	chatRepo := &collectNowChatRepo{}
+	nop := zap.NewNop()
+	p := &Processor{
+		collectorClient:  collector_client.NewClient(collectorURL, 4, nop),
+		annotationClient: annotation_client.NewClient(annotationURL, nop),
+		messageRepo:      msgRepo,
+		chatRepo:         chatRepo,
+		keyManager:       km,
+		systemUserID:     1,
+		systemUserDKEnc:  dkEnc,
+		logger:           nop,
+		pollInterval:     time.Hour, // the ticker must not fire during the test
+		urgencyScorer:    signals.NewUrgencyScorer(nil),
+		clsCache:         classification_cache.New(16, time.Minute, nop),
+		manualRuns:       make(map[int64]bool),
+		manualReqs:       make(chan manualCollectRequest),
+	}
+	return p, msgRepo, chatRepo
+}
+
+func TestCollectChatNowAgainstMockCollector(t *testing.T) {
+	now := time.Now().UTC()
+	collector := mockCollector(t, []collector_client.Message{
+		{ID: 101, ChatID: 1, SenderUsername: "stranger", Text: "привет", Timestamp: now, Type: "message", Source: "telegram"},
+		{ID: 102, ChatID: 1, SenderUsername: "stranger", Text: "как дела", Timestamp: now, Type: "message", Source: "telegram"},
+	})
+	annotation := mockAnnotationService(t)
+
+	p, msgRepo, chatRepo := collectNowProcessor(t, collector.URL, annotation.URL)
+
+	runCtx, stopRun := context.WithCancel(context.Background())
+	defer stopRun()
+	go p.Run(runCtx)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	telegramID := int64(555)
+	chat := &models.Chat{ID: 1, Source: "telegram", TelegramID: &telegramID, MonitoringActive: true}
+	messages, incidents, err := p.CollectChatNow(ctx, chat)
+	if err != nil {
+		t.Fatalf("CollectChatNow: %v", err)
+	}
+	if messages != 2 {
+		t.Errorf("expected 2 collected messages, got %d", messages)
+	}
+	if incidents != 0 {
+		t.Errorf("expected no incidents from neutral verdicts, got %d", incidents)
+	}
+	if len(msgRepo.saved) != 2 {
+		t.Errorf("expected both messages saved, got %d", len(msgRepo.saved))
+	}
+	if got := chatRepo.lastCollected.Load(); got != 102 {
+		t.Errorf("expected the collection cursor advanced to 102, got %d", got)
+	}
+}
+
+func TestCollectChatNowRejectsConcurrentRunForSameChat(t *testing.T) {
+	p, _, _ := collectNowProcessor(t, "http://unused.invalid", "http://unused.invalid")
+	// No Run goroutine: simulate a pass already in flight for the chat.
+	p.manualRuns[1] = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	chat := &models.Chat{ID: 1, Source: "telegram"}
+	if _, _, err := p.CollectChatNow(ctx, chat); err != ErrCollectionInProgress {
+		t.Errorf("expected ErrCollectionInProgress, got %v", err)
+	}
+}
+
+func TestCollectChatNowTimesOutWhenProcessorIsNotRunning(t *testing.T) {
+	p, _, _ := collectNowProcessor(t, "http://unused.invalid", "http://unused.invalid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	chat := &models.Chat{ID: 1, Source: "telegram"}
+	_, _, err := p.CollectChatNow(ctx, chat)
+	if err == nil {
+		t.Fatal("expected an error when no polling goroutine services the request")
+	}
+	if err == ErrCollectionInProgress {
+		t.Fatal("a queued-but-unserviced request must not look like a concurrent run")
+	}
+}
@@ -0,0 +1,62 @@
+package message_processor
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// datasetWriter buffers ML dataset entries and writes them with one multi-row
+// insert when the buffer fills or the oldest buffered entry has waited longer
+// than the flush interval, so busy cycles don't pay a DB round-trip per
+// message. Like the mlBatcher it is only touched from the processor's polling
+// goroutine, so it needs no locking.
+type datasetWriter struct {
+	repo       repository.MLDatasetRepository
+	size       int
+	interval   time.Duration
+	logger     *zap.Logger
+	pending    []*models.MLDatasetEntry
+	firstAdded time.Time
+}
+
+func newDatasetWriter(repo repository.MLDatasetRepository, size int, interval time.Duration, logger *zap.Logger) *datasetWriter {
+	return &datasetWriter{
+		repo:     repo,
+		size:     size,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// add queues one entry and flushes when the buffer is full or the oldest
+// buffered entry has waited longer than the interval.
+func (w *datasetWriter) add(entry *models.MLDatasetEntry) {
+	if len(w.pending) == 0 {
+		w.firstAdded = time.Now()
+	}
+	w.pending = append(w.pending, entry)
+
+	if len(w.pending) >= w.size || time.Since(w.firstAdded) >= w.interval {
+		w.flush()
+	}
+}
+
+// flush writes the buffered entries in one insert. A failed batch is logged
+// and dropped, matching the previous per-entry behavior.
+func (w *datasetWriter) flush() {
+	if len(w.pending) == 0 {
+		return
+	}
+	batch := w.pending
+	w.pending = nil
+
+	if err := w.repo.SaveEntries(batch); err != nil {
+		w.logger.Error("Failed to save ML dataset batch", zap.Int("batch_size", len(batch)), zap.Error(err))
+		return
+	}
+	w.logger.Debug("ML dataset batch flushed", zap.Int("batch_size", len(batch)))
+}
@@ -0,0 +1,144 @@
+package message_processor
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"backend/internal/collector_client"
+	"backend/internal/crypto"
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// editTrackingRepo stubs the repository methods handleRecollectedMessage
+// touches; everything else panics via the embedded nil interface.
+type editTrackingRepo struct {
+	repository.MessageRepository
+	edits   []*models.MessageEdit
+	deleted []int64
+}
+
+func (r *editTrackingRepo) RecordMessageEdit(edit *models.MessageEdit) error {
+	r.edits = append(r.edits, edit)
+	return nil
+}
+
+func (r *editTrackingRepo) MarkMessageDeleted(messageID int64) error {
+	r.deleted = append(r.deleted, messageID)
+	return nil
+}
+
+// newTestKeyManager builds a key manager with a throwaway master key and
+// returns it together with an encrypted data key for the test system user.
+func newTestKeyManager(t *testing.T) (*crypto.KeyManager, string) {
+	t.Helper()
+	masterKey := make([]byte, 32)
+	if _, err := rand.Read(masterKey); err != nil {
+		t.Fatalf("failed to generate master key: %v", err)
+	}
+	t.Setenv("MASTER_KEY", base64.StdEncoding.EncodeToString(masterKey))
+
+	km, err := crypto.NewKeyManager()
+	if err != nil {
+		t.Fatalf("NewKeyManager: %v", err)
+	}
+	dkEnc, err := km.GenerateAndEncryptDataKey()
+	if err != nil {
+		t.Fatalf("GenerateAndEncryptDataKey: %v", err)
+	}
+	return km, dkEnc
+}
+
+func editTrackingProcessor(t *testing.T) (*Processor, *editTrackingRepo) {
+	t.Helper()
+	km, dkEnc := newTestKeyManager(t)
+	repo := &editTrackingRepo{}
+	p := &Processor{
+		messageRepo:     repo,
+		keyManager:      km,
+		systemUserID:    1,
+		systemUserDKEnc: dkEnc,
+		logger:          zap.NewNop(),
+	}
+	return p, repo
+}
+
+func (p *Processor) encryptForTest(t *testing.T, text string) string {
+	t.Helper()
+	encrypted, err := p.keyManager.EncryptMessage(text, p.systemUserID, p.systemUserDKEnc)
+	if err != nil {
+		t.Fatalf("EncryptMessage: %v", err)
+	}
+	return encrypted
+}
+
+func TestRecollectedMessageRecordsEdit(t *testing.T) {
+	p, repo := editTrackingProcessor(t)
+
+	oldEncrypted := p.encryptForTest(t, "original text")
+	existing := &models.Message{ID: 7, ContentEncrypted: oldEncrypted}
+	editedAt := time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	p.handleRecollectedMessage(existing, collector_client.Message{
+		Text:     "edited text",
+		Source:   "telegram",
+		EditedAt: &editedAt,
+	})
+
+	if len(repo.edits) != 1 {
+		t.Fatalf("expected 1 recorded edit, got %d", len(repo.edits))
+	}
+	edit := repo.edits[0]
+	if edit.MessageID != 7 {
+		t.Errorf("edit recorded for message %d, want 7", edit.MessageID)
+	}
+	if !edit.EditedAt.Equal(editedAt) {
+		t.Errorf("edit timestamp %v, want the source-reported %v", edit.EditedAt, editedAt)
+	}
+	if edit.OldContentEncrypted != oldEncrypted {
+		t.Error("old encrypted content must be preserved verbatim")
+	}
+
+	// Both versions must decrypt: the edit keeps evidence of what changed.
+	oldText, err := p.keyManager.DecryptMessage(edit.OldContentEncrypted, p.systemUserID, p.systemUserDKEnc)
+	if err != nil || oldText != "original text" {
+		t.Errorf("old content round trip failed: %q, %v", oldText, err)
+	}
+	newText, err := p.keyManager.DecryptMessage(edit.NewContentEncrypted, p.systemUserID, p.systemUserDKEnc)
+	if err != nil || newText != "edited text" {
+		t.Errorf("new content round trip failed: %q, %v", newText, err)
+	}
+}
+
+func TestRecollectedMessageUnchangedTextIsNoOp(t *testing.T) {
+	p, repo := editTrackingProcessor(t)
+
+	existing := &models.Message{ID: 7, ContentEncrypted: p.encryptForTest(t, "same text")}
+	p.handleRecollectedMessage(existing, collector_client.Message{Text: "same text", Source: "telegram"})
+
+	if len(repo.edits) != 0 || len(repo.deleted) != 0 {
+		t.Errorf("unchanged re-report must record nothing, got edits=%d deleted=%d", len(repo.edits), len(repo.deleted))
+	}
+}
+
+func TestRecollectedMessageFlagsDeletion(t *testing.T) {
+	p, repo := editTrackingProcessor(t)
+
+	existing := &models.Message{ID: 9, ContentEncrypted: p.encryptForTest(t, "gone")}
+	p.handleRecollectedMessage(existing, collector_client.Message{Deleted: true, Source: "vk"})
+
+	if len(repo.deleted) != 1 || repo.deleted[0] != 9 {
+		t.Fatalf("expected message 9 marked deleted, got %v", repo.deleted)
+	}
+
+	// A second deletion report for an already-deleted message is a no-op.
+	existing.Deleted = true
+	p.handleRecollectedMessage(existing, collector_client.Message{Deleted: true, Source: "vk"})
+	if len(repo.deleted) != 1 {
+		t.Errorf("already-deleted message must not be re-marked, got %v", repo.deleted)
+	}
+}
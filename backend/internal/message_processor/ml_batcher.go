@@ -0,0 +1,112 @@
+package message_processor
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"backend/internal/ml_client"
+)
+
+// pendingClassification is one buffered classification request; the verdict
+// is handed to deliver when the batch flushes.
+type pendingClassification struct {
+	text    string
+	deliver func(*ml_client.ClassifyResponse)
+}
+
+// mlBatcher accumulates classification requests across chats within a polling
+// cycle and flushes them to the ML service's batch endpoint when the buffer
+// fills or the batching window elapses. Deployments with many low-volume
+// chats thus amortize ML round-trips instead of paying one per message. The
+// batcher is only touched from the processor's single polling goroutine, so
+// it needs no locking.
+type mlBatcher struct {
+	mlClient   *ml_client.Client
+	size       int
+	window     time.Duration
+	logger     *zap.Logger
+	pending    []pendingClassification
+	firstAdded time.Time
+}
+
+func newMLBatcher(mlClient *ml_client.Client, size int, window time.Duration, logger *zap.Logger) *mlBatcher {
+	return &mlBatcher{
+		mlClient: mlClient,
+		size:     size,
+		window:   window,
+		logger:   logger,
+	}
+}
+
+// add queues one message for classification and flushes when the buffer is
+// full or the oldest buffered message has waited longer than the window.
+func (b *mlBatcher) add(ctx context.Context, text string, deliver func(*ml_client.ClassifyResponse)) {
+	if len(b.pending) == 0 {
+		b.firstAdded = time.Now()
+	}
+	b.pending = append(b.pending, pendingClassification{text: text, deliver: deliver})
+
+	if len(b.pending) >= b.size || time.Since(b.firstAdded) >= b.window {
+		b.flush(ctx)
+	}
+}
+
+// flush sends the buffered messages in one batch call and fans the results
+// back to their callbacks. On failure every callback receives nil so the
+// caller's fallback path runs.
+func (b *mlBatcher) flush(ctx context.Context) {
+	if len(b.pending) == 0 {
+		return
+	}
+	batch := b.pending
+	b.pending = nil
+
+	messages := make([]ml_client.BatchMessage, len(batch))
+	for i, item := range batch {
+		messages[i] = ml_client.BatchMessage{ID: int64(i), Text: item.text}
+	}
+
+	mlCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	resp, err := b.mlClient.ClassifyBatch(mlCtx, messages)
+	cancel()
+	if err != nil {
+		b.logger.Error("Batch classification failed", zap.Int("batch_size", len(batch)), zap.Error(err))
+		for _, item := range batch {
+			item.deliver(nil)
+		}
+		return
+	}
+
+	b.logger.Debug("Batch classification flushed", zap.Int("batch_size", len(batch)))
+
+	delivered := make([]bool, len(batch))
+	for _, result := range resp.Results {
+		if result.ID < 0 || result.ID >= int64(len(batch)) {
+			b.logger.Warn("Batch result references unknown message", zap.Int64("result_id", result.ID))
+			continue
+		}
+		batch[result.ID].deliver(&ml_client.ClassifyResponse{
+			Text:              result.Text,
+			Category:          result.Category,
+			CategoryID:        result.CategoryID,
+			Confidence:        result.Confidence,
+			ClassScores:       result.ClassScores,
+			ModelVersion:      result.ModelVersion,
+			IsAttack:          result.IsAttack,
+			V2Prediction:      result.V2Prediction,
+			V4Prediction:      result.V4Prediction,
+			ModelsAgree:       result.ModelsAgree,
+			PrimaryCategory:   result.PrimaryCategory,
+			PrimaryCategoryID: result.PrimaryCategoryID,
+			PrimaryConfidence: result.PrimaryConfidence,
+		})
+		delivered[result.ID] = true
+	}
+	for i, item := range batch {
+		if !delivered[i] {
+			item.deliver(nil)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package message_processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"backend/internal/ml_client"
+)
+
+// TestMLBatcherFanOutCarriesDistribution asserts the batched path delivers the
+// same verdict fields as the single-message path: category, confidence and the
+// full class-score distribution.
+func TestMLBatcherFanOutCarriesDistribution(t *testing.T) {
+	agree := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/classify/batch" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Messages []ml_client.BatchMessage `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding batch request: %v", err)
+		}
+		results := make([]ml_client.BatchResult, len(req.Messages))
+		for i, msg := range req.Messages {
+			results[i] = ml_client.BatchResult{
+				ID:         msg.ID,
+				Text:       msg.Text,
+				Category:   "grooming",
+				CategoryID: 9,
+				Confidence: 0.91,
+				ClassScores: map[string]float64{
+					"grooming": 0.91,
+					"neutral":  0.09,
+				},
+				ModelVersion: "v4.2",
+				ModelsAgree:  &agree,
+				IsAttack:     true,
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	}))
+	defer srv.Close()
+
+	batcher := newMLBatcher(ml_client.NewClient(srv.URL), 2, time.Minute, zap.NewNop())
+
+	var delivered []*ml_client.ClassifyResponse
+	deliver := func(resp *ml_client.ClassifyResponse) {
+		delivered = append(delivered, resp)
+	}
+	batcher.add(context.Background(), "привет, это наш секрет", deliver)
+	batcher.add(context.Background(), "никому не рассказывай", deliver) // fills the batch
+
+	if len(delivered) != 2 {
+		t.Fatalf("got %d verdicts, want 2", len(delivered))
+	}
+	for i, resp := range delivered {
+		if resp == nil {
+			t.Fatalf("verdict %d is nil", i)
+		}
+		if resp.Category != "grooming" || resp.CategoryID != 9 || resp.Confidence != 0.91 {
+			t.Errorf("verdict %d lost category fields: %+v", i, resp)
+		}
+		if resp.ClassScores["grooming"] != 0.91 || resp.ClassScores["neutral"] != 0.09 {
+			t.Errorf("verdict %d lost the class-score distribution: %v", i, resp.ClassScores)
+		}
+		if resp.ModelVersion != "v4.2" {
+			t.Errorf("verdict %d lost the model version: %q", i, resp.ModelVersion)
+		}
+		if resp.ModelsAgree == nil || !*resp.ModelsAgree {
+			t.Errorf("verdict %d lost models_agree", i)
+		}
+	}
+}
+
+// TestMLBatcherFailureDeliversNil asserts every buffered callback gets nil
+// when the batch call fails, so callers fall back to single classification.
+func TestMLBatcherFailureDeliversNil(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	batcher := newMLBatcher(ml_client.NewClient(srv.URL), 10, time.Minute, zap.NewNop())
+
+	calls := 0
+	var got *ml_client.ClassifyResponse = &ml_client.ClassifyResponse{}
+	batcher.add(context.Background(), "текст", func(resp *ml_client.ClassifyResponse) {
+		calls++
+		got = resp
+	})
+	batcher.flush(context.Background())
+
+	if calls != 1 {
+		t.Fatalf("callback ran %d times, want 1", calls)
+	}
+	if got != nil {
+		t.Errorf("failed batch must deliver nil, got %+v", got)
+	}
+}
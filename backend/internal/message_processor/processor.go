@@ -2,32 +2,89 @@ package message_processor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"backend/internal/annotation_client"
+	"backend/internal/classification_cache"
 	"backend/internal/collector_client"
+	"backend/internal/config"
+	"backend/internal/conversation"
 	"backend/internal/crypto"
 	"backend/internal/ml_client"
 	"backend/internal/models"
+	"backend/internal/monitoring"
+	"backend/internal/notifier"
 	"backend/internal/repository"
+	"backend/internal/signals"
 )
 
 // Processor handles fetching, processing, and saving messages.
 type Processor struct {
-	collectorClient  *collector_client.Client
-	mlClient         *ml_client.Client
-	annotationClient *annotation_client.Client
-	messageRepo      repository.MessageRepository
-	chatRepo         repository.ChatRepository
-	mlDatasetRepo    repository.MLDatasetRepository
-	keyManager       *crypto.KeyManager
-	systemUserID     int64
-	systemUserDKEnc  string
-	logger           *zap.Logger
-	pollInterval     int64
-	chatProcessDelay int64
+	collectorClient             *collector_client.Client
+	mlClient                    *ml_client.Client
+	annotationClient            *annotation_client.Client
+	messageRepo                 repository.MessageRepository
+	chatRepo                    repository.ChatRepository
+	entityRepo                  repository.MonitoredEntityRepository
+	mlDatasetRepo               repository.MLDatasetRepository
+	failedRepo                  repository.FailedClassificationRepository
+	keyManager                  *crypto.KeyManager
+	systemUserID                int64
+	systemUserDKEnc             string
+	logger                      *zap.Logger
+	pollInterval                time.Duration
+	chatProcessDelay            time.Duration
+	drainTimeout                time.Duration
+	quietIdleLogging            bool
+	recordNeutral               bool
+	neutralSecondOpinion        bool
+	annotationDefaultConfidence float64
+	fpSuppressWindow            time.Duration
+	sessionGap                  time.Duration
+	generateSummaries           bool
+	maxIncidentsPerChat         int
+	ignoreOutgoing              bool
+	ignoredSenders              map[string]struct{}
+	newChatObserveHours         int64
+	urgencyScorer               *signals.UrgencyScorer
+	clsCache                    *classification_cache.Cache
+	profiles                    map[string]config.SensitivityProfile
+	alerts                      *notifier.Router
+	monitoring                  *monitoring.State
+	mlBatcher                   *mlBatcher
+	datasetWriter               *datasetWriter
+
+	// manualMu guards manualRuns, the set of chats with an on-demand
+	// collection pass currently in flight or queued.
+	manualMu   sync.Mutex
+	manualRuns map[int64]bool
+	// manualReqs carries on-demand collection requests to the polling
+	// goroutine, which services them between cycles. Running them there
+	// keeps processChat, the mlBatcher, and the datasetWriter confined to a
+	// single goroutine.
+	manualReqs chan manualCollectRequest
+}
+
+// manualCollectRequest asks the polling goroutine to run one out-of-band
+// collection pass and reply with its result.
+type manualCollectRequest struct {
+	ctx   context.Context
+	chat  *models.Chat
+	reply chan manualCollectResult
+}
+
+type manualCollectResult struct {
+	messages  int
+	incidents int
 }
 
 // NewProcessor creates a new message processor.
@@ -37,35 +94,92 @@ func NewProcessor(
 	annotationClient *annotation_client.Client,
 	messageRepo repository.MessageRepository,
 	chatRepo repository.ChatRepository,
+	entityRepo repository.MonitoredEntityRepository,
 	mlDatasetRepo repository.MLDatasetRepository,
+	failedRepo repository.FailedClassificationRepository,
 	keyManager *crypto.KeyManager,
 	systemUserID int64,
 	systemUserDKEnc string,
 	logger *zap.Logger,
-	pollInterval int64,
-	chatProcessDelay int64,
+	pollInterval time.Duration,
+	chatProcessDelay time.Duration,
+	drainTimeout time.Duration,
+	quietIdleLogging bool,
+	recordNeutral bool,
+	neutralSecondOpinion bool,
+	annotationDefaultConfidence float64,
+	falsePositiveSuppressSeconds int64,
+	sessionGapSeconds int64,
+	generateSummaries bool,
+	maxIncidentsPerChat int,
+	mlBatchSize int,
+	mlBatchWindowSeconds int64,
+	mlDatasetBatchSize int,
+	mlDatasetFlushSeconds int64,
+	ignoreOutgoing bool,
+	ignoredSenders []string,
+	newChatObserveHours int64,
+	urgencyScorer *signals.UrgencyScorer,
+	clsCache *classification_cache.Cache,
+	profiles map[string]config.SensitivityProfile,
+	alerts *notifier.Router,
+	monitoringState *monitoring.State,
 ) *Processor {
-	return &Processor{
-		collectorClient:  collectorClient,
-		mlClient:         mlClient,
-		annotationClient: annotationClient,
-		messageRepo:      messageRepo,
-		chatRepo:         chatRepo,
-		mlDatasetRepo:    mlDatasetRepo,
-		keyManager:       keyManager,
-		systemUserID:     systemUserID,
-		systemUserDKEnc:  systemUserDKEnc,
-		logger:           logger,
-		pollInterval:     pollInterval,
-		chatProcessDelay: chatProcessDelay,
+	ignored := make(map[string]struct{}, len(ignoredSenders))
+	for _, sender := range ignoredSenders {
+		if sender = strings.ToLower(strings.TrimSpace(sender)); sender != "" {
+			ignored[sender] = struct{}{}
+		}
+	}
+	proc := &Processor{
+		collectorClient:             collectorClient,
+		mlClient:                    mlClient,
+		annotationClient:            annotationClient,
+		messageRepo:                 messageRepo,
+		chatRepo:                    chatRepo,
+		entityRepo:                  entityRepo,
+		mlDatasetRepo:               mlDatasetRepo,
+		failedRepo:                  failedRepo,
+		keyManager:                  keyManager,
+		systemUserID:                systemUserID,
+		systemUserDKEnc:             systemUserDKEnc,
+		logger:                      logger,
+		pollInterval:                pollInterval,
+		chatProcessDelay:            chatProcessDelay,
+		drainTimeout:                drainTimeout,
+		quietIdleLogging:            quietIdleLogging,
+		recordNeutral:               recordNeutral,
+		neutralSecondOpinion:        neutralSecondOpinion,
+		annotationDefaultConfidence: annotationDefaultConfidence,
+		fpSuppressWindow:            time.Duration(falsePositiveSuppressSeconds) * time.Second,
+		sessionGap:                  time.Duration(sessionGapSeconds) * time.Second,
+		generateSummaries:           generateSummaries,
+		maxIncidentsPerChat:         maxIncidentsPerChat,
+		ignoreOutgoing:              ignoreOutgoing,
+		ignoredSenders:              ignored,
+		newChatObserveHours:         newChatObserveHours,
+		urgencyScorer:               urgencyScorer,
+		clsCache:                    clsCache,
+		profiles:                    profiles,
+		alerts:                      alerts,
+		monitoring:                  monitoringState,
+		manualRuns:                  make(map[int64]bool),
+		manualReqs:                  make(chan manualCollectRequest),
+	}
+	if mlBatchSize > 1 && mlClient != nil {
+		proc.mlBatcher = newMLBatcher(mlClient, mlBatchSize, time.Duration(mlBatchWindowSeconds)*time.Second, logger)
 	}
+	if mlDatasetBatchSize > 1 && mlDatasetRepo != nil {
+		proc.datasetWriter = newDatasetWriter(mlDatasetRepo, mlDatasetBatchSize, time.Duration(mlDatasetFlushSeconds)*time.Second, logger)
+	}
+	return proc
 }
 
 // Run starts the periodic message collection and processing.
 func (p *Processor) Run(ctx context.Context) {
 	p.logger.Info("Message processor started.")
 
-	ticker := time.NewTicker(time.Duration(p.pollInterval) * time.Second)
+	ticker := time.NewTicker(p.pollInterval)
 	defer ticker.Stop()
 
 	// Initial chat discovery on startup
@@ -76,7 +190,14 @@ func (p *Processor) Run(ctx context.Context) {
 		case <-ctx.Done():
 			p.logger.Info("Message processor stopped.")
 			return
+		case req := <-p.manualReqs:
+			p.runManualCollection(req)
 		case <-ticker.C:
+			if !p.monitoring.Enabled() {
+				p.logger.Info("Monitoring is paused globally; skipping cycle")
+				continue
+			}
+
 			p.logger.Info("Polling collector for new messages...")
 
 			// Periodically discover and manage chats
@@ -93,215 +214,986 @@ func (p *Processor) Run(ctx context.Context) {
 				continue
 			}
 
+			// Burst aggregates flush at the end of the cycle, after any
+			// batched classifications have fanned their results back.
+			var cycleBursts []pendingBurst
+			idleChats := 0
+
 			for i, chat := range chats {
+				// Drain: once shutdown is requested, stop starting new chats
+				// but let already-processed work flush below.
+				if ctx.Err() != nil {
+					p.logger.Info("Shutdown requested; draining before exit")
+					break
+				}
 				if !chat.MonitoringActive {
 					p.logger.Debug("Skipping inactive chat", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source))
 					continue
 				}
-
-				// Fetch messages based on source (Telegram or VK)
-				collectorCtx, collectorCancel := context.WithTimeout(ctx, 15*time.Second)
-				var messages []collector_client.Message
-				var err error
-
-				if chat.Source == "vk" && chat.VKPeerID != nil {
-					p.logger.Info("Fetching VK messages for chat", zap.Int64("chat_id", chat.ID), zap.Int64("vk_peer_id", *chat.VKPeerID), zap.Int64("last_collected_message_id", chat.LastCollectedMessageID))
-					messages, err = p.collectorClient.GetVKMessages(collectorCtx, *chat.VKPeerID, chat.LastCollectedMessageID)
-				} else if chat.Source == "telegram" && chat.TelegramID != nil {
-					p.logger.Info("Fetching Telegram messages for chat", zap.Int64("chat_id", chat.ID), zap.Int64("telegram_id", *chat.TelegramID), zap.Int64("last_collected_message_id", chat.LastCollectedMessageID))
-					messages, err = p.collectorClient.GetMessages(collectorCtx, *chat.TelegramID, chat.LastCollectedMessageID)
-				} else {
-					p.logger.Warn("Chat has invalid source configuration", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source))
-					collectorCancel()
+				if chat.Inaccessible {
+					p.logger.Debug("Skipping inaccessible chat", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source))
 					continue
 				}
 
-				collectorCancel()
-				if err != nil {
-					p.logger.Error("Failed to get messages from collector", zap.Error(err), zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source))
-					continue
+				burst, fetched := p.processChat(ctx, chat)
+				if fetched == 0 {
+					idleChats++
+				}
+				if burst != nil {
+					cycleBursts = append(cycleBursts, pendingBurst{chat: chat, burst: burst})
 				}
 
-				if len(messages) == 0 {
-					p.logger.Info("No new messages from collector for chat", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source))
-					continue
+				// Add a delay after processing each chat to avoid FLOOD_WAIT errors
+				if i < len(chats)-1 && p.chatProcessDelay > 0 && ctx.Err() == nil {
+					p.logger.Debug("Waiting before processing next chat", zap.Duration("delay", p.chatProcessDelay))
+					time.Sleep(p.chatProcessDelay)
 				}
+			}
 
-				p.logger.Info("Received messages from collector for chat", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source), zap.Int("count", len(messages)))
+			// Detach the final flush from a cancelled context so the drain
+			// can still reach the ML service, bounded by the drain timeout.
+			flushCtx := ctx
+			var flushCancel context.CancelFunc
+			if ctx.Err() != nil {
+				flushCtx, flushCancel = context.WithTimeout(context.Background(), p.drainTimeout)
+			}
+			if p.mlBatcher != nil {
+				p.mlBatcher.flush(flushCtx)
+			}
+			if p.datasetWriter != nil {
+				p.datasetWriter.flush()
+			}
+			for _, pb := range cycleBursts {
+				p.flushIncidentBurst(pb.chat, pb.burst)
+			}
+			if flushCancel != nil {
+				flushCancel()
+			}
 
-				var maxMessageID int64 = chat.LastCollectedMessageID
-				for _, msg := range messages {
-					// Encrypt message content with system user's data key
-					encryptedContent, err := p.keyManager.EncryptMessage(msg.Text, p.systemUserID, p.systemUserDKEnc)
-					if err != nil {
-						p.logger.Error("Failed to encrypt message content", zap.Error(err), zap.Int64("message_id", msg.ID))
-						continue
-					}
+			if ctx.Err() != nil {
+				p.logger.Info("Message processor drained and stopped.")
+				return
+			}
+
+			// One summary line replaces the per-chat idle logs demoted to
+			// debug above.
+			if p.quietIdleLogging && idleChats > 0 {
+				p.logger.Info("Cycle complete", zap.Int("idle_chats", idleChats), zap.Int("total_chats", len(chats)))
+			}
+		}
+	}
+}
+
+// ErrCollectionInProgress is returned when an on-demand collection is
+// requested for a chat that already has one running.
+var ErrCollectionInProgress = errors.New("collection already in progress for this chat")
+
+// CollectChatNow runs an immediate out-of-band collection pass for one chat,
+// bypassing the poll timer. The pass itself executes on the polling
+// goroutine — this call hands it a request and waits — so manual runs never
+// race a poll cycle over the same chat, the batchers, or the collection
+// cursor. It returns how many new messages were received and how many
+// incidents the pass produced. A second request for a chat whose pass is
+// still in flight or queued is rejected with ErrCollectionInProgress.
+func (p *Processor) CollectChatNow(ctx context.Context, chat *models.Chat) (messages int, incidents int, err error) {
+	p.manualMu.Lock()
+	if p.manualRuns[chat.ID] {
+		p.manualMu.Unlock()
+		return 0, 0, ErrCollectionInProgress
+	}
+	p.manualRuns[chat.ID] = true
+	p.manualMu.Unlock()
+	defer func() {
+		p.manualMu.Lock()
+		delete(p.manualRuns, chat.ID)
+		p.manualMu.Unlock()
+	}()
+
+	req := manualCollectRequest{ctx: ctx, chat: chat, reply: make(chan manualCollectResult, 1)}
+	select {
+	case p.manualReqs <- req:
+	case <-ctx.Done():
+		return 0, 0, fmt.Errorf("waiting for the polling goroutine: %w", ctx.Err())
+	}
+	select {
+	case res := <-req.reply:
+		return res.messages, res.incidents, nil
+	case <-ctx.Done():
+		return 0, 0, fmt.Errorf("waiting for the polling goroutine: %w", ctx.Err())
+	}
+}
+
+// runManualCollection services one on-demand request. It runs on the polling
+// goroutine, between cycles, so it can use processChat and the batchers
+// without any locking.
+func (p *Processor) runManualCollection(req manualCollectRequest) {
+	// The requester may have given up while the request sat queued behind a
+	// cycle; don't run the pass for nobody.
+	if req.ctx.Err() != nil {
+		return
+	}
+
+	burst, fetched := p.processChat(req.ctx, req.chat)
+	res := manualCollectResult{messages: fetched}
+	if burst != nil {
+		// Push any batched classifications through before counting, so
+		// incidents from this pass are included in the response.
+		if p.mlBatcher != nil {
+			p.mlBatcher.flush(req.ctx)
+		}
+		if p.datasetWriter != nil {
+			p.datasetWriter.flush()
+		}
+		p.flushIncidentBurst(req.chat, burst)
+		res.incidents = burst.saved
+	}
+	req.reply <- res // buffered, never blocks
+}
+
+// processChat runs one collection pass for a single chat: fetch new
+// messages from the collector, store and classify them, and advance the
+// chat's collection cursor. It returns the chat's incident-burst aggregate
+// (nil when nothing was fetched) and the number of new messages received;
+// the caller flushes the burst after any batched classifications have
+// delivered their results.
+func (p *Processor) processChat(ctx context.Context, chat *models.Chat) (*incidentBurst, int) {
+	// Fetch messages based on source (Telegram or VK)
+	collectorCtx, collectorCancel := context.WithTimeout(ctx, 15*time.Second)
+	var messages []collector_client.Message
+	var err error
+
+	key, keyErr := conversation.FromChat(chat)
+	if keyErr != nil {
+		p.logger.Warn("Chat has invalid source configuration", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source), zap.Error(keyErr))
+		collectorCancel()
+		return nil, 0
+	}
+	// Routine fetch lines only reach info level when idle cycles are not
+	// quieted; anything noteworthy below still logs at info or above.
+	fetchLog := p.logger.Info
+	if p.quietIdleLogging {
+		fetchLog = p.logger.Debug
+	}
+	if key.Source == conversation.SourceVK {
+		fetchLog("Fetching VK messages for chat", zap.Int64("chat_id", chat.ID), zap.Int64("vk_peer_id", key.ID), zap.Int64("last_collected_message_id", chat.LastCollectedMessageID))
+		messages, err = p.collectorClient.GetVKMessages(collectorCtx, key.ID, chat.LastCollectedMessageID)
+	} else {
+		fetchLog("Fetching Telegram messages for chat", zap.Int64("chat_id", chat.ID), zap.Int64("telegram_id", key.ID), zap.Int64("last_collected_message_id", chat.LastCollectedMessageID))
+		messages, err = p.collectorClient.GetMessages(collectorCtx, key.ID, chat.LastCollectedMessageID)
+	}
+
+	collectorCancel()
+	if err != nil {
+		if errors.Is(err, collector_client.ErrInsufficientVKScope) {
+			p.logger.Warn("Skipping VK private-message collection: token lacks messages scope, re-authorize VK with the messages permission", zap.Int64("chat_id", chat.ID))
+		} else if errors.Is(err, collector_client.ErrVKPeerInaccessible) {
+			p.logger.Warn("VK peer is deactivated or inaccessible; marking chat inaccessible", zap.Int64("chat_id", chat.ID))
+			if markErr := p.chatRepo.SetChatInaccessible(chat.ID, true); markErr != nil {
+				p.logger.Error("Failed to mark chat inaccessible", zap.Error(markErr), zap.Int64("chat_id", chat.ID))
+			}
+		} else {
+			p.logger.Error("Failed to get messages from collector", zap.Error(err), zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source))
+		}
+		return nil, 0
+	}
+
+	if len(messages) == 0 {
+		fetchLog("No new messages from collector for chat", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source))
+		return nil, 0
+	}
+
+	p.logger.Info("Received messages from collector for chat", zap.Int64("chat_id", chat.ID), zap.String("source", chat.Source), zap.Int("count", len(messages)))
+
+	var maxMessageID int64 = chat.LastCollectedMessageID
+	burst := &incidentBurst{categories: make(map[string]int)}
+	for _, msg := range messages {
+		// Drain: finish the message being processed, then stop so
+		// LastCollectedMessageID only covers what was handled.
+		if ctx.Err() != nil {
+			break
+		}
+
+		// Edit/delete tracking: the collector re-reports known message
+		// IDs when they were edited or deleted at the source.
+		existing, err := p.messageRepo.GetMessageBySourceID(chat.ID, msg.Source, msg.ID)
+		if err != nil {
+			p.logger.Error("Failed to look up existing message", zap.Error(err), zap.Int64("message_id", msg.ID))
+			continue
+		}
+		if existing != nil {
+			p.handleRecollectedMessage(existing, msg)
+			continue
+		}
+		if msg.Deleted {
+			// Deletion of a message we never collected; nothing to preserve.
+			continue
+		}
+
+		// Encrypt message content with system user's data key
+		encryptedContent, err := p.keyManager.EncryptMessage(msg.Text, p.systemUserID, p.systemUserDKEnc)
+		if err != nil {
+			p.logger.Error("Failed to encrypt message content", zap.Error(err), zap.Int64("message_id", msg.ID))
+			continue
+		}
 
-					// Save the raw message with source-specific fields
-					messageToSave := &models.Message{
-						ChatID:           chat.ID,
-						SenderUsername:   msg.SenderUsername,
-						Timestamp:        msg.Timestamp,
-						ContentEncrypted: encryptedContent,
-						Source:           msg.Source,
-						MessageType:      msg.Type,
+		// Save the raw message with source-specific fields
+		messageToSave := &models.Message{
+			ChatID:           chat.ID,
+			SenderID:         msg.SenderID,
+			SenderUsername:   msg.SenderUsername,
+			Timestamp:        msg.Timestamp,
+			ContentEncrypted: encryptedContent,
+			Source:           msg.Source,
+			MessageType:      msg.Type,
+			IsOutgoing:       msg.IsOutgoing,
+			Forwarded:        msg.Forwarded,
+			ForwardedFrom:    msg.ForwardedFrom,
+			ReplyToMessageID: msg.ReplyToMessageID,
+			ReactionCount:    msg.ReactionCount,
+		}
+
+		// Set source-specific message IDs
+		if msg.Source == "telegram" {
+			messageToSave.TelegramMessageID = &msg.ID
+		} else if msg.Source == "vk" {
+			messageToSave.VKMessageID = &msg.ID
+		}
+		err = p.messageRepo.SaveMessage(messageToSave)
+		if err != nil {
+			p.logger.Error("Failed to save message", zap.Error(err), zap.Int64("telegram_message_id", msg.ID))
+			continue
+		}
+
+		if msg.ID > maxMessageID {
+			maxMessageID = msg.ID
+		}
+
+		// Sender filtering: the child's own outgoing messages and
+		// known bot/service accounts are stored for the record but
+		// never classified.
+		if p.senderFiltered(&msg) {
+			p.logger.Debug("Message excluded from classification by sender filter",
+				zap.Int64("message_id", msg.ID),
+				zap.String("sender", msg.SenderUsername),
+				zap.Bool("is_outgoing", msg.IsOutgoing))
+			continue
+		}
+
+		// First-contact enrichment: a message from a sender who has
+		// never written in this chat before is a higher-risk signal.
+		firstContact := false
+		if !msg.IsOutgoing && msg.SenderUsername != "" {
+			isNew, seenErr := p.messageRepo.MarkSenderSeen(chat.ID, msg.SenderUsername)
+			if seenErr != nil {
+				p.logger.Error("Failed to update seen senders", zap.Error(seenErr), zap.Int64("chat_id", chat.ID))
+			} else {
+				firstContact = isNew
+				if isNew {
+					p.logger.Info("First contact from new sender",
+						zap.Int64("chat_id", chat.ID),
+						zap.String("sender", msg.SenderUsername))
+				}
+			}
+		}
+
+		// Cheap lexical pre-classifier signal: urgency/pressure cues
+		// are themselves a social-engineering tell.
+		urgencyScore := p.urgencyScorer.Score(msg.Text)
+		if urgencyScore >= signals.HighUrgency {
+			p.logger.Info("High-urgency message detected",
+				zap.Int64("message_id", msg.ID),
+				zap.Float64("urgency_score", urgencyScore))
+		}
+
+		// Replies are classified together with the message they
+		// answer: a reply's threat often only shows against the
+		// message it reacts to.
+		classifyText := p.classificationText(chat, &msg)
+
+		// Identical content (the same forwarded scam in many chats)
+		// is classified once and served from cache afterwards.
+		cacheKey := contentFingerprint(classifyText)
+
+		// If annotation service is enabled, use it for dataset collection
+		if p.annotationClient != nil {
+			var annotation *annotation_client.AnnotationResponse
+			var err error
+			if cached, ok := p.clsCache.Get(cacheKey); ok {
+				annotation = cached.(*annotation_client.AnnotationResponse)
+				p.logger.Debug("Annotation served from classification cache", zap.Int64("message_id", msg.ID))
+			} else {
+				annotationCtx, annotationCancel := context.WithTimeout(ctx, 30*time.Second)
+				annotation, err = p.annotationClient.AnnotateSingle(annotationCtx, classifyText)
+				annotationCancel()
+				if err == nil {
+					// An obvious threat labelled Neutral despite risky
+					// pre-signals gets one second opinion before the
+					// verdict is accepted.
+					if p.neutralSecondOpinion && annotation.CategoryID == 9 &&
+						(urgencyScore >= signals.HighUrgency || firstContact) {
+						annotation = p.secondOpinion(ctx, annotation, classifyText, msg.ID, urgencyScore, firstContact)
 					}
+					p.clsCache.Put(cacheKey, annotation.Provider+"/"+annotation.ModelVersion, annotation)
+				}
+			}
+
+			if err != nil {
+				p.logger.Error("Failed to annotate message with Annotation Service", zap.Error(err), zap.Int64("message_id", msg.ID))
+				p.recordClassificationFailure(messageToSave.ID, chat.ID, "annotation", err)
+			} else {
+				p.logger.Info("Message annotated",
+					zap.Int64("message_id", msg.ID),
+					zap.Int("category_id", annotation.CategoryID),
+					zap.String("category_name", annotation.CategoryName))
 
-					// Set source-specific message IDs
-					if msg.Source == "telegram" {
-						messageToSave.TelegramMessageID = &msg.ID
-					} else if msg.Source == "vk" {
-						messageToSave.VKMessageID = &msg.ID
+				// Save ALL annotations to ML dataset (both threats and neutral)
+				if p.mlDatasetRepo != nil {
+					mlEntry := &models.MLDatasetEntry{
+						MessageText:       msg.Text, // Plain text, NOT encrypted
+						CategoryID:        annotation.CategoryID,
+						CategoryName:      annotation.CategoryName,
+						Justification:     annotation.Justification,
+						Provider:          annotation.Provider,
+						ModelVersion:      annotation.ModelVersion,
+						AnnotatedAt:       annotation.AnnotatedAt,
+						OriginalMessageID: &messageToSave.ID,
+						IsValidated:       false,
+						Source:            msg.Source, // Use actual source (telegram or vk)
 					}
-					err = p.messageRepo.SaveMessage(messageToSave)
-					if err != nil {
-						p.logger.Error("Failed to save message", zap.Error(err), zap.Int64("telegram_message_id", msg.ID))
-						continue
+					p.saveDatasetEntry(mlEntry)
+				}
+
+				// Annotation confidence: use the model's own figure when it
+				// reports one, otherwise the configured default — so LLM labels
+				// don't all count as a certain 1.0 in confidence analytics.
+				annotationConfidence := annotation.Confidence
+				confidenceSource := "model"
+				if annotationConfidence <= 0 {
+					annotationConfidence = p.annotationDefaultConfidence
+					confidenceSource = "default"
+				}
+
+				// Save as incident if it's a threat (category 1-8, not 9 which is neutral)
+				if annotation.CategoryID != 9 && !p.profileAllowsIncident(chat, annotation.CategoryID, annotationConfidence) {
+					p.logger.Info("Incident filtered by sensitivity profile",
+						zap.Int64("chat_id", chat.ID),
+						zap.String("profile", chat.SensitivityProfile),
+						zap.Int("category_id", annotation.CategoryID))
+				} else if annotation.CategoryID != 9 && chat.ClassificationRule == models.ChatRuleSuppress {
+					p.logger.Info("Incident suppressed by chat rule",
+						zap.Int64("chat_id", chat.ID),
+						zap.Int64("message_id", msg.ID),
+						zap.String("category", annotation.CategoryName))
+				} else if annotation.CategoryID != 9 {
+					// The LLM's justification is already a concise neutral
+					// description of the threat; when summary generation is
+					// on, store it instead of the raw message text so
+					// reviewers see the gist without the sensitive content.
+					summaryText := msg.Text
+					if p.generateSummaries && annotation.Justification != "" {
+						summaryText = annotation.Justification
 					}
 
-					if msg.ID > maxMessageID {
-						maxMessageID = msg.ID
+					// Encrypt incident summary with system user's data key
+					encryptedSummary, encErr := p.keyManager.EncryptMessage(summaryText, p.systemUserID, p.systemUserDKEnc)
+					if encErr != nil {
+						p.logger.Error("Failed to encrypt incident summary", zap.Error(encErr), zap.Int64("message_id", msg.ID))
+						encryptedSummary = "" // Use empty string if encryption fails
 					}
 
-					// If annotation service is enabled, use it for dataset collection
-					if p.annotationClient != nil {
-						annotationCtx, annotationCancel := context.WithTimeout(ctx, 30*time.Second)
-						annotation, err := p.annotationClient.AnnotateSingle(annotationCtx, msg.Text)
-						annotationCancel()
-
-						if err != nil {
-							p.logger.Error("Failed to annotate message with Annotation Service", zap.Error(err), zap.Int64("message_id", msg.ID))
-						} else {
-							p.logger.Info("Message annotated",
-								zap.Int64("message_id", msg.ID),
-								zap.Int("category_id", annotation.CategoryID),
-								zap.String("category_name", annotation.CategoryName))
-
-							// Save ALL annotations to ML dataset (both threats and neutral)
-							if p.mlDatasetRepo != nil {
-								mlEntry := &models.MLDatasetEntry{
-									MessageText:       msg.Text, // Plain text, NOT encrypted
-									CategoryID:        annotation.CategoryID,
-									CategoryName:      annotation.CategoryName,
-									Justification:     annotation.Justification,
-									Provider:          annotation.Provider,
-									ModelVersion:      annotation.ModelVersion,
-									AnnotatedAt:       annotation.AnnotatedAt,
-									OriginalMessageID: &messageToSave.ID,
-									IsValidated:       false,
-									Source:            msg.Source, // Use actual source (telegram or vk)
-								}
-								err := p.mlDatasetRepo.SaveEntry(mlEntry)
-								if err != nil {
-									p.logger.Error("Failed to save ML dataset entry", zap.Error(err), zap.Int64("message_id", msg.ID))
-								} else {
-									p.logger.Debug("ML dataset entry saved",
-										zap.Int64("dataset_id", mlEntry.ID),
-										zap.Int("category_id", annotation.CategoryID))
-								}
-							}
-
-							// Save as incident if it's a threat (category 1-8, not 9 which is neutral)
-							if annotation.CategoryID != 9 {
-								// Encrypt incident summary with system user's data key
-								encryptedSummary, encErr := p.keyManager.EncryptMessage(msg.Text, p.systemUserID, p.systemUserDKEnc)
-								if encErr != nil {
-									p.logger.Error("Failed to encrypt incident summary", zap.Error(encErr), zap.Int64("message_id", msg.ID))
-									encryptedSummary = "" // Use empty string if encryption fails
-								}
-
-								incidentToSave := &models.Incident{
-									MessageID:        messageToSave.ID,
-									ThreatType:       annotation.CategoryName,
-									ModelConfidence:  1.0, // LLM annotation
-									Status:           "new",
-									SummaryEncrypted: encryptedSummary,
-								}
-								err := p.messageRepo.SaveIncident(incidentToSave)
-								if err != nil {
-									p.logger.Error("Failed to save annotated incident", zap.Error(err), zap.Int64("message_id", msg.ID))
-								}
-							}
-						}
-					} else {
-						// Use ML service for production classification
-						mlCtx, mlCancel := context.WithTimeout(ctx, 5*time.Second)
-						classification, err := p.mlClient.ClassifySingle(mlCtx, msg.Text)
-						mlCancel()
-
-						if err != nil {
-							p.logger.Error("Failed to classify message with ML service", zap.Error(err), zap.Int64("message_id", msg.ID))
-							// Fallback to mock service if ML service is unavailable
-							isSocialEngineering := p.mockAIService(msg.Text)
-							if isSocialEngineering {
-								classification = &ml_client.ClassifyResponse{
-									PrimaryCategory:   "social_engineering",
-									PrimaryCategoryID: 1,
-									PrimaryConfidence: 0.5,
-									IsAttack:          true,
-								}
-							}
-						}
-
-						if classification != nil && classification.IsAttack {
-							// Use category from single model or fallback to primary category
-							category := classification.Category
-							categoryID := classification.CategoryID
-							confidence := classification.Confidence
-
-							// Fallback to legacy dual model fields if present
-							if category == "" && classification.PrimaryCategory != "" {
-								category = classification.PrimaryCategory
-								categoryID = classification.PrimaryCategoryID
-								confidence = classification.PrimaryConfidence
-							}
-
-							p.logger.Info("Social engineering message detected.",
-								zap.Int64("message_id", msg.ID),
-								zap.String("category", category),
-								zap.Int("category_id", categoryID),
-								zap.Float64("confidence", confidence))
-
-							// Encrypt incident summary with system user's data key
-							encryptedSummary, encErr := p.keyManager.EncryptMessage(msg.Text, p.systemUserID, p.systemUserDKEnc)
-							if encErr != nil {
-								p.logger.Error("Failed to encrypt incident summary", zap.Error(encErr), zap.Int64("message_id", msg.ID))
-								encryptedSummary = "" // Use empty string if encryption fails
-							}
-
-							// Save the message as an incident
-							incidentToSave := &models.Incident{
-								MessageID:        messageToSave.ID,
-								ThreatType:       category,
-								ModelConfidence:  confidence,
-								Status:           "new",
-								SummaryEncrypted: encryptedSummary,
-							}
-							err := p.messageRepo.SaveIncident(incidentToSave)
-							if err != nil {
-								p.logger.Error("Failed to save social engineering incident", zap.Error(err), zap.Int64("message_id", msg.ID))
-							}
-						}
+					// The stage vocabulary is fixed; anything else the model
+					// invents is dropped rather than stored.
+					groomingStage := annotation.GroomingStage
+					if !models.ValidGroomingStage(groomingStage) {
+						groomingStage = ""
 					}
-				}
 
-				// Update LastCollectedMessageID for the chat
-				if maxMessageID > chat.LastCollectedMessageID {
-					err := p.chatRepo.UpdateLastCollectedMessageID(chat.ID, maxMessageID)
+					incidentToSave := &models.Incident{
+						MessageID:        messageToSave.ID,
+						ThreatType:       annotation.CategoryName,
+						ModelConfidence:  annotationConfidence,
+						ModelProvider:    annotation.Provider,
+						ConfidenceSource: confidenceSource,
+						ModelVersion:     annotation.ModelVersion,
+						Status:           incidentStatusForChat(chat),
+						SummaryEncrypted: encryptedSummary,
+						FirstContact:     firstContact,
+						GroomingStage:    groomingStage,
+					}
+					err := p.saveIncidentCapped(incidentToSave, msg, burst, annotation.CategoryID, chat)
 					if err != nil {
-						p.logger.Error("Failed to update last collected message ID for chat", zap.Error(err), zap.Int64("chat_id", chat.ID), zap.Int64("new_max_message_id", maxMessageID))
+						p.logger.Error("Failed to save annotated incident", zap.Error(err), zap.Int64("message_id", msg.ID))
+					}
+				} else if p.recordNeutral {
+					// Keep a lightweight record of neutral traffic so the
+					// dashboard's detection-rate denominator stays accurate.
+					cm := &models.ClassifiedMessage{
+						ChatID:       chat.ID,
+						CategoryID:   annotation.CategoryID,
+						CategoryName: annotation.CategoryName,
+					}
+					if err := p.messageRepo.SaveClassifiedMessage(cm); err != nil {
+						p.logger.Error("Failed to save classified message record", zap.Error(err), zap.Int64("message_id", msg.ID))
 					}
 				}
-
-				// Add a delay after processing each chat to avoid FLOOD_WAIT errors
-				if i < len(chats)-1 && p.chatProcessDelay > 0 {
-					p.logger.Debug("Waiting before processing next chat", zap.Int64("delay_seconds", p.chatProcessDelay))
-					time.Sleep(time.Duration(p.chatProcessDelay) * time.Second)
+			}
+		} else {
+			// Use ML service for production classification
+			if cached, ok := p.clsCache.Get(cacheKey); ok {
+				p.logger.Debug("Classification served from cache", zap.Int64("message_id", msg.ID))
+				p.handleMLClassification(cached.(*ml_client.ClassifyResponse), chat, &msg, messageToSave.ID, burst, firstContact, urgencyScore)
+			} else if p.mlBatcher != nil {
+				// Cross-chat batching: the verdict comes back via
+				// the deliver callback when the buffer flushes.
+				msgCopy := msg
+				savedID := messageToSave.ID
+				p.mlBatcher.add(ctx, classifyText, func(classification *ml_client.ClassifyResponse) {
+					if classification != nil {
+						p.clsCache.Put(cacheKey, "ml", classification)
+					}
+					p.handleMLClassification(classification, chat, &msgCopy, savedID, burst, firstContact, urgencyScore)
+				})
+			} else {
+				mlCtx, mlCancel := context.WithTimeout(ctx, 5*time.Second)
+				classification, err := p.mlClient.ClassifySingle(mlCtx, classifyText)
+				mlCancel()
+				if err != nil {
+					p.logger.Error("Failed to classify message with ML service", zap.Error(err), zap.Int64("message_id", msg.ID))
+					p.recordClassificationFailure(messageToSave.ID, chat.ID, "ml", err)
+					classification = nil
+				} else {
+					p.clsCache.Put(cacheKey, "ml", classification)
 				}
+				p.handleMLClassification(classification, chat, &msg, messageToSave.ID, burst, firstContact, urgencyScore)
 			}
 		}
 	}
+
+	// Update LastCollectedMessageID for the chat
+	if maxMessageID > chat.LastCollectedMessageID {
+		err := p.chatRepo.UpdateLastCollectedMessageID(chat.ID, maxMessageID)
+		if err != nil {
+			p.logger.Error("Failed to update last collected message ID for chat", zap.Error(err), zap.Int64("chat_id", chat.ID), zap.Int64("new_max_message_id", maxMessageID))
+		}
+	}
+	return burst, len(messages)
+}
+
+// recordClassificationFailure writes a dead-letter entry for a message whose
+// classification call failed, so operators can replay it once the classifier
+// is back. Best-effort: a write failure only logs.
+func (p *Processor) recordClassificationFailure(messageID, chatID int64, classifier string, cause error) {
+	if p.failedRepo == nil {
+		return
+	}
+	fc := &models.FailedClassification{
+		MessageID:  messageID,
+		ChatID:     chatID,
+		Classifier: classifier,
+		Error:      cause.Error(),
+	}
+	if err := p.failedRepo.RecordFailure(fc); err != nil {
+		p.logger.Error("Failed to record classification failure", zap.Error(err), zap.Int64("message_id", messageID))
+	}
+}
+
+// handleRecollectedMessage reconciles a message the collector re-reported:
+// it flags deletions and records edits with both encrypted versions kept.
+func (p *Processor) handleRecollectedMessage(existing *models.Message, msg collector_client.Message) {
+	if msg.Deleted {
+		if existing.Deleted {
+			return
+		}
+		if err := p.messageRepo.MarkMessageDeleted(existing.ID); err != nil {
+			p.logger.Error("Failed to mark message as deleted", zap.Error(err), zap.Int64("message_id", existing.ID))
+			return
+		}
+		p.logger.Info("Message deleted at source", zap.Int64("message_id", existing.ID), zap.String("source", msg.Source))
+		return
+	}
+
+	oldText, err := p.keyManager.DecryptMessage(existing.ContentEncrypted, p.systemUserID, p.systemUserDKEnc)
+	if err != nil {
+		p.logger.Error("Failed to decrypt stored message for edit comparison", zap.Error(err), zap.Int64("message_id", existing.ID))
+		return
+	}
+	if oldText == msg.Text {
+		return
+	}
+
+	newEncrypted, err := p.keyManager.EncryptMessage(msg.Text, p.systemUserID, p.systemUserDKEnc)
+	if err != nil {
+		p.logger.Error("Failed to encrypt edited message content", zap.Error(err), zap.Int64("message_id", existing.ID))
+		return
+	}
+
+	editedAt := time.Now()
+	if msg.EditedAt != nil {
+		editedAt = *msg.EditedAt
+	}
+
+	edit := &models.MessageEdit{
+		MessageID:           existing.ID,
+		OldContentEncrypted: existing.ContentEncrypted,
+		NewContentEncrypted: newEncrypted,
+		EditedAt:            editedAt,
+	}
+	if err := p.messageRepo.RecordMessageEdit(edit); err != nil {
+		p.logger.Error("Failed to record message edit", zap.Error(err), zap.Int64("message_id", existing.ID))
+		return
+	}
+	p.logger.Info("Message edit recorded",
+		zap.Int64("message_id", existing.ID),
+		zap.String("source", msg.Source),
+		zap.Time("edited_at", editedAt))
+}
+
+// incidentStatusForChat returns the initial incident status for a chat,
+// honoring a force-escalation rule on flagged chats.
+func incidentStatusForChat(chat *models.Chat) string {
+	if chat.ClassificationRule == models.ChatRuleEscalate {
+		return "escalated"
+	}
+	return "new"
+}
+
+// classificationText returns the text handed to classifiers. For replies it
+// prefixes the replied-to message when it is on record, so the classifier
+// sees the exchange rather than an answer out of context. The stored message
+// and the ML dataset keep the reply text alone.
+func (p *Processor) classificationText(chat *models.Chat, msg *collector_client.Message) string {
+	if msg.ReplyToMessageID == nil {
+		return msg.Text
+	}
+
+	parent, err := p.messageRepo.GetMessageBySourceID(chat.ID, msg.Source, *msg.ReplyToMessageID)
+	if err != nil {
+		p.logger.Error("Failed to look up replied-to message", zap.Error(err), zap.Int64("message_id", msg.ID))
+		return msg.Text
+	}
+	if parent == nil {
+		return msg.Text
+	}
+
+	parentText, err := p.keyManager.DecryptMessage(parent.ContentEncrypted, p.systemUserID, p.systemUserDKEnc)
+	if err != nil {
+		p.logger.Error("Failed to decrypt replied-to message", zap.Error(err), zap.Int64("message_id", msg.ID))
+		return msg.Text
+	}
+
+	return fmt.Sprintf("В ответ на «%s»: %s", parentText, msg.Text)
+}
+
+// contentFingerprint hashes normalized message text (lowercased, whitespace
+// collapsed) so the same content is recognized across chats and forwards.
+func contentFingerprint(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// saveIncident persists an incident, first stamping the content fingerprint.
+// A forwarded message whose content already produced an incident does not get
+// a new one; the existing incident's occurrence counter is bumped instead.
+func (p *Processor) saveIncident(incident *models.Incident, msg collector_client.Message) error {
+	fingerprint := contentFingerprint(msg.Text)
+	incident.ContentFingerprint = &fingerprint
+
+	if msg.Forwarded {
+		existing, err := p.messageRepo.GetIncidentByFingerprint(fingerprint)
+		if err != nil {
+			p.logger.Error("Failed to look up incident by fingerprint", zap.Error(err), zap.Int64("message_id", msg.ID))
+		} else if existing != nil {
+			p.logger.Info("Forwarded duplicate linked to existing incident",
+				zap.Int64("incident_id", existing.ID),
+				zap.Int64("message_id", msg.ID),
+				zap.String("forwarded_from", msg.ForwardedFrom))
+			return p.messageRepo.IncrementIncidentOccurrence(existing.ID)
+		}
+	}
+
+	return p.messageRepo.SaveIncident(incident)
+}
+
+// secondOpinion re-annotates a message once after a Neutral verdict that the
+// urgency/first-contact pre-signals disagree with. The second verdict wins
+// when it names a threat category; otherwise the original Neutral stands.
+// Overturns are logged so the false-negative rate of the first pass can be
+// tracked.
+func (p *Processor) secondOpinion(ctx context.Context, first *annotation_client.AnnotationResponse, text string, messageID int64, urgencyScore float64, firstContact bool) *annotation_client.AnnotationResponse {
+	soCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	second, err := p.annotationClient.AnnotateSecondOpinion(soCtx, text)
+	cancel()
+	if err != nil {
+		p.logger.Error("Second-opinion annotation failed, keeping Neutral verdict",
+			zap.Int64("message_id", messageID),
+			zap.Error(err))
+		return first
+	}
+
+	if second.CategoryID != 9 {
+		p.logger.Info("Second opinion overturned Neutral verdict",
+			zap.Int64("message_id", messageID),
+			zap.Int("category_id", second.CategoryID),
+			zap.String("category_name", second.CategoryName),
+			zap.Float64("urgency_score", urgencyScore),
+			zap.Bool("first_contact", firstContact))
+		return second
+	}
+
+	p.logger.Debug("Second opinion confirmed Neutral verdict",
+		zap.Int64("message_id", messageID))
+	return first
+}
+
+// senderFiltered reports whether the message's sender is excluded from
+// classification by the deployment's filtering config.
+func (p *Processor) senderFiltered(msg *collector_client.Message) bool {
+	if p.ignoreOutgoing && msg.IsOutgoing {
+		return true
+	}
+	if msg.SenderUsername == "" {
+		return false
+	}
+	_, ignored := p.ignoredSenders[strings.ToLower(msg.SenderUsername)]
+	return ignored
+}
+
+// profileAllowsIncident applies the chat's sensitivity profile to a
+// classifier verdict. It returns false when the profile mutes the category or
+// sets a per-category threshold the confidence does not reach; chats without
+// a profile (or with an unknown one) are unaffected.
+func (p *Processor) profileAllowsIncident(chat *models.Chat, categoryID int, confidence float64) bool {
+	if chat.SensitivityProfile == "" {
+		return true
+	}
+	profile, ok := p.profiles[chat.SensitivityProfile]
+	if !ok {
+		p.logger.Warn("Chat references unknown sensitivity profile",
+			zap.Int64("chat_id", chat.ID),
+			zap.String("profile", chat.SensitivityProfile))
+		return true
+	}
+
+	for _, muted := range profile.MutedCategories {
+		if muted == categoryID {
+			return false
+		}
+	}
+	if threshold, ok := profile.CategoryThresholds[categoryID]; ok && confidence < threshold {
+		return false
+	}
+	return true
+}
+
+// handleMLClassification turns one ML verdict into incident records. A nil
+// classification means the ML call failed; the keyword mock then stands in so
+// a service outage doesn't silence detection entirely.
+func (p *Processor) handleMLClassification(classification *ml_client.ClassifyResponse, chat *models.Chat, msg *collector_client.Message, savedMessageID int64, burst *incidentBurst, firstContact bool, urgencyScore float64) {
+	modelProvider := "ml_service"
+	confidenceSource := "model"
+	if classification == nil {
+		if !p.mockAIService(msg.Text) {
+			return
+		}
+		modelProvider = "mock"
+		confidenceSource = "default"
+		classification = &ml_client.ClassifyResponse{
+			PrimaryCategory:   "social_engineering",
+			PrimaryCategoryID: 1,
+			PrimaryConfidence: 0.5,
+			IsAttack:          true,
+		}
+	}
+
+	// A per-chat threshold override can pull borderline classifications into
+	// incidents even when the model did not flag them as attacks.
+	isAttack := classification.IsAttack
+	if !isAttack && chat.ConfidenceThresholdOverride != nil &&
+		classification.CategoryID != 9 && classification.Confidence >= *chat.ConfidenceThresholdOverride {
+		isAttack = true
+	}
+
+	// High-urgency messages and first contacts from unknown senders lower the
+	// bar: borderline classifications of such messages become incidents.
+	if !isAttack && classification.CategoryID != 9 &&
+		(urgencyScore >= signals.HighUrgency || firstContact) && classification.Confidence >= 0.5 {
+		p.logger.Info("Borderline classification promoted by risk signal",
+			zap.Int64("message_id", msg.ID),
+			zap.Float64("confidence", classification.Confidence),
+			zap.Float64("urgency_score", urgencyScore),
+			zap.Bool("first_contact", firstContact))
+		isAttack = true
+	}
+
+	if isAttack {
+		categoryID := classification.CategoryID
+		confidence := classification.Confidence
+		if categoryID == 0 && classification.PrimaryCategoryID != 0 {
+			categoryID = classification.PrimaryCategoryID
+			confidence = classification.PrimaryConfidence
+		}
+		if !p.profileAllowsIncident(chat, categoryID, confidence) {
+			p.logger.Info("Incident filtered by sensitivity profile",
+				zap.Int64("chat_id", chat.ID),
+				zap.String("profile", chat.SensitivityProfile),
+				zap.Int("category_id", categoryID))
+			isAttack = false
+		}
+	}
+
+	if isAttack && chat.ClassificationRule == models.ChatRuleSuppress {
+		p.logger.Info("Incident suppressed by chat rule",
+			zap.Int64("chat_id", chat.ID),
+			zap.Int64("message_id", msg.ID))
+	} else if isAttack {
+		// Use category from single model or fallback to primary category
+		category := classification.Category
+		categoryID := classification.CategoryID
+		confidence := classification.Confidence
+
+		// Fallback to legacy dual model fields if present
+		if category == "" && classification.PrimaryCategory != "" {
+			category = classification.PrimaryCategory
+			categoryID = classification.PrimaryCategoryID
+			confidence = classification.PrimaryConfidence
+		}
+
+		p.logger.Info("Social engineering message detected.",
+			zap.Int64("message_id", msg.ID),
+			zap.String("category", category),
+			zap.Int("category_id", categoryID),
+			zap.Float64("confidence", confidence))
+
+		// The ML service returns no justification, so the neutral
+		// summary falls back to a category description.
+		summaryText := msg.Text
+		if p.generateSummaries {
+			summaryText = fmt.Sprintf("Обнаружена угроза категории: %s", category)
+		}
+
+		// Encrypt incident summary with system user's data key
+		encryptedSummary, encErr := p.keyManager.EncryptMessage(summaryText, p.systemUserID, p.systemUserDKEnc)
+		if encErr != nil {
+			p.logger.Error("Failed to encrypt incident summary", zap.Error(encErr), zap.Int64("message_id", msg.ID))
+			encryptedSummary = "" // Use empty string if encryption fails
+		}
+
+		// Save the message as an incident
+		incidentToSave := &models.Incident{
+			MessageID:        savedMessageID,
+			ThreatType:       category,
+			ModelConfidence:  confidence,
+			ModelProvider:    modelProvider,
+			ConfidenceSource: confidenceSource,
+			ModelVersion:     classification.ModelVersion,
+			Status:           incidentStatusForChat(chat),
+			SummaryEncrypted: encryptedSummary,
+			FirstContact:     firstContact,
+			// Keep the full distribution so reviewers see how decisive
+			// the verdict was, not just the winning category.
+			ClassScores: models.ClassScores(classification.ClassScores),
+		}
+		if err := p.saveIncidentCapped(incidentToSave, *msg, burst, categoryID, chat); err != nil {
+			p.logger.Error("Failed to save social engineering incident", zap.Error(err), zap.Int64("message_id", msg.ID))
+		}
+	}
+}
+
+// pendingBurst pairs a chat with its per-cycle burst aggregate so bursts can
+// flush after batched classifications have delivered their results.
+type pendingBurst struct {
+	chat  *models.Chat
+	burst *incidentBurst
+}
+
+// incidentBurst tracks, for one chat and one polling cycle, how many
+// incidents were saved and which were deferred past the per-cycle cap.
+type incidentBurst struct {
+	saved         int
+	deferred      int
+	categories    map[string]int
+	lastMessageID int64
+	maxConfidence float64
+}
+
+// saveIncidentCapped saves an incident unless the per-chat per-cycle cap is
+// reached; beyond the cap the incident is folded into the chat's aggregate
+// burst incident instead of being stored individually.
+func (p *Processor) saveIncidentCapped(incident *models.Incident, msg collector_client.Message, burst *incidentBurst, categoryID int, chat *models.Chat) error {
+	if p.suppressedAsFalsePositive(msg) {
+		return nil
+	}
+
+	if p.appendedToSession(incident, msg, chat) {
+		return nil
+	}
+
+	if p.maxIncidentsPerChat > 0 && burst.saved >= p.maxIncidentsPerChat {
+		burst.deferred++
+		burst.categories[incident.ThreatType]++
+		burst.lastMessageID = incident.MessageID
+		if incident.ModelConfidence > burst.maxConfidence {
+			burst.maxConfidence = incident.ModelConfidence
+		}
+		p.logger.Warn("Incident cap reached for chat this cycle; folding into burst incident",
+			zap.Int("cap", p.maxIncidentsPerChat),
+			zap.Int64("message_id", msg.ID))
+		return nil
+	}
+
+	if err := p.saveIncident(incident, msg); err != nil {
+		return err
+	}
+	burst.saved++
+	p.notifyIncident(categoryID, incident, chat)
+	return nil
+}
+
+// appendedToSession folds the flagged message into the chat's open session
+// incident when session grouping is on and the previous flagged message was
+// recent enough. Returns true when the message was appended and no separate
+// incident should be created; a lapsed gap (or any lookup failure) lets the
+// normal per-message incident path open a new session.
+func (p *Processor) appendedToSession(incident *models.Incident, msg collector_client.Message, chat *models.Chat) bool {
+	if p.sessionGap <= 0 {
+		return false
+	}
+
+	open, err := p.messageRepo.GetOpenSessionIncident(chat.ID, msg.Timestamp.Add(-p.sessionGap))
+	if err != nil {
+		p.logger.Error("Failed to look up open session incident", zap.Error(err), zap.Int64("chat_id", chat.ID))
+		return false
+	}
+	if open == nil {
+		return false
+	}
+
+	if err := p.messageRepo.AddIncidentMessage(open.ID, incident.MessageID); err != nil {
+		p.logger.Error("Failed to append message to session incident",
+			zap.Error(err),
+			zap.Int64("incident_id", open.ID),
+			zap.Int64("message_id", incident.MessageID))
+		return false
+	}
+
+	p.logger.Info("Flagged message appended to session incident",
+		zap.Int64("incident_id", open.ID),
+		zap.Int64("message_id", incident.MessageID),
+		zap.Int64("chat_id", chat.ID),
+		zap.Duration("session_gap", p.sessionGap))
+	return true
+}
+
+// suppressedAsFalsePositive reports whether this message's content matches an
+// incident a reviewer dismissed as false_positive within the configured
+// window. Suppressed messages create no incident; they are instead recorded
+// in the ML dataset as neutral so the reviewer's dismissal feeds training.
+func (p *Processor) suppressedAsFalsePositive(msg collector_client.Message) bool {
+	if p.fpSuppressWindow <= 0 {
+		return false
+	}
+
+	fingerprint := contentFingerprint(msg.Text)
+	suppressed, err := p.messageRepo.HasRecentFalsePositive(fingerprint, time.Now().Add(-p.fpSuppressWindow))
+	if err != nil {
+		p.logger.Error("Failed to check false-positive suppression", zap.Error(err), zap.Int64("message_id", msg.ID))
+		return false
+	}
+	if !suppressed {
+		return false
+	}
+
+	p.logger.Info("Incident suppressed: content matches a recently dismissed false positive",
+		zap.Int64("message_id", msg.ID),
+		zap.Duration("window", p.fpSuppressWindow))
+
+	if p.mlDatasetRepo != nil {
+		entry := &models.MLDatasetEntry{
+			MessageText:   msg.Text,
+			CategoryID:    models.NeutralCategoryID,
+			CategoryName:  models.CategoryName(models.NeutralCategoryID),
+			Justification: "Совпадает с инцидентом, отмеченным рецензентом как ложное срабатывание",
+			Provider:      "reviewer_feedback",
+			AnnotatedAt:   time.Now(),
+			Source:        msg.Source,
+		}
+		p.saveDatasetEntry(entry)
+	}
+	return true
+}
+
+// saveDatasetEntry buffers the entry in the dataset writer when batching is
+// on, and falls back to a direct insert otherwise.
+func (p *Processor) saveDatasetEntry(entry *models.MLDatasetEntry) {
+	if p.datasetWriter != nil {
+		p.datasetWriter.add(entry)
+		return
+	}
+	if err := p.mlDatasetRepo.SaveEntry(entry); err != nil {
+		p.logger.Error("Failed to save ML dataset entry", zap.Error(err), zap.Int("category_id", entry.CategoryID))
+		return
+	}
+	p.logger.Debug("ML dataset entry saved",
+		zap.Int64("dataset_id", entry.ID),
+		zap.Int("category_id", entry.CategoryID))
+}
+
+// notifyIncident routes an alert for a newly created incident to the
+// recipients configured for its category. A nil router means notification
+// routing is disabled.
+func (p *Processor) notifyIncident(categoryID int, incident *models.Incident, chat *models.Chat) {
+	if p.alerts == nil {
+		return
+	}
+	if chat.ObserveOnly(time.Now()) {
+		p.logger.Debug("Alert suppressed: chat is in observe-only warm-up",
+			zap.Int64("chat_id", chat.ID),
+			zap.Timep("observe_until", chat.ObserveUntil))
+		return
+	}
+	text := fmt.Sprintf("⚠️ Обнаружена угроза: %s\nЧат: %s\nУверенность: %.2f",
+		incident.ThreatType, chat.Name, incident.ModelConfidence)
+	p.alerts.NotifyIncident(categoryID, text)
+}
+
+// flushIncidentBurst creates one aggregate incident covering everything
+// deferred past the cap, so a spam flood still alerts without creating
+// hundreds of individual incidents.
+func (p *Processor) flushIncidentBurst(chat *models.Chat, burst *incidentBurst) {
+	if burst.deferred == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(burst.categories))
+	for category, count := range burst.categories {
+		parts = append(parts, fmt.Sprintf("%s: %d", category, count))
+	}
+	sort.Strings(parts)
+	summary := fmt.Sprintf("Всплеск активности: еще %d инцидентов сверх лимита за цикл (%s)",
+		burst.deferred, strings.Join(parts, ", "))
+
+	encryptedSummary, err := p.keyManager.EncryptMessage(summary, p.systemUserID, p.systemUserDKEnc)
+	if err != nil {
+		p.logger.Error("Failed to encrypt burst incident summary", zap.Error(err), zap.Int64("chat_id", chat.ID))
+		encryptedSummary = ""
+	}
+
+	incident := &models.Incident{
+		MessageID:        burst.lastMessageID,
+		ThreatType:       "burst",
+		ModelConfidence:  burst.maxConfidence,
+		Status:           incidentStatusForChat(chat),
+		SummaryEncrypted: encryptedSummary,
+	}
+	if err := p.messageRepo.SaveIncident(incident); err != nil {
+		p.logger.Error("Failed to save burst incident", zap.Error(err), zap.Int64("chat_id", chat.ID))
+		return
+	}
+	p.logger.Info("Burst incident created",
+		zap.Int64("chat_id", chat.ID),
+		zap.Int("deferred_incidents", burst.deferred))
+	p.notifyIncident(0, incident, chat)
 }
 
 func (p *Processor) discoverAndManageChats(ctx context.Context) {
@@ -320,6 +1212,13 @@ func (p *Processor) discoverAndManageChats(ctx context.Context) {
 		return
 	}
 
+	// Resolve the monitored entity owning the collector's Telegram account so
+	// newly discovered chats are scoped to the right child.
+	entity, err := p.entityRepo.GetEntityForSource("telegram")
+	if err != nil {
+		p.logger.Error("Failed to resolve monitored entity for telegram", zap.Error(err))
+	}
+
 	for _, cChat := range collectorChats {
 		dbChat, err := p.chatRepo.GetChatByTelegramID(cChat.ID)
 		if err != nil {
@@ -339,11 +1238,26 @@ func (p *Processor) discoverAndManageChats(ctx context.Context) {
 				MonitoringActive:       true, // Default to active monitoring for new chats
 				LastCollectedMessageID: 0,
 			}
+			if p.newChatObserveHours > 0 {
+				until := time.Now().Add(time.Duration(p.newChatObserveHours) * time.Hour)
+				newChat.ObserveUntil = &until
+			}
+			if entity != nil {
+				newChat.MonitoredEntityID = &entity.ID
+			}
 			err = p.chatRepo.CreateChat(newChat)
 			if err != nil {
 				p.logger.Error("Failed to create new chat in DB", zap.Error(err), zap.Int64("telegram_id", cChat.ID))
 			}
 		} else {
+			// Backfill the entity association for chats discovered before
+			// monitored entities existed.
+			if dbChat.MonitoredEntityID == nil && entity != nil {
+				if err := p.entityRepo.AssignChatToEntity(dbChat.ID, entity.ID); err != nil {
+					p.logger.Error("Failed to assign chat to monitored entity", zap.Error(err), zap.Int64("chat_id", dbChat.ID))
+				}
+			}
+
 			// Chat already exists, ensure its name is up-to-date (optional, but good practice)
 			if dbChat.Name != cChat.Name || dbChat.IsGroup != cChat.IsGroup {
 				p.logger.Info("Updating existing chat info",
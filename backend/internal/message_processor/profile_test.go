@@ -0,0 +1,66 @@
+package message_processor
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+
+	"backend/internal/config"
+	"backend/internal/models"
+)
+
+func profileTestProcessor() *Processor {
+	return &Processor{
+		logger: zap.NewNop(),
+		profiles: map[string]config.SensitivityProfile{
+			"gaming_group": {
+				MutedCategories:    []int{5},
+				CategoryThresholds: map[int]float64{2: 0.8},
+			},
+		},
+	}
+}
+
+func TestProfileAllowsIncidentWithoutProfile(t *testing.T) {
+	p := profileTestProcessor()
+	chat := &models.Chat{ID: 1}
+	if !p.profileAllowsIncident(chat, 2, 0.1) {
+		t.Error("a chat without a profile must not filter incidents")
+	}
+}
+
+func TestProfileAllowsIncidentUnknownProfile(t *testing.T) {
+	p := profileTestProcessor()
+	chat := &models.Chat{ID: 1, SensitivityProfile: "no_such_profile"}
+	if !p.profileAllowsIncident(chat, 2, 0.1) {
+		t.Error("an unknown profile must leave the chat unaffected")
+	}
+}
+
+func TestProfileMutesCategory(t *testing.T) {
+	p := profileTestProcessor()
+	chat := &models.Chat{ID: 1, SensitivityProfile: "gaming_group"}
+	if p.profileAllowsIncident(chat, 5, 0.99) {
+		t.Error("a muted category must never produce an incident, regardless of confidence")
+	}
+}
+
+func TestProfileThresholdFiltersBorderlineConfidence(t *testing.T) {
+	p := profileTestProcessor()
+	chat := &models.Chat{ID: 1, SensitivityProfile: "gaming_group"}
+
+	if p.profileAllowsIncident(chat, 2, 0.79) {
+		t.Error("confidence below the per-category threshold must be filtered")
+	}
+	if !p.profileAllowsIncident(chat, 2, 0.8) {
+		t.Error("confidence at the threshold must pass")
+	}
+}
+
+func TestProfileLeavesOtherCategoriesAtDefaultThreshold(t *testing.T) {
+	p := profileTestProcessor()
+	chat := &models.Chat{ID: 1, SensitivityProfile: "gaming_group"}
+	if !p.profileAllowsIncident(chat, 3, 0.4) {
+		t.Error("categories without a profile threshold must keep the default behavior")
+	}
+}
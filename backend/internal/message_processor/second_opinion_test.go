@@ -0,0 +1,89 @@
+package message_processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"backend/internal/annotation_client"
+)
+
+// secondOpinionService fakes the annotation service: first-pass requests get
+// Neutral, second-opinion requests get the configured verdict.
+func secondOpinionService(t *testing.T, secondCategoryID int, secondStatus int) (*httptest.Server, *int) {
+	t.Helper()
+	secondOpinionCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Text          string `json:"text"`
+			SecondOpinion bool   `json:"second_opinion"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		if !req.SecondOpinion {
+			t.Error("expected only second-opinion requests in this test")
+		}
+		secondOpinionCalls++
+
+		if secondStatus != http.StatusOK {
+			w.WriteHeader(secondStatus)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"category_id":   secondCategoryID,
+			"category_name": "Груминг",
+			"confidence":    0.9,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &secondOpinionCalls
+}
+
+func secondOpinionProcessor(srv *httptest.Server) *Processor {
+	return &Processor{
+		annotationClient: annotation_client.NewClient(srv.URL, zap.NewNop()),
+		logger:           zap.NewNop(),
+	}
+}
+
+func TestSecondOpinionOverturnsNeutral(t *testing.T) {
+	srv, calls := secondOpinionService(t, 2, http.StatusOK)
+	p := secondOpinionProcessor(srv)
+	first := &annotation_client.AnnotationResponse{CategoryID: 9, CategoryName: "Нейтральное"}
+
+	got := p.secondOpinion(context.Background(), first, "приходи один, никому не говори", 1, 0.9, true)
+
+	if *calls != 1 {
+		t.Fatalf("expected exactly one second-opinion call, got %d", *calls)
+	}
+	if got.CategoryID != 2 {
+		t.Errorf("pre-signal case: expected the category to be upgraded to 2, got %d", got.CategoryID)
+	}
+}
+
+func TestSecondOpinionConfirmedNeutralStands(t *testing.T) {
+	srv, _ := secondOpinionService(t, 9, http.StatusOK)
+	p := secondOpinionProcessor(srv)
+	first := &annotation_client.AnnotationResponse{CategoryID: 9, CategoryName: "Нейтральное"}
+
+	got := p.secondOpinion(context.Background(), first, "обычное сообщение", 1, 0.9, false)
+	if got != first {
+		t.Error("a confirming second opinion must keep the original verdict")
+	}
+}
+
+func TestSecondOpinionFailureKeepsFirstVerdict(t *testing.T) {
+	srv, _ := secondOpinionService(t, 0, http.StatusInternalServerError)
+	p := secondOpinionProcessor(srv)
+	first := &annotation_client.AnnotationResponse{CategoryID: 9, CategoryName: "Нейтральное"}
+
+	got := p.secondOpinion(context.Background(), first, "текст", 1, 0.9, true)
+	if got != first {
+		t.Error("a failed second opinion must fall back to the first verdict")
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RecoveryMiddleware catches unhandled panics and returns the unified error
+// envelope with a generic message, without leaking the stack to the client.
+func RecoveryMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Unhandled panic in handler",
+					zap.String("path", c.Request.URL.Path),
+					zap.Any("panic", r))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"code":    "internal_error",
+					"message": "Internal server error",
+				})
+			}
+		}()
+		c.Next()
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
+
+	"backend/internal/httpx"
 )
 
 // Client is a client for the ML Service API
@@ -41,12 +42,18 @@ type ModelPrediction struct {
 
 // ClassifyResponse represents the classification result
 type ClassifyResponse struct {
-	Text              string           `json:"text"`
-	Category          string           `json:"category"`
-	CategoryID        int              `json:"category_id"`
-	Confidence        float64          `json:"confidence"`
-	IsAttack          bool             `json:"is_attack"`
-	ProcessingTimeMs  float64          `json:"processing_time_ms,omitempty"`
+	Text       string  `json:"text"`
+	Category   string  `json:"category"`
+	CategoryID int     `json:"category_id"`
+	Confidence float64 `json:"confidence"`
+	IsAttack   bool    `json:"is_attack"`
+	// ClassScores is the full per-class probability distribution (category
+	// name -> probability), when the service reports it.
+	ClassScores      map[string]float64 `json:"class_scores,omitempty"`
+	ProcessingTimeMs float64            `json:"processing_time_ms,omitempty"`
+	// ModelVersion identifies the model that produced the verdict, when the
+	// service reports it.
+	ModelVersion string `json:"model_version,omitempty"`
 	// Legacy dual model fields for backwards compatibility
 	V2Prediction      *ModelPrediction `json:"v2_prediction,omitempty"`
 	V4Prediction      *ModelPrediction `json:"v4_prediction,omitempty"`
@@ -65,15 +72,22 @@ type BatchClassifyResponse struct {
 
 // BatchResult represents a single result in batch response
 type BatchResult struct {
-	ID                int64            `json:"id"`
-	Text              string           `json:"text"`
-	V2Prediction      *ModelPrediction `json:"v2_prediction"`
-	V4Prediction      *ModelPrediction `json:"v4_prediction"`
-	ModelsAgree       *bool            `json:"models_agree"`
-	PrimaryCategory   string           `json:"primary_category"`
-	PrimaryCategoryID int              `json:"primary_category_id"`
-	PrimaryConfidence float64          `json:"primary_confidence"`
-	IsAttack          bool             `json:"is_attack"`
+	ID         int64   `json:"id"`
+	Text       string  `json:"text"`
+	Category   string  `json:"category,omitempty"`
+	CategoryID int     `json:"category_id,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+	// ClassScores is the full per-class probability distribution (category
+	// name -> probability), when the service reports it.
+	ClassScores       map[string]float64 `json:"class_scores,omitempty"`
+	ModelVersion      string             `json:"model_version,omitempty"`
+	V2Prediction      *ModelPrediction   `json:"v2_prediction"`
+	V4Prediction      *ModelPrediction   `json:"v4_prediction"`
+	ModelsAgree       *bool              `json:"models_agree"`
+	PrimaryCategory   string             `json:"primary_category"`
+	PrimaryCategoryID int                `json:"primary_category_id"`
+	PrimaryConfidence float64            `json:"primary_confidence"`
+	IsAttack          bool               `json:"is_attack"`
 }
 
 // ModelInfo represents model information
@@ -98,10 +112,8 @@ type HealthResponse struct {
 // NewClient creates a new ML Service client
 func NewClient(baseURL string) *Client {
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: httpx.NewClient(30 * time.Second),
 	}
 }
 
@@ -116,26 +128,20 @@ func (c *Client) ClassifySingle(ctx context.Context, text string) (*ClassifyResp
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/classify/single", bytes.NewBuffer(jsonData))
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/classify/single", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, httpx.Options{Name: "ML service"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var result ClassifyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -153,26 +159,20 @@ func (c *Client) ClassifyBatch(ctx context.Context, messages []BatchMessage) (*B
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/classify/batch", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/classify/batch", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, httpx.Options{Name: "ML service"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var result BatchClassifyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -181,24 +181,15 @@ func (c *Client) ClassifyBatch(ctx context.Context, messages []BatchMessage) (*B
 
 // HealthCheck checks if the ML service is healthy
 func (c *Client) HealthCheck(ctx context.Context) (*HealthResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/health", nil)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/health", nil)
+	}, httpx.Options{Name: "ML service"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var result HealthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -207,24 +198,15 @@ func (c *Client) HealthCheck(ctx context.Context) (*HealthResponse, error) {
 
 // GetModelInfo retrieves information about the loaded models
 func (c *Client) GetModelInfo(ctx context.Context) (*ModelInfo, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/model/info", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
+	body, _, err := httpx.DoWithRetry(ctx, c.httpClient, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/model/info", nil)
+	}, httpx.Options{Name: "ML service"})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var result ModelInfo
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
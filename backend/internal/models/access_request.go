@@ -1,23 +1,82 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Field scopes for granular access requests. A request may ask for specific
+// incident fields instead of everything; AccessScopeAll keeps the historical
+// all-or-nothing behavior.
+const (
+	AccessScopeText       = "text"       // raw message / summary text
+	AccessScopeSender     = "sender"     // sender identities
+	AccessScopeIndicators = "indicators" // supporting evidence: conversation context and edit history
+	AccessScopeAll        = "all"
+)
+
+// NormalizeAccessScopes validates requested scopes and canonicalizes them
+// into the comma-separated form stored on access requests. An empty list
+// means everything.
+func NormalizeAccessScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return AccessScopeAll, nil
+	}
+	seen := make(map[string]bool, len(scopes))
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		switch scope {
+		case AccessScopeAll:
+			return AccessScopeAll, nil
+		case AccessScopeText, AccessScopeSender, AccessScopeIndicators:
+			if !seen[scope] {
+				seen[scope] = true
+				out = append(out, scope)
+			}
+		default:
+			return "", fmt.Errorf("unknown access scope %q", scope)
+		}
+	}
+	return strings.Join(out, ","), nil
+}
+
+// ScopeAllows reports whether a granted scope covers one field scope. An
+// empty scope is treated as full access for incidents granted before scopes
+// existed.
+func ScopeAllows(scope, field string) bool {
+	if scope == "" || scope == AccessScopeAll {
+		return true
+	}
+	for _, s := range strings.Split(scope, ",") {
+		if s == field || s == AccessScopeAll {
+			return true
+		}
+	}
+	return false
+}
 
 // AccessRequest represents a request from a parent to access incident message content
 type AccessRequest struct {
-	ID          int64     `db:"id" json:"id"`
-	IncidentID  int64     `db:"incident_id" json:"incident_id"`
-	ParentID    int64     `db:"parent_id" json:"parent_id"`
-	ChildID     int64     `db:"child_id" json:"child_id"`
-	Status      string    `db:"status" json:"status"` // pending, approved, rejected
-	RequestedAt time.Time `db:"requested_at" json:"requested_at"`
+	ID          int64      `db:"id" json:"id"`
+	IncidentID  int64      `db:"incident_id" json:"incident_id"`
+	ParentID    int64      `db:"parent_id" json:"parent_id"`
+	ChildID     int64      `db:"child_id" json:"child_id"`
+	Status      string     `db:"status" json:"status"` // pending, approved, rejected
+	Scope       string     `db:"scope" json:"scope"`   // "all" or comma-separated field scopes
+	RequestedAt time.Time  `db:"requested_at" json:"requested_at"`
 	RespondedAt *time.Time `db:"responded_at" json:"responded_at,omitempty"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 // CreateAccessRequestInput represents input for creating an access request
 type CreateAccessRequestInput struct {
 	IncidentID int64 `json:"incident_id" binding:"required"`
+	// Scopes limits the request to specific incident fields (see the access
+	// scope constants); empty requests everything.
+	Scopes []string `json:"scopes"`
 }
 
 // RespondToAccessRequestInput represents input for responding to an access request
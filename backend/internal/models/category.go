@@ -0,0 +1,63 @@
+package models
+
+// Category describes one entry of the threat taxonomy as exposed to API
+// consumers, so the frontend can render category filters and labels without
+// hardcoding the nine categories. It mirrors the taxonomy used by the
+// annotation service and the ML classifier.
+type Category struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	IsThreat    bool   `json:"is_threat"`
+	Description string `json:"description"`
+}
+
+// NeutralCategoryID is the ID of the non-threat category; classifications with
+// this ID never produce incidents.
+const NeutralCategoryID = 9
+
+// CategoryName returns the canonical name for a category ID, or "" for an ID
+// outside the taxonomy.
+func CategoryName(id int) string {
+	for _, category := range Categories() {
+		if category.ID == id {
+			return category.Name
+		}
+	}
+	return ""
+}
+
+// CategoryIDFromName resolves a canonical category name back to its ID; the
+// inverse of CategoryName.
+func CategoryIDFromName(name string) (int, bool) {
+	for _, category := range Categories() {
+		if category.Name == name {
+			return category.ID, true
+		}
+	}
+	return 0, false
+}
+
+// Categories returns the full taxonomy in ID order. Every category except
+// neutral communication is a threat.
+func Categories() []Category {
+	return []Category{
+		{ID: 1, Name: "Склонение к сексуальным действиям (Груминг)", IsThreat: true,
+			Description: "Попытки склонить ребенка к сексуальным действиям или выманить интимные материалы"},
+		{ID: 2, Name: "Угрозы, шантаж, вымогательство", IsThreat: true,
+			Description: "Угрозы, шантаж и вымогательство денег или действий"},
+		{ID: 3, Name: "Физическое насилие/Буллинг", IsThreat: true,
+			Description: "Травля, оскорбления и угрозы физического насилия"},
+		{ID: 4, Name: "Склонение к суициду/Самоповреждению", IsThreat: true,
+			Description: "Склонение к суициду или самоповреждению"},
+		{ID: 5, Name: "Склонение к опасным играм/действиям", IsThreat: true,
+			Description: "Вовлечение в опасные игры, челленджи и действия"},
+		{ID: 6, Name: "Пропаганда запрещенных веществ", IsThreat: true,
+			Description: "Пропаганда и предложение запрещенных веществ"},
+		{ID: 7, Name: "Финансовое мошенничество", IsThreat: true,
+			Description: "Финансовое мошенничество и выманивание денег"},
+		{ID: 8, Name: "Сбор личных данных (Фишинг)", IsThreat: true,
+			Description: "Сбор личных данных, паролей и другой чувствительной информации"},
+		{ID: NeutralCategoryID, Name: "Нейтральное общение", IsThreat: false,
+			Description: "Обычное общение без признаков угрозы"},
+	}
+}
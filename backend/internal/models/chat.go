@@ -3,18 +3,49 @@ package models
 import "time"
 
 type Chat struct {
-	ID                     int64      `db:"id" json:"id"`
-	TelegramID             *int64     `db:"telegram_id" json:"telegram_id,omitempty"`  // Nullable for VK chats
-	VKPeerID               *int64     `db:"vk_peer_id" json:"vk_peer_id,omitempty"`    // VK conversation peer_id
-	Source                 string     `db:"source" json:"source"`                       // "telegram" or "vk"
-	Name                   string     `db:"name" json:"title"`                          // Frontend expects "title"
-	IsGroup                bool       `db:"is_group" json:"is_group"`
-	MonitoringActive       bool       `db:"monitoring_active" json:"is_monitored"`      // Frontend expects "is_monitored"
-	LastCollectedMessageID int64      `db:"last_collected_message_id" json:"last_collected_message_id"`
+	ID                     int64  `db:"id" json:"id"`
+	TelegramID             *int64 `db:"telegram_id" json:"telegram_id,omitempty"` // Nullable for VK chats
+	VKPeerID               *int64 `db:"vk_peer_id" json:"vk_peer_id,omitempty"`   // VK conversation peer_id
+	Source                 string `db:"source" json:"source"`                     // "telegram" or "vk"
+	Name                   string `db:"name" json:"title"`                        // Frontend expects "title"
+	IsGroup                bool   `db:"is_group" json:"is_group"`
+	MonitoringActive       bool   `db:"monitoring_active" json:"is_monitored"` // Frontend expects "is_monitored"
+	LastCollectedMessageID int64  `db:"last_collected_message_id" json:"last_collected_message_id"`
+	MonitoredEntityID      *int64 `db:"monitored_entity_id" json:"monitored_entity_id,omitempty"` // Child this chat belongs to
+	Inaccessible           bool   `db:"inaccessible" json:"inaccessible"`                         // Peer is deactivated/banned at the source; collection is skipped
+
+	// ClassificationRule overrides global classification behavior for this
+	// chat: ChatRuleSuppress drops incidents (trusted chats), ChatRuleEscalate
+	// marks new incidents as escalated (flagged chats).
+	ClassificationRule string `db:"classification_rule" json:"classification_rule"`
+	// SensitivityProfile names a configured detection profile (category
+	// muting and per-category thresholds) applied to this chat; empty means
+	// no profile.
+	SensitivityProfile string `db:"sensitivity_profile" json:"sensitivity_profile"`
+	// ConfidenceThresholdOverride, when set, pulls classifications at or above
+	// it into incidents even if the model did not flag them as attacks.
+	ConfidenceThresholdOverride *float64 `db:"confidence_threshold_override" json:"confidence_threshold_override,omitempty"`
+	// ObserveUntil, when set, keeps the chat in an observe-only warm-up until
+	// that time: messages are classified and incidents stored, but no alerts
+	// are sent. Cleared when a parent confirms monitoring.
+	ObserveUntil *time.Time `db:"observe_until" json:"observe_until,omitempty"`
 
 	// Statistics fields (computed from joined queries)
-	MessageCount     int        `db:"message_count" json:"message_count"`
-	MemberCount      *int       `db:"member_count" json:"member_count"`               // Nullable
-	LastMessageDate  *time.Time `db:"last_message_date" json:"last_message_date"`    // Nullable
-	ChatType         string     `db:"chat_type" json:"chat_type"`                     // user, group, chat, channel
+	MessageCount    int        `db:"message_count" json:"message_count"`
+	MemberCount     *int       `db:"member_count" json:"member_count"`           // Nullable
+	LastMessageDate *time.Time `db:"last_message_date" json:"last_message_date"` // Nullable
+	ChatType        string     `db:"chat_type" json:"chat_type"`                 // user, group, chat, channel
+}
+
+// Per-chat classification override rules.
+const (
+	ChatRuleNone     = "none"
+	ChatRuleSuppress = "suppress"
+	ChatRuleEscalate = "escalate"
+)
+
+// ObserveOnly reports whether the chat is still in its observe-only warm-up
+// at the given time.
+func (c *Chat) ObserveOnly(now time.Time) bool {
+	return c.ObserveUntil != nil && now.Before(*c.ObserveUntil)
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestClassScoresValueScanRoundTrip(t *testing.T) {
+	scores := ClassScores{"grooming": 0.7, "neutral": 0.25, "blackmail": 0.05}
+
+	value, err := scores.Value()
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	serialized, ok := value.(string)
+	if !ok {
+		// lib/pq sends []byte as bytea, which a JSONB column rejects.
+		t.Fatalf("Value must produce a string for JSONB, got %T", value)
+	}
+
+	var got ClassScores
+	if err := got.Scan([]byte(serialized)); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(got) != len(scores) {
+		t.Fatalf("round trip changed the distribution size: %v", got)
+	}
+	for category, score := range scores {
+		if got[category] != score {
+			t.Errorf("category %q: got %v, want %v", category, got[category], score)
+		}
+	}
+}
+
+func TestClassScoresScanString(t *testing.T) {
+	var got ClassScores
+	if err := got.Scan(`{"grooming":0.9}`); err != nil {
+		t.Fatalf("Scan(string): %v", err)
+	}
+	if got["grooming"] != 0.9 {
+		t.Errorf("unexpected distribution: %v", got)
+	}
+}
+
+func TestClassScoresEmptyStoresNull(t *testing.T) {
+	for _, scores := range []ClassScores{nil, {}} {
+		value, err := scores.Value()
+		if err != nil {
+			t.Fatalf("Value: %v", err)
+		}
+		if value != nil {
+			t.Errorf("empty distribution must store NULL, got %v", value)
+		}
+	}
+}
+
+func TestClassScoresScanNullAndGarbage(t *testing.T) {
+	got := ClassScores{"stale": 1}
+	if err := got.Scan(nil); err != nil {
+		t.Fatalf("Scan(nil): %v", err)
+	}
+	if got != nil {
+		t.Errorf("NULL must reset the distribution, got %v", got)
+	}
+
+	if err := got.Scan(42); err == nil {
+		t.Error("expected an error for an unsupported source type")
+	}
+}
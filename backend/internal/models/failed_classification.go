@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// FailedClassification is one dead-letter entry: a collected message whose
+// classification call failed (ML or annotation service outage). The message
+// itself is stored normally; only the verdict is missing. Entries are marked
+// reprocessed when an operator replays them via the admin endpoint.
+type FailedClassification struct {
+	ID          int64     `db:"id" json:"id"`
+	MessageID   int64     `db:"message_id" json:"message_id"`
+	ChatID      int64     `db:"chat_id" json:"chat_id"`
+	Classifier  string    `db:"classifier" json:"classifier"` // "ml" or "annotation"
+	Error       string    `db:"error" json:"error"`
+	FailedAt    time.Time `db:"failed_at" json:"failed_at"`
+	Reprocessed bool      `db:"reprocessed" json:"reprocessed"`
+}
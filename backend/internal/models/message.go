@@ -1,34 +1,179 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Message represents a message stored in the 'messages' table.
 type Message struct {
-	ID                int64     `db:"id"`
-	ChatID            int64     `db:"chat_id"`
-	TelegramMessageID *int64    `db:"telegram_message_id"` // Nullable for VK messages
-	VKMessageID       *int64    `db:"vk_message_id"`       // VK message ID
-	Source            string    `db:"source"`              // "telegram" or "vk"
-	MessageType       string    `db:"message_type"`        // "message", "post", "comment"
-	SenderUsername    string    `db:"sender_username"`
-	Timestamp         time.Time `db:"timestamp"`
-	ContentEncrypted  string    `db:"content_encrypted"`
+	ID                int64      `db:"id"`
+	ChatID            int64      `db:"chat_id"`
+	TelegramMessageID *int64     `db:"telegram_message_id"` // Nullable for VK messages
+	VKMessageID       *int64     `db:"vk_message_id"`       // VK message ID
+	Source            string     `db:"source"`              // "telegram" or "vk"
+	MessageType       string     `db:"message_type"`        // "message", "post", "comment"
+	SenderID          int64      `db:"sender_id"`           // Stable platform ID (Telegram user ID / VK from_id); 0 when unknown
+	SenderUsername    string     `db:"sender_username"`     // Display name; presentational only, can change or collide
+	Timestamp         time.Time  `db:"timestamp"`
+	ContentEncrypted  string     `db:"content_encrypted"`
+	IsOutgoing        bool       `db:"is_outgoing"`         // Sent by the monitored account rather than received
+	Forwarded         bool       `db:"forwarded"`           // Relayed from another chat or account
+	ForwardedFrom     string     `db:"forwarded_from"`      // Original author, when known
+	ReplyToMessageID  *int64     `db:"reply_to_message_id"` // Source-side ID of the message this one replies to
+	ReactionCount     int        `db:"reaction_count"`      // Total reactions reported by the source
+	Deleted           bool       `db:"deleted"`             // Removed at the source after collection
+	DeletedAt         *time.Time `db:"deleted_at"`
+}
+
+// MessageEdit records one edit of a collected message: both versions are kept
+// encrypted so evidence survives even if the source text changes or vanishes.
+type MessageEdit struct {
+	ID                  int64     `db:"id" json:"id"`
+	MessageID           int64     `db:"message_id" json:"message_id"`
+	OldContentEncrypted string    `db:"old_content_encrypted" json:"old_text"`
+	NewContentEncrypted string    `db:"new_content_encrypted" json:"new_text"`
+	EditedAt            time.Time `db:"edited_at" json:"edited_at"`
+	RecordedAt          time.Time `db:"recorded_at" json:"recorded_at"`
+}
+
+// ConfidenceStats summarizes the model-confidence distribution of incidents
+// within one threat category.
+type ConfidenceStats struct {
+	ThreatType    string  `db:"threat_type" json:"threat_type"`
+	Total         int     `db:"total" json:"total"`
+	AvgConfidence float64 `db:"avg_confidence" json:"avg_confidence"`
+	MinConfidence float64 `db:"min_confidence" json:"min_confidence"`
+	MaxConfidence float64 `db:"max_confidence" json:"max_confidence"`
+	HighCount     int     `db:"high_count" json:"high_count"`     // >= 0.8
+	MediumCount   int     `db:"medium_count" json:"medium_count"` // 0.5 - 0.8
+	LowCount      int     `db:"low_count" json:"low_count"`       // < 0.5
+}
+
+// ClassifiedMessage is a lightweight record of a classification result stored
+// in the 'classified_messages' table. Used for baselining when neutral traffic
+// recording is enabled; unlike incidents it carries no message content.
+type ClassifiedMessage struct {
+	ID           int64     `db:"id" json:"id"`
+	ChatID       int64     `db:"chat_id" json:"chat_id"`
+	CategoryID   int       `db:"category_id" json:"category_id"`
+	CategoryName string    `db:"category_name" json:"category_name"`
+	ClassifiedAt time.Time `db:"classified_at" json:"classified_at"`
+}
+
+// ClassScores is the classifier's full per-category probability distribution
+// (category name -> probability). Stored as JSONB so the schema survives
+// changes to the category set; nil when the classifier did not report one.
+type ClassScores map[string]float64
+
+// Value implements driver.Valuer; an empty distribution is stored as NULL.
+func (s ClassScores) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	// A string, not []byte: lib/pq would send []byte as bytea, which a
+	// JSONB column rejects.
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (s *ClassScores) Scan(src interface{}) error {
+	if src == nil {
+		*s = nil
+		return nil
+	}
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("unsupported class_scores type %T", src)
 }
 
 // Incident represents an incident stored in the 'incidents' table.
 type Incident struct {
-	ID                     int64     `db:"id" json:"id"`
-	MessageID              int64     `db:"message_id" json:"message_id"` // References messages.id
-	ThreatType             string    `db:"threat_type" json:"threat_type"`
-	ModelConfidence        float64   `db:"model_confidence" json:"confidence"`
-	Status                 string    `db:"status" json:"status"`
-	ChatTitle              string    `db:"chat_title" json:"chat_title"`
-	CreatedAt              time.Time `db:"created_at" json:"created_at"`
-	SummaryEncrypted       string    `db:"summary_encrypted" json:"message_text"`
-	AccessGranted          bool      `db:"access_granted" json:"access_granted"`
-	CurrentAccessRequestID *int64    `db:"current_access_request_id" json:"current_access_request_id,omitempty"`
-	V2CategoryID           *int      `db:"v2_category_id" json:"v2_category_id,omitempty"`
-	V4CategoryID           *int      `db:"v4_category_id" json:"v4_category_id,omitempty"`
-	ModelsAgree            *bool     `db:"models_agree" json:"models_agree,omitempty"`
-	Source                 string    `db:"source" json:"source"` // "telegram" or "vk"
-}
\ No newline at end of file
+	ID                     int64       `db:"id" json:"id"`
+	MessageID              int64       `db:"message_id" json:"message_id"` // References messages.id
+	ThreatType             string      `db:"threat_type" json:"threat_type"`
+	ModelConfidence        float64     `db:"model_confidence" json:"confidence"`
+	ModelProvider          string      `db:"model_provider" json:"model_provider,omitempty"`       // Classifier that produced this incident ("ml_service", "gemini", ...)
+	ModelVersion           string      `db:"model_version" json:"model_version,omitempty"`         // Model name/version reported by the classifier
+	ConfidenceSource       string      `db:"confidence_source" json:"confidence_source,omitempty"` // "model" when the classifier supplied the confidence, "default" when substituted
+	ClassScores            ClassScores `db:"class_scores" json:"class_scores,omitempty"`           // Full probability distribution, when the classifier reports one
+	Status                 string      `db:"status" json:"status"`
+	StatusChangedAt        *time.Time  `db:"status_changed_at" json:"status_changed_at,omitempty"` // Last manual status transition; nil while untouched
+	ChatTitle              string      `db:"chat_title" json:"chat_title"`
+	CreatedAt              time.Time   `db:"created_at" json:"created_at"`
+	SummaryEncrypted       string      `db:"summary_encrypted" json:"message_text"`
+	AccessGranted          bool        `db:"access_granted" json:"access_granted"`
+	CurrentAccessRequestID *int64      `db:"current_access_request_id" json:"current_access_request_id,omitempty"`
+	AccessScope            string      `db:"access_scope" json:"access_scope,omitempty"` // Granted field scopes: "all" or comma-separated (see access scope constants)
+	V2CategoryID           *int        `db:"v2_category_id" json:"v2_category_id,omitempty"`
+	V4CategoryID           *int        `db:"v4_category_id" json:"v4_category_id,omitempty"`
+	ModelsAgree            *bool       `db:"models_agree" json:"models_agree,omitempty"`
+	Source                 string      `db:"source" json:"source"`                           // "telegram" or "vk"
+	IsOutgoing             bool        `db:"is_outgoing" json:"is_outgoing"`                 // Underlying message was sent by the monitored account
+	ContentFingerprint     *string     `db:"content_fingerprint" json:"-"`                   // Normalized-content hash for forward dedup
+	FirstContact           bool        `db:"first_contact" json:"first_contact"`             // Sender had never written in this chat before
+	OccurrenceCount        int         `db:"occurrence_count" json:"occurrence_count"`       // Times this content was seen (forward duplicates)
+	GroomingStage          string      `db:"grooming_stage" json:"grooming_stage,omitempty"` // Detected grooming stage (see GroomingStages); "" when untagged
+	UpdatedAt              time.Time   `db:"updated_at" json:"updated_at"`                   // Last write to the incident row (update-on-write, drives incremental sync)
+	Tags                   []string    `db:"-" json:"tags"`                                  // Free-form triage tags (incident_tags table)
+}
+
+// GroomingStages is the fixed vocabulary of grooming stages the annotation
+// service may tag on an incident, in typical escalation order. Values outside
+// this vocabulary are never stored.
+var GroomingStages = []string{
+	"friendship_forming",
+	"isolation",
+	"secrecy",
+	"desensitization",
+	"request",
+}
+
+// ValidGroomingStage reports whether stage belongs to the fixed vocabulary.
+func ValidGroomingStage(stage string) bool {
+	for _, name := range GroomingStages {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IncidentFilter narrows an incident listing. Zero-value fields are ignored,
+// so filters combine freely. From is inclusive, To is exclusive, which lets
+// adjacent ranges (e.g. day by day) tile without double-counting.
+type IncidentFilter struct {
+	Status        string
+	ThreatType    string
+	From          *time.Time
+	To            *time.Time
+	Tag           string
+	MinConfidence float64
+	ModelVersion  string
+}
+
+// IsZero reports whether no filter field is set.
+func (f IncidentFilter) IsZero() bool {
+	return f == IncidentFilter{}
+}
+
+// IncidentChange is one entry of the incremental-sync changelog: an incident
+// plus what happened to it since the caller's last pull. Archived (merged)
+// incidents come back as tombstones so integrations can drop them instead of
+// re-pulling the whole dataset to notice.
+type IncidentChange struct {
+	Incident   *Incident `json:"incident"`
+	ChangeType string    `json:"change_type"` // "created", "updated" or "archived"
+	ChangedAt  time.Time `json:"changed_at"`
+	Tombstone  bool      `json:"tombstone,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// MonitoredEntity represents a monitored child and the messenger accounts that
+// belong to them. Chats are associated with an entity through the account the
+// collector reads from, which makes per-child scoping, notifications and
+// access control consistent instead of relying on hardcoded IDs.
+// Contact aggregates one sender's interactions across all of a child's
+// monitored chats: how much they talk, where, and how often they triggered
+// incidents.
+type Contact struct {
+	SenderID       int64     `db:"sender_id" json:"sender_id"`             // Stable platform ID; 0 for legacy rows aggregated by name
+	SenderUsername string    `db:"sender_username" json:"sender_username"` // Most recent display name seen for this sender
+	Sources        string    `db:"sources" json:"sources"`                 // comma-joined distinct sources ("telegram,vk")
+	ChatCount      int       `db:"chat_count" json:"chat_count"`
+	MessageCount   int       `db:"message_count" json:"message_count"`
+	IncidentCount  int       `db:"incident_count" json:"incident_count"`
+	FirstSeen      time.Time `db:"first_seen" json:"first_seen"`
+	LastSeen       time.Time `db:"last_seen" json:"last_seen"`
+}
+
+// GroomingTimelineEntry is one staged incident on a child's grooming
+// timeline: which stage was detected, where, by whom, and when. Entries are
+// ordered chronologically so reviewers can see escalation progression.
+type GroomingTimelineEntry struct {
+	IncidentID     int64     `db:"incident_id" json:"incident_id"`
+	GroomingStage  string    `db:"grooming_stage" json:"grooming_stage"`
+	ThreatType     string    `db:"threat_type" json:"threat_type"`
+	Confidence     float64   `db:"model_confidence" json:"confidence"`
+	Status         string    `db:"status" json:"status"`
+	ChatID         int64     `db:"chat_id" json:"chat_id"`
+	ChatTitle      string    `db:"chat_title" json:"chat_title"`
+	SenderID       int64     `db:"sender_id" json:"sender_id"`
+	SenderUsername string    `db:"sender_username" json:"sender_username"`
+	Timestamp      time.Time `db:"timestamp" json:"timestamp"` // When the underlying message was sent
+}
+
+type MonitoredEntity struct {
+	ID                int64     `db:"id" json:"id"`
+	Name              string    `db:"name" json:"name"`
+	TelegramAccountID *int64    `db:"telegram_account_id" json:"telegram_account_id,omitempty"` // Telegram user ID of the child's account
+	VKAccountID       *int64    `db:"vk_account_id" json:"vk_account_id,omitempty"`             // VK user ID of the child's account
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,29 @@
+// Package monitoring holds the global monitoring kill switch shared between
+// the API (which flips it) and the message processor (which checks it every
+// cycle). When off, no collection, classification, or incident creation
+// happens; the API stays up for reviewing past incidents.
+package monitoring
+
+import "sync/atomic"
+
+// State is the hot-reloadable global monitoring switch.
+type State struct {
+	enabled atomic.Bool
+}
+
+// NewState creates the switch with its initial position.
+func NewState(enabled bool) *State {
+	s := &State{}
+	s.enabled.Store(enabled)
+	return s
+}
+
+// Enabled reports whether monitoring is currently on.
+func (s *State) Enabled() bool {
+	return s.enabled.Load()
+}
+
+// SetEnabled flips the switch.
+func (s *State) SetEnabled(enabled bool) {
+	s.enabled.Store(enabled)
+}
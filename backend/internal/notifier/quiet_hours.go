@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"backend/internal/config"
+)
+
+// quietWindow is one parsed quiet-hours window: minutes-of-day bounds in a
+// resolved location. A window may span midnight (start > end).
+type quietWindow struct {
+	start int
+	end   int
+	loc   *time.Location
+}
+
+// contains reports whether the instant falls inside the window, evaluated in
+// the window's own timezone.
+func (w *quietWindow) contains(now time.Time) bool {
+	if w.start == w.end {
+		return false
+	}
+	local := now.In(w.loc)
+	minutes := local.Hour()*60 + local.Minute()
+	if w.start < w.end {
+		return minutes >= w.start && minutes < w.end
+	}
+	return minutes >= w.start || minutes < w.end
+}
+
+// QuietHours decides whether an alert for a recipient should be deferred
+// right now. Critical categories (grooming, suicide inducement by default)
+// always break through; everything else raised inside a recipient's quiet
+// window waits for the digest.
+type QuietHours struct {
+	defaultWindow *quietWindow
+	recipients    map[int64]*quietWindow
+	critical      map[int]bool
+}
+
+// NewQuietHours parses the configured default window, per-recipient
+// overrides, and critical category list. A recipient without an override
+// uses the default window; a recipient override replaces it entirely.
+func NewQuietHours(defaultWindow config.QuietWindow, recipients map[int64]config.QuietWindow, criticalCategories []int) (*QuietHours, error) {
+	parsed, err := parseWindow(defaultWindow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default quiet window: %w", err)
+	}
+
+	perRecipient := make(map[int64]*quietWindow, len(recipients))
+	for recipient, window := range recipients {
+		w, err := parseWindow(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet window for recipient %d: %w", recipient, err)
+		}
+		perRecipient[recipient] = w
+	}
+
+	critical := make(map[int]bool, len(criticalCategories))
+	for _, categoryID := range criticalCategories {
+		critical[categoryID] = true
+	}
+
+	return &QuietHours{
+		defaultWindow: parsed,
+		recipients:    perRecipient,
+		critical:      critical,
+	}, nil
+}
+
+// parseWindow resolves one configured window. Both bounds empty means no
+// window (nil); a single empty bound is a configuration error.
+func parseWindow(window config.QuietWindow) (*quietWindow, error) {
+	if window.Start == "" && window.End == "" {
+		return nil, nil
+	}
+	if window.Start == "" || window.End == "" {
+		return nil, fmt.Errorf("start and end must both be set (got start=%q end=%q)", window.Start, window.End)
+	}
+
+	start, err := parseMinutes(window.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseMinutes(window.End)
+	if err != nil {
+		return nil, err
+	}
+
+	locName := window.Timezone
+	if locName == "" {
+		locName = "Local"
+	}
+	loc, err := time.LoadLocation(locName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", window.Timezone, err)
+	}
+
+	return &quietWindow{start: start, end: end, loc: loc}, nil
+}
+
+// parseMinutes converts an "HH:MM" bound to minutes of day.
+func parseMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM): %w", value, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// windowFor returns the recipient's override when one exists, the default
+// window otherwise.
+func (q *QuietHours) windowFor(recipient int64) *quietWindow {
+	if w, ok := q.recipients[recipient]; ok {
+		return w
+	}
+	return q.defaultWindow
+}
+
+// InQuietWindow reports whether the recipient's quiet window covers the
+// instant, regardless of category.
+func (q *QuietHours) InQuietWindow(recipient int64, now time.Time) bool {
+	w := q.windowFor(recipient)
+	return w != nil && w.contains(now)
+}
+
+// ShouldDefer reports whether an alert for the category should wait for the
+// recipient's next active window instead of being sent now.
+func (q *QuietHours) ShouldDefer(recipient int64, categoryID int, now time.Time) bool {
+	if q.critical[categoryID] {
+		return false
+	}
+	return q.InQuietWindow(recipient, now)
+}
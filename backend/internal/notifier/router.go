@@ -0,0 +1,137 @@
+// Package notifier routes incident alerts to per-category recipients, so a
+// grooming incident can page a counselor while a phishing incident only goes
+// to the parent. Recipients are Telegram chat IDs delivered through the
+// access-control bot.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AlertSender delivers one alert message to one recipient.
+type AlertSender interface {
+	SendIncidentAlert(recipientChatID int64, text string) error
+}
+
+// Router resolves which recipients an incident category alerts and fans the
+// message out to them. With quiet hours configured, non-critical alerts
+// raised inside a recipient's quiet window are held and delivered as one
+// digest at the next active window.
+type Router struct {
+	sender            AlertSender
+	routes            map[int][]int64
+	defaultRecipients []int64
+	quiet             *QuietHours
+	logger            *zap.Logger
+
+	mu       sync.Mutex
+	deferred map[int64][]string
+}
+
+// NewRouter creates an alert router from the configured category routes.
+// A nil quiet means every alert is delivered immediately.
+func NewRouter(sender AlertSender, routes map[int][]int64, defaultRecipients []int64, quiet *QuietHours, logger *zap.Logger) *Router {
+	return &Router{
+		sender:            sender,
+		routes:            routes,
+		defaultRecipients: defaultRecipients,
+		quiet:             quiet,
+		logger:            logger,
+		deferred:          make(map[int64][]string),
+	}
+}
+
+// Recipients resolves the recipients for a category: the configured route
+// when one exists, the default list otherwise.
+func (r *Router) Recipients(categoryID int) []int64 {
+	if recipients, ok := r.routes[categoryID]; ok {
+		return recipients
+	}
+	return r.defaultRecipients
+}
+
+// NotifyIncident sends the alert text to every recipient routed for the
+// category. Recipients inside their quiet window get the alert deferred to
+// their digest instead, unless the category is critical. Send failures are
+// logged per recipient and don't stop the rest.
+func (r *Router) NotifyIncident(categoryID int, text string) {
+	recipients := r.Recipients(categoryID)
+	if len(recipients) == 0 {
+		r.logger.Debug("No alert recipients routed for category", zap.Int("category_id", categoryID))
+		return
+	}
+
+	now := time.Now()
+	for _, recipient := range recipients {
+		if r.quiet != nil && r.quiet.ShouldDefer(recipient, categoryID, now) {
+			r.mu.Lock()
+			r.deferred[recipient] = append(r.deferred[recipient], text)
+			r.mu.Unlock()
+			r.logger.Debug("Alert deferred by quiet hours",
+				zap.Int64("recipient", recipient),
+				zap.Int("category_id", categoryID))
+			continue
+		}
+		if err := r.sender.SendIncidentAlert(recipient, text); err != nil {
+			r.logger.Error("Failed to deliver incident alert",
+				zap.Int64("recipient", recipient),
+				zap.Int("category_id", categoryID),
+				zap.Error(err))
+		}
+	}
+}
+
+// Run periodically delivers deferred alert digests once a recipient's quiet
+// window ends. It blocks until the context is cancelled; without quiet hours
+// it returns immediately.
+func (r *Router) Run(ctx context.Context) {
+	if r.quiet == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.FlushDeferred(now)
+		}
+	}
+}
+
+// FlushDeferred sends one digest to every recipient with deferred alerts
+// whose quiet window no longer covers the instant.
+func (r *Router) FlushDeferred(now time.Time) {
+	if r.quiet == nil {
+		return
+	}
+
+	due := make(map[int64][]string)
+	r.mu.Lock()
+	for recipient, texts := range r.deferred {
+		if !r.quiet.InQuietWindow(recipient, now) {
+			due[recipient] = texts
+			delete(r.deferred, recipient)
+		}
+	}
+	r.mu.Unlock()
+
+	for recipient, texts := range due {
+		digest := fmt.Sprintf("🌙 Уведомления, отложенные на время тишины (%d):\n\n%s",
+			len(texts), strings.Join(texts, "\n\n"))
+		if err := r.sender.SendIncidentAlert(recipient, digest); err != nil {
+			r.logger.Error("Failed to deliver deferred alert digest",
+				zap.Int64("recipient", recipient),
+				zap.Int("alert_count", len(texts)),
+				zap.Error(err))
+		}
+	}
+}
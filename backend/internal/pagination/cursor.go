@@ -0,0 +1,47 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor identifies a position in a (created_at, id) ordered listing.
+// It is serialized to an opaque base64 token so clients cannot rely on its internals.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode serializes the cursor into an opaque token suitable for the `after` query parameter.
+func (c *Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.URLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses an opaque cursor token produced by Encode.
+func Decode(token string) (*Cursor, error) {
+	raw, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding: %w", err)
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor timestamp: %w", err)
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor id: %w", err)
+	}
+
+	return &Cursor{CreatedAt: time.Unix(0, nanos), ID: id}, nil
+}
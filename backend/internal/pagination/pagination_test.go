@@ -0,0 +1,125 @@
+package pagination
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	orig := &Cursor{CreatedAt: time.Date(2026, 3, 14, 15, 9, 26, 535897932, time.UTC), ID: 42}
+
+	decoded, err := Decode(orig.Encode())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(orig.CreatedAt) {
+		t.Errorf("CreatedAt changed in round trip: got %v, want %v", decoded.CreatedAt, orig.CreatedAt)
+	}
+	if decoded.ID != orig.ID {
+		t.Errorf("ID changed in round trip: got %d, want %d", decoded.ID, orig.ID)
+	}
+}
+
+func TestDecodeRejectsMalformedTokens(t *testing.T) {
+	for _, token := range []string{
+		"not base64 at all!",
+		"bm8gY29sb24",      // base64 of "no colon"
+		"YWJjOjEyMw==",     // base64 of "abc:123" (bad timestamp)
+		"MTIzNDU2Nzg5OmFi", // base64 of "123456789:ab" (bad id)
+	} {
+		if _, err := Decode(token); err == nil {
+			t.Errorf("Decode(%q) accepted a malformed token", token)
+		}
+	}
+}
+
+// row mirrors the (created_at, id) ordering key the incident listing pages
+// over.
+type row struct {
+	createdAt time.Time
+	id        int64
+}
+
+// pageAfter applies the same keyset predicate and ordering the repository
+// query uses: rows strictly older than the cursor, newest first.
+func pageAfter(rows []row, after *Cursor, limit int) []row {
+	var eligible []row
+	for _, r := range rows {
+		if after == nil || r.createdAt.Before(after.CreatedAt) ||
+			(r.createdAt.Equal(after.CreatedAt) && r.id < after.ID) {
+			eligible = append(eligible, r)
+		}
+	}
+	sort.Slice(eligible, func(i, j int) bool {
+		if !eligible[i].createdAt.Equal(eligible[j].createdAt) {
+			return eligible[i].createdAt.After(eligible[j].createdAt)
+		}
+		return eligible[i].id > eligible[j].id
+	})
+	if len(eligible) > limit {
+		eligible = eligible[:limit]
+	}
+	return eligible
+}
+
+// TestCursorOrderingStableAcrossInserts verifies the property keyset
+// pagination exists for: rows inserted between page fetches appear before
+// the cursor position and therefore cannot shift or duplicate later pages,
+// unlike OFFSET pagination.
+func TestCursorOrderingStableAcrossInserts(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rows []row
+	for i := int64(1); i <= 6; i++ {
+		rows = append(rows, row{createdAt: base.Add(time.Duration(i) * time.Minute), id: i})
+	}
+
+	page1 := pageAfter(rows, nil, 3)
+	if len(page1) != 3 || page1[0].id != 6 || page1[2].id != 4 {
+		t.Fatalf("unexpected first page: %+v", page1)
+	}
+
+	last := page1[len(page1)-1]
+	token := (&Cursor{CreatedAt: last.createdAt, ID: last.id}).Encode()
+	after, err := Decode(token)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	// New rows land between the two page fetches.
+	rows = append(rows,
+		row{createdAt: base.Add(10 * time.Minute), id: 7},
+		row{createdAt: base.Add(11 * time.Minute), id: 8},
+	)
+
+	page2 := pageAfter(rows, after, 3)
+	if len(page2) != 3 {
+		t.Fatalf("unexpected second page size: %+v", page2)
+	}
+	for i, want := range []int64{3, 2, 1} {
+		if page2[i].id != want {
+			t.Errorf("second page position %d: got id %d, want %d (inserts shifted the page)", i, page2[i].id, want)
+		}
+	}
+}
+
+// Ties on created_at must be broken by id so the composite key stays total.
+func TestCursorTieBreakOnEqualTimestamps(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []row{{ts, 1}, {ts, 2}, {ts, 3}}
+
+	page1 := pageAfter(rows, nil, 2)
+	after := &Cursor{CreatedAt: page1[len(page1)-1].createdAt, ID: page1[len(page1)-1].id}
+	page2 := pageAfter(rows, after, 2)
+
+	seen := map[int64]bool{}
+	for _, r := range append(page1, page2...) {
+		if seen[r.id] {
+			t.Fatalf("row %d appeared on two pages", r.id)
+		}
+		seen[r.id] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected all 3 rows across pages, saw %d", len(seen))
+	}
+}
@@ -0,0 +1,153 @@
+// Package readiness gates processor startup on the critical service
+// dependencies (database, collector, ML service, annotation service). The
+// processor waits for the gate at startup so orchestrated deployments don't
+// spend their first cycles failing noisily, and the HTTP server exposes the
+// gate's state via /readyz while /livez only reports that the process is up.
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type probe struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// Gate tracks named dependency probes and whether they have all passed.
+type Gate struct {
+	mu           sync.Mutex
+	probes       []probe
+	pending      map[string]string // probe name -> last error
+	ready        atomic.Bool
+	waitTimeout  time.Duration
+	probeTimeout time.Duration
+	logger       *zap.Logger
+}
+
+// NewGate creates a gate that waits up to waitTimeout for all probes, running
+// each individual probe with probeTimeout.
+func NewGate(waitTimeout, probeTimeout time.Duration, logger *zap.Logger) *Gate {
+	return &Gate{
+		pending:      make(map[string]string),
+		waitTimeout:  waitTimeout,
+		probeTimeout: probeTimeout,
+		logger:       logger,
+	}
+}
+
+// AddProbe registers a dependency check. Probes must be added before Wait is
+// called.
+func (g *Gate) AddProbe(name string, check func(ctx context.Context) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.probes = append(g.probes, probe{name: name, check: check})
+	g.pending[name] = "not probed yet"
+}
+
+// Ready reports whether every registered probe has passed at least once.
+func (g *Gate) Ready() bool {
+	return g.ready.Load()
+}
+
+// Pending returns the sorted names of probes that have not passed yet.
+func (g *Gate) Pending() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.pending))
+	for name := range g.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait blocks until every probe passes, the wait timeout elapses, or ctx is
+// cancelled, retrying failed probes with exponential backoff. On timeout it
+// returns an error naming the failing dependencies and keeps probing in the
+// background so /readyz eventually turns ready once they come up.
+func (g *Gate) Wait(ctx context.Context) error {
+	deadline := time.Now().Add(g.waitTimeout)
+	backoff := time.Second
+
+	for {
+		if g.probeAll(ctx) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			go g.probeUntilReady(ctx)
+			return fmt.Errorf("dependencies not ready after %s: %s",
+				g.waitTimeout, strings.Join(g.Pending(), ", "))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < 10*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// probeAll runs every still-pending probe once and reports whether the gate
+// became ready.
+func (g *Gate) probeAll(ctx context.Context) bool {
+	for _, p := range g.probes {
+		g.mu.Lock()
+		_, stillPending := g.pending[p.name]
+		g.mu.Unlock()
+		if !stillPending {
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, g.probeTimeout)
+		err := p.check(probeCtx)
+		cancel()
+
+		g.mu.Lock()
+		if err != nil {
+			g.pending[p.name] = err.Error()
+		} else {
+			delete(g.pending, p.name)
+			g.logger.Info("Dependency ready", zap.String("dependency", p.name))
+		}
+		g.mu.Unlock()
+	}
+
+	g.mu.Lock()
+	allPassed := len(g.pending) == 0
+	g.mu.Unlock()
+	if allPassed {
+		g.ready.Store(true)
+	}
+	return allPassed
+}
+
+// probeUntilReady keeps retrying failed probes after the startup wait gave
+// up, so late-starting dependencies still flip the gate to ready.
+func (g *Gate) probeUntilReady(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if g.probeAll(ctx) {
+				g.logger.Info("All dependencies ready")
+				return
+			}
+		}
+	}
+}
@@ -33,8 +33,8 @@ func NewAccessRequestRepository(db *sqlx.DB, logger *zap.Logger) AccessRequestRe
 
 func (r *accessRequestRepository) Create(req *models.AccessRequest) error {
 	query := `
-		INSERT INTO access_requests (incident_id, parent_id, child_id, status, requested_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO access_requests (incident_id, parent_id, child_id, status, scope, requested_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -44,6 +44,7 @@ func (r *accessRequestRepository) Create(req *models.AccessRequest) error {
 		req.ParentID,
 		req.ChildID,
 		req.Status,
+		req.Scope,
 		req.RequestedAt,
 	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt)
 
@@ -58,7 +59,7 @@ func (r *accessRequestRepository) Create(req *models.AccessRequest) error {
 func (r *accessRequestRepository) GetByID(id int64) (*models.AccessRequest, error) {
 	var req models.AccessRequest
 	query := `
-		SELECT id, incident_id, parent_id, child_id, status, requested_at, responded_at, created_at, updated_at
+		SELECT id, incident_id, parent_id, child_id, status, scope, requested_at, responded_at, created_at, updated_at
 		FROM access_requests
 		WHERE id = $1
 	`
@@ -78,7 +79,7 @@ func (r *accessRequestRepository) GetByID(id int64) (*models.AccessRequest, erro
 func (r *accessRequestRepository) GetByIncidentID(incidentID int64) (*models.AccessRequest, error) {
 	var req models.AccessRequest
 	query := `
-		SELECT id, incident_id, parent_id, child_id, status, requested_at, responded_at, created_at, updated_at
+		SELECT id, incident_id, parent_id, child_id, status, scope, requested_at, responded_at, created_at, updated_at
 		FROM access_requests
 		WHERE incident_id = $1
 		ORDER BY created_at DESC
@@ -100,7 +101,7 @@ func (r *accessRequestRepository) GetByIncidentID(incidentID int64) (*models.Acc
 func (r *accessRequestRepository) GetPendingByChildID(childID int64) ([]*models.AccessRequest, error) {
 	var requests []*models.AccessRequest
 	query := `
-		SELECT id, incident_id, parent_id, child_id, status, requested_at, responded_at, created_at, updated_at
+		SELECT id, incident_id, parent_id, child_id, status, scope, requested_at, responded_at, created_at, updated_at
 		FROM access_requests
 		WHERE child_id = $1 AND status = 'pending'
 		ORDER BY created_at DESC
@@ -14,7 +14,7 @@ type AuthRepository interface {
 }
 
 type authRepository struct {
-	db  *sqlx.DB
+	db     *sqlx.DB
 	logger *zap.Logger
 }
 
@@ -11,9 +11,14 @@ import (
 type ChatRepository interface {
 	GetChatByTelegramID(telegramID int64) (*models.Chat, error)
 	GetChatByVKPeerID(vkPeerID int64) (*models.Chat, error)
+	GetChatBySourceAndName(source, name string) (*models.Chat, error)
 	GetChatByID(id int64) (*models.Chat, error)
 	UpdateLastCollectedMessageID(chatID, lastCollectedMessageID int64) error
 	UpdateMonitoringStatus(chatID int64, active bool) error
+	SetChatInaccessible(chatID int64, inaccessible bool) error
+	UpdateChatProfile(chatID int64, profile string) error
+	UpdateChatRules(chatID int64, rule string, thresholdOverride *float64) error
+	ConfirmChatMonitoring(chatID int64) error
 	CreateChat(chat *models.Chat) error
 	GetAllChats() ([]*models.Chat, error)
 }
@@ -29,7 +34,7 @@ func NewChatRepository(db *sqlx.DB, logger *zap.Logger) ChatRepository {
 
 func (r *chatRepository) GetChatByTelegramID(telegramID int64) (*models.Chat, error) {
 	var chat models.Chat
-	query := `SELECT id, telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, chat_type FROM chats WHERE telegram_id = $1`
+	query := `SELECT id, telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, monitored_entity_id, classification_rule, confidence_threshold_override, sensitivity_profile, inaccessible, chat_type, observe_until FROM chats WHERE telegram_id = $1`
 	err := r.db.Get(&chat, query, telegramID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -42,7 +47,7 @@ func (r *chatRepository) GetChatByTelegramID(telegramID int64) (*models.Chat, er
 
 func (r *chatRepository) GetChatByVKPeerID(vkPeerID int64) (*models.Chat, error) {
 	var chat models.Chat
-	query := `SELECT id, telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, chat_type FROM chats WHERE vk_peer_id = $1`
+	query := `SELECT id, telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, monitored_entity_id, classification_rule, confidence_threshold_override, sensitivity_profile, inaccessible, chat_type, observe_until FROM chats WHERE vk_peer_id = $1`
 	err := r.db.Get(&chat, query, vkPeerID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -60,15 +65,33 @@ func (r *chatRepository) UpdateLastCollectedMessageID(chatID, lastCollectedMessa
 }
 
 func (r *chatRepository) CreateChat(chat *models.Chat) error {
-	query := `INSERT INTO chats (telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, chat_type)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	if chat.ClassificationRule == "" {
+		chat.ClassificationRule = models.ChatRuleNone
+	}
+	query := `INSERT INTO chats (telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, monitored_entity_id, classification_rule, confidence_threshold_override, sensitivity_profile, inaccessible, chat_type, observe_until)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`
 	return r.db.QueryRowx(query, chat.TelegramID, chat.VKPeerID, chat.Source, chat.Name, chat.IsGroup,
-		chat.MonitoringActive, chat.LastCollectedMessageID, chat.ChatType).StructScan(chat)
+		chat.MonitoringActive, chat.LastCollectedMessageID, chat.MonitoredEntityID, chat.ClassificationRule, chat.ConfidenceThresholdOverride, chat.SensitivityProfile, chat.Inaccessible, chat.ChatType, chat.ObserveUntil).StructScan(chat)
+}
+
+// GetChatBySourceAndName finds a chat by its source and display name, used
+// for synthetic chats (e.g. manual submissions) that have no platform ID.
+func (r *chatRepository) GetChatBySourceAndName(source, name string) (*models.Chat, error) {
+	var chat models.Chat
+	query := `SELECT id, telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, monitored_entity_id, classification_rule, confidence_threshold_override, sensitivity_profile, inaccessible, chat_type, observe_until FROM chats WHERE source = $1 AND name = $2`
+	err := r.db.Get(&chat, query, source, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &chat, nil
 }
 
 func (r *chatRepository) GetChatByID(id int64) (*models.Chat, error) {
 	var chat models.Chat
-	query := `SELECT id, telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, chat_type FROM chats WHERE id = $1`
+	query := `SELECT id, telegram_id, vk_peer_id, source, name, is_group, monitoring_active, last_collected_message_id, monitored_entity_id, classification_rule, confidence_threshold_override, sensitivity_profile, inaccessible, chat_type, observe_until FROM chats WHERE id = $1`
 	err := r.db.Get(&chat, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -85,6 +108,38 @@ func (r *chatRepository) UpdateMonitoringStatus(chatID int64, active bool) error
 	return err
 }
 
+// UpdateChatRules sets the per-chat classification override rule and optional
+// confidence threshold override.
+// SetChatInaccessible flags a chat whose peer can no longer be read at the
+// source (e.g. a deactivated VK user); the processor skips such chats.
+func (r *chatRepository) SetChatInaccessible(chatID int64, inaccessible bool) error {
+	query := `UPDATE chats SET inaccessible = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, inaccessible, chatID)
+	return err
+}
+
+// UpdateChatProfile assigns a named sensitivity profile to a chat; an empty
+// profile clears the assignment.
+func (r *chatRepository) UpdateChatProfile(chatID int64, profile string) error {
+	query := `UPDATE chats SET sensitivity_profile = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, profile, chatID)
+	return err
+}
+
+func (r *chatRepository) UpdateChatRules(chatID int64, rule string, thresholdOverride *float64) error {
+	query := `UPDATE chats SET classification_rule = $1, confidence_threshold_override = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, rule, thresholdOverride, chatID)
+	return err
+}
+
+// ConfirmChatMonitoring ends a chat's observe-only warm-up early, so alerts
+// start immediately.
+func (r *chatRepository) ConfirmChatMonitoring(chatID int64) error {
+	query := `UPDATE chats SET observe_until = NULL WHERE id = $1`
+	_, err := r.db.Exec(query, chatID)
+	return err
+}
+
 func (r *chatRepository) GetAllChats() ([]*models.Chat, error) {
 	var chats []*models.Chat
 	query := `
@@ -97,13 +152,19 @@ func (r *chatRepository) GetAllChats() ([]*models.Chat, error) {
 			c.is_group,
 			c.monitoring_active,
 			c.last_collected_message_id,
+			c.monitored_entity_id,
+			c.classification_rule,
+			c.confidence_threshold_override,
+			c.sensitivity_profile,
+			c.inaccessible,
 			COALESCE(COUNT(m.id), 0) as message_count,
 			NULL::integer as member_count,
 			MAX(m.timestamp) as last_message_date,
-			c.chat_type
+			c.chat_type,
+			c.observe_until
 		FROM chats c
 		LEFT JOIN messages m ON c.id = m.chat_id
-		GROUP BY c.id, c.telegram_id, c.vk_peer_id, c.source, c.name, c.is_group, c.monitoring_active, c.last_collected_message_id, c.chat_type
+		GROUP BY c.id, c.telegram_id, c.vk_peer_id, c.source, c.name, c.is_group, c.monitoring_active, c.last_collected_message_id, c.monitored_entity_id, c.classification_rule, c.confidence_threshold_override, c.sensitivity_profile, c.inaccessible, c.chat_type, c.observe_until
 		ORDER BY c.id
 	`
 	err := r.db.Select(&chats, query)
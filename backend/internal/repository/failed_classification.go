@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type FailedClassificationRepository interface {
+	RecordFailure(fc *models.FailedClassification) error
+	GetPendingFailures(chatID *int64, since *time.Time) ([]*models.FailedClassification, error)
+	MarkReprocessed(id int64) error
+}
+
+type failedClassificationRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+func NewFailedClassificationRepository(db *sqlx.DB, logger *zap.Logger) FailedClassificationRepository {
+	return &failedClassificationRepository{db: db, logger: logger}
+}
+
+func (r *failedClassificationRepository) RecordFailure(fc *models.FailedClassification) error {
+	query := `INSERT INTO failed_classifications (message_id, chat_id, classifier, error)
+	          VALUES ($1, $2, $3, $4) RETURNING id, failed_at`
+	return r.db.QueryRowx(query, fc.MessageID, fc.ChatID, fc.Classifier, fc.Error).StructScan(fc)
+}
+
+// GetPendingFailures returns not-yet-reprocessed entries, oldest first, so
+// replays happen in collection order. Both filters are optional.
+func (r *failedClassificationRepository) GetPendingFailures(chatID *int64, since *time.Time) ([]*models.FailedClassification, error) {
+	query := `SELECT id, message_id, chat_id, classifier, error, failed_at, reprocessed
+	          FROM failed_classifications WHERE reprocessed = FALSE`
+
+	var args []interface{}
+	if chatID != nil {
+		args = append(args, *chatID)
+		query += fmt.Sprintf(" AND chat_id = $%d", len(args))
+	}
+	if since != nil {
+		args = append(args, *since)
+		query += fmt.Sprintf(" AND failed_at >= $%d", len(args))
+	}
+	query += " ORDER BY failed_at ASC"
+
+	var failures []*models.FailedClassification
+	if err := r.db.Select(&failures, query, args...); err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+func (r *failedClassificationRepository) MarkReprocessed(id int64) error {
+	query := `UPDATE failed_classifications SET reprocessed = TRUE WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
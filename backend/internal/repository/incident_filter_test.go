@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+)
+
+func TestIncidentFilterConditionsEmptyFilter(t *testing.T) {
+	conditions, args := incidentFilterConditions(models.IncidentFilter{}, nil)
+	if len(conditions) != 0 || len(args) != 0 {
+		t.Errorf("empty filter produced conditions %v args %v", conditions, args)
+	}
+}
+
+func TestIncidentFilterConditionsNumberPlaceholdersAfterExistingArgs(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	filter := models.IncidentFilter{
+		Status:        "new",
+		ThreatType:    "grooming",
+		From:          &from,
+		To:            &to,
+		Tag:           "reviewed",
+		MinConfidence: 0.7,
+		ModelVersion:  "v4",
+	}
+
+	// Two leading args, as when the cursor predicate occupies $1 and $2.
+	conditions, args := incidentFilterConditions(filter, []interface{}{"cursor-ts", "cursor-id"})
+	if len(conditions) != 7 {
+		t.Fatalf("expected 7 conditions, got %d: %v", len(conditions), conditions)
+	}
+	if len(args) != 9 {
+		t.Fatalf("expected 9 args (2 existing + 7 filter), got %d", len(args))
+	}
+
+	joined := strings.Join(conditions, " AND ")
+	for _, placeholder := range []string{"$3", "$4", "$5", "$6", "$7", "$8", "$9"} {
+		if !strings.Contains(joined, placeholder) {
+			t.Errorf("expected placeholder %s to continue after existing args, conditions: %s", placeholder, joined)
+		}
+	}
+	if strings.Contains(joined, "$1") || strings.Contains(joined, "$10") {
+		t.Errorf("filter conditions reused cursor placeholders: %s", joined)
+	}
+}
@@ -2,11 +2,14 @@ package repository
 
 import (
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -14,12 +17,38 @@ type MessageRepository interface {
 	SaveMessage(msg *models.Message) error
 	GetMessageByID(id int64) (*models.Message, error)
 	SaveIncident(incident *models.Incident) error
+	MarkSenderSeen(chatID int64, senderUsername string) (bool, error)
+	GetIncidentByFingerprint(fingerprint string) (*models.Incident, error)
+	HasRecentFalsePositive(fingerprint string, since time.Time) (bool, error)
+	GetOpenSessionIncident(chatID int64, activeSince time.Time) (*models.Incident, error)
+	AddIncidentMessage(incidentID, messageID int64) error
+	GetIncidentMessages(incidentID int64) ([]*models.Message, error)
+	IncrementIncidentOccurrence(id int64) error
+	SaveClassifiedMessage(cm *models.ClassifiedMessage) error
+	GetMessageBySourceID(chatID int64, source string, sourceMessageID int64) (*models.Message, error)
+	RecordMessageEdit(edit *models.MessageEdit) error
+	MarkMessageDeleted(messageID int64) error
+	GetMessageEdits(messageID int64) ([]*models.MessageEdit, error)
 	GetAllIncidents() ([]*models.Incident, error)
 	GetIncidentByID(id int64) (*models.Incident, error)
 	UpdateIncidentStatus(id int64, status string) error
 	GetIncidentsByStatus(status string) ([]*models.Incident, error)
 	GetIncidentsByThreatType(threatType string) ([]*models.Incident, error)
-	UpdateIncidentAccessGranted(incidentID int64, granted bool, requestID *int64) error
+	GetIncidentsByDateRange(from, to time.Time) ([]*models.Incident, error)
+	GetIncidentsFiltered(filter models.IncidentFilter) ([]*models.Incident, error)
+	GetIncidentsPage(filter models.IncidentFilter, after *pagination.Cursor, limit int) ([]*models.Incident, error)
+	GetIncidentChangesSince(since time.Time) ([]*models.Incident, error)
+	AddIncidentTag(incidentID int64, tag string) error
+	RemoveIncidentTag(incidentID int64, tag string) error
+	GetTagsForIncidents(incidentIDs []int64) (map[int64][]string, error)
+	GetIncidentsByTag(tag string) ([]*models.Incident, error)
+	GetIncidentsByMinConfidence(minConfidence float64) ([]*models.Incident, error)
+	GetIncidentsByModelVersion(modelVersion string) ([]*models.Incident, error)
+	GetConfidenceDistribution() ([]*models.ConfidenceStats, error)
+	ListIncidentTags() ([]string, error)
+	UpdateIncidentAccessGranted(incidentID int64, granted bool, requestID *int64, scope string) error
+	MergeIncidents(primaryID int64, duplicateIDs []int64) error
+	GetMessageContext(chatID int64, around time.Time, radius int) ([]*models.Message, error)
 }
 
 type messageRepository struct {
@@ -32,15 +61,15 @@ func NewMessageRepository(db *sqlx.DB, logger *zap.Logger) MessageRepository {
 }
 
 func (r *messageRepository) SaveMessage(msg *models.Message) error {
-	query := `INSERT INTO messages (chat_id, telegram_message_id, vk_message_id, source, message_type, sender_username, timestamp, content_encrypted)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	query := `INSERT INTO messages (chat_id, telegram_message_id, vk_message_id, source, message_type, sender_id, sender_username, timestamp, content_encrypted, is_outgoing, forwarded, forwarded_from, reply_to_message_id, reaction_count)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`
 	return r.db.QueryRowx(query, msg.ChatID, msg.TelegramMessageID, msg.VKMessageID, msg.Source,
-		msg.MessageType, msg.SenderUsername, msg.Timestamp, msg.ContentEncrypted).StructScan(msg)
+		msg.MessageType, msg.SenderID, msg.SenderUsername, msg.Timestamp, msg.ContentEncrypted, msg.IsOutgoing, msg.Forwarded, msg.ForwardedFrom, msg.ReplyToMessageID, msg.ReactionCount).StructScan(msg)
 }
 
 func (r *messageRepository) GetMessageByID(id int64) (*models.Message, error) {
 	var msg models.Message
-	query := `SELECT id, chat_id, telegram_message_id, vk_message_id, source, message_type, sender_username, timestamp, content_encrypted FROM messages WHERE id = $1`
+	query := `SELECT id, chat_id, telegram_message_id, vk_message_id, source, message_type, sender_id, sender_username, timestamp, content_encrypted, is_outgoing, forwarded, COALESCE(forwarded_from, '') AS forwarded_from, reply_to_message_id, reaction_count, deleted, deleted_at FROM messages WHERE id = $1`
 	err := r.db.Get(&msg, query, id)
 	if err != nil {
 		return nil, err
@@ -48,9 +77,219 @@ func (r *messageRepository) GetMessageByID(id int64) (*models.Message, error) {
 	return &msg, nil
 }
 
+// GetMessageContext returns up to radius messages on each side of a point in
+// time within one chat, ordered chronologically. Used by the evidence bundle
+// export to capture the conversation surrounding a flagged message.
+func (r *messageRepository) GetMessageContext(chatID int64, around time.Time, radius int) ([]*models.Message, error) {
+	var messages []*models.Message
+	query := `SELECT * FROM (
+	            SELECT id, chat_id, telegram_message_id, vk_message_id, source, message_type, sender_id, sender_username, timestamp, content_encrypted, is_outgoing, forwarded, COALESCE(forwarded_from, '') AS forwarded_from, reply_to_message_id, reaction_count, deleted, deleted_at
+	            FROM messages WHERE chat_id = $1 AND timestamp <= $2
+	            ORDER BY timestamp DESC, id DESC LIMIT $3
+	          ) before
+	          UNION ALL
+	          SELECT * FROM (
+	            SELECT id, chat_id, telegram_message_id, vk_message_id, source, message_type, sender_id, sender_username, timestamp, content_encrypted, is_outgoing, forwarded, COALESCE(forwarded_from, '') AS forwarded_from, reply_to_message_id, reaction_count, deleted, deleted_at
+	            FROM messages WHERE chat_id = $1 AND timestamp > $2
+	            ORDER BY timestamp ASC, id ASC LIMIT $3
+	          ) after
+	          ORDER BY timestamp, id`
+	err := r.db.Select(&messages, query, chatID, around, radius)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetMessageBySourceID looks up a previously collected message by its
+// source-specific ID within a chat. Returns nil when it has not been seen.
+func (r *messageRepository) GetMessageBySourceID(chatID int64, source string, sourceMessageID int64) (*models.Message, error) {
+	var msg models.Message
+	query := `SELECT id, chat_id, telegram_message_id, vk_message_id, source, message_type, sender_id, sender_username, timestamp, content_encrypted, is_outgoing, forwarded, COALESCE(forwarded_from, '') AS forwarded_from, reply_to_message_id, reaction_count, deleted, deleted_at
+	          FROM messages
+	          WHERE chat_id = $1 AND source = $2 AND (telegram_message_id = $3 OR vk_message_id = $3)`
+	err := r.db.Get(&msg, query, chatID, source, sourceMessageID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// RecordMessageEdit stores an edit and moves the new text onto the message,
+// keeping the previous encrypted content in the edit history.
+func (r *messageRepository) RecordMessageEdit(edit *models.MessageEdit) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO message_edits (message_id, old_content_encrypted, new_content_encrypted, edited_at)
+	           VALUES ($1, $2, $3, $4) RETURNING id, recorded_at`
+	if err := tx.QueryRowx(insert, edit.MessageID, edit.OldContentEncrypted, edit.NewContentEncrypted, edit.EditedAt).StructScan(edit); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE messages SET content_encrypted = $1 WHERE id = $2`, edit.NewContentEncrypted, edit.MessageID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MarkMessageDeleted flags a message as removed at the source.
+func (r *messageRepository) MarkMessageDeleted(messageID int64) error {
+	_, err := r.db.Exec(`UPDATE messages SET deleted = TRUE, deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND NOT deleted`, messageID)
+	return err
+}
+
+// GetMessageEdits returns the edit history of a message, oldest first.
+func (r *messageRepository) GetMessageEdits(messageID int64) ([]*models.MessageEdit, error) {
+	var edits []*models.MessageEdit
+	query := `SELECT id, message_id, old_content_encrypted, new_content_encrypted, edited_at, recorded_at
+	          FROM message_edits WHERE message_id = $1 ORDER BY edited_at ASC, id ASC`
+	err := r.db.Select(&edits, query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// SaveIncident inserts an incident. (message_id, threat_type) is the
+// idempotency key: if the same verdict was already saved — e.g. a retry after
+// a transient error where the first attempt actually committed — the insert
+// is a no-op and the existing incident's identity is returned instead.
 func (r *messageRepository) SaveIncident(incident *models.Incident) error {
-	query := `INSERT INTO incidents (message_id, threat_type, model_confidence, status, summary_encrypted) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
-	return r.db.QueryRowx(query, incident.MessageID, incident.ThreatType, incident.ModelConfidence, incident.Status, incident.SummaryEncrypted).StructScan(incident)
+	query := `INSERT INTO incidents (message_id, threat_type, model_confidence, model_provider, model_version, confidence_source, status, summary_encrypted, content_fingerprint, first_contact, grooming_stage, class_scores)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	          ON CONFLICT (message_id, threat_type) DO NOTHING
+	          RETURNING id, created_at`
+	err := r.db.QueryRowx(query, incident.MessageID, incident.ThreatType, incident.ModelConfidence, incident.ModelProvider, incident.ModelVersion, incident.ConfidenceSource, incident.Status, incident.SummaryEncrypted, incident.ContentFingerprint, incident.FirstContact, incident.GroomingStage, incident.ClassScores).StructScan(incident)
+	if err == sql.ErrNoRows {
+		return r.db.QueryRowx(`SELECT id, created_at FROM incidents WHERE message_id = $1 AND threat_type = $2`,
+			incident.MessageID, incident.ThreatType).StructScan(incident)
+	}
+	return err
+}
+
+// MarkSenderSeen records a sender in the chat's seen-senders set. It returns
+// true when this is the sender's first appearance in the chat.
+func (r *messageRepository) MarkSenderSeen(chatID int64, senderUsername string) (bool, error) {
+	result, err := r.db.Exec(`INSERT INTO seen_senders (chat_id, sender_username) VALUES ($1, $2)
+		ON CONFLICT (chat_id, sender_username) DO NOTHING`, chatID, senderUsername)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return inserted == 1, nil
+}
+
+// GetIncidentByFingerprint returns the most recent incident whose content
+// fingerprint matches, or nil when none exists. Used to collapse forwarded
+// duplicates of the same content into one incident.
+func (r *messageRepository) GetIncidentByFingerprint(fingerprint string) (*models.Incident, error) {
+	var incident models.Incident
+	query := `SELECT id, message_id, threat_type, model_confidence, status, summary_encrypted, access_granted, occurrence_count, created_at
+	          FROM incidents WHERE content_fingerprint = $1 ORDER BY created_at DESC LIMIT 1`
+	err := r.db.Get(&incident, query, fingerprint)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// HasRecentFalsePositive reports whether any incident with this content
+// fingerprint was marked false_positive since the given time. Used to
+// suppress re-flagging benign content a reviewer already dismissed.
+func (r *messageRepository) HasRecentFalsePositive(fingerprint string, since time.Time) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (
+	            SELECT 1 FROM incidents
+	            WHERE content_fingerprint = $1
+	              AND status = 'false_positive'
+	              AND COALESCE(status_changed_at, created_at) >= $2)`
+	if err := r.db.Get(&exists, query, fingerprint, since); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetOpenSessionIncident finds the most recent still-open ("new") incident in
+// a chat whose latest flagged message (primary or appended) is at or after
+// activeSince, or nil when the chat has no active session. Used to fold a
+// sustained flagged conversation into one session incident.
+func (r *messageRepository) GetOpenSessionIncident(chatID int64, activeSince time.Time) (*models.Incident, error) {
+	incident := &models.Incident{}
+	query := `
+		SELECT i.id, i.message_id, i.threat_type, i.model_confidence, i.status, i.created_at
+		FROM incidents i
+		JOIN messages m ON i.message_id = m.id
+		LEFT JOIN incident_messages im ON im.incident_id = i.id
+		LEFT JOIN messages m2 ON im.message_id = m2.id
+		WHERE m.chat_id = $1 AND i.status = 'new'
+		GROUP BY i.id
+		HAVING MAX(GREATEST(m.timestamp, COALESCE(m2.timestamp, m.timestamp))) >= $2
+		ORDER BY i.created_at DESC
+		LIMIT 1
+	`
+	err := r.db.Get(incident, query, chatID, activeSince)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return incident, nil
+}
+
+// AddIncidentMessage links an additional flagged message to a session
+// incident. Duplicate links are ignored.
+func (r *messageRepository) AddIncidentMessage(incidentID, messageID int64) error {
+	query := `INSERT INTO incident_messages (incident_id, message_id) VALUES ($1, $2) ON CONFLICT (incident_id, message_id) DO NOTHING`
+	_, err := r.db.Exec(query, incidentID, messageID)
+	return err
+}
+
+// GetIncidentMessages returns every flagged message of an incident in
+// chronological order: the primary message plus any appended to the session.
+func (r *messageRepository) GetIncidentMessages(incidentID int64) ([]*models.Message, error) {
+	var messages []*models.Message
+	query := `
+		SELECT id, chat_id, telegram_message_id, vk_message_id, source, message_type, sender_id, sender_username, timestamp, content_encrypted, is_outgoing, forwarded, COALESCE(forwarded_from, '') AS forwarded_from, reply_to_message_id, reaction_count, deleted, deleted_at
+		FROM messages
+		WHERE id IN (
+			SELECT message_id FROM incidents WHERE id = $1
+			UNION
+			SELECT message_id FROM incident_messages WHERE incident_id = $1
+		)
+		ORDER BY timestamp, id
+	`
+	if err := r.db.Select(&messages, query, incidentID); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// IncrementIncidentOccurrence bumps the occurrence counter of an incident when
+// the same content shows up again via a forward.
+func (r *messageRepository) IncrementIncidentOccurrence(id int64) error {
+	query := `UPDATE incidents SET occurrence_count = occurrence_count + 1, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *messageRepository) SaveClassifiedMessage(cm *models.ClassifiedMessage) error {
+	query := `INSERT INTO classified_messages (chat_id, category_id, category_name) VALUES ($1, $2, $3) RETURNING id, classified_at`
+	return r.db.QueryRowx(query, cm.ChatID, cm.CategoryID, cm.CategoryName).StructScan(cm)
 }
 
 func (r *messageRepository) GetAllIncidents() ([]*models.Incident, error) {
@@ -61,13 +300,22 @@ func (r *messageRepository) GetAllIncidents() ([]*models.Incident, error) {
 			i.message_id,
 			i.threat_type,
 			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
 			i.status,
 			COALESCE(c.name, 'Неизвестно') AS chat_title,
 			i.created_at,
 			i.summary_encrypted,
 			i.access_granted,
 			i.current_access_request_id,
-			m.source
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.updated_at
 		FROM incidents i
 		LEFT JOIN messages m ON i.message_id = m.id
 		LEFT JOIN chats c ON m.chat_id = c.id
@@ -118,6 +366,28 @@ func (r *messageRepository) GetAllIncidents() ([]*models.Incident, error) {
 			incident.ModelConfidence = conf
 		}
 
+		if val := result["model_provider"]; val != nil {
+			if provider, ok := val.([]byte); ok {
+				incident.ModelProvider = string(provider)
+			} else if provider, ok := val.(string); ok {
+				incident.ModelProvider = provider
+			}
+		}
+		if val := result["model_version"]; val != nil {
+			if version, ok := val.([]byte); ok {
+				incident.ModelVersion = string(version)
+			} else if version, ok := val.(string); ok {
+				incident.ModelVersion = version
+			}
+		}
+		if val := result["confidence_source"]; val != nil {
+			if source, ok := val.([]byte); ok {
+				incident.ConfidenceSource = string(source)
+			} else if source, ok := val.(string); ok {
+				incident.ConfidenceSource = source
+			}
+		}
+
 		// Handle status
 		if val := result["status"]; val != nil {
 			if status, ok := val.([]byte); ok {
@@ -143,6 +413,9 @@ func (r *messageRepository) GetAllIncidents() ([]*models.Incident, error) {
 		if createdAt, ok := result["created_at"].(time.Time); ok {
 			incident.CreatedAt = createdAt
 		}
+		if updatedAt, ok := result["updated_at"].(time.Time); ok {
+			incident.UpdatedAt = updatedAt
+		}
 
 		// Handle summary_encrypted
 		if val := result["summary_encrypted"]; val != nil {
@@ -165,6 +438,35 @@ func (r *messageRepository) GetAllIncidents() ([]*models.Incident, error) {
 			incident.CurrentAccessRequestID = &requestID
 		}
 
+		// Handle access_scope
+		if accessScope, ok := result["access_scope"].(string); ok {
+			incident.AccessScope = accessScope
+		}
+
+		// Handle occurrence_count
+		if occurrences, ok := result["occurrence_count"].(int64); ok {
+			incident.OccurrenceCount = int(occurrences)
+		}
+
+		// Handle is_outgoing
+		if isOutgoing, ok := result["is_outgoing"].(bool); ok {
+			incident.IsOutgoing = isOutgoing
+		}
+
+		// Handle first_contact
+		if firstContact, ok := result["first_contact"].(bool); ok {
+			incident.FirstContact = firstContact
+		}
+
+		// Handle grooming_stage
+		if val := result["grooming_stage"]; val != nil {
+			if stage, ok := val.([]byte); ok {
+				incident.GroomingStage = string(stage)
+			} else if stage, ok := val.(string); ok {
+				incident.GroomingStage = stage
+			}
+		}
+
 		// Handle source
 		if val := result["source"]; val != nil {
 			if source, ok := val.([]byte); ok {
@@ -190,13 +492,23 @@ func (r *messageRepository) GetIncidentByID(id int64) (*models.Incident, error)
 			i.message_id,
 			i.threat_type,
 			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
 			i.status,
 			COALESCE(c.name, 'Неизвестно') as chat_title,
 			i.created_at,
 			i.summary_encrypted,
 			i.access_granted,
 			i.current_access_request_id,
-			m.source
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.class_scores,
+			i.updated_at
 		FROM incidents i
 		LEFT JOIN messages m ON i.message_id = m.id
 		LEFT JOIN chats c ON m.chat_id = c.id
@@ -214,8 +526,125 @@ func (r *messageRepository) GetIncidentByID(id int64) (*models.Incident, error)
 	return incident, nil
 }
 
+// GetIncidentsPage returns one page of incidents ordered by (created_at, id) descending.
+// When after is non-nil, only incidents strictly older than the cursor position are returned,
+// which keeps ordering stable across pages even as new rows are inserted. The
+// filter's conditions compose with the cursor predicate, so paginating a
+// filtered listing pages over the same rows the unpaged listing would return.
+func (r *messageRepository) GetIncidentsPage(filter models.IncidentFilter, after *pagination.Cursor, limit int) ([]*models.Incident, error) {
+	query := `
+		SELECT
+			i.id,
+			i.message_id,
+			i.threat_type,
+			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
+			i.status,
+			COALESCE(c.name, 'Неизвестно') AS chat_title,
+			i.created_at,
+			i.summary_encrypted,
+			i.access_granted,
+			i.current_access_request_id,
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.updated_at
+		FROM incidents i
+		LEFT JOIN messages m ON i.message_id = m.id
+		LEFT JOIN chats c ON m.chat_id = c.id
+	`
+
+	var conditions []string
+	var args []interface{}
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		conditions = append(conditions, "(i.created_at, i.id) < ($1, $2)")
+	}
+	conditions, args = incidentFilterConditions(filter, args)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(` ORDER BY i.created_at DESC, i.id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*models.Incident
+	for rows.Next() {
+		incident := &models.Incident{}
+		if err := rows.StructScan(incident); err != nil {
+			r.logger.Error("Failed to scan incident", zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+
+	return incidents, rows.Err()
+}
+
+// GetIncidentChangesSince returns incidents created or modified after the
+// timestamp in modification order, so external integrations can sync
+// incrementally instead of re-pulling everything.
+func (r *messageRepository) GetIncidentChangesSince(since time.Time) ([]*models.Incident, error) {
+	query := `
+		SELECT
+			i.id,
+			i.message_id,
+			i.threat_type,
+			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
+			i.status,
+			COALESCE(c.name, 'Неизвестно') AS chat_title,
+			i.created_at,
+			i.summary_encrypted,
+			i.access_granted,
+			i.current_access_request_id,
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.updated_at
+		FROM incidents i
+		LEFT JOIN messages m ON i.message_id = m.id
+		LEFT JOIN chats c ON m.chat_id = c.id
+		WHERE i.updated_at > $1
+		ORDER BY i.updated_at ASC, i.id ASC
+	`
+
+	rows, err := r.db.Queryx(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*models.Incident
+	for rows.Next() {
+		incident := &models.Incident{}
+		if err := rows.StructScan(incident); err != nil {
+			r.logger.Error("Failed to scan incident change", zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+
+	return incidents, rows.Err()
+}
+
 func (r *messageRepository) UpdateIncidentStatus(id int64, status string) error {
-	query := `UPDATE incidents SET status = $1 WHERE id = $2`
+	query := `UPDATE incidents SET status = $1, status_changed_at = NOW(), updated_at = NOW() WHERE id = $2`
 	_, err := r.db.Exec(query, status, id)
 	return err
 }
@@ -228,6 +657,9 @@ func (r *messageRepository) GetIncidentsByStatus(status string) ([]*models.Incid
 			i.message_id,
 			i.threat_type,
 			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
 			i.status,
 			COALESCE(c.name, 'Неизвестно') as chat_title,
 			i.created_at,
@@ -266,6 +698,28 @@ func (r *messageRepository) GetIncidentsByStatus(status string) ([]*models.Incid
 		if conf, ok := result["model_confidence"].(float64); ok {
 			incident.ModelConfidence = conf
 		}
+
+		if val := result["model_provider"]; val != nil {
+			if provider, ok := val.([]byte); ok {
+				incident.ModelProvider = string(provider)
+			} else if provider, ok := val.(string); ok {
+				incident.ModelProvider = provider
+			}
+		}
+		if val := result["model_version"]; val != nil {
+			if version, ok := val.([]byte); ok {
+				incident.ModelVersion = string(version)
+			} else if version, ok := val.(string); ok {
+				incident.ModelVersion = version
+			}
+		}
+		if val := result["confidence_source"]; val != nil {
+			if source, ok := val.([]byte); ok {
+				incident.ConfidenceSource = string(source)
+			} else if source, ok := val.(string); ok {
+				incident.ConfidenceSource = source
+			}
+		}
 		if statusVal, ok := result["status"].([]byte); ok {
 			incident.Status = string(statusVal)
 		}
@@ -277,6 +731,9 @@ func (r *messageRepository) GetIncidentsByStatus(status string) ([]*models.Incid
 		if createdAt, ok := result["created_at"].(time.Time); ok {
 			incident.CreatedAt = createdAt
 		}
+		if updatedAt, ok := result["updated_at"].(time.Time); ok {
+			incident.UpdatedAt = updatedAt
+		}
 		if summary, ok := result["summary_encrypted"].([]byte); ok {
 			incident.SummaryEncrypted = string(summary)
 		}
@@ -295,6 +752,9 @@ func (r *messageRepository) GetIncidentsByThreatType(threatType string) ([]*mode
 			i.message_id,
 			i.threat_type,
 			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
 			i.status,
 			COALESCE(c.name, 'Неизвестно') as chat_title,
 			i.created_at,
@@ -333,6 +793,28 @@ func (r *messageRepository) GetIncidentsByThreatType(threatType string) ([]*mode
 		if conf, ok := result["model_confidence"].(float64); ok {
 			incident.ModelConfidence = conf
 		}
+
+		if val := result["model_provider"]; val != nil {
+			if provider, ok := val.([]byte); ok {
+				incident.ModelProvider = string(provider)
+			} else if provider, ok := val.(string); ok {
+				incident.ModelProvider = provider
+			}
+		}
+		if val := result["model_version"]; val != nil {
+			if version, ok := val.([]byte); ok {
+				incident.ModelVersion = string(version)
+			} else if version, ok := val.(string); ok {
+				incident.ModelVersion = version
+			}
+		}
+		if val := result["confidence_source"]; val != nil {
+			if source, ok := val.([]byte); ok {
+				incident.ConfidenceSource = string(source)
+			} else if source, ok := val.(string); ok {
+				incident.ConfidenceSource = source
+			}
+		}
 		if status, ok := result["status"].([]byte); ok {
 			incident.Status = string(status)
 		}
@@ -344,6 +826,9 @@ func (r *messageRepository) GetIncidentsByThreatType(threatType string) ([]*mode
 		if createdAt, ok := result["created_at"].(time.Time); ok {
 			incident.CreatedAt = createdAt
 		}
+		if updatedAt, ok := result["updated_at"].(time.Time); ok {
+			incident.UpdatedAt = updatedAt
+		}
 		if summary, ok := result["summary_encrypted"].([]byte); ok {
 			incident.SummaryEncrypted = string(summary)
 		}
@@ -354,14 +839,406 @@ func (r *messageRepository) GetIncidentsByThreatType(threatType string) ([]*mode
 	return incidents, rows.Err()
 }
 
-func (r *messageRepository) UpdateIncidentAccessGranted(incidentID int64, granted bool, requestID *int64) error {
+// MergeIncidents folds duplicate incidents into a primary in one transaction:
+// occurrence counts are summed into the primary, tags are moved over, and the
+// duplicates are archived with status "merged" so they drop out of triage.
+func (r *messageRepository) MergeIncidents(primaryID int64, duplicateIDs []int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM incidents WHERE id = $1)`, primaryID); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("primary incident %d not found", primaryID)
+	}
+
+	result, err := tx.Exec(`UPDATE incidents SET status = 'merged', updated_at = NOW() WHERE id = ANY($1) AND status <> 'merged'`, pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if archived != int64(len(duplicateIDs)) {
+		return fmt.Errorf("expected to archive %d duplicates, archived %d (missing or already merged)", len(duplicateIDs), archived)
+	}
+
+	_, err = tx.Exec(`UPDATE incidents SET occurrence_count = occurrence_count +
+		(SELECT COALESCE(SUM(occurrence_count), 0) FROM incidents WHERE id = ANY($2)),
+		updated_at = NOW()
+		WHERE id = $1`, primaryID, pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO incident_tags (incident_id, tag)
+		SELECT $1, tag FROM incident_tags WHERE incident_id = ANY($2)
+		ON CONFLICT (incident_id, tag) DO NOTHING`, primaryID, pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM incident_tags WHERE incident_id = ANY($1)`, pq.Array(duplicateIDs)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddIncidentTag attaches a free-form triage tag to an incident. Adding the
+// same tag twice is a no-op.
+func (r *messageRepository) AddIncidentTag(incidentID int64, tag string) error {
+	query := `INSERT INTO incident_tags (incident_id, tag) VALUES ($1, $2) ON CONFLICT (incident_id, tag) DO NOTHING`
+	_, err := r.db.Exec(query, incidentID, tag)
+	return err
+}
+
+// RemoveIncidentTag detaches a tag from an incident.
+func (r *messageRepository) RemoveIncidentTag(incidentID int64, tag string) error {
+	result, err := r.db.Exec(`DELETE FROM incident_tags WHERE incident_id = $1 AND tag = $2`, incidentID, tag)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("tag %q not found on incident %d", tag, incidentID)
+	}
+	return nil
+}
+
+// GetTagsForIncidents returns the tags for a set of incidents in a single query.
+func (r *messageRepository) GetTagsForIncidents(incidentIDs []int64) (map[int64][]string, error) {
+	tags := make(map[int64][]string)
+	if len(incidentIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := r.db.Queryx(`SELECT incident_id, tag FROM incident_tags WHERE incident_id = ANY($1) ORDER BY tag`, pq.Array(incidentIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var incidentID int64
+		var tag string
+		if err := rows.Scan(&incidentID, &tag); err != nil {
+			return nil, err
+		}
+		tags[incidentID] = append(tags[incidentID], tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetIncidentsByTag returns incidents carrying the given tag, newest first.
+func (r *messageRepository) GetIncidentsByTag(tag string) ([]*models.Incident, error) {
+	query := `
+		SELECT
+			i.id,
+			i.message_id,
+			i.threat_type,
+			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
+			i.status,
+			COALESCE(c.name, 'Неизвестно') AS chat_title,
+			i.created_at,
+			i.summary_encrypted,
+			i.access_granted,
+			i.current_access_request_id,
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.updated_at
+		FROM incidents i
+		INNER JOIN incident_tags t ON t.incident_id = i.id AND t.tag = $1
+		LEFT JOIN messages m ON i.message_id = m.id
+		LEFT JOIN chats c ON m.chat_id = c.id
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := r.db.Queryx(query, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*models.Incident
+	for rows.Next() {
+		incident := &models.Incident{}
+		if err := rows.StructScan(incident); err != nil {
+			r.logger.Error("Failed to scan incident", zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// GetIncidentsByMinConfidence returns incidents at or above the given model
+// confidence, newest first.
+func (r *messageRepository) GetIncidentsByMinConfidence(minConfidence float64) ([]*models.Incident, error) {
+	query := `
+		SELECT
+			i.id,
+			i.message_id,
+			i.threat_type,
+			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
+			i.status,
+			COALESCE(c.name, 'Неизвестно') AS chat_title,
+			i.created_at,
+			i.summary_encrypted,
+			i.access_granted,
+			i.current_access_request_id,
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.updated_at
+		FROM incidents i
+		LEFT JOIN messages m ON i.message_id = m.id
+		LEFT JOIN chats c ON m.chat_id = c.id
+		WHERE i.model_confidence >= $1
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := r.db.Queryx(query, minConfidence)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*models.Incident
+	for rows.Next() {
+		incident := &models.Incident{}
+		if err := rows.StructScan(incident); err != nil {
+			r.logger.Error("Failed to scan incident", zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// GetIncidentsByModelVersion filters incidents by the classifier version that
+// produced them, for comparing incident volume across model upgrades.
+func (r *messageRepository) GetIncidentsByModelVersion(modelVersion string) ([]*models.Incident, error) {
+	query := `
+		SELECT
+			i.id,
+			i.message_id,
+			i.threat_type,
+			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
+			i.status,
+			COALESCE(c.name, 'Неизвестно') AS chat_title,
+			i.created_at,
+			i.summary_encrypted,
+			i.access_granted,
+			i.current_access_request_id,
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.updated_at
+		FROM incidents i
+		LEFT JOIN messages m ON i.message_id = m.id
+		LEFT JOIN chats c ON m.chat_id = c.id
+		WHERE i.model_version = $1
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := r.db.Queryx(query, modelVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*models.Incident
+	for rows.Next() {
+		incident := &models.Incident{}
+		if err := rows.StructScan(incident); err != nil {
+			r.logger.Error("Failed to scan incident", zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// incidentFilterConditions translates the set fields of an IncidentFilter
+// into WHERE conditions, appending their values to args. Placeholder numbers
+// continue from len(args), so callers can combine the result with their own
+// predicates.
+func incidentFilterConditions(filter models.IncidentFilter, args []interface{}) ([]string, []interface{}) {
+	var conditions []string
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("i.status = $%d", len(args)))
+	}
+	if filter.ThreatType != "" {
+		args = append(args, filter.ThreatType)
+		conditions = append(conditions, fmt.Sprintf("i.threat_type = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("i.created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("i.created_at < $%d", len(args)))
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM incident_tags t WHERE t.incident_id = i.id AND t.tag = $%d)", len(args)))
+	}
+	if filter.MinConfidence > 0 {
+		args = append(args, filter.MinConfidence)
+		conditions = append(conditions, fmt.Sprintf("i.model_confidence >= $%d", len(args)))
+	}
+	if filter.ModelVersion != "" {
+		args = append(args, filter.ModelVersion)
+		conditions = append(conditions, fmt.Sprintf("i.model_version = $%d", len(args)))
+	}
+	return conditions, args
+}
+
+// GetIncidentsFiltered returns incidents matching every set field of the
+// filter, newest first. The time range is half-open: created_at >= From and
+// created_at < To.
+func (r *messageRepository) GetIncidentsFiltered(filter models.IncidentFilter) ([]*models.Incident, error) {
+	query := `
+		SELECT
+			i.id,
+			i.message_id,
+			i.threat_type,
+			i.model_confidence,
+			i.model_provider,
+			i.model_version,
+			i.confidence_source,
+			i.status,
+			COALESCE(c.name, 'Неизвестно') AS chat_title,
+			i.created_at,
+			i.summary_encrypted,
+			i.access_granted,
+			i.current_access_request_id,
+			i.access_scope,
+			m.source,
+			COALESCE(m.is_outgoing, FALSE) AS is_outgoing,
+			i.occurrence_count,
+			i.first_contact,
+			i.grooming_stage,
+			i.updated_at
+		FROM incidents i
+		LEFT JOIN messages m ON i.message_id = m.id
+		LEFT JOIN chats c ON m.chat_id = c.id
+	`
+
+	conditions, args := incidentFilterConditions(filter, nil)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY i.created_at DESC"
+
+	rows, err := r.db.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*models.Incident
+	for rows.Next() {
+		incident := &models.Incident{}
+		if err := rows.StructScan(incident); err != nil {
+			r.logger.Error("Failed to scan incident", zap.Error(err))
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// GetIncidentsByDateRange returns incidents created in [from, to), newest
+// first.
+func (r *messageRepository) GetIncidentsByDateRange(from, to time.Time) ([]*models.Incident, error) {
+	return r.GetIncidentsFiltered(models.IncidentFilter{From: &from, To: &to})
+}
+
+// GetConfidenceDistribution returns per-category confidence statistics so
+// operators can pick sensible display thresholds.
+func (r *messageRepository) GetConfidenceDistribution() ([]*models.ConfidenceStats, error) {
+	query := `
+		SELECT
+			threat_type,
+			COUNT(*) AS total,
+			AVG(model_confidence) AS avg_confidence,
+			MIN(model_confidence) AS min_confidence,
+			MAX(model_confidence) AS max_confidence,
+			COUNT(*) FILTER (WHERE model_confidence >= 0.8) AS high_count,
+			COUNT(*) FILTER (WHERE model_confidence >= 0.5 AND model_confidence < 0.8) AS medium_count,
+			COUNT(*) FILTER (WHERE model_confidence < 0.5) AS low_count
+		FROM incidents
+		GROUP BY threat_type
+		ORDER BY threat_type
+	`
+
+	rows, err := r.db.Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*models.ConfidenceStats
+	for rows.Next() {
+		entry := &models.ConfidenceStats{}
+		if err := rows.StructScan(entry); err != nil {
+			r.logger.Error("Failed to scan confidence stats", zap.Error(err))
+			continue
+		}
+		stats = append(stats, entry)
+	}
+	return stats, rows.Err()
+}
+
+// ListIncidentTags returns all distinct tags currently in use.
+func (r *messageRepository) ListIncidentTags() ([]string, error) {
+	var tags []string
+	err := r.db.Select(&tags, `SELECT DISTINCT tag FROM incident_tags ORDER BY tag`)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *messageRepository) UpdateIncidentAccessGranted(incidentID int64, granted bool, requestID *int64, scope string) error {
 	query := `
 		UPDATE incidents
-		SET access_granted = $1, current_access_request_id = $2
-		WHERE id = $3
+		SET access_granted = $1, current_access_request_id = $2, access_scope = $3, updated_at = NOW()
+		WHERE id = $4
 	`
 
-	result, err := r.db.Exec(query, granted, requestID, incidentID)
+	result, err := r.db.Exec(query, granted, requestID, scope, incidentID)
 	if err != nil {
 		r.logger.Error("Failed to update incident access granted",
 			zap.Int64("incident_id", incidentID),
@@ -380,4 +1257,4 @@ func (r *messageRepository) UpdateIncidentAccessGranted(incidentID int64, grante
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -2,17 +2,27 @@ package repository
 
 import (
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 // MLDatasetRepository handles database operations for the ML dataset table.
 type MLDatasetRepository interface {
 	SaveEntry(entry *models.MLDatasetEntry) error
+	SaveEntries(entries []*models.MLDatasetEntry) error
 	GetAllEntries() ([]*models.MLDatasetEntry, error)
+	GetEntriesPage(after *pagination.Cursor, limit int) ([]*models.MLDatasetEntry, error)
 	GetEntriesByCategory(categoryID int) ([]*models.MLDatasetEntry, error)
 	GetValidatedEntries() ([]*models.MLDatasetEntry, error)
 	GetUnvalidatedEntries() ([]*models.MLDatasetEntry, error)
+	ReannotateEntry(entryID int64, categoryID int, categoryName, justification, provider, modelVersion string, annotatedAt time.Time) (bool, error)
 	ValidateEntry(entryID int64, validatedBy int64) error
+	ValidateEntries(entryIDs []int64, validatedBy int64, corrections map[int64]models.CategoryCorrection) (int, error)
 	GetDatasetStats() (map[string]interface{}, error)
 }
 
@@ -50,6 +60,57 @@ func (r *mlDatasetRepository) SaveEntry(entry *models.MLDatasetEntry) error {
 	).Scan(&entry.ID, &entry.CreatedAt)
 }
 
+// SaveEntries saves a batch of ML dataset entries with one multi-row insert.
+func (r *mlDatasetRepository) SaveEntries(entries []*models.MLDatasetEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	const columns = 10
+	placeholders := make([]string, 0, len(entries))
+	args := make([]interface{}, 0, len(entries)*columns)
+	for i, entry := range entries {
+		base := i * columns
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10))
+		args = append(args,
+			entry.MessageText,
+			entry.CategoryID,
+			entry.CategoryName,
+			entry.Justification,
+			entry.Provider,
+			entry.ModelVersion,
+			entry.AnnotatedAt,
+			entry.OriginalMessageID,
+			entry.IsValidated,
+			entry.Source,
+		)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO ml_dataset (
+			message_text, category_id, category_name, justification,
+			provider, model_version, annotated_at,
+			original_message_id, is_validated, source
+		) VALUES %s
+		RETURNING id, created_at
+	`, strings.Join(placeholders, ", "))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Rows come back in insertion order for a plain VALUES list.
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(&entries[i].ID, &entries[i].CreatedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // GetAllEntries returns all ML dataset entries.
 func (r *mlDatasetRepository) GetAllEntries() ([]*models.MLDatasetEntry, error) {
 	query := `
@@ -83,6 +144,48 @@ func (r *mlDatasetRepository) GetAllEntries() ([]*models.MLDatasetEntry, error)
 	return entries, rows.Err()
 }
 
+// GetEntriesPage returns one page of ML dataset entries ordered by (created_at, id) descending.
+// When after is non-nil, only entries strictly older than the cursor position are returned,
+// so paging stays stable while new entries are being inserted.
+func (r *mlDatasetRepository) GetEntriesPage(after *pagination.Cursor, limit int) ([]*models.MLDatasetEntry, error) {
+	query := `
+		SELECT id, message_text, category_id, category_name, justification,
+		       provider, model_version, annotated_at,
+		       original_message_id, is_validated, validated_by, validated_at,
+		       source, created_at
+		FROM ml_dataset
+	`
+	args := []interface{}{}
+	if after != nil {
+		query += ` WHERE (created_at, id) < ($1, $2)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.MLDatasetEntry
+	for rows.Next() {
+		entry := &models.MLDatasetEntry{}
+		err := rows.Scan(
+			&entry.ID, &entry.MessageText, &entry.CategoryID, &entry.CategoryName,
+			&entry.Justification, &entry.Provider, &entry.ModelVersion, &entry.AnnotatedAt,
+			&entry.OriginalMessageID, &entry.IsValidated, &entry.ValidatedBy,
+			&entry.ValidatedAt, &entry.Source, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
 // GetEntriesByCategory returns ML dataset entries filtered by category.
 func (r *mlDatasetRepository) GetEntriesByCategory(categoryID int) ([]*models.MLDatasetEntry, error) {
 	query := `
@@ -185,6 +288,27 @@ func (r *mlDatasetRepository) GetUnvalidatedEntries() ([]*models.MLDatasetEntry,
 	return entries, rows.Err()
 }
 
+// ReannotateEntry replaces an entry's machine-generated label fields with a
+// fresh annotation. The is_validated guard makes human-confirmed entries
+// immune even if one slips into a re-annotation batch; the returned bool
+// reports whether the entry was actually updated.
+func (r *mlDatasetRepository) ReannotateEntry(entryID int64, categoryID int, categoryName, justification, provider, modelVersion string, annotatedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE ml_dataset
+		SET category_id = $1, category_name = $2, justification = $3,
+		    provider = $4, model_version = $5, annotated_at = $6
+		WHERE id = $7 AND is_validated = FALSE
+	`, categoryID, categoryName, justification, provider, modelVersion, annotatedAt, entryID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
 // ValidateEntry marks an entry as validated by a user.
 func (r *mlDatasetRepository) ValidateEntry(entryID int64, validatedBy int64) error {
 	query := `
@@ -198,6 +322,46 @@ func (r *mlDatasetRepository) ValidateEntry(entryID int64, validatedBy int64) er
 	return err
 }
 
+// ValidateEntries marks a batch of entries as validated in one transaction and
+// returns how many rows were actually updated (nonexistent IDs are skipped by
+// the database). Entries with a supplied correction also get their category
+// replaced.
+func (r *mlDatasetRepository) ValidateEntries(entryIDs []int64, validatedBy int64, corrections map[int64]models.CategoryCorrection) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE ml_dataset
+		SET is_validated = TRUE,
+		    validated_by = $1,
+		    validated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($2)
+	`
+	result, err := tx.Exec(query, validatedBy, pq.Array(entryIDs))
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	for id, correction := range corrections {
+		if _, err := tx.Exec(`UPDATE ml_dataset SET category_id = $1, category_name = $2 WHERE id = $3`,
+			correction.CategoryID, correction.CategoryName, id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
 // GetDatasetStats returns statistics about the ML dataset.
 func (r *mlDatasetRepository) GetDatasetStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type MonitoredEntityRepository interface {
+	CreateEntity(entity *models.MonitoredEntity) error
+	GetEntityByID(id int64) (*models.MonitoredEntity, error)
+	GetAllEntities() ([]*models.MonitoredEntity, error)
+	GetEntityByTelegramAccountID(telegramAccountID int64) (*models.MonitoredEntity, error)
+	GetEntityByVKAccountID(vkAccountID int64) (*models.MonitoredEntity, error)
+	GetEntityForSource(source string) (*models.MonitoredEntity, error)
+	AssignChatToEntity(chatID, entityID int64) error
+	GetContacts(entityID int64, sortBy string) ([]*models.Contact, error)
+	GetGroomingTimeline(entityID int64) ([]*models.GroomingTimelineEntry, error)
+}
+
+type monitoredEntityRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+func NewMonitoredEntityRepository(db *sqlx.DB, logger *zap.Logger) MonitoredEntityRepository {
+	return &monitoredEntityRepository{db: db, logger: logger}
+}
+
+func (r *monitoredEntityRepository) CreateEntity(entity *models.MonitoredEntity) error {
+	query := `INSERT INTO monitored_entities (name, telegram_account_id, vk_account_id)
+	          VALUES ($1, $2, $3) RETURNING id, created_at`
+	return r.db.QueryRowx(query, entity.Name, entity.TelegramAccountID, entity.VKAccountID).
+		Scan(&entity.ID, &entity.CreatedAt)
+}
+
+func (r *monitoredEntityRepository) GetEntityByID(id int64) (*models.MonitoredEntity, error) {
+	var entity models.MonitoredEntity
+	query := `SELECT id, name, telegram_account_id, vk_account_id, created_at FROM monitored_entities WHERE id = $1`
+	err := r.db.Get(&entity, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *monitoredEntityRepository) GetAllEntities() ([]*models.MonitoredEntity, error) {
+	var entities []*models.MonitoredEntity
+	query := `SELECT id, name, telegram_account_id, vk_account_id, created_at FROM monitored_entities ORDER BY id`
+	if err := r.db.Select(&entities, query); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *monitoredEntityRepository) GetEntityByTelegramAccountID(telegramAccountID int64) (*models.MonitoredEntity, error) {
+	var entity models.MonitoredEntity
+	query := `SELECT id, name, telegram_account_id, vk_account_id, created_at FROM monitored_entities WHERE telegram_account_id = $1`
+	err := r.db.Get(&entity, query, telegramAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *monitoredEntityRepository) GetEntityByVKAccountID(vkAccountID int64) (*models.MonitoredEntity, error) {
+	var entity models.MonitoredEntity
+	query := `SELECT id, name, telegram_account_id, vk_account_id, created_at FROM monitored_entities WHERE vk_account_id = $1`
+	err := r.db.Get(&entity, query, vkAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// GetEntityForSource returns the entity that owns the collector account for the
+// given source ("telegram" or "vk"). The collector serves one account per
+// source, so when exactly one entity has an account there it owns every chat
+// discovered from that source. Returns nil when no entity matches or the
+// assignment would be ambiguous.
+func (r *monitoredEntityRepository) GetEntityForSource(source string) (*models.MonitoredEntity, error) {
+	column := "telegram_account_id"
+	if source == "vk" {
+		column = "vk_account_id"
+	}
+
+	var entities []*models.MonitoredEntity
+	query := `SELECT id, name, telegram_account_id, vk_account_id, created_at FROM monitored_entities WHERE ` + column + ` IS NOT NULL ORDER BY id LIMIT 2`
+	if err := r.db.Select(&entities, query); err != nil {
+		return nil, err
+	}
+
+	if len(entities) != 1 {
+		if len(entities) > 1 {
+			r.logger.Warn("Multiple monitored entities have an account for this source; skipping automatic chat assignment",
+				zap.String("source", source))
+		}
+		return nil, nil
+	}
+	return entities[0], nil
+}
+
+// GetContacts aggregates every distinct incoming sender across the child's
+// chats with message/incident counts and first/last seen timestamps. The
+// aggregation key is the stable platform sender_id when known, falling back
+// to the display name for rows collected before IDs were stored — so a
+// sender renaming themselves still counts as one contact. sortBy is "risk"
+// (incidents first) or "recency" (most recent contact first).
+func (r *monitoredEntityRepository) GetContacts(entityID int64, sortBy string) ([]*models.Contact, error) {
+	orderBy := "last_seen DESC"
+	if sortBy == "risk" {
+		orderBy = "incident_count DESC, message_count DESC"
+	}
+
+	var contacts []*models.Contact
+	query := fmt.Sprintf(`SELECT MAX(m.sender_id) AS sender_id,
+	                 (array_agg(m.sender_username ORDER BY m.timestamp DESC))[1] AS sender_username,
+	                 string_agg(DISTINCT m.source, ',') AS sources,
+	                 COUNT(DISTINCT m.chat_id) AS chat_count,
+	                 COUNT(m.id) AS message_count,
+	                 COUNT(i.id) AS incident_count,
+	                 MIN(m.timestamp) AS first_seen,
+	                 MAX(m.timestamp) AS last_seen
+	          FROM messages m
+	          JOIN chats c ON c.id = m.chat_id
+	          LEFT JOIN incidents i ON i.message_id = m.id
+	          WHERE c.monitored_entity_id = $1 AND (m.sender_id <> 0 OR m.sender_username <> '') AND NOT m.is_outgoing
+	          GROUP BY CASE WHEN m.sender_id <> 0 THEN 'id:' || m.sender_id::text ELSE 'name:' || m.sender_username END
+	          ORDER BY %s`, orderBy)
+	if err := r.db.Select(&contacts, query, entityID); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+func (r *monitoredEntityRepository) AssignChatToEntity(chatID, entityID int64) error {
+	query := `UPDATE chats SET monitored_entity_id = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, entityID, chatID)
+	return err
+}
+
+// GetGroomingTimeline returns the child's stage-tagged incidents in
+// chronological message order, so escalation through the grooming stages
+// (friendship-forming through request) is visible across a conversation.
+// Incidents without a stage tag are not part of the timeline.
+func (r *monitoredEntityRepository) GetGroomingTimeline(entityID int64) ([]*models.GroomingTimelineEntry, error) {
+	var entries []*models.GroomingTimelineEntry
+	query := `SELECT i.id AS incident_id,
+	                 i.grooming_stage,
+	                 i.threat_type,
+	                 i.model_confidence,
+	                 i.status,
+	                 m.chat_id,
+	                 COALESCE(c.name, '') AS chat_title,
+	                 m.sender_id,
+	                 m.sender_username,
+	                 m.timestamp
+	          FROM incidents i
+	          JOIN messages m ON m.id = i.message_id
+	          JOIN chats c ON c.id = m.chat_id
+	          WHERE c.monitored_entity_id = $1 AND i.grooming_stage <> ''
+	          ORDER BY m.timestamp ASC, i.id ASC`
+	if err := r.db.Select(&entries, query, entityID); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
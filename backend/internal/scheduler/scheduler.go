@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TaskFunc is a single run of a periodic background task.
+type TaskFunc func(ctx context.Context) error
+
+// Task holds a registered periodic task and its runtime state.
+type Task struct {
+	Name     string
+	Interval time.Duration
+
+	fn TaskFunc
+
+	mu        sync.Mutex
+	lastRun   time.Time
+	lastError string
+	runCount  int64
+}
+
+// BackgroundScheduler runs named periodic tasks with panic recovery and staggered start,
+// so individual features do not need to spawn their own ticker goroutines.
+type BackgroundScheduler struct {
+	mu      sync.RWMutex
+	tasks   []*Task
+	logger  *zap.Logger
+	started bool
+}
+
+// NewBackgroundScheduler creates a new scheduler.
+func NewBackgroundScheduler(logger *zap.Logger) *BackgroundScheduler {
+	return &BackgroundScheduler{logger: logger}
+}
+
+// Register adds a named periodic task. Must be called before Start.
+func (s *BackgroundScheduler) Register(name string, interval time.Duration, fn TaskFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("cannot register task %q: scheduler already started", name)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("cannot register task %q: interval must be positive", name)
+	}
+
+	s.tasks = append(s.tasks, &Task{
+		Name:     name,
+		Interval: interval,
+		fn:       fn,
+	})
+	s.logger.Info("Background task registered",
+		zap.String("task", name),
+		zap.Duration("interval", interval))
+	return nil
+}
+
+// Start launches all registered tasks. Starts are staggered so tasks with the same
+// interval do not all fire at once. Blocks until ctx is cancelled.
+func (s *BackgroundScheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.started = true
+	tasks := s.tasks
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(offset time.Duration, t *Task) {
+			defer wg.Done()
+
+			// Staggered start: spread initial runs across the first intervals.
+			select {
+			case <-time.After(offset):
+			case <-ctx.Done():
+				return
+			}
+
+			ticker := time.NewTicker(t.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.runTask(ctx, t)
+				}
+			}
+		}(time.Duration(i+1)*time.Second, task)
+	}
+
+	wg.Wait()
+	s.logger.Info("Background scheduler stopped.")
+}
+
+// runTask executes one task run, recovering from panics so a misbehaving task
+// cannot take down the scheduler.
+func (s *BackgroundScheduler) runTask(ctx context.Context, t *Task) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Background task panicked",
+				zap.String("task", t.Name),
+				zap.Any("panic", r))
+			t.mu.Lock()
+			t.lastError = fmt.Sprintf("panic: %v", r)
+			t.mu.Unlock()
+		}
+	}()
+
+	err := t.fn(ctx)
+
+	t.mu.Lock()
+	t.lastRun = time.Now()
+	t.runCount++
+	if err != nil {
+		t.lastError = err.Error()
+	} else {
+		t.lastError = ""
+	}
+	t.mu.Unlock()
+
+	if err != nil {
+		s.logger.Error("Background task failed",
+			zap.String("task", t.Name),
+			zap.Error(err))
+	}
+}
+
+// TasksInfo returns a snapshot of all registered tasks for the admin endpoint.
+func (s *BackgroundScheduler) TasksInfo() []map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info := make([]map[string]interface{}, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		t.mu.Lock()
+		entry := map[string]interface{}{
+			"name":       t.Name,
+			"interval":   t.Interval.String(),
+			"run_count":  t.runCount,
+			"last_error": t.lastError,
+		}
+		if !t.lastRun.IsZero() {
+			entry["last_run"] = t.lastRun
+		}
+		t.mu.Unlock()
+		info = append(info, entry)
+	}
+	return info
+}
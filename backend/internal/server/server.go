@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"net/http"
 
+	"backend/internal/annotation_client"
+	"backend/internal/classification_cache"
 	"backend/internal/collector_client"
 	"backend/internal/config"
 	"backend/internal/crypto"
 	"backend/internal/handler"
 	"backend/internal/middleware"
+	"backend/internal/ml_client"
+	"backend/internal/monitoring"
+	"backend/internal/readiness"
 	"backend/internal/repository"
+	"backend/internal/scheduler"
 	"backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -23,16 +29,27 @@ type Server struct {
 	cfg             *config.Config
 	logger          *zap.Logger
 	bot             handler.TelegramBot
+	chatCollector   handler.ChatCollector
 	collectorClient *collector_client.Client
 	keyManager      *crypto.KeyManager
+	scheduler       *scheduler.BackgroundScheduler
+
+	mlClient         *ml_client.Client
+	annotationClient *annotation_client.Client
+	monitoring       *monitoring.State
+	clsCache         *classification_cache.Cache
+	readiness        *readiness.Gate
 }
 
-func NewServer(db *sqlx.DB, cfg *config.Config, logger *zap.Logger, bot handler.TelegramBot, collectorClient *collector_client.Client, keyManager *crypto.KeyManager) *Server {
+func NewServer(db *sqlx.DB, cfg *config.Config, logger *zap.Logger, bot handler.TelegramBot, chatCollector handler.ChatCollector, collectorClient *collector_client.Client, keyManager *crypto.KeyManager, sched *scheduler.BackgroundScheduler, mlClient *ml_client.Client, annotationClient *annotation_client.Client, monitoringState *monitoring.State, clsCache *classification_cache.Cache, readinessGate *readiness.Gate) *Server {
 	router := gin.Default()
 
 	// Add CORS middleware
 	router.Use(middleware.CORSMiddleware())
 
+	// Return the unified error envelope for unhandled panics (no stack leak)
+	router.Use(middleware.RecoveryMiddleware(logger))
+
 	// Initialize server with DB, Config and Logger
 	s := &Server{
 		router:          router,
@@ -40,8 +57,16 @@ func NewServer(db *sqlx.DB, cfg *config.Config, logger *zap.Logger, bot handler.
 		cfg:             cfg,
 		logger:          logger,
 		bot:             bot,
+		chatCollector:   chatCollector,
 		collectorClient: collectorClient,
 		keyManager:      keyManager,
+		scheduler:       sched,
+
+		mlClient:         mlClient,
+		annotationClient: annotationClient,
+		monitoring:       monitoringState,
+		clsCache:         clsCache,
+		readiness:        readinessGate,
 	}
 
 	// Setup routes
@@ -56,20 +81,28 @@ func (s *Server) setupRoutes() {
 	messageRepo := repository.NewMessageRepository(s.db, s.logger)
 	chatRepo := repository.NewChatRepository(s.db, s.logger)
 	accessRequestRepo := repository.NewAccessRequestRepository(s.db, s.logger)
+	failedRepo := repository.NewFailedClassificationRepository(s.db, s.logger)
 
 	// Initialize services
 	authService := service.NewAuthService(authRepo, s.keyManager, s.logger)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService, s.logger)
-	incidentHandler := handler.NewIncidentHandler(messageRepo, authRepo, s.cfg, s.logger, s.keyManager)
-	chatHandler := handler.NewChatHandler(chatRepo, s.logger)
-	vkHandler := handler.NewVKHandler(s.collectorClient, chatRepo, s.logger)
+	monitoredEntityRepo := repository.NewMonitoredEntityRepository(s.db, s.logger)
+	incidentHandler := handler.NewIncidentHandler(messageRepo, chatRepo, monitoredEntityRepo, authRepo, s.annotationClient, s.cfg, s.logger, s.keyManager)
+	chatHandler := handler.NewChatHandler(chatRepo, s.chatCollector, s.cfg.Classification.SensitivityProfiles, s.logger)
+	categoryHandler := handler.NewCategoryHandler()
+	vkHandler := handler.NewVKHandler(s.collectorClient, chatRepo, monitoredEntityRepo, s.logger)
+	contactHandler := handler.NewContactHandler(monitoredEntityRepo, s.logger)
 	configHandler := handler.NewConfigHandler(s.cfg, s.collectorClient, s.logger)
-	analyticsHandler := handler.NewAnalyticsHandler(messageRepo, chatRepo, s.logger)
-	mlDatasetHandler := handler.NewMLDatasetHandler(s.db.DB, s.logger)
+	analyticsHandler := handler.NewAnalyticsHandler(messageRepo, chatRepo, s.cfg, s.clsCache, s.logger)
+	mlDatasetHandler := handler.NewMLDatasetHandler(s.db.DB, s.annotationClient, s.logger)
 	accessRequestHandler := handler.NewAccessRequestHandler(accessRequestRepo, messageRepo, authRepo, s.cfg, s.logger, s.bot)
-	settingsHandler := handler.NewSettingsHandler(s.cfg, s.logger)
+	settingsHandler := handler.NewSettingsHandler(s.cfg, s.monitoring, s.logger)
+	schedulerHandler := handler.NewSchedulerHandler(s.scheduler, s.logger)
+	replayHandler := handler.NewReplayHandler(messageRepo, authRepo, s.mlClient, s.annotationClient, s.keyManager, s.logger)
+	selfTestHandler := handler.NewSelfTestHandler(s.db, authRepo, s.mlClient, s.annotationClient, s.keyManager, s.logger)
+	reprocessHandler := handler.NewReprocessHandler(messageRepo, failedRepo, authRepo, s.mlClient, s.annotationClient, s.keyManager, s.logger)
 
 	// Ping route for health check
 	s.router.GET("/ping", func(c *gin.Context) {
@@ -78,6 +111,23 @@ func (s *Server) setupRoutes() {
 		})
 	})
 
+	// Liveness: the process is up and serving HTTP.
+	s.router.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive"})
+	})
+
+	// Readiness: all critical dependencies have been reachable at least once.
+	s.router.GET("/readyz", func(c *gin.Context) {
+		if s.readiness.Ready() {
+			c.JSON(http.StatusOK, gin.H{"status": "ready"})
+			return
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":     "not_ready",
+			"waiting_on": s.readiness.Pending(),
+		})
+	})
+
 	// Authentication routes (public)
 	authGroup := s.router.Group("/api/auth")
 	{
@@ -92,15 +142,38 @@ func (s *Server) setupRoutes() {
 		// Auth endpoints
 		authRequired.POST("/auth/logout", authHandler.Logout)
 
+		// Threat taxonomy
+		authRequired.GET("/categories", categoryHandler.GetCategories)
+
 		// Incidents endpoints
 		authRequired.GET("/events", incidentHandler.GetAllIncidents)
+		authRequired.GET("/events/changes", incidentHandler.GetIncidentChanges)
+		authRequired.GET("/events/tags", incidentHandler.ListIncidentTags)
+		authRequired.POST("/events/merge", incidentHandler.MergeIncidents)
+		authRequired.POST("/events/manual", incidentHandler.CreateManualIncident)
 		authRequired.GET("/events/:id", incidentHandler.GetIncidentByID)
+		authRequired.GET("/events/:id/evidence", incidentHandler.GetIncidentEvidence)
+		authRequired.GET("/events/:id/messages", incidentHandler.GetIncidentMessages)
+		authRequired.GET("/events/overdue", incidentHandler.GetOverdueIncidents)
 		authRequired.PUT("/events/:id/status", incidentHandler.UpdateIncidentStatus)
+		authRequired.POST("/events/:id/tags", incidentHandler.AddIncidentTag)
+		authRequired.DELETE("/events/:id/tags/:tag", incidentHandler.RemoveIncidentTag)
+
+		// Message edit history
+		authRequired.GET("/messages/:id/edits", incidentHandler.GetMessageEdits)
 
 		// Chats endpoints
 		authRequired.GET("/chats", chatHandler.GetAllChats)
 		authRequired.GET("/chats/:id", chatHandler.GetChatByID)
 		authRequired.PUT("/chats/:id/monitoring", chatHandler.UpdateMonitoringStatus)
+		authRequired.POST("/chats/:id/confirm-monitoring", chatHandler.ConfirmMonitoring)
+		authRequired.PUT("/chats/:id/rules", chatHandler.UpdateChatRules)
+		authRequired.PUT("/chats/:id/profile", chatHandler.UpdateChatProfile)
+		authRequired.POST("/chats/:id/collect", chatHandler.CollectChat)
+
+		// Children endpoints
+		authRequired.GET("/children/:id/contacts", contactHandler.GetContacts)
+		authRequired.GET("/children/:id/grooming-timeline", contactHandler.GetGroomingTimeline)
 
 		// VK endpoints
 		authRequired.GET("/vk/conversations", vkHandler.GetVKConversations)
@@ -119,9 +192,19 @@ func (s *Server) setupRoutes() {
 		// Settings endpoints
 		authRequired.GET("/settings", settingsHandler.GetSettings)
 		authRequired.POST("/settings", settingsHandler.UpdateSettings)
+		authRequired.POST("/settings/monitoring", settingsHandler.UpdateMonitoring)
 
 		// Analytics endpoints
 		authRequired.GET("/analytics/dashboard", analyticsHandler.GetDashboard)
+		authRequired.GET("/analytics/confidence", analyticsHandler.GetConfidenceDistribution)
+		authRequired.GET("/analytics/classifier-cache", analyticsHandler.GetClassifierCacheStats)
+
+		// Admin endpoints
+		authRequired.GET("/admin/scheduler", schedulerHandler.GetSchedulerStatus)
+		authRequired.POST("/admin/messages/:id/replay", replayHandler.ReplayMessage)
+		authRequired.GET("/admin/selftest", selfTestHandler.RunSelfTest)
+		authRequired.POST("/admin/reprocess-failed", reprocessHandler.ReprocessFailed)
+		authRequired.GET("/admin/reprocess-failed/status", reprocessHandler.GetReprocessStatus)
 
 		// ML Dataset endpoints (for training and validation)
 		authRequired.GET("/ml-dataset", mlDatasetHandler.GetAllEntries)
@@ -132,6 +215,9 @@ func (s *Server) setupRoutes() {
 		authRequired.GET("/ml-dataset/unvalidated", mlDatasetHandler.GetUnvalidatedEntries)
 		authRequired.GET("/ml-dataset/export", mlDatasetHandler.ExportDataset)
 		authRequired.POST("/ml-dataset/:id/validate", mlDatasetHandler.ValidateEntry)
+		authRequired.POST("/ml-dataset/validate-bulk", mlDatasetHandler.ValidateBulkEntries)
+		authRequired.POST("/ml-dataset/reannotate", mlDatasetHandler.ReannotateDataset)
+		authRequired.GET("/ml-dataset/reannotate/status", mlDatasetHandler.GetReannotationStatus)
 
 		// Access Request endpoints (for access control feature)
 		authRequired.POST("/access-requests", accessRequestHandler.CreateAccessRequest)
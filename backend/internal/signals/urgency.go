@@ -0,0 +1,82 @@
+// Package signals computes cheap lexical signals for collected messages
+// before they reach the LLM/ML classifier. The scores are heuristics, not
+// classifications: they help prioritize and tune thresholds, never replace
+// the model's verdict.
+package signals
+
+import "strings"
+
+// HighUrgency is the score at or above which a message is considered
+// high-urgency by consumers of the scorer.
+const HighUrgency = 0.66
+
+// defaultUrgencyLexicon holds the built-in urgency/pressure/secrecy cues.
+// Matching is case-insensitive substring matching, so entries should be
+// lowercase and specific enough to avoid false positives.
+var defaultUrgencyLexicon = []string{
+	// Time pressure
+	"сейчас же",
+	"срочно",
+	"немедленно",
+	"быстрее",
+	"прямо сейчас",
+	"пока не поздно",
+	"последний шанс",
+	"только сегодня",
+	"у тебя мало времени",
+	"right now",
+	"hurry",
+	"urgent",
+	"last chance",
+	// Secrecy pressure
+	"никому не говори",
+	"никому не рассказывай",
+	"никому не показывай",
+	"это наш секрет",
+	"это секрет",
+	"удали переписку",
+	"удали сообщения",
+	"don't tell anyone",
+	"keep this secret",
+	"delete this chat",
+	// Coercion
+	"иначе",
+	"а то пожалеешь",
+	"or else",
+}
+
+// UrgencyScorer scores urgency/pressure cues in message text against a
+// phrase lexicon.
+type UrgencyScorer struct {
+	phrases []string
+}
+
+// NewUrgencyScorer creates a scorer using the built-in lexicon plus any
+// configured extra phrases.
+func NewUrgencyScorer(extraPhrases []string) *UrgencyScorer {
+	phrases := make([]string, 0, len(defaultUrgencyLexicon)+len(extraPhrases))
+	phrases = append(phrases, defaultUrgencyLexicon...)
+	for _, p := range extraPhrases {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" {
+			phrases = append(phrases, p)
+		}
+	}
+	return &UrgencyScorer{phrases: phrases}
+}
+
+// Score returns an urgency score in [0, 1]: 0 when no cue matches, 1 when
+// three or more distinct cues match. Matching is case-insensitive.
+func (s *UrgencyScorer) Score(text string) float64 {
+	lowered := strings.ToLower(text)
+	hits := 0
+	for _, phrase := range s.phrases {
+		if strings.Contains(lowered, phrase) {
+			hits++
+			if hits >= 3 {
+				return 1.0
+			}
+		}
+	}
+	return float64(hits) / 3.0
+}
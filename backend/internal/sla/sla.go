@@ -0,0 +1,131 @@
+// Package sla tracks how long incidents sit unreviewed. High-severity
+// incidents in a safety product have a target review time; this package
+// evaluates open incidents against the configured per-severity windows and
+// alerts admins when a review is overdue.
+package sla
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/internal/notifier"
+	"backend/internal/repository"
+)
+
+// Checker evaluates incidents against the configured review SLAs.
+type Checker struct {
+	windows          map[string]time.Duration
+	highConfidence   float64
+	mediumConfidence float64
+}
+
+// NewChecker builds a checker from the sla config section.
+func NewChecker(cfg *config.Config) *Checker {
+	windows := make(map[string]time.Duration, len(cfg.SLA.ReviewMinutes))
+	for severity, minutes := range cfg.SLA.ReviewMinutes {
+		if minutes > 0 {
+			windows[severity] = time.Duration(minutes) * time.Minute
+		}
+	}
+	return &Checker{
+		windows:          windows,
+		highConfidence:   cfg.SLA.HighConfidence,
+		mediumConfidence: cfg.SLA.MediumConfidence,
+	}
+}
+
+// Severity buckets an incident by model confidence.
+func (c *Checker) Severity(confidence float64) string {
+	switch {
+	case confidence >= c.highConfidence:
+		return "high"
+	case confidence >= c.mediumConfidence:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// Evaluate reports whether the incident's review is overdue at the given
+// time, along with its severity and review deadline. Only incidents still in
+// status "new" can be overdue; a severity without a configured window never
+// is, and deadline is zero in that case.
+func (c *Checker) Evaluate(incident *models.Incident, now time.Time) (overdue bool, severity string, deadline time.Time) {
+	severity = c.Severity(incident.ModelConfidence)
+	window, ok := c.windows[severity]
+	if !ok {
+		return false, severity, time.Time{}
+	}
+	deadline = incident.CreatedAt.Add(window)
+	overdue = incident.Status == "new" && now.After(deadline)
+	return overdue, severity, deadline
+}
+
+// Monitor periodically re-checks open incidents and alerts the default
+// notification recipients once per incident when its review SLA is breached.
+type Monitor struct {
+	checker     *Checker
+	messageRepo repository.MessageRepository
+	alerts      *notifier.Router
+	logger      *zap.Logger
+	notified    map[int64]bool
+}
+
+// NewMonitor creates a monitor wired to the alert router.
+func NewMonitor(checker *Checker, messageRepo repository.MessageRepository, alerts *notifier.Router, logger *zap.Logger) *Monitor {
+	return &Monitor{
+		checker:     checker,
+		messageRepo: messageRepo,
+		alerts:      alerts,
+		logger:      logger,
+		notified:    make(map[int64]bool),
+	}
+}
+
+// Check is a scheduler task: it finds newly breached incidents and sends one
+// aggregate alert. Incidents already alerted stay silent until they leave
+// status "new" and breach again.
+func (m *Monitor) Check(ctx context.Context) error {
+	incidents, err := m.messageRepo.GetIncidentsByStatus("new")
+	if err != nil {
+		return fmt.Errorf("failed to load open incidents: %w", err)
+	}
+
+	now := time.Now()
+	stillBreached := make(map[int64]bool)
+	var lines []string
+	for _, incident := range incidents {
+		overdue, severity, deadline := m.checker.Evaluate(incident, now)
+		if !overdue {
+			continue
+		}
+		stillBreached[incident.ID] = true
+		if m.notified[incident.ID] {
+			continue
+		}
+		overdueBy := now.Sub(deadline).Round(time.Minute)
+		lines = append(lines, fmt.Sprintf("#%d %s (серьёзность: %s, просрочен на %s)",
+			incident.ID, incident.ThreatType, severity, overdueBy))
+		m.logger.Warn("Incident review SLA breached",
+			zap.Int64("incident_id", incident.ID),
+			zap.String("severity", severity),
+			zap.Time("deadline", deadline))
+	}
+	// Forget incidents that were reviewed or re-opened, so a later breach
+	// alerts again.
+	m.notified = stillBreached
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	text := fmt.Sprintf("⏰ Просрочен разбор инцидентов (%d):\n%s", len(lines), strings.Join(lines, "\n"))
+	m.alerts.NotifyIncident(0, text)
+	return nil
+}
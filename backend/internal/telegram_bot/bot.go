@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -16,11 +17,23 @@ import (
 
 // Bot represents the Telegram bot for access request notifications
 type Bot struct {
-	api              *tgbotapi.BotAPI
-	logger           *zap.Logger
+	api               *tgbotapi.BotAPI
+	logger            *zap.Logger
 	accessRequestRepo repository.AccessRequestRepository
-	messageRepo      repository.MessageRepository
-	cfg              *config.Config
+	messageRepo       repository.MessageRepository
+	cfg               *config.Config
+
+	// Short-lived dedup cache so a quickly repeated access request (retries,
+	// double submits) does not send the child the same notification twice.
+	notifyMu     sync.Mutex
+	lastNotified map[notificationKey]time.Time
+	dedupWindow  time.Duration
+}
+
+// notificationKey identifies one child/incident notification for dedup.
+type notificationKey struct {
+	childTelegramID int64
+	incidentID      int64
 }
 
 // NewBot creates a new Telegram bot instance
@@ -38,11 +51,13 @@ func NewBot(cfg *config.Config, accessRequestRepo repository.AccessRequestReposi
 	logger.Info("Telegram bot authorized", zap.String("username", botAPI.Self.UserName))
 
 	return &Bot{
-		api:              botAPI,
-		logger:           logger,
+		api:               botAPI,
+		logger:            logger,
 		accessRequestRepo: accessRequestRepo,
-		messageRepo:      messageRepo,
-		cfg:              cfg,
+		messageRepo:       messageRepo,
+		cfg:               cfg,
+		lastNotified:      make(map[notificationKey]time.Time),
+		dedupWindow:       time.Duration(cfg.AccessControl.NotificationDedupSeconds) * time.Second,
 	}, nil
 }
 
@@ -128,7 +143,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		newStatus = "approved"
 		responseMessage = "✅ Доступ предоставлен"
 		// Update incident access_granted
-		if err := b.messageRepo.UpdateIncidentAccessGranted(accessRequest.IncidentID, true, &requestID); err != nil {
+		if err := b.messageRepo.UpdateIncidentAccessGranted(accessRequest.IncidentID, true, &requestID, accessRequest.Scope); err != nil {
 			b.logger.Error("Failed to update incident access_granted", zap.Error(err))
 			b.sendMessage(query.From.ID, "❌ Ошибка обновления инцидента")
 			return
@@ -213,6 +228,15 @@ func (b *Bot) SendAccessRequestNotification(childTelegramID int64, requestID int
 		return fmt.Errorf("bot is disabled")
 	}
 
+	if b.recentlyNotified(childTelegramID, incidentID) {
+		b.logger.Info("Skipping duplicate access request notification",
+			zap.Int64("child_telegram_id", childTelegramID),
+			zap.Int64("incident_id", incidentID),
+			zap.Duration("dedup_window", b.dedupWindow),
+		)
+		return nil
+	}
+
 	b.logger.Info("Preparing notification",
 		zap.Int64("incident_id", incidentID),
 		zap.String("threat_type", threatType),
@@ -266,6 +290,46 @@ func (b *Bot) SendAccessRequestNotification(childTelegramID int64, requestID int
 	return nil
 }
 
+// recentlyNotified reports whether a notification for this child/incident pair
+// was sent within the dedup window, and records the current attempt if not.
+// Expired entries are pruned on the way through to keep the map small.
+func (b *Bot) recentlyNotified(childTelegramID, incidentID int64) bool {
+	if b.dedupWindow <= 0 {
+		return false
+	}
+
+	b.notifyMu.Lock()
+	defer b.notifyMu.Unlock()
+
+	now := time.Now()
+	for key, sentAt := range b.lastNotified {
+		if now.Sub(sentAt) > b.dedupWindow {
+			delete(b.lastNotified, key)
+		}
+	}
+
+	key := notificationKey{childTelegramID: childTelegramID, incidentID: incidentID}
+	if sentAt, ok := b.lastNotified[key]; ok && now.Sub(sentAt) <= b.dedupWindow {
+		return true
+	}
+
+	b.lastNotified[key] = now
+	return false
+}
+
+// SendIncidentAlert delivers one routed incident alert to a recipient chat.
+// Used by the notification router; safe to call on a disabled (nil) bot.
+func (b *Bot) SendIncidentAlert(recipientChatID int64, text string) error {
+	if b == nil {
+		return nil
+	}
+	msg := tgbotapi.NewMessage(recipientChatID, text)
+	if _, err := b.api.Send(msg); err != nil {
+		return fmt.Errorf("failed to send incident alert: %w", err)
+	}
+	return nil
+}
+
 // sendMessage is a helper to send a simple text message
 func (b *Bot) sendMessage(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
@@ -5,18 +5,27 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
 	"backend/internal/annotation_client"
+	"backend/internal/classification_cache"
 	"backend/internal/collector_client"
 	"backend/internal/config"
 	"backend/internal/crypto"
+	"backend/internal/httpx"
 	"backend/internal/message_processor"
 	"backend/internal/ml_client"
 	"backend/internal/models"
+	"backend/internal/monitoring"
+	"backend/internal/notifier"
+	"backend/internal/readiness"
 	"backend/internal/repository"
+	"backend/internal/scheduler"
 	"backend/internal/server"
+	"backend/internal/signals"
+	"backend/internal/sla"
 	"backend/internal/telegram_bot"
 )
 
@@ -36,6 +45,15 @@ func main() {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// Tune the shared HTTP transport before any service client is created.
+	if err := httpx.Configure(httpx.ClientConfig{
+		MaxIdleConnsPerHost: cfg.HTTPClient.MaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(cfg.HTTPClient.IdleConnTimeoutSeconds) * time.Second,
+		ProxyURL:            cfg.HTTPClient.ProxyURL,
+	}); err != nil {
+		logger.Fatal("Invalid HTTP client configuration", zap.Error(err))
+	}
+
 	// Database connection
 	db, err := repository.NewPostgresDB(cfg.Database.URL, logger)
 	if err != nil {
@@ -61,13 +79,26 @@ func main() {
 		systemUser = &models.User{ID: 1, DKEncrypted: ""} // Placeholder
 	}
 
+	// Verify the system user's data key actually loads: a bad master key or
+	// corrupted DK would otherwise surface only as per-incident decryption
+	// failures on the dashboard.
+	if systemUser.DKEncrypted != "" {
+		if _, err := keyManager.LoadDataKey(systemUser.ID, systemUser.DKEncrypted); err != nil {
+			logger.Error("System user's data key cannot be loaded - incident summaries will show a decryption-unavailable placeholder until this is fixed", zap.Error(err))
+		} else {
+			logger.Info("System user's data key loaded successfully")
+		}
+	}
+
 	// Initialize repositories
 	messageRepo := repository.NewMessageRepository(db, logger)
 	chatRepo := repository.NewChatRepository(db, logger)
+	monitoredEntityRepo := repository.NewMonitoredEntityRepository(db, logger)
 	mlDatasetRepo := repository.NewMLDatasetRepository(db.DB)
+	failedRepo := repository.NewFailedClassificationRepository(db, logger)
 
 	// Initialize collector client
-	collectorClient := collector_client.NewClient(cfg.Collector.URL, logger)
+	collectorClient := collector_client.NewClient(cfg.Collector.URL, cfg.Collector.MaxConcurrentRequests, logger)
 
 	// Initialize ML service client
 	mlClient := ml_client.NewClient(cfg.MLService.URL)
@@ -79,9 +110,6 @@ func main() {
 		logger.Info("Annotation Service enabled for dataset collection")
 	}
 
-	// Initialize message processor
-	processor := message_processor.NewProcessor(collectorClient, mlClient, annotationClient, messageRepo, chatRepo, mlDatasetRepo, keyManager, systemUser.ID, systemUser.DKEncrypted, logger, cfg.Collector.PollInterval, cfg.Collector.ChatProcessDelay)
-
 	// Initialize Telegram bot for access control notifications
 	accessRequestRepo := repository.NewAccessRequestRepository(db, logger)
 	bot, err := telegram_bot.NewBot(cfg, accessRequestRepo, messageRepo, logger)
@@ -90,10 +118,38 @@ func main() {
 		bot = nil
 	}
 
+	// Per-category incident alert routing, delivered through the bot
+	var alertRouter *notifier.Router
+	if cfg.Notifications.Enabled && bot != nil {
+		var quietHours *notifier.QuietHours
+		if cfg.Notifications.QuietHours.Enabled {
+			quietHours, err = notifier.NewQuietHours(cfg.Notifications.QuietHours.Default,
+				cfg.Notifications.QuietHours.Recipients, cfg.Notifications.QuietHours.CriticalCategories)
+			if err != nil {
+				logger.Fatal("Invalid quiet hours configuration", zap.Error(err))
+			}
+			logger.Info("Notification quiet hours enabled")
+		}
+		alertRouter = notifier.NewRouter(bot, cfg.Notifications.Routes, cfg.Notifications.DefaultRecipients, quietHours, logger)
+		logger.Info("Incident alert routing enabled")
+	}
+
+	// Initialize message processor
+	monitoringState := monitoring.NewState(*cfg.Monitoring.Enabled)
+	clsCache := classification_cache.New(cfg.Classification.CacheSize,
+		time.Duration(cfg.Classification.CacheTTLSeconds)*time.Second, logger)
+
+	processor := message_processor.NewProcessor(collectorClient, mlClient, annotationClient, messageRepo, chatRepo, monitoredEntityRepo, mlDatasetRepo, failedRepo, keyManager, systemUser.ID, systemUser.DKEncrypted, logger, cfg.PollIntervalDuration(), cfg.ChatProcessDelayDuration(), cfg.DrainTimeoutDuration(), *cfg.Collector.QuietIdleLogging, cfg.Classification.RecordNeutral, cfg.Classification.NeutralSecondOpinion, cfg.Classification.AnnotationDefaultConfidence, cfg.Classification.FalsePositiveSuppressSeconds, cfg.Classification.SessionGapSeconds, cfg.Classification.GenerateSummaries, cfg.Classification.MaxIncidentsPerChatPerCycle, cfg.Classification.MLBatchSize, cfg.Classification.MLBatchWindowSeconds, cfg.Classification.MLDatasetBatchSize, cfg.Classification.MLDatasetFlushSeconds, cfg.Filtering.IgnoreOutgoing, cfg.Filtering.IgnoredSenders, cfg.Monitoring.ObserveOnlyNewChatHours, signals.NewUrgencyScorer(cfg.Classification.UrgencyLexicon), clsCache, cfg.Classification.SensitivityProfiles, alertRouter, monitoringState)
+
 	// Context for graceful shutdown
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	// Deliver deferred alert digests once quiet windows end
+	if alertRouter != nil {
+		go alertRouter.Run(ctx)
+	}
+
 	// Run Telegram bot in a goroutine (if enabled)
 	if bot != nil {
 		go func() {
@@ -103,11 +159,47 @@ func main() {
 		}()
 	}
 
-	// Run message processor in a goroutine
-	go processor.Run(ctx)
+	// Wait for critical dependencies before the processor starts polling, so
+	// orchestrated startup ordering doesn't produce a burst of failed cycles.
+	readinessGate := readiness.NewGate(
+		time.Duration(cfg.Readiness.WaitTimeoutSeconds)*time.Second,
+		time.Duration(cfg.Readiness.ProbeTimeoutSeconds)*time.Second,
+		logger)
+	readinessGate.AddProbe("database", func(probeCtx context.Context) error {
+		return db.PingContext(probeCtx)
+	})
+	readinessGate.AddProbe("collector", collectorClient.Ping)
+	readinessGate.AddProbe("ml_service", func(probeCtx context.Context) error {
+		_, err := mlClient.HealthCheck(probeCtx)
+		return err
+	})
+	if annotationClient != nil {
+		readinessGate.AddProbe("annotation_service", annotationClient.Ping)
+	}
+
+	// Run message processor in a goroutine once dependencies are reachable
+	go func() {
+		if err := readinessGate.Wait(ctx); err != nil {
+			logger.Warn("Starting processor without all dependencies ready", zap.Error(err))
+		}
+		processor.Run(ctx)
+	}()
+
+	// Background scheduler for periodic maintenance tasks
+	sched := scheduler.NewBackgroundScheduler(logger)
+
+	// Periodic SLA check: alert admins about incidents whose review is overdue.
+	if cfg.SLA.Enabled && alertRouter != nil {
+		slaMonitor := sla.NewMonitor(sla.NewChecker(cfg), messageRepo, alertRouter, logger)
+		if err := sched.Register("sla_overdue_check", time.Duration(cfg.SLA.CheckIntervalSeconds)*time.Second, slaMonitor.Check); err != nil {
+			logger.Error("Failed to register SLA check task", zap.Error(err))
+		}
+	}
+
+	go sched.Start(ctx)
 
 	// Initialize and run the server
-	srv := server.NewServer(db, cfg, logger, bot, collectorClient, keyManager)
+	srv := server.NewServer(db, cfg, logger, bot, processor, collectorClient, keyManager, sched, mlClient, annotationClient, monitoringState, clsCache, readinessGate)
 	srv.Run(cfg.Server.Port)
 
 	<-ctx.Done()
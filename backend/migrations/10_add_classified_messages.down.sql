@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_classified_messages_chat_time;
+DROP TABLE IF EXISTS classified_messages;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS classified_messages (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    category_id INT NOT NULL,
+    category_name TEXT NOT NULL,
+    classified_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_classified_messages_chat_time ON classified_messages(chat_id, classified_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_message_edits_message_id;
+DROP TABLE IF EXISTS message_edits;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted;
@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_outgoing BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_chats_monitored_entity;
+ALTER TABLE chats DROP COLUMN IF EXISTS monitored_entity_id;
+DROP TABLE IF EXISTS monitored_entities;
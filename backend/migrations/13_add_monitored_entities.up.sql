@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS monitored_entities (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    telegram_account_id BIGINT UNIQUE,
+    vk_account_id BIGINT UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS monitored_entity_id BIGINT REFERENCES monitored_entities(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_chats_monitored_entity ON chats(monitored_entity_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_incidents_fingerprint;
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS occurrence_count;
+ALTER TABLE incidents DROP COLUMN IF EXISTS content_fingerprint;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS forwarded_from;
+ALTER TABLE messages DROP COLUMN IF EXISTS forwarded;
@@ -0,0 +1,7 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS forwarded BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS forwarded_from TEXT;
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS content_fingerprint TEXT;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS occurrence_count INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_fingerprint ON incidents(content_fingerprint);
@@ -0,0 +1,2 @@
+ALTER TABLE chats DROP COLUMN IF EXISTS confidence_threshold_override;
+ALTER TABLE chats DROP COLUMN IF EXISTS classification_rule;
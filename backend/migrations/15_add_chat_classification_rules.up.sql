@@ -0,0 +1,2 @@
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS classification_rule TEXT NOT NULL DEFAULT 'none';
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS confidence_threshold_override DOUBLE PRECISION;
@@ -0,0 +1 @@
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS inaccessible BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE incidents DROP COLUMN IF EXISTS first_contact;
+DROP TABLE IF EXISTS seen_senders;
@@ -0,0 +1,11 @@
+-- Seen-senders set per chat: lets the processor flag incidents whose sender
+-- has never written in that chat before (first contact).
+CREATE TABLE IF NOT EXISTS seen_senders (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    sender_username TEXT NOT NULL,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (chat_id, sender_username)
+);
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS first_contact BOOLEAN NOT NULL DEFAULT FALSE;
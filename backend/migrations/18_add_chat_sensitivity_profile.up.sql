@@ -0,0 +1 @@
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS sensitivity_profile TEXT NOT NULL DEFAULT '';
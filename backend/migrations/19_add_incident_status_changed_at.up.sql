@@ -0,0 +1 @@
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
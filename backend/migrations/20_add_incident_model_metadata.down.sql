@@ -0,0 +1,2 @@
+ALTER TABLE incidents DROP COLUMN IF EXISTS model_provider;
+ALTER TABLE incidents DROP COLUMN IF EXISTS model_version;
@@ -0,0 +1,2 @@
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS model_provider TEXT NOT NULL DEFAULT '';
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS model_version TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to_message_id BIGINT;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reaction_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS incident_messages (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (incident_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_messages_incident ON incident_messages(incident_id);
@@ -0,0 +1,4 @@
+-- Record where an incident's model_confidence came from: "model" when the
+-- classifier supplied it, "default" when a configured fallback was substituted
+-- (e.g. annotations from providers that report no confidence).
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS confidence_source TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_incidents_updated_at;
+ALTER TABLE incidents DROP COLUMN IF EXISTS updated_at;
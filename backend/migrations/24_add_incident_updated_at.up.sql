@@ -0,0 +1,6 @@
+-- Track the last write to each incident row so external integrations can pull
+-- incremental changes (GET /api/events/changes?since=...) instead of
+-- re-syncing everything on every pull.
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+UPDATE incidents SET updated_at = COALESCE(status_changed_at, created_at);
+CREATE INDEX IF NOT EXISTS idx_incidents_updated_at ON incidents (updated_at);
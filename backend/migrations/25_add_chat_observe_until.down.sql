@@ -0,0 +1 @@
+ALTER TABLE chats DROP COLUMN IF EXISTS observe_until;
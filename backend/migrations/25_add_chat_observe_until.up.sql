@@ -0,0 +1,5 @@
+-- Newly discovered chats can start in an observe-only warm-up: messages are
+-- still collected, classified and stored as incidents, but no alerts are sent
+-- until this timestamp passes or a parent confirms monitoring (which clears
+-- it). NULL means alerting is active.
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS observe_until TIMESTAMP;
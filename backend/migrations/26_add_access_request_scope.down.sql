@@ -0,0 +1,2 @@
+ALTER TABLE access_requests DROP COLUMN IF EXISTS scope;
+ALTER TABLE incidents DROP COLUMN IF EXISTS access_scope;
@@ -0,0 +1,7 @@
+-- Field-level access control: an access request can now ask for specific
+-- incident fields (text, sender, indicators) instead of everything. 'all'
+-- preserves the historical all-or-nothing grants.
+ALTER TABLE access_requests ADD COLUMN IF NOT EXISTS scope TEXT NOT NULL DEFAULT 'all';
+-- The granted scope is denormalized onto the incident so list filtering does
+-- not need a join per row.
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS access_scope TEXT NOT NULL DEFAULT 'all';
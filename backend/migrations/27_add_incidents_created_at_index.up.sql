@@ -0,0 +1,3 @@
+-- Incident listings and the new date-range filter order and filter on
+-- created_at; without an index both degrade to a full table scan.
+CREATE INDEX IF NOT EXISTS idx_incidents_created_at ON incidents (created_at);
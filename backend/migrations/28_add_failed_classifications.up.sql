@@ -0,0 +1,15 @@
+-- Dead-letter queue for messages whose classification call failed (ML or
+-- annotation service outage). Operators replay them via the admin
+-- reprocess-failed endpoint once the service is back.
+CREATE TABLE IF NOT EXISTS failed_classifications (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    chat_id BIGINT NOT NULL,
+    classifier VARCHAR(32) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reprocessed BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_failed_classifications_chat ON failed_classifications(chat_id);
+CREATE INDEX IF NOT EXISTS idx_failed_classifications_failed_at ON failed_classifications(failed_at);
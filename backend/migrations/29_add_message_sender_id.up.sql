@@ -0,0 +1,6 @@
+-- Stable platform identifier of the sender (Telegram user ID / VK from_id).
+-- Display names collide and change; sender-based aggregation keys on this
+-- instead. 0 for rows collected before the column existed.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS sender_id BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_messages_sender_id ON messages(sender_id);
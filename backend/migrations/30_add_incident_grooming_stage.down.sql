@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_incidents_grooming_stage;
+ALTER TABLE incidents DROP COLUMN IF EXISTS grooming_stage;
@@ -0,0 +1,9 @@
+-- Grooming follows recognizable stages (friendship-forming, isolation,
+-- secrecy, desensitization, request). When stage extraction is enabled in the
+-- annotation service, the detected stage is stored on the incident so the
+-- per-child timeline can show escalation progression.
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS grooming_stage VARCHAR(32) NOT NULL DEFAULT '';
+
+-- Only staged incidents appear on the timeline; the partial index keeps it
+-- cheap to find them among the (mostly unstaged) incident rows.
+CREATE INDEX IF NOT EXISTS idx_incidents_grooming_stage ON incidents (grooming_stage) WHERE grooming_stage <> '';
@@ -0,0 +1,10 @@
+-- A processor retry after a transient error where the incident actually
+-- committed used to create a duplicate row. (message_id, threat_type)
+-- uniquely identifies a classification verdict, so it serves as the
+-- idempotency key: re-attempting the insert becomes a no-op.
+
+-- Collapse any duplicates created before the constraint, keeping the oldest.
+DELETE FROM incidents a USING incidents b
+WHERE a.message_id = b.message_id AND a.threat_type = b.threat_type AND a.id > b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_message_threat ON incidents (message_id, threat_type);
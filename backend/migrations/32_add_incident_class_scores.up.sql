@@ -0,0 +1 @@
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS class_scores JSONB;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS incident_tags (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (incident_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_tags_tag ON incident_tags(tag);
@@ -8,8 +8,6 @@ import (
 
 	"go.uber.org/zap"
 
-	"time"
-
 	"collector/pkg/api"
 	"collector/pkg/collector"
 	"collector/pkg/config"
@@ -38,7 +36,7 @@ func main() {
 	defer cancel()
 
 	// Initialize Telegram client
-	tgClient, err := telegram.NewClient(&cfg.Telegram)
+	tgClient, err := telegram.NewClient(&cfg.Telegram, cfg.MaxMessageLength, *cfg.SanitizeText)
 	if err != nil {
 		logger.Fatal("Failed to create Telegram client", zap.Error(err))
 	}
@@ -46,11 +44,11 @@ func main() {
 	// Initialize VK client and collector (optional)
 	var vkCollector *collector.VKCollector
 	if cfg.VK.Enabled && cfg.VK.AccessToken != "" {
-		vkClient, err := vk.NewClient(&cfg.VK, logger)
+		vkClient, err := vk.NewClient(&cfg.VK, cfg.MaxMessageLength, *cfg.SanitizeText, logger)
 		if err != nil {
 			logger.Warn("Failed to create VK client, VK collection will be disabled", zap.Error(err))
 		} else {
-			vkCollector = collector.NewVKCollector(vkClient, logger, 5*time.Minute)
+			vkCollector = collector.NewVKCollector(vkClient, logger, cfg.Interval, cfg.VK.WallParallelism)
 			logger.Info("VK collector initialized successfully")
 		}
 	} else {
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -11,27 +12,30 @@ import (
 	"go.uber.org/zap"
 
 	"collector/pkg/telegram"
+	"collector/pkg/vk"
 )
 
 // VKCollectorInterface defines methods for VK collection
 type VKCollectorInterface interface {
 	// Public content (groups/communities)
 	CollectWallPosts(ctx context.Context, groupID string, lastPostID int64) (interface{}, error)
+	CollectGroupWallPostsBatch(ctx context.Context, requests []vk.GroupWallRequest) (interface{}, error)
 	CollectPostComments(ctx context.Context, ownerID int64, postID int64, lastCommentID int64) (interface{}, error)
 	GetGroupInfo(ctx context.Context, groupID string) (interface{}, error)
 	// Private messages (requires OAuth)
 	GetAllConversations(ctx context.Context) (interface{}, error)
 	CollectConversationMessages(ctx context.Context, peerID int64, lastMessageID int64) (interface{}, error)
+	GetAccountID(ctx context.Context) (int64, error)
 }
 
 // APIServer holds the Gin engine and references to Telegram and VK clients.
 type APIServer struct {
-	router      *gin.Engine
-	tgClient    *telegram.Client
-	vkCollector VKCollectorInterface
-	vkAppID     int
+	router        *gin.Engine
+	tgClient      *telegram.Client
+	vkCollector   VKCollectorInterface
+	vkAppID       int
 	vkRedirectURI string
-	logger      *zap.Logger
+	logger        *zap.Logger
 }
 
 // NewAPIServer creates a new API server instance.
@@ -66,6 +70,11 @@ func NewAPIServer(tgClient *telegram.Client, vkCollector VKCollectorInterface, v
 }
 
 func (s *APIServer) setupRoutes() {
+	// Ping route for health/readiness probes
+	s.router.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	})
+
 	// Telegram endpoints
 	tg := s.router.Group("/telegram")
 	{
@@ -75,6 +84,8 @@ func (s *APIServer) setupRoutes() {
 		tg.GET("/collect", s.handleCollectMessages)
 		// Endpoint to get all available chats
 		tg.GET("/chats", s.handleGetChats)
+		// Endpoint to collect comments from a channel's linked discussion group
+		tg.GET("/channel/comments", s.handleCollectChannelComments)
 	}
 
 	// VK endpoints
@@ -87,8 +98,10 @@ func (s *APIServer) setupRoutes() {
 		vk.GET("/group/info", s.handleGetVKGroupInfo)
 		vk.GET("/wall/posts", s.handleCollectVKWallPosts)
 		vk.GET("/wall/comments", s.handleCollectVKPostComments)
+		vk.POST("/wall/posts/batch", s.handleCollectVKWallPostsBatch)
 
 		// Private messages (requires OAuth token)
+		vk.GET("/account", s.handleGetVKAccount)
 		vk.GET("/conversations", s.handleGetVKConversations)
 		vk.GET("/messages/collect", s.handleCollectVKMessages)
 	}
@@ -154,6 +167,42 @@ func (s *APIServer) handleCollectMessages(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"messages": messages})
 }
 
+func (s *APIServer) handleCollectChannelComments(c *gin.Context) {
+	channelIDStr := c.Query("channel_id")
+	if channelIDStr == "" {
+		s.logger.Error("channel_id query parameter is required")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel_id query parameter is required"})
+		return
+	}
+
+	channelID, err := strconv.ParseInt(channelIDStr, 10, 64)
+	if err != nil {
+		s.logger.Error("Invalid channel_id format", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel_id format"})
+		return
+	}
+
+	lastCollectedMessageIDStr := c.Query("last_collected_message_id")
+	var lastCollectedMessageID int64 = 0 // Default to 0 if not provided
+	if lastCollectedMessageIDStr != "" {
+		lastCollectedMessageID, err = strconv.ParseInt(lastCollectedMessageIDStr, 10, 64)
+		if err != nil {
+			s.logger.Error("Invalid last_collected_message_id format", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid last_collected_message_id format"})
+			return
+		}
+	}
+
+	comments, err := s.tgClient.GetChannelComments(c.Request.Context(), channelID, lastCollectedMessageID)
+	if err != nil {
+		s.logger.Error("Failed to collect channel comments", zap.Error(err), zap.Int64("channel_id", channelID), zap.Int64("last_collected_message_id", lastCollectedMessageID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to collect channel comments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": comments})
+}
+
 func (s *APIServer) handleGetChats(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // Short timeout for chat list
 	defer cancel()
@@ -226,6 +275,10 @@ func (s *APIServer) handleCollectVKWallPosts(c *gin.Context) {
 
 	posts, err := s.vkCollector.CollectWallPosts(ctx, groupID, lastPostID)
 	if err != nil {
+		if errors.Is(err, vk.ErrPeerInaccessible) {
+			c.JSON(http.StatusGone, gin.H{"error": "VK group is deactivated or its wall is inaccessible"})
+			return
+		}
 		s.logger.Error("Failed to collect VK wall posts", zap.Error(err), zap.String("group_id", groupID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to collect VK wall posts"})
 		return
@@ -234,6 +287,31 @@ func (s *APIServer) handleCollectVKWallPosts(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"posts": posts})
 }
 
+func (s *APIServer) handleCollectVKWallPostsBatch(c *gin.Context) {
+	if s.vkCollector == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "VK collector is not enabled"})
+		return
+	}
+
+	var req struct {
+		Groups []vk.GroupWallRequest `json:"groups" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.logger.Error("Failed to bind JSON for wall posts batch", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := s.vkCollector.CollectGroupWallPostsBatch(c.Request.Context(), req.Groups)
+	if err != nil {
+		s.logger.Error("Failed to collect VK wall posts batch", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to collect wall posts batch"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
 func (s *APIServer) handleCollectVKPostComments(c *gin.Context) {
 	if s.vkCollector == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "VK collector is not enabled"})
@@ -284,6 +362,10 @@ func (s *APIServer) handleCollectVKPostComments(c *gin.Context) {
 
 	comments, err := s.vkCollector.CollectPostComments(ctx, ownerID, postID, lastCommentID)
 	if err != nil {
+		if errors.Is(err, vk.ErrPeerInaccessible) {
+			c.JSON(http.StatusGone, gin.H{"error": "VK post owner is deactivated or its comments are inaccessible"})
+			return
+		}
 		s.logger.Error("Failed to collect VK post comments", zap.Error(err),
 			zap.Int64("owner_id", ownerID),
 			zap.Int64("post_id", postID))
@@ -305,11 +387,35 @@ func (s *APIServer) handleGetVKAuthURL(c *gin.Context) {
 		s.vkAppID, s.vkRedirectURI)
 
 	c.JSON(http.StatusOK, gin.H{
-		"auth_url": authURL,
+		"auth_url":     authURL,
 		"instructions": "Open this URL in browser, authorize the app, and copy the access_token from the redirect URL",
 	})
 }
 
+// handleGetVKAccount returns the VK user ID of the monitored account.
+func (s *APIServer) handleGetVKAccount(c *gin.Context) {
+	if s.vkCollector == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "VK collector is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	selfID, err := s.vkCollector.GetAccountID(ctx)
+	if err != nil {
+		if errors.Is(err, vk.ErrInsufficientScope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "VK token lacks the required scope; re-authorize via /vk/auth/url"})
+			return
+		}
+		s.logger.Error("Failed to get VK account ID", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get VK account ID"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"self_id": selfID})
+}
+
 func (s *APIServer) handleGetVKConversations(c *gin.Context) {
 	if s.vkCollector == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "VK collector is not enabled"})
@@ -321,6 +427,10 @@ func (s *APIServer) handleGetVKConversations(c *gin.Context) {
 
 	conversations, err := s.vkCollector.GetAllConversations(ctx)
 	if err != nil {
+		if errors.Is(err, vk.ErrInsufficientScope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "VK token lacks the messages scope; re-authorize via /vk/auth/url with the messages permission"})
+			return
+		}
 		s.logger.Error("Failed to get VK conversations", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get VK conversations"})
 		return
@@ -365,6 +475,14 @@ func (s *APIServer) handleCollectVKMessages(c *gin.Context) {
 
 	messages, err := s.vkCollector.CollectConversationMessages(ctx, peerID, lastMessageID)
 	if err != nil {
+		if errors.Is(err, vk.ErrInsufficientScope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "VK token lacks the messages scope; re-authorize via /vk/auth/url with the messages permission"})
+			return
+		}
+		if errors.Is(err, vk.ErrPeerInaccessible) {
+			c.JSON(http.StatusGone, gin.H{"error": "VK peer is deactivated or its messages are inaccessible"})
+			return
+		}
 		s.logger.Error("Failed to collect VK messages", zap.Error(err), zap.Int64("peer_id", peerID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to collect VK messages"})
 		return
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.uber.org/zap"
@@ -11,20 +12,33 @@ import (
 
 // VKCollector is responsible for collecting posts and comments from VK groups.
 type VKCollector struct {
-	vkClient *vk.Client
-	logger   *zap.Logger
-	interval time.Duration
+	vkClient        *vk.Client
+	logger          *zap.Logger
+	interval        time.Duration
+	wallParallelism int
 }
 
 // NewVKCollector creates a new VKCollector instance.
-func NewVKCollector(vkClient *vk.Client, logger *zap.Logger, interval time.Duration) *VKCollector {
+func NewVKCollector(vkClient *vk.Client, logger *zap.Logger, interval time.Duration, wallParallelism int) *VKCollector {
 	return &VKCollector{
-		vkClient: vkClient,
-		logger:   logger,
-		interval: interval,
+		vkClient:        vkClient,
+		logger:          logger,
+		interval:        interval,
+		wallParallelism: wallParallelism,
 	}
 }
 
+// GetAccountID returns the VK user ID of the monitored account (the token
+// owner), so the backend can associate VK chats with the right child.
+func (c *VKCollector) GetAccountID(ctx context.Context) (int64, error) {
+	selfID, err := c.vkClient.GetSelfID(ctx)
+	if err != nil {
+		c.logger.Error("Error resolving VK account ID", zap.Error(err))
+		return 0, err
+	}
+	return selfID, nil
+}
+
 // CollectWallPosts fetches new posts from a VK group wall.
 func (c *VKCollector) CollectWallPosts(ctx context.Context, groupID string, lastPostID int64) (interface{}, error) {
 	c.logger.Info("Fetching VK wall posts...", zap.String("group_id", groupID))
@@ -37,6 +51,17 @@ func (c *VKCollector) CollectWallPosts(ctx context.Context, groupID string, last
 	return messages, nil
 }
 
+// CollectGroupWallPostsBatch fetches new posts from several group walls
+// concurrently, bounded by the configured parallelism. Per-group failures are
+// reported in the results instead of failing the whole pass.
+func (c *VKCollector) CollectGroupWallPostsBatch(ctx context.Context, requests []vk.GroupWallRequest) (interface{}, error) {
+	c.logger.Info("Fetching VK wall posts for group batch",
+		zap.Int("groups", len(requests)),
+		zap.Int("parallelism", c.wallParallelism))
+	results := c.vkClient.GetMultipleGroupsWallPosts(ctx, requests, c.wallParallelism)
+	return results, nil
+}
+
 // CollectPostComments fetches comments for a specific post.
 func (c *VKCollector) CollectPostComments(ctx context.Context, ownerID int64, postID int64, lastCommentID int64) (interface{}, error) {
 	c.logger.Info("Fetching VK post comments...",
@@ -68,7 +93,11 @@ func (c *VKCollector) GetAllConversations(ctx context.Context) (interface{}, err
 	c.logger.Info("Fetching VK conversations...")
 	conversations, err := c.vkClient.GetAllConversations(ctx)
 	if err != nil {
-		c.logger.Error("Error fetching VK conversations", zap.Error(err))
+		if errors.Is(err, vk.ErrInsufficientScope) {
+			c.logger.Warn("VK token lacks the messages scope; private-message collection disabled, public wall/comment collection still works", zap.Error(err))
+		} else {
+			c.logger.Error("Error fetching VK conversations", zap.Error(err))
+		}
 		return nil, err
 	}
 	c.logger.Info("Fetched VK conversations.", zap.Int("count", len(conversations)))
@@ -82,7 +111,11 @@ func (c *VKCollector) CollectConversationMessages(ctx context.Context, peerID in
 		zap.Int64("from_message_id", lastMessageID))
 	messages, err := c.vkClient.GetConversationMessages(ctx, peerID, lastMessageID)
 	if err != nil {
-		c.logger.Error("Error fetching VK conversation messages", zap.Error(err))
+		if errors.Is(err, vk.ErrInsufficientScope) {
+			c.logger.Warn("VK token lacks the messages scope; private-message collection disabled, public wall/comment collection still works", zap.Error(err))
+		} else {
+			c.logger.Error("Error fetching VK conversation messages", zap.Error(err))
+		}
 		return nil, err
 	}
 	c.logger.Info("Fetched VK conversation messages.", zap.Int("count", len(messages)))
@@ -1,19 +1,40 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"time"
+
+	"collector/pkg/crypto"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config структура для всей конфигурации приложения.
 type Config struct {
-	Telegram         TelegramConfig `yaml:"telegram"`
-	VK               VKConfig       `yaml:"vk"`
-	Database         DatabaseConfig `yaml:"database"`
-	API              APIConfig      `yaml:"api"`
-	CollectorInterval string         `yaml:"collector_interval"`
+	Telegram TelegramConfig `yaml:"telegram"`
+	VK       VKConfig       `yaml:"vk"`
+	Database DatabaseConfig `yaml:"database"`
+	API      APIConfig      `yaml:"api"`
+	// CollectorInterval is how often the VK collector polls, as a duration
+	// string with units ("5m", "90s"). Defaults to 5m; zero or negative
+	// values are rejected at startup.
+	CollectorInterval string `yaml:"collector_interval"`
+	// Interval is the parsed and validated CollectorInterval.
+	Interval time.Duration `yaml:"-"`
+	// MaxMessageLength is the maximum content length (in runes) for collected messages.
+	// Longer messages are truncated, keeping the beginning which usually carries the threat.
+	MaxMessageLength int `yaml:"max_message_length"`
+	// SanitizeText repairs invalid UTF-8 and strips control characters from
+	// collected text before it is returned. Defaults to true when omitted.
+	SanitizeText *bool `yaml:"sanitize_text"`
+	// MessageEncryptionKey enables at-rest encryption of Telegram message
+	// text stored by the collector: a base64-encoded 32-byte AES-256 key.
+	// Empty (the default) keeps plaintext storage for compatibility.
+	MessageEncryptionKey string `yaml:"message_encryption_key"`
+	// MessageKey is the decoded and validated MessageEncryptionKey.
+	MessageKey []byte `yaml:"-"`
 }
 
 // APIConfig contains configuration for the API server.
@@ -31,6 +52,9 @@ type TelegramConfig struct {
 	APIID   int    `yaml:"api_id"`
 	APIHash string `yaml:"api_hash"`
 	Phone   string `yaml:"phone"`
+	// MaxDialogs bounds how many dialogs chat discovery enumerates across
+	// paged MessagesGetDialogs calls (default 1000, 0 means unlimited).
+	MaxDialogs int `yaml:"max_dialogs"`
 }
 
 // VKConfig содержит конфигурацию для клиента VK API.
@@ -40,6 +64,10 @@ type VKConfig struct {
 	AppID        int    `yaml:"app_id"`        // VK Application ID
 	ClientSecret string `yaml:"client_secret"` // VK Application Secret
 	RedirectURI  string `yaml:"redirect_uri"`  // OAuth redirect URI
+	// WallParallelism bounds how many group walls are collected
+	// concurrently in a multi-group pass (default 3). The global rate
+	// limit is shared regardless.
+	WallParallelism int `yaml:"wall_parallelism"`
 }
 
 // LoadConfig читает конфигурацию из указанного пути.
@@ -81,6 +109,60 @@ func LoadConfig(path string) (*Config, error) {
 	if enabled := os.Getenv("VK_ENABLED"); enabled != "" {
 		cfg.VK.Enabled = enabled == "true" || enabled == "1"
 	}
+	if key := os.Getenv("MESSAGE_ENCRYPTION_KEY"); key != "" {
+		cfg.MessageEncryptionKey = key
+	}
+	if maxLen := os.Getenv("MAX_MESSAGE_LENGTH"); maxLen != "" {
+		if n, err := strconv.Atoi(maxLen); err == nil {
+			cfg.MaxMessageLength = n
+		}
+	}
+
+	// Default message length budget keeps encryption and classification costs bounded
+	if cfg.MaxMessageLength == 0 {
+		cfg.MaxMessageLength = 4096
+	}
+
+	// Dialog enumeration cap keeps discovery bounded on huge accounts
+	if cfg.Telegram.MaxDialogs == 0 {
+		cfg.Telegram.MaxDialogs = 1000
+	}
+
+	// Multi-group wall collection runs a few groups at once; the shared
+	// rate limit keeps the actual request rate within VK's budget
+	if cfg.VK.WallParallelism == 0 {
+		cfg.VK.WallParallelism = 3
+	}
+
+	// Sanitization is on unless explicitly disabled
+	if cfg.SanitizeText == nil {
+		enabled := true
+		cfg.SanitizeText = &enabled
+	}
+
+	// A zero or negative interval would spin-loop the collector or never
+	// poll at all; reject it at startup instead.
+	if cfg.CollectorInterval == "" {
+		cfg.CollectorInterval = "5m"
+	}
+	interval, err := time.ParseDuration(cfg.CollectorInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collector_interval %q: %w", cfg.CollectorInterval, err)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("collector_interval must be positive, got %s", interval)
+	}
+	cfg.Interval = interval
+
+	// Decode and validate the at-rest encryption key up front so a bad key
+	// fails startup instead of the first message save.
+	if cfg.MessageEncryptionKey != "" {
+		key, err := crypto.KeyFromBase64(cfg.MessageEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message_encryption_key: %w", err)
+		}
+		cfg.MessageKey = key
+	}
 
 	return &cfg, nil
-}
\ No newline at end of file
+}
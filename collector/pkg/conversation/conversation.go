@@ -0,0 +1,102 @@
+// Package conversation normalizes the source-specific chat identifiers the
+// collector juggles — Telegram chat IDs, VK peer IDs, negative owner IDs for
+// VK groups and offset local IDs for VK multi-user chats — into one typed
+// key with validated conversions, so the arithmetic lives in one place
+// instead of ad-hoc at every call site.
+package conversation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Source identifiers, matching the values stored on collected messages.
+const (
+	SourceTelegram = "telegram"
+	SourceVK       = "vk"
+)
+
+// vkChatPeerBase is VK's peer-ID offset for multi-user chats:
+// peer_id = 2000000000 + local_id.
+const vkChatPeerBase = 2000000000
+
+// Key identifies one conversation in its source's canonical form: the chat
+// ID for Telegram, the peer ID for VK (negative for group walls, offset past
+// the chat base for multi-user chats).
+type Key struct {
+	Source string `json:"source"`
+	ID     int64  `json:"id"`
+}
+
+// Telegram builds the key for a Telegram chat.
+func Telegram(chatID int64) Key {
+	return Key{Source: SourceTelegram, ID: chatID}
+}
+
+// VKPeer builds the key for a VK conversation from its peer ID.
+func VKPeer(peerID int64) Key {
+	return Key{Source: SourceVK, ID: peerID}
+}
+
+// VKGroup builds the key for a VK group wall from its group ID, accepting
+// either sign (123 and -123 name the same group).
+func VKGroup(groupID int64) (Key, error) {
+	if groupID == 0 {
+		return Key{}, fmt.Errorf("VK group ID must be non-zero")
+	}
+	if groupID > 0 {
+		groupID = -groupID
+	}
+	return Key{Source: SourceVK, ID: groupID}, nil
+}
+
+// VKChat builds the key for a VK multi-user chat from its local ID.
+func VKChat(localID int64) (Key, error) {
+	if localID <= 0 {
+		return Key{}, fmt.Errorf("VK chat local ID must be positive, got %d", localID)
+	}
+	return Key{Source: SourceVK, ID: vkChatPeerBase + localID}, nil
+}
+
+// IsVKGroup reports whether the key names a VK group wall.
+func (k Key) IsVKGroup() bool {
+	return k.Source == SourceVK && k.ID < 0
+}
+
+// VKGroupID returns the positive group ID for VK group keys.
+func (k Key) VKGroupID() (int64, bool) {
+	if !k.IsVKGroup() {
+		return 0, false
+	}
+	return -k.ID, true
+}
+
+// VKLocalID returns the local chat ID for VK multi-user chat keys.
+func (k Key) VKLocalID() (int64, bool) {
+	if k.Source != SourceVK || k.ID <= vkChatPeerBase {
+		return 0, false
+	}
+	return k.ID - vkChatPeerBase, true
+}
+
+// VKGroupOwnerID normalizes a textual VK group identifier to the negative
+// owner_id form wall.get expects. Numeric input ("123456" or "-123456")
+// converts directly; screen names ("apiclub") return ok=false so the caller
+// can resolve them via the API first. Empty input is an error rather than
+// an index panic.
+func VKGroupOwnerID(raw string) (ownerID string, ok bool, err error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", false, fmt.Errorf("empty VK group ID")
+	}
+	id, parseErr := strconv.ParseInt(trimmed, 10, 64)
+	if parseErr != nil {
+		return "", false, nil // Screen name; the caller resolves it.
+	}
+	key, err := VKGroup(id)
+	if err != nil {
+		return "", false, err
+	}
+	return strconv.FormatInt(key.ID, 10), true, nil
+}
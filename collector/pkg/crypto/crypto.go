@@ -0,0 +1,92 @@
+// Package crypto provides AES-256-GCM encryption for message text the
+// collector stores at rest. The ciphertext format (base64 of nonce +
+// ciphertext + tag) matches the backend's crypto package, so either service
+// can read the other's stored text given the key.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrInvalidKeySize    = errors.New("invalid key size: must be 32 bytes for AES-256")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext: too short")
+)
+
+// KeyFromBase64 decodes a base64-encoded AES-256 key and validates its size.
+func KeyFromBase64(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("key is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, ErrInvalidKeySize
+	}
+	return key, nil
+}
+
+// Encrypt encrypts plaintext using AES-256-GCM with the provided key.
+// Returns base64-encoded ciphertext (nonce + ciphertext + tag).
+func Encrypt(plaintext string, key []byte) (string, error) {
+	if len(key) != 32 {
+		return "", ErrInvalidKeySize
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt decrypts base64-encoded ciphertext using AES-256-GCM.
+func Decrypt(ciphertextBase64 string, key []byte) (string, error) {
+	if len(key) != 32 {
+		return "", ErrInvalidKeySize
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextBase64)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
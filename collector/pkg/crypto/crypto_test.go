@@ -0,0 +1,76 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testKey() []byte {
+	return bytes.Repeat([]byte{0x42}, 32)
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	key := testKey()
+	plaintext := "сообщение с личными данными"
+
+	ciphertext, err := Encrypt(plaintext, key)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if ciphertext == plaintext {
+		t.Fatal("ciphertext equals plaintext")
+	}
+
+	got, err := Decrypt(ciphertext, key)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if got != plaintext {
+		t.Errorf("round trip mismatch: got %q, want %q", got, plaintext)
+	}
+}
+
+func TestDecryptWithWrongKeyFails(t *testing.T) {
+	ciphertext, err := Encrypt("secret", testKey())
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	wrongKey := bytes.Repeat([]byte{0x43}, 32)
+	if _, err := Decrypt(ciphertext, wrongKey); err == nil {
+		t.Fatal("decryption with the wrong key must fail")
+	}
+}
+
+func TestEncryptRejectsBadKeySize(t *testing.T) {
+	if _, err := Encrypt("x", []byte("short")); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("expected ErrInvalidKeySize, got %v", err)
+	}
+}
+
+func TestDecryptRejectsTruncatedCiphertext(t *testing.T) {
+	short := base64.StdEncoding.EncodeToString([]byte("abc"))
+	if _, err := Decrypt(short, testKey()); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestKeyFromBase64(t *testing.T) {
+	key := testKey()
+	got, err := KeyFromBase64(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("KeyFromBase64: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Error("decoded key differs from original")
+	}
+
+	if _, err := KeyFromBase64(base64.StdEncoding.EncodeToString([]byte("short"))); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("expected ErrInvalidKeySize for a short key, got %v", err)
+	}
+	if _, err := KeyFromBase64("not base64!!"); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+}
@@ -0,0 +1,62 @@
+// Package models defines the canonical shapes for collected data shared by
+// all collection sources. The telegram and vk packages previously each defined
+// their own near-identical CollectorMessage; they now alias the types here so
+// every source maps into one schema and the backend can decode a single shape.
+package models
+
+import "time"
+
+// Message is the canonical representation of a collected item (private
+// message, wall post, or comment) regardless of source.
+type Message struct {
+	ID     int64 `json:"id"`
+	ChatID int64 `json:"chat_id"` // Conversation/peer ID; for VK posts and comments this is the owning group ID
+
+	// Deprecated: mirrors ChatID for VK group content. Kept for consumers
+	// that still read group_id; new code should use ChatID.
+	GroupID int64 `json:"group_id,omitempty"`
+
+	// SenderID is the stable platform identifier of the sender (Telegram user
+	// ID or VK from_id). SenderUsername is the display name, which can change
+	// or collide; consumers should aggregate by SenderID when present.
+	SenderID       int64     `json:"sender_id,omitempty"`
+	SenderUsername string    `json:"sender_username"`
+	Timestamp      time.Time `json:"timestamp"`
+	Text           string    `json:"text"`
+	Truncated      bool      `json:"truncated"`         // True if the content was cut to the configured limit
+	Type           string    `json:"type,omitempty"`    // "message", "post", or "comment"
+	Source         string    `json:"source,omitempty"`  // "telegram" or "vk"
+	PostID         *int64    `json:"post_id,omitempty"` // Parent post ID for comments
+
+	// IsOutgoing is true when the monitored account sent the message rather
+	// than received it; direction matters when assessing who initiated contact.
+	IsOutgoing bool `json:"is_outgoing,omitempty"`
+
+	// Forwarded marks content relayed from another chat or account;
+	// ForwardedFrom names the original author when the source exposes it.
+	Forwarded     bool   `json:"forwarded,omitempty"`
+	ForwardedFrom string `json:"forwarded_from,omitempty"`
+
+	// ReplyToMessageID links a reply to the source-side ID of the message it
+	// answers; ReactionCount totals reactions on the message. Both carry
+	// social context around a flagged message.
+	ReplyToMessageID *int64 `json:"reply_to_msg_id,omitempty"`
+	ReactionCount    int    `json:"reaction_count,omitempty"`
+
+	// EditedAt is set when the source reports the message was edited after
+	// sending; the backend uses it to record edit history.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+	// Deleted marks a previously collected message that has since been
+	// removed at the source. Only ID/ChatID are meaningful in that case.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Chat is the canonical representation of a conversation the collector can
+// read from, regardless of source.
+type Chat struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	IsGroup bool   `json:"is_group"`
+	Type    string `json:"type,omitempty"`   // "user", "chat", "channel", "group"
+	Source  string `json:"source,omitempty"` // "telegram" or "vk"
+}
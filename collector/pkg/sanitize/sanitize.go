@@ -0,0 +1,47 @@
+// Package sanitize repairs collected message text before it leaves the
+// collector. Source APIs occasionally hand back malformed UTF-8, control
+// bytes, or pathological whitespace that break JSON encoding downstream —
+// better to clean it here than to encrypt and store garbage.
+package sanitize
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text validates and repairs one collected message: invalid UTF-8 sequences
+// are replaced with U+FFFD, control characters other than newline are
+// dropped (so "\r\n" collapses to "\n"), and runs of horizontal whitespace
+// collapse to a single space. Newlines survive so message structure does.
+func Text(s string) string {
+	if s == "" {
+		return s
+	}
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "�")
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	pendingSpace := false
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteRune(r)
+			pendingSpace = false
+		case r == '\t' || unicode.IsSpace(r):
+			pendingSpace = true
+		case unicode.IsControl(r):
+			// Dropped: control characters carry no message content and
+			// confuse downstream parsers.
+		default:
+			if pendingSpace && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			pendingSpace = false
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,50 @@
+package sanitize
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTextRepairsInvalidUTF8(t *testing.T) {
+	// A truncated two-byte sequence followed by a stray continuation byte.
+	in := "при\xd0вет\x80!"
+	got := Text(in)
+	if !utf8.ValidString(got) {
+		t.Fatalf("output is not valid UTF-8: %q", got)
+	}
+}
+
+func TestTextStripsControlBytes(t *testing.T) {
+	got := Text("danger\x00ous\x07 text\x1b[31m")
+	for _, r := range got {
+		if r != '\n' && r < 0x20 {
+			t.Fatalf("control character %q survived sanitization: %q", r, got)
+		}
+	}
+	if got != "dangerous text[31m" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestTextKeepsNewlinesDropsCarriageReturns(t *testing.T) {
+	got := Text("line one\r\nline two")
+	if got != "line one\nline two" {
+		t.Errorf("expected CRLF to collapse to LF, got %q", got)
+	}
+}
+
+func TestTextCollapsesWhitespaceRuns(t *testing.T) {
+	got := Text("a  \t b  c")
+	if got != "a b c" {
+		t.Errorf("expected single spaces, got %q", got)
+	}
+}
+
+func TestTextEmptyAndCleanInputsUnchanged(t *testing.T) {
+	if got := Text(""); got != "" {
+		t.Errorf("empty input changed: %q", got)
+	}
+	if got := Text("обычное сообщение"); got != "обычное сообщение" {
+		t.Errorf("clean input changed: %q", got)
+	}
+}
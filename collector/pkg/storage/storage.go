@@ -8,9 +8,12 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq" // PostgreSQL driver
 	"github.com/gotd/td/tg"
+	_ "github.com/lib/pq" // PostgreSQL driver
 	"github.com/sirupsen/logrus"
+
+	"collector/pkg/crypto"
+	"collector/pkg/models"
 )
 
 // Message represents a Telegram message to be stored in the database.
@@ -28,13 +31,32 @@ type Message struct {
 	IsGroupMessage  bool
 }
 
+// ToCollectorMessage converts a stored row into the canonical collected-message
+// shape shared by all sources.
+func (m Message) ToCollectorMessage() models.Message {
+	return models.Message{
+		ID:             m.ID,
+		ChatID:         m.ChatID,
+		SenderID:       m.SenderID,
+		SenderUsername: m.SenderUsername.String,
+		Timestamp:      m.MessageDate,
+		Text:           m.MessageText.String,
+		Type:           "message",
+		Source:         "telegram",
+		IsOutgoing:     m.IsOutgoing,
+	}
+}
+
 // Storage manages database operations.
 type Storage struct {
-	db *sql.DB
+	db  *sql.DB
+	key []byte // At-rest encryption key for message text; nil stores plaintext
 }
 
 // NewStorage creates a new Storage instance and initializes the database.
-func NewStorage(dataSourceName string) (*Storage, error) {
+// A non-nil encryptionKey (32-byte AES-256) enables at-rest encryption of
+// stored message text; nil keeps plaintext storage for compatibility.
+func NewStorage(dataSourceName string, encryptionKey []byte) (*Storage, error) {
 	db, err := sql.Open("postgres", dataSourceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -44,7 +66,7 @@ func NewStorage(dataSourceName string) (*Storage, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return &Storage{db: db}, nil
+	return &Storage{db: db, key: encryptionKey}, nil
 }
 
 // Close closes the database connection.
@@ -129,14 +151,20 @@ func (s *Storage) SaveMessage(msg tg.MessageClass) error {
 	) ON CONFLICT (id) DO NOTHING;
 	`
 
-	_, err := s.db.Exec(query,
+	// Encrypt message text at rest when a key is configured
+	messageText, err := s.encryptAtRest(message.Message)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt message text: %w", err)
+	}
+
+	_, err = s.db.Exec(query,
 		message.ID,
 		chatID,
 		senderID,
 		senderUsername,
 		senderFirstName,
 		senderLastName,
-		message.Message,
+		messageText,
 		time.Unix(int64(message.Date), 0),
 		isOutgoing,
 		isChannelPost,
@@ -144,4 +172,59 @@ func (s *Storage) SaveMessage(msg tg.MessageClass) error {
 	)
 
 	return err
-}
\ No newline at end of file
+}
+
+// encryptAtRest encrypts message text with the configured key; with no key
+// the text passes through unchanged.
+func (s *Storage) encryptAtRest(text string) (string, error) {
+	if s.key == nil {
+		return text, nil
+	}
+	return crypto.Encrypt(text, s.key)
+}
+
+// decryptAtRest reverses encryptAtRest on a loaded row in place.
+func (s *Storage) decryptAtRest(m *Message) error {
+	if s.key == nil || !m.MessageText.Valid {
+		return nil
+	}
+	plaintext, err := crypto.Decrypt(m.MessageText.String, s.key)
+	if err != nil {
+		return err
+	}
+	m.MessageText.String = plaintext
+	return nil
+}
+
+// GetMessages returns stored messages for a chat with IDs greater than
+// sinceID, oldest first, decrypting message text when at-rest encryption is
+// enabled.
+func (s *Storage) GetMessages(chatID int64, sinceID int64) ([]Message, error) {
+	query := `
+	SELECT id, chat_id, sender_id, sender_username, sender_first_name, sender_last_name,
+		message_text, message_date, is_outgoing, is_channel_post, is_group_message
+	FROM messages
+	WHERE chat_id = $1 AND id > $2
+	ORDER BY id;
+	`
+
+	rows, err := s.db.Query(query, chatID, sinceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ID, &m.ChatID, &m.SenderID, &m.SenderUsername, &m.SenderFirstName, &m.SenderLastName,
+			&m.MessageText, &m.MessageDate, &m.IsOutgoing, &m.IsChannelPost, &m.IsGroupMessage); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if err := s.decryptAtRest(&m); err != nil {
+			return nil, fmt.Errorf("failed to decrypt message %d: %w", m.ID, err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
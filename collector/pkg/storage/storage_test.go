@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	return key
+}
+
+func TestAtRestEncryptionRoundTrip(t *testing.T) {
+	s := &Storage{key: testKey(t)}
+	const text = "это наш секрет, никому не говори"
+
+	stored, err := s.encryptAtRest(text)
+	if err != nil {
+		t.Fatalf("encryptAtRest: %v", err)
+	}
+	if stored == text {
+		t.Fatal("message text stored as plaintext despite a configured key")
+	}
+
+	m := Message{ID: 1, MessageText: sql.NullString{String: stored, Valid: true}}
+	if err := s.decryptAtRest(&m); err != nil {
+		t.Fatalf("decryptAtRest: %v", err)
+	}
+	if m.MessageText.String != text {
+		t.Fatalf("round trip changed the text: %q", m.MessageText.String)
+	}
+}
+
+func TestAtRestEncryptionDisabledByDefault(t *testing.T) {
+	s := &Storage{} // no key: plaintext storage for compatibility
+	const text = "привет, как дела"
+
+	stored, err := s.encryptAtRest(text)
+	if err != nil {
+		t.Fatalf("encryptAtRest: %v", err)
+	}
+	if stored != text {
+		t.Fatalf("plaintext mode changed the text: %q", stored)
+	}
+
+	m := Message{MessageText: sql.NullString{String: stored, Valid: true}}
+	if err := s.decryptAtRest(&m); err != nil {
+		t.Fatalf("decryptAtRest: %v", err)
+	}
+	if m.MessageText.String != text {
+		t.Fatalf("plaintext mode changed the text on read: %q", m.MessageText.String)
+	}
+}
+
+func TestAtRestDecryptWrongKeyFails(t *testing.T) {
+	writer := &Storage{key: testKey(t)}
+	stored, err := writer.encryptAtRest("текст")
+	if err != nil {
+		t.Fatalf("encryptAtRest: %v", err)
+	}
+
+	reader := &Storage{key: testKey(t)}
+	m := Message{MessageText: sql.NullString{String: stored, Valid: true}}
+	if err := reader.decryptAtRest(&m); err == nil {
+		t.Fatal("expected decryption with the wrong key to fail")
+	}
+}
@@ -15,23 +15,20 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"collector/pkg/config"
+	"collector/pkg/models"
+	"collector/pkg/sanitize"
 )
 
-// CollectorMessage represents a simplified structure of a message to be sent to the backend.
-type CollectorMessage struct {
-	ID             int64     `json:"id"`
-	ChatID         int64     `json:"chat_id"`
-	SenderUsername string    `json:"sender_username"`
-	Timestamp      time.Time `json:"timestamp"`
-	Text           string    `json:"text"`
-}
+// CollectorMessage is the canonical collected-message shape.
+//
+// Deprecated: use models.Message directly; this alias exists so existing
+// call sites keep compiling.
+type CollectorMessage = models.Message
 
-// ChatInfo represents simplified information about a Telegram chat.
-type ChatInfo struct {
-	ID      int64  `json:"id"`
-	Name    string `json:"name"`
-	IsGroup bool   `json:"is_group"`
-}
+// ChatInfo is the canonical chat shape.
+//
+// Deprecated: use models.Chat directly.
+type ChatInfo = models.Chat
 
 // Client encapsulates the Telegram client.
 type Client struct {
@@ -41,12 +38,18 @@ type Client struct {
 	AuthCompleted chan struct{} // Channel to signal authentication completion
 	logger        *zap.Logger
 
+	maxMessageLength int
+	sanitizeText     bool
+	maxDialogs       int
+
 	cachedUsers []tg.UserClass
 	cachedChats []tg.ChatClass
 }
 
 // NewClient creates and initializes a new Telegram client.
-func NewClient(cfg *config.TelegramConfig) (*Client, error) {
+// maxMessageLength bounds collected message content; longer texts are truncated.
+// sanitizeText repairs malformed UTF-8 and strips control bytes on the way in.
+func NewClient(cfg *config.TelegramConfig, maxMessageLength int, sanitizeText bool) (*Client, error) {
 	sessionFile := "session.json"
 
 	// Create a new Zap logger
@@ -61,11 +64,14 @@ func NewClient(cfg *config.TelegramConfig) (*Client, error) {
 	})
 
 	return &Client{
-		Client:        client,
-		Sender:        message.NewSender(client.API()),
-		AuthCode:      make(chan string),
-		AuthCompleted: make(chan struct{}),
-		logger:        logger,
+		Client:           client,
+		Sender:           message.NewSender(client.API()),
+		AuthCode:         make(chan string),
+		AuthCompleted:    make(chan struct{}),
+		logger:           logger,
+		maxMessageLength: maxMessageLength,
+		sanitizeText:     sanitizeText,
+		maxDialogs:       cfg.MaxDialogs,
 	}, nil
 }
 
@@ -101,38 +107,107 @@ func (c *Client) auth(ctx context.Context, phone string) error {
 	return flow.Run(ctx, c.Client.Auth())
 }
 
-// GetAllChatsInfo fetches information about all available chats.
-func (c *Client) GetAllChatsInfo(ctx context.Context) ([]ChatInfo, error) {
-	var chatsInfo []ChatInfo
+// dialogPageSize is how many dialogs one MessagesGetDialogs call requests.
+const dialogPageSize = 100
 
-	dialogs, err := c.API().MessagesGetDialogs(ctx, &tg.MessagesGetDialogsRequest{
-		ExcludePinned: false,
-		OffsetDate:    0,
-		OffsetID:      0,
-		OffsetPeer:    &tg.InputPeerEmpty{},
-		Limit:         100, // Fetch a reasonable number of dialogs
-		Hash:          0,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get dialogs: %w", err)
-	}
+// dialogFetcher issues one MessagesGetDialogs call. Abstracted so the paging
+// loop can be exercised against canned responses in tests.
+type dialogFetcher func(ctx context.Context, req *tg.MessagesGetDialogsRequest) (tg.MessagesDialogsClass, error)
 
+// collectDialogPages pages through the dialog list with the
+// OffsetDate/OffsetID/OffsetPeer cursor and returns every dialog along with
+// the user and chat entities the pages carried. maxDialogs > 0 bounds the
+// enumeration.
+func collectDialogPages(ctx context.Context, fetch dialogFetcher, maxDialogs int, logger *zap.Logger) ([]tg.DialogClass, []tg.UserClass, []tg.ChatClass, error) {
 	var dialogsList []tg.DialogClass
 	var users []tg.UserClass
 	var chats []tg.ChatClass
 
-	switch d := dialogs.(type) {
-	case *tg.MessagesDialogs:
-		dialogsList = d.Dialogs
-		users = d.Users
-		chats = d.Chats
-	case *tg.MessagesDialogsSlice:
-		dialogsList = d.Dialogs
-		users = d.Users
-		chats = d.Chats
-	default:
-		c.logger.Warn("Unknown MessagesDialogsClass type", zap.String("type", fmt.Sprintf("%T", dialogs)))
-		return nil, fmt.Errorf("unknown dialogs type: %T", dialogs)
+	offsetDate := 0
+	offsetID := 0
+	var offsetPeer tg.InputPeerClass = &tg.InputPeerEmpty{}
+
+	for {
+		limit := dialogPageSize
+		if maxDialogs > 0 && len(dialogsList)+limit > maxDialogs {
+			limit = maxDialogs - len(dialogsList)
+		}
+		if limit <= 0 {
+			logger.Warn("Dialog enumeration stopped at the configured maximum", zap.Int("max_dialogs", maxDialogs))
+			break
+		}
+
+		dialogs, err := fetch(ctx, &tg.MessagesGetDialogsRequest{
+			ExcludePinned: false,
+			OffsetDate:    offsetDate,
+			OffsetID:      offsetID,
+			OffsetPeer:    offsetPeer,
+			Limit:         limit,
+			Hash:          0,
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get dialogs: %w", err)
+		}
+
+		var page []tg.DialogClass
+		var pageMessages []tg.MessageClass
+		lastPage := false
+
+		switch d := dialogs.(type) {
+		case *tg.MessagesDialogs:
+			// The server returned the complete list; no further pages exist.
+			page = d.Dialogs
+			users = append(users, d.Users...)
+			chats = append(chats, d.Chats...)
+			lastPage = true
+		case *tg.MessagesDialogsSlice:
+			page = d.Dialogs
+			pageMessages = d.Messages
+			users = append(users, d.Users...)
+			chats = append(chats, d.Chats...)
+			lastPage = len(page) < limit
+		default:
+			logger.Warn("Unknown MessagesDialogsClass type", zap.String("type", fmt.Sprintf("%T", dialogs)))
+			return nil, nil, nil, fmt.Errorf("unknown dialogs type: %T", dialogs)
+		}
+
+		dialogsList = append(dialogsList, page...)
+		if lastPage || len(page) == 0 {
+			break
+		}
+
+		// Advance the cursor to the last dialog of this page: its peer plus
+		// the date and ID of its top message.
+		last, ok := page[len(page)-1].(*tg.Dialog)
+		if !ok {
+			break
+		}
+		offsetPeer = inputPeerForDialogPeer(last.Peer, users, chats)
+		offsetID = last.TopMessage
+		offsetDate = 0
+		for _, m := range pageMessages {
+			if msg, ok := m.(*tg.Message); ok && msg.ID == last.TopMessage {
+				offsetDate = msg.Date
+				break
+			}
+		}
+	}
+
+	return dialogsList, users, chats, nil
+}
+
+// GetAllChatsInfo fetches information about all available chats, paging
+// through the dialog list with the OffsetDate/OffsetID/OffsetPeer cursor so
+// accounts with more dialogs than one page aren't truncated. The configured
+// max_dialogs bounds the enumeration.
+func (c *Client) GetAllChatsInfo(ctx context.Context) ([]ChatInfo, error) {
+	var chatsInfo []ChatInfo
+
+	dialogsList, users, chats, err := collectDialogPages(ctx, func(ctx context.Context, req *tg.MessagesGetDialogsRequest) (tg.MessagesDialogsClass, error) {
+		return c.API().MessagesGetDialogs(ctx, req)
+	}, c.maxDialogs, c.logger)
+	if err != nil {
+		return nil, err
 	}
 
 	c.cachedUsers = users
@@ -179,6 +254,7 @@ func (c *Client) GetAllChatsInfo(ctx context.Context) ([]ChatInfo, error) {
 				ID:      peerID,
 				Name:    chatName,
 				IsGroup: isGroup,
+				Source:  "telegram",
 			})
 		}
 	}
@@ -186,6 +262,29 @@ func (c *Client) GetAllChatsInfo(ctx context.Context) ([]ChatInfo, error) {
 	return chatsInfo, nil
 }
 
+// inputPeerForDialogPeer converts a dialog's peer to the InputPeer form the
+// dialogs cursor expects, resolving access hashes from the entities returned
+// alongside the page.
+func inputPeerForDialogPeer(peer tg.PeerClass, users []tg.UserClass, chats []tg.ChatClass) tg.InputPeerClass {
+	switch p := peer.(type) {
+	case *tg.PeerUser:
+		for _, u := range users {
+			if user, ok := u.(*tg.User); ok && user.ID == p.UserID {
+				return &tg.InputPeerUser{UserID: user.ID, AccessHash: user.AccessHash}
+			}
+		}
+	case *tg.PeerChat:
+		return &tg.InputPeerChat{ChatID: p.ChatID}
+	case *tg.PeerChannel:
+		for _, ch := range chats {
+			if channel, ok := ch.(*tg.Channel); ok && channel.ID == p.ChannelID {
+				return &tg.InputPeerChannel{ChannelID: channel.ID, AccessHash: channel.AccessHash}
+			}
+		}
+	}
+	return &tg.InputPeerEmpty{}
+}
+
 // GetMessages fetches new messages from a specific chat starting from lastCollectedMessageID.
 func (c *Client) GetMessages(ctx context.Context, chatID int64, lastCollectedMessageID int64) ([]CollectorMessage, error) {
 	var newMessages []CollectorMessage
@@ -229,21 +328,79 @@ func (c *Client) GetMessages(ctx context.Context, chatID int64, lastCollectedMes
 		return nil, fmt.Errorf("unknown messages type: %T", history)
 	}
 
-	// Filter messages to ensure they are newer than lastCollectedMessageID and populate CollectorMessage
+	// Filter messages to ensure they are newer than lastCollectedMessageID and populate CollectorMessage.
+	// Already-collected messages are re-reported when they carry an edit date or
+	// show up as empty (deleted), so the backend can preserve edit history.
 	for _, m := range msgs {
-		if msg, ok := m.(*tg.Message); ok && msg.ID > int(lastCollectedMessageID) {
+		switch msg := m.(type) {
+		case *tg.Message:
+			isNew := msg.ID > int(lastCollectedMessageID)
+			isEdited := !isNew && lastCollectedMessageID > 0 && msg.EditDate > 0
+			if !isNew && !isEdited {
+				continue
+			}
+
 			senderUsername := ""
+			var senderID int64
 			if msg.FromID != nil {
 				senderUsername = resolveSenderUsername(msg.FromID, historyUsers, historyChats)
+				if p, ok := msg.FromID.(*tg.PeerUser); ok {
+					senderID = p.UserID
+				}
 			}
 
-			newMessages = append(newMessages, CollectorMessage{
+			text, truncated := truncateText(c.cleanText(msg.Message), c.maxMessageLength)
+			cm := CollectorMessage{
 				ID:             int64(msg.ID),
 				ChatID:         chatID,
+				SenderID:       senderID,
 				SenderUsername: senderUsername,
 				Timestamp:      time.Unix(int64(msg.Date), 0),
-				Text:           msg.Message,
-			})
+				Text:           text,
+				Truncated:      truncated,
+				Type:           "message",
+				Source:         "telegram",
+				IsOutgoing:     msg.Out,
+			}
+			if msg.EditDate > 0 {
+				editedAt := time.Unix(int64(msg.EditDate), 0)
+				cm.EditedAt = &editedAt
+			}
+			if replyTo, ok := msg.GetReplyTo(); ok {
+				if header, ok := replyTo.(*tg.MessageReplyHeader); ok {
+					if replyToMsgID, ok := header.GetReplyToMsgID(); ok {
+						id := int64(replyToMsgID)
+						cm.ReplyToMessageID = &id
+					}
+				}
+			}
+			if reactions, ok := msg.GetReactions(); ok {
+				total := 0
+				for _, result := range reactions.Results {
+					total += result.Count
+				}
+				cm.ReactionCount = total
+			}
+			if fwd, ok := msg.GetFwdFrom(); ok {
+				cm.Forwarded = true
+				if fwd.FromName != "" {
+					cm.ForwardedFrom = fwd.FromName
+				} else if fwd.FromID != nil {
+					cm.ForwardedFrom = resolveSenderUsername(fwd.FromID, historyUsers, historyChats)
+				}
+			}
+			newMessages = append(newMessages, cm)
+		case *tg.MessageEmpty:
+			// A previously collected ID now comes back empty: deleted at source.
+			if lastCollectedMessageID > 0 && int64(msg.ID) <= lastCollectedMessageID {
+				newMessages = append(newMessages, CollectorMessage{
+					ID:      int64(msg.ID),
+					ChatID:  chatID,
+					Type:    "message",
+					Source:  "telegram",
+					Deleted: true,
+				})
+			}
 		}
 	}
 
@@ -251,6 +408,82 @@ func (c *Client) GetMessages(ctx context.Context, chatID int64, lastCollectedMes
 	return newMessages, nil
 }
 
+// GetChannelComments fetches new messages from the discussion group linked to
+// a public channel, mirroring the VK wall/comment model. Comments come back
+// with Type "comment", ChatID set to the channel so the backend attributes
+// them to the monitored chat, and PostID pointing at the thread root (the
+// channel post's forwarded copy inside the group) the comment replies to.
+func (c *Client) GetChannelComments(ctx context.Context, channelID int64, lastCollectedMessageID int64) ([]CollectorMessage, error) {
+	linkedChatID, err := c.resolveLinkedChat(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := c.GetMessages(ctx, linkedChatID, lastCollectedMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages from linked discussion group %d: %w", linkedChatID, err)
+	}
+
+	for i := range comments {
+		comments[i].ChatID = channelID
+		if comments[i].Deleted {
+			continue
+		}
+		comments[i].Type = "comment"
+		if comments[i].ReplyToMessageID != nil {
+			postID := *comments[i].ReplyToMessageID
+			comments[i].PostID = &postID
+		}
+	}
+	return comments, nil
+}
+
+// resolveLinkedChat resolves the discussion group linked to a channel via
+// channels.getFullChannel. The group usually isn't in the dialog list, so its
+// entity from the response is cached for resolveInputPeer.
+func (c *Client) resolveLinkedChat(ctx context.Context, channelID int64) (int64, error) {
+	var inputChannel *tg.InputChannel
+	for _, ch := range c.cachedChats {
+		if channel, ok := ch.(*tg.Channel); ok && channel.ID == channelID {
+			inputChannel = &tg.InputChannel{ChannelID: channel.ID, AccessHash: channel.AccessHash}
+			break
+		}
+	}
+	if inputChannel == nil {
+		return 0, fmt.Errorf("channel with ID %d not found in cache", channelID)
+	}
+
+	full, err := c.API().ChannelsGetFullChannel(ctx, inputChannel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get full channel %d: %w", channelID, err)
+	}
+	channelFull, ok := full.FullChat.(*tg.ChannelFull)
+	if !ok {
+		return 0, fmt.Errorf("unexpected full chat type for channel %d: %T", channelID, full.FullChat)
+	}
+	linkedChatID, ok := channelFull.GetLinkedChatID()
+	if !ok || linkedChatID == 0 {
+		return 0, fmt.Errorf("channel %d has no linked discussion group", channelID)
+	}
+
+	if _, err := c.resolveInputPeer(linkedChatID); err != nil {
+		for _, ch := range full.Chats {
+			switch entity := ch.(type) {
+			case *tg.Chat:
+				if entity.ID == linkedChatID {
+					c.cachedChats = append(c.cachedChats, ch)
+				}
+			case *tg.Channel:
+				if entity.ID == linkedChatID {
+					c.cachedChats = append(c.cachedChats, ch)
+				}
+			}
+		}
+	}
+
+	return linkedChatID, nil
+}
+
 // resolveSenderUsername tries to find the username of the sender from the provided users and chats.
 func resolveSenderUsername(peerID tg.PeerClass, users []tg.UserClass, chats []tg.ChatClass) string {
 	switch p := peerID.(type) {
@@ -302,6 +535,27 @@ func (c *Client) resolveInputPeer(chatID int64) (tg.InputPeerClass, error) {
 	return nil, fmt.Errorf("chat with ID %d not found in cache", chatID)
 }
 
+// cleanText applies the configured sanitization to raw message content.
+func (c *Client) cleanText(text string) string {
+	if !c.sanitizeText {
+		return text
+	}
+	return sanitize.Text(text)
+}
+
+// truncateText trims text to maxLen runes, preserving the beginning of the message.
+// Returns the (possibly shortened) text and whether truncation happened.
+func truncateText(text string, maxLen int) (string, bool) {
+	if maxLen <= 0 {
+		return text, false
+	}
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text, false
+	}
+	return string(runes[:maxLen]), true
+}
+
 // Helper to get peer ID and type
 func getPeerIDAndType(peer tg.PeerClass) (int64, string) {
 	switch p := peer.(type) {
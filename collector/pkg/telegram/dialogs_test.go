@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gotd/td/tg"
+	"go.uber.org/zap"
+)
+
+// fakeDialogs builds one dialogs page: n dialogs with sequential user peers
+// starting at firstID, plus the matching user entities and top messages.
+func fakeDialogs(firstID int64, n int) ([]tg.DialogClass, []tg.UserClass, []tg.MessageClass) {
+	var dialogs []tg.DialogClass
+	var users []tg.UserClass
+	var messages []tg.MessageClass
+	for i := 0; i < n; i++ {
+		id := firstID + int64(i)
+		dialogs = append(dialogs, &tg.Dialog{
+			Peer:       &tg.PeerUser{UserID: id},
+			TopMessage: int(id),
+		})
+		users = append(users, &tg.User{ID: id, AccessHash: id * 10, FirstName: "User"})
+		messages = append(messages, &tg.Message{ID: int(id), Date: 1700000000 + int(id)})
+	}
+	return dialogs, users, messages
+}
+
+func TestCollectDialogPagesEnumeratesAllPages(t *testing.T) {
+	page1Dialogs, page1Users, page1Messages := fakeDialogs(1, dialogPageSize)
+	page2Dialogs, page2Users, page2Messages := fakeDialogs(int64(dialogPageSize)+1, 30)
+
+	var calls []*tg.MessagesGetDialogsRequest
+	fetch := func(ctx context.Context, req *tg.MessagesGetDialogsRequest) (tg.MessagesDialogsClass, error) {
+		calls = append(calls, req)
+		switch len(calls) {
+		case 1:
+			return &tg.MessagesDialogsSlice{
+				Count:    dialogPageSize + 30,
+				Dialogs:  page1Dialogs,
+				Users:    page1Users,
+				Messages: page1Messages,
+			}, nil
+		case 2:
+			return &tg.MessagesDialogsSlice{
+				Count:    dialogPageSize + 30,
+				Dialogs:  page2Dialogs,
+				Users:    page2Users,
+				Messages: page2Messages,
+			}, nil
+		default:
+			t.Fatalf("unexpected third MessagesGetDialogs call: %+v", req)
+			return nil, nil
+		}
+	}
+
+	dialogs, users, _, err := collectDialogPages(context.Background(), fetch, 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("collectDialogPages: %v", err)
+	}
+	if len(dialogs) != dialogPageSize+30 {
+		t.Errorf("expected %d dialogs across both pages, got %d", dialogPageSize+30, len(dialogs))
+	}
+	if len(users) != dialogPageSize+30 {
+		t.Errorf("expected %d user entities, got %d", dialogPageSize+30, len(users))
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 paged calls, got %d", len(calls))
+	}
+
+	// The second call must carry the cursor of the first page's last dialog.
+	second := calls[1]
+	lastID := int64(dialogPageSize)
+	if second.OffsetID != int(lastID) {
+		t.Errorf("expected OffsetID %d, got %d", lastID, second.OffsetID)
+	}
+	if second.OffsetDate != 1700000000+int(lastID) {
+		t.Errorf("expected OffsetDate of the last top message, got %d", second.OffsetDate)
+	}
+	peer, ok := second.OffsetPeer.(*tg.InputPeerUser)
+	if !ok {
+		t.Fatalf("expected InputPeerUser offset peer, got %T", second.OffsetPeer)
+	}
+	if peer.UserID != lastID || peer.AccessHash != lastID*10 {
+		t.Errorf("offset peer not resolved from page entities: %+v", peer)
+	}
+}
+
+func TestCollectDialogPagesCompleteListStopsAfterOneCall(t *testing.T) {
+	dialogs, users, messages := fakeDialogs(1, 7)
+	calls := 0
+	fetch := func(ctx context.Context, req *tg.MessagesGetDialogsRequest) (tg.MessagesDialogsClass, error) {
+		calls++
+		return &tg.MessagesDialogs{Dialogs: dialogs, Users: users, Messages: messages}, nil
+	}
+
+	got, _, _, err := collectDialogPages(context.Background(), fetch, 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("collectDialogPages: %v", err)
+	}
+	if len(got) != 7 {
+		t.Errorf("expected 7 dialogs, got %d", len(got))
+	}
+	if calls != 1 {
+		t.Errorf("a complete MessagesDialogs response must end the enumeration, got %d calls", calls)
+	}
+}
+
+func TestCollectDialogPagesHonorsMaxDialogs(t *testing.T) {
+	fetch := func(ctx context.Context, req *tg.MessagesGetDialogsRequest) (tg.MessagesDialogsClass, error) {
+		dialogs, users, messages := fakeDialogs(1, req.Limit)
+		return &tg.MessagesDialogsSlice{Count: 1000, Dialogs: dialogs, Users: users, Messages: messages}, nil
+	}
+
+	got, _, _, err := collectDialogPages(context.Background(), fetch, 40, zap.NewNop())
+	if err != nil {
+		t.Fatalf("collectDialogPages: %v", err)
+	}
+	if len(got) != 40 {
+		t.Errorf("expected enumeration bounded at 40 dialogs, got %d", len(got))
+	}
+}
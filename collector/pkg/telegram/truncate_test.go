@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateTextOversizedMessage(t *testing.T) {
+	text := strings.Repeat("опасно ", 100) // 700 runes
+	got, truncated := truncateText(text, 50)
+	if !truncated {
+		t.Fatal("expected an oversized message to be truncated")
+	}
+	if runes := []rune(got); len(runes) != 50 {
+		t.Fatalf("expected 50 runes after truncation, got %d", len(runes))
+	}
+	if !strings.HasPrefix(text, got) {
+		t.Error("truncation must preserve the beginning of the message")
+	}
+}
+
+func TestTruncateTextWithinLimit(t *testing.T) {
+	got, truncated := truncateText("short message", 50)
+	if truncated {
+		t.Fatal("a message within the limit must not be flagged as truncated")
+	}
+	if got != "short message" {
+		t.Errorf("text changed without truncation: %q", got)
+	}
+}
+
+func TestTruncateTextDisabled(t *testing.T) {
+	text := strings.Repeat("a", 1000)
+	got, truncated := truncateText(text, 0)
+	if truncated || got != text {
+		t.Error("maxLen <= 0 must disable truncation")
+	}
+}
+
+func TestTruncateTextCountsRunesNotBytes(t *testing.T) {
+	// Five two-byte Cyrillic runes: a byte-based cut at 4 would split one.
+	got, truncated := truncateText("привет", 4)
+	if !truncated {
+		t.Fatal("expected truncation")
+	}
+	if got != "прив" {
+		t.Errorf("expected a rune-aligned cut, got %q", got)
+	}
+}
@@ -3,29 +3,28 @@ package vk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"collector/pkg/config"
+	"collector/pkg/conversation"
+	"collector/pkg/models"
+	"collector/pkg/sanitize"
 )
 
-// CollectorMessage represents a VK post, comment, or private message to be sent to the backend.
-type CollectorMessage struct {
-	ID             int64     `json:"id"`
-	ChatID         int64     `json:"chat_id"`         // Conversation/Peer ID (for messages) or Group ID (for posts/comments)
-	GroupID        int64     `json:"group_id"`        // VK group/community ID (deprecated, use ChatID)
-	SenderUsername string    `json:"sender_username"` // Author name
-	Timestamp      time.Time `json:"timestamp"`
-	Text           string    `json:"text"`
-	Type           string    `json:"type"`            // "post", "comment", or "message"
-	PostID         *int64    `json:"post_id,omitempty"` // Parent post ID for comments
-}
+// CollectorMessage is the canonical collected-message shape.
+//
+// Deprecated: use models.Message directly; this alias exists so existing
+// call sites keep compiling.
+type CollectorMessage = models.Message
 
 // GroupInfo represents information about a VK group/community.
 type GroupInfo struct {
@@ -35,12 +34,41 @@ type GroupInfo struct {
 	IsActive   bool   `json:"is_active"`
 }
 
+// ErrInsufficientScope indicates the access token lacks a permission required
+// by the requested method (VK error codes 7 "permission denied" and
+// 15 "access denied"). Callers should prompt for re-authorization with the
+// missing scope instead of treating this as a transient failure.
+var ErrInsufficientScope = errors.New("vk: access token lacks required scope")
+
+// ErrPeerInaccessible indicates the requested peer can no longer be read: the
+// user was deleted or banned (code 18), the profile is private (code 30), or
+// access to the group's content is denied (code 203). Callers should skip the
+// peer and mark it inaccessible instead of retrying every cycle.
+var ErrPeerInaccessible = errors.New("vk: peer is deactivated or its content is inaccessible")
+
 // Client encapsulates the VK API client.
 type Client struct {
-	accessToken string
-	apiVersion  string
-	logger      *zap.Logger
-	httpClient  *http.Client
+	accessToken      string
+	apiVersion       string
+	maxMessageLength int
+	sanitizeText     bool
+	logger           *zap.Logger
+	httpClient       *http.Client
+	throttle         <-chan time.Time // global token bucket pacing all API calls
+
+	selfMu sync.Mutex // guards selfID; resolution may race across gin handlers
+	selfID int64      // ID of the authorized user, resolved lazily via users.get
+}
+
+// requestInterval paces VK API calls to the documented 3 req/s limit.
+const requestInterval = 350 * time.Millisecond
+
+// cleanText applies the configured sanitization to raw message content.
+func (c *Client) cleanText(text string) string {
+	if !c.sanitizeText {
+		return text
+	}
+	return sanitize.Text(text)
 }
 
 // VK API response structures
@@ -55,8 +83,8 @@ type vkError struct {
 }
 
 type vkWallGetResponse struct {
-	Count int       `json:"count"`
-	Items []vkPost  `json:"items"`
+	Count int      `json:"count"`
+	Items []vkPost `json:"items"`
 }
 
 type vkPost struct {
@@ -76,11 +104,11 @@ type vkCommentsGetResponse struct {
 }
 
 type vkComment struct {
-	ID      int    `json:"id"`
-	FromID  int64  `json:"from_id"`
-	Date    int64  `json:"date"`
-	Text    string `json:"text"`
-	PostID  int    `json:"post_id,omitempty"`
+	ID     int    `json:"id"`
+	FromID int64  `json:"from_id"`
+	Date   int64  `json:"date"`
+	Text   string `json:"text"`
+	PostID int    `json:"post_id,omitempty"`
 }
 
 type vkGroupsGetByIdResponse []vkGroup
@@ -101,19 +129,41 @@ type vkUser struct {
 }
 
 // NewClient creates and initializes a new VK API client.
-func NewClient(cfg *config.VKConfig, logger *zap.Logger) (*Client, error) {
+// maxMessageLength bounds collected message content; longer texts are truncated.
+// sanitizeText repairs malformed UTF-8 and strips control bytes on the way in.
+func NewClient(cfg *config.VKConfig, maxMessageLength int, sanitizeText bool, logger *zap.Logger) (*Client, error) {
 	if cfg.AccessToken == "" {
 		return nil, fmt.Errorf("VK access token is required")
 	}
 
-	return &Client{
-		accessToken: cfg.AccessToken,
-		apiVersion:  "5.131", // VK API version
-		logger:      logger,
+	client := &Client{
+		accessToken:      cfg.AccessToken,
+		apiVersion:       "5.131", // VK API version
+		maxMessageLength: maxMessageLength,
+		sanitizeText:     sanitizeText,
+		logger:           logger,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-	}, nil
+		throttle: time.Tick(requestInterval),
+	}
+
+	// Resolve the token owner's user ID up front so direction tagging and
+	// chat ownership work from the first collection run. Failure is not
+	// fatal: the ID is re-resolved lazily on first use.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := client.GetSelfID(ctx); err != nil {
+		logger.Warn("Failed to resolve VK self user ID at init", zap.Error(err))
+	}
+
+	return client, nil
+}
+
+// GetSelfID returns the user ID of the account the access token belongs to,
+// resolving and caching it on first call.
+func (c *Client) GetSelfID(ctx context.Context) (int64, error) {
+	return c.getSelfUserID(ctx)
 }
 
 // makeAPIRequest performs a VK API request
@@ -123,6 +173,14 @@ func (c *Client) makeAPIRequest(ctx context.Context, method string, params url.V
 
 	apiURL := fmt.Sprintf("https://api.vk.com/method/%s?%s", method, params.Encode())
 
+	// Global token bucket: concurrent collection passes share the same
+	// 3 req/s budget instead of sleeping independently.
+	select {
+	case <-c.throttle:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -145,12 +203,15 @@ func (c *Client) makeAPIRequest(ctx context.Context, method string, params url.V
 	}
 
 	if vkResp.Error != nil {
+		switch vkResp.Error.ErrorCode {
+		case 7, 15:
+			return nil, fmt.Errorf("VK API error %d: %s: %w", vkResp.Error.ErrorCode, vkResp.Error.ErrorMsg, ErrInsufficientScope)
+		case 18, 30, 203:
+			return nil, fmt.Errorf("VK API error %d: %s: %w", vkResp.Error.ErrorCode, vkResp.Error.ErrorMsg, ErrPeerInaccessible)
+		}
 		return nil, fmt.Errorf("VK API error %d: %s", vkResp.Error.ErrorCode, vkResp.Error.ErrorMsg)
 	}
 
-	// VK API rate limit: 3 requests per second
-	time.Sleep(350 * time.Millisecond)
-
 	return vkResp.Response, nil
 }
 
@@ -190,11 +251,13 @@ func (c *Client) GetGroupInfo(ctx context.Context, groupID string) (*GroupInfo,
 func (c *Client) GetWallPosts(ctx context.Context, groupID string, lastPostID int64) ([]CollectorMessage, error) {
 	params := url.Values{}
 
-	// Convert group ID to negative owner_id format
-	// VK uses negative IDs for groups: group 123 -> owner_id -123
-	ownerID := groupID
-	if groupID[0] != '-' {
-		// Try to get numeric ID
+	// Convert the group identifier to the negative owner_id format wall.get
+	// expects (group 123 -> owner_id -123); screen names resolve via the API.
+	ownerID, numeric, err := conversation.VKGroupOwnerID(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group ID %q: %w", groupID, err)
+	}
+	if !numeric {
 		groupInfo, err := c.GetGroupInfo(ctx, groupID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve group ID: %w", err)
@@ -228,13 +291,18 @@ func (c *Client) GetWallPosts(ctx context.Context, groupID string, lastPostID in
 				authorName = fmt.Sprintf("ID%d", post.FromID)
 			}
 
+			text, truncated := truncateText(c.cleanText(post.Text), c.maxMessageLength)
 			messages = append(messages, CollectorMessage{
 				ID:             int64(post.ID),
+				ChatID:         post.OwnerID,
 				GroupID:        post.OwnerID,
+				SenderID:       post.FromID,
 				SenderUsername: authorName,
 				Timestamp:      time.Unix(post.Date, 0),
-				Text:           post.Text,
+				Text:           text,
+				Truncated:      truncated,
 				Type:           "post",
+				Source:         "vk",
 			})
 		}
 	}
@@ -244,6 +312,55 @@ func (c *Client) GetWallPosts(ctx context.Context, groupID string, lastPostID in
 }
 
 // GetPostComments fetches comments for a specific post.
+// GroupWallRequest identifies one group wall to collect and where the last
+// pass stopped.
+type GroupWallRequest struct {
+	GroupID    string `json:"group_id"`
+	LastPostID int64  `json:"last_post_id"`
+}
+
+// GroupWallResult is one group's outcome from a multi-group collection pass.
+type GroupWallResult struct {
+	GroupID  string             `json:"group_id"`
+	Messages []CollectorMessage `json:"messages"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// GetMultipleGroupsWallPosts collects several group walls concurrently with
+// up to parallelism workers. Every request draws from the client's global
+// token bucket, so idle time between groups is used without exceeding VK's
+// rate limit. Results come back in request order; a per-group failure is
+// recorded in its result and doesn't stop the rest.
+func (c *Client) GetMultipleGroupsWallPosts(ctx context.Context, requests []GroupWallRequest, parallelism int) []GroupWallResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]GroupWallResult, len(requests))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, request GroupWallRequest) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			messages, err := c.GetWallPosts(ctx, request.GroupID, request.LastPostID)
+			result := GroupWallResult{GroupID: request.GroupID, Messages: messages}
+			if err != nil {
+				c.logger.Error("Failed to collect VK group wall",
+					zap.String("group_id", request.GroupID),
+					zap.Error(err))
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, request)
+	}
+	wg.Wait()
+	return results
+}
+
 func (c *Client) GetPostComments(ctx context.Context, ownerID int64, postID int64, lastCommentID int64) ([]CollectorMessage, error) {
 	params := url.Values{}
 	params.Set("owner_id", strconv.FormatInt(ownerID, 10))
@@ -276,13 +393,18 @@ func (c *Client) GetPostComments(ctx context.Context, ownerID int64, postID int6
 				authorName = fmt.Sprintf("ID%d", comment.FromID)
 			}
 
+			text, truncated := truncateText(c.cleanText(comment.Text), c.maxMessageLength)
 			messages = append(messages, CollectorMessage{
 				ID:             int64(comment.ID),
+				ChatID:         ownerID,
 				GroupID:        ownerID,
+				SenderID:       comment.FromID,
 				SenderUsername: authorName,
 				Timestamp:      time.Unix(comment.Date, 0),
-				Text:           comment.Text,
+				Text:           text,
+				Truncated:      truncated,
 				Type:           "comment",
+				Source:         "vk",
 				PostID:         &postID,
 			})
 		}
@@ -351,10 +473,10 @@ type ConversationInfo struct {
 
 // VK Messages API structures
 type vkConversationsGetResponse struct {
-	Count int                   `json:"count"`
-	Items []vkConversationItem  `json:"items"`
-	Profiles []vkUser           `json:"profiles"`
-	Groups   []vkGroup          `json:"groups"`
+	Count    int                  `json:"count"`
+	Items    []vkConversationItem `json:"items"`
+	Profiles []vkUser             `json:"profiles"`
+	Groups   []vkGroup            `json:"groups"`
 }
 
 type vkConversationItem struct {
@@ -363,13 +485,13 @@ type vkConversationItem struct {
 }
 
 type vkConversationData struct {
-	Peer            vkPeerData         `json:"peer"`
-	LastMessageID   int                `json:"last_message_id"`
-	InRead          int                `json:"in_read"`
-	OutRead         int                `json:"out_read"`
-	UnreadCount     int                `json:"unread_count"`
-	CanWrite        vkCanWrite         `json:"can_write"`
-	ChatSettings    *vkChatSettingsData `json:"chat_settings,omitempty"`
+	Peer          vkPeerData          `json:"peer"`
+	LastMessageID int                 `json:"last_message_id"`
+	InRead        int                 `json:"in_read"`
+	OutRead       int                 `json:"out_read"`
+	UnreadCount   int                 `json:"unread_count"`
+	CanWrite      vkCanWrite          `json:"can_write"`
+	ChatSettings  *vkChatSettingsData `json:"chat_settings,omitempty"`
 }
 
 type vkPeerData struct {
@@ -389,6 +511,7 @@ type vkChatSettingsData struct {
 type vkMessageData struct {
 	ID                    int    `json:"id"`
 	Date                  int64  `json:"date"`
+	UpdateTime            int64  `json:"update_time"` // Set when the message was edited
 	PeerID                int64  `json:"peer_id"`
 	FromID                int64  `json:"from_id"`
 	Text                  string `json:"text"`
@@ -452,7 +575,7 @@ func (c *Client) GetAllConversations(ctx context.Context) ([]ConversationInfo, e
 			}
 		case "group":
 			// Find group in groups
-			groupID := -item.Conversation.Peer.ID // Groups have negative IDs
+			groupID, _ := conversation.VKPeer(item.Conversation.Peer.ID).VKGroupID()
 			for _, group := range convResp.Groups {
 				if group.ID == groupID {
 					conv.Name = group.Name
@@ -460,7 +583,7 @@ func (c *Client) GetAllConversations(ctx context.Context) ([]ConversationInfo, e
 				}
 			}
 			if conv.Name == "" {
-				conv.Name = fmt.Sprintf("Group %d", -item.Conversation.Peer.ID)
+				conv.Name = fmt.Sprintf("Group %d", groupID)
 			}
 		default:
 			conv.Name = fmt.Sprintf("Conversation %d", item.Conversation.Peer.ID)
@@ -473,6 +596,36 @@ func (c *Client) GetAllConversations(ctx context.Context) ([]ConversationInfo, e
 	return conversations, nil
 }
 
+// getSelfUserID resolves and caches the ID of the account the access token
+// belongs to. Used to derive message direction: a message whose from_id
+// matches the monitored account was sent by it, not received.
+func (c *Client) getSelfUserID(ctx context.Context) (int64, error) {
+	// The lock is held across the resolving call: concurrent handlers wait
+	// for one users.get instead of racing on the cache, and a failed
+	// resolution is simply retried by the next caller.
+	c.selfMu.Lock()
+	defer c.selfMu.Unlock()
+	if c.selfID != 0 {
+		return c.selfID, nil
+	}
+
+	respData, err := c.makeAPIRequest(ctx, "users.get", url.Values{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve own user ID: %w", err)
+	}
+
+	var users []vkUser
+	if err := json.Unmarshal(respData, &users); err != nil {
+		return 0, fmt.Errorf("failed to parse users.get response: %w", err)
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("users.get returned no users for the current token")
+	}
+
+	c.selfID = users[0].ID
+	return c.selfID, nil
+}
+
 // GetConversationMessages fetches messages from a specific VK conversation.
 // peerID is the conversation/peer ID (can be user ID, chat ID, or negative group ID)
 // lastMessageID is the ID of the last collected message (0 to fetch latest)
@@ -496,10 +649,19 @@ func (c *Client) GetConversationMessages(ctx context.Context, peerID int64, last
 		return nil, fmt.Errorf("failed to parse messages response: %w", err)
 	}
 
+	selfID, err := c.getSelfUserID(ctx)
+	if err != nil {
+		// Direction is enrichment, not a reason to drop the collection run.
+		c.logger.Warn("Failed to resolve own VK user ID; is_outgoing will be unset", zap.Error(err))
+	}
+
 	var messages []CollectorMessage
 	for _, msg := range msgResp.Items {
-		// Filter messages newer than lastMessageID
-		if int64(msg.ID) > lastMessageID {
+		// Keep messages newer than lastMessageID, plus already-collected ones
+		// that were edited since (update_time set), so edits reach the backend.
+		isNew := int64(msg.ID) > lastMessageID
+		isEdited := !isNew && lastMessageID > 0 && msg.UpdateTime > 0
+		if isNew || isEdited {
 			senderName := ""
 
 			// Resolve sender name
@@ -528,14 +690,24 @@ func (c *Client) GetConversationMessages(ctx context.Context, peerID int64, last
 				}
 			}
 
-			messages = append(messages, CollectorMessage{
+			text, truncated := truncateText(c.cleanText(msg.Text), c.maxMessageLength)
+			cm := CollectorMessage{
 				ID:             int64(msg.ID),
 				ChatID:         peerID,
+				SenderID:       msg.FromID,
 				SenderUsername: senderName,
 				Timestamp:      time.Unix(msg.Date, 0),
-				Text:           msg.Text,
+				Text:           text,
+				Truncated:      truncated,
 				Type:           "message",
-			})
+				Source:         "vk",
+				IsOutgoing:     selfID != 0 && msg.FromID == selfID,
+			}
+			if msg.UpdateTime > 0 {
+				editedAt := time.Unix(msg.UpdateTime, 0)
+				cm.EditedAt = &editedAt
+			}
+			messages = append(messages, cm)
 		}
 	}
 
@@ -545,6 +717,19 @@ func (c *Client) GetConversationMessages(ctx context.Context, peerID int64, last
 	return messages, nil
 }
 
+// truncateText trims text to maxLen runes, preserving the beginning of the message.
+// Returns the (possibly shortened) text and whether truncation happened.
+func truncateText(text string, maxLen int) (string, bool) {
+	if maxLen <= 0 {
+		return text, false
+	}
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text, false
+	}
+	return string(runes[:maxLen]), true
+}
+
 // GenerateOAuthURL generates VK OAuth authorization URL.
 func GenerateOAuthURL(appID int, redirectURI string) string {
 	params := url.Values{}